@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/laurendc/nannytracker/pkg/config"
+	model "github.com/laurendc/nannytracker/pkg/core"
+	"github.com/laurendc/nannytracker/pkg/core/maps"
+	"github.com/laurendc/nannytracker/pkg/core/storage"
+)
+
+// runCommand runs a subcommand that records a trip or expense, recalculates trip
+// distances or prints the configuration, without starting the interface. The distance
+// client is only created when a trip needs its miles calculated.
+func runCommand(name string, args []string, cfg *config.Config, store *storage.FileStorage, newClient func() (maps.DistanceCalculator, error), out io.Writer) error {
+	switch name {
+	case "add-trip":
+		return addTrip(args, cfg, store, newClient, out)
+	case "add-expense":
+		return addExpense(args, cfg, store, out)
+	case "recalc":
+		return recalcTrips(args, cfg, store, newClient, out)
+	case "config":
+		return printConfig(args, cfg, out)
+	default:
+		return fmt.Errorf("unknown command %q (expected add-trip, add-expense, recalc or config)", name)
+	}
+}
+
+// addTrip validates and saves a single trip, calculating its distance unless -miles is
+// given, and prints the saved trip as JSON
+func addTrip(args []string, cfg *config.Config, store *storage.FileStorage, newClient func() (maps.DistanceCalculator, error), out io.Writer) error {
+	fs := flag.NewFlagSet("add-trip", flag.ContinueOnError)
+	date := fs.String("date", model.FormatDate(time.Now().Format("2006-01-02"), cfg.DateFormat), "Trip date ("+model.DateFormatPattern(cfg.DateFormat)+")")
+	origin := fs.String("origin", cfg.HomeAddress, "Starting address (defaults to NANNYTRACKER_HOME_ADDRESS)")
+	destination := fs.String("destination", "", "Destination address")
+	tripType := fs.String("type", "single", "Trip type: single, round or custom (custom requires -miles)")
+	miles := fs.Float64("miles", 0, "One-way distance, or total for a custom trip; calculated with Google Maps when omitted")
+	notes := fs.String("notes", "", "Optional notes")
+	tags := fs.String("tags", "", "Optional comma-separated tags")
+	family := fs.String("family", "", "Family the trip is billed to")
+	passengers := fs.Int("passengers", 0, "Number of children driven, for splitting the cost (default 1)")
+	returnTo := fs.String("return-to", "", "For round trips, where the return leg heads instead of back the same way")
+	returnMiles := fs.Float64("return-miles", 0, "Distance of the return leg; calculated with Google Maps when omitted")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	tripDate, err := model.ParseDate(*date, cfg.DateFormat)
+	if err != nil {
+		return err
+	}
+	trip := model.Trip{
+		Date:              tripDate,
+		Origin:            strings.TrimSpace(*origin),
+		Destination:       strings.TrimSpace(*destination),
+		Miles:             *miles,
+		Type:              strings.ToLower(strings.TrimSpace(*tripType)),
+		Notes:             strings.TrimSpace(*notes),
+		Tags:              model.ParseTags(*tags),
+		Family:            strings.TrimSpace(*family),
+		Passengers:        *passengers,
+		ReturnDestination: strings.TrimSpace(*returnTo),
+		ReturnMiles:       *returnMiles,
+	}
+	trip.Family = trip.FamilyOrDefault()
+	if !cfg.IsKnownFamily(trip.Family) {
+		return fmt.Errorf("unknown family: %s", trip.Family)
+	}
+
+	// Check everything but the distances before spending an API call on them. Custom
+	// trips are never looked up, so missing miles are reported by the validation below.
+	needsReturnMiles := trip.ReturnDestination != "" && trip.ReturnMiles == 0
+	if (trip.Miles == 0 && !trip.IsCustom()) || needsReturnMiles {
+		probe := trip
+		if probe.Miles == 0 {
+			probe.Miles = 1 // Dummy value for validation
+		}
+		if needsReturnMiles {
+			probe.ReturnMiles = 1 // Dummy value for validation
+		}
+		if err := probe.ValidateWithBounds(time.Now(), cfg.MaxFutureDays); err != nil {
+			return err
+		}
+
+		client, err := newClient()
+		if err != nil {
+			return fmt.Errorf("failed to initialize Google Maps client: %w", err)
+		}
+		if trip.Miles == 0 {
+			trip.Miles, err = client.CalculateDistance(context.Background(), trip.Origin, trip.Destination)
+			if err != nil {
+				return fmt.Errorf("failed to calculate distance: %w", err)
+			}
+		}
+		if needsReturnMiles {
+			trip.ReturnMiles, err = client.CalculateDistance(context.Background(), trip.Destination, trip.ReturnDestination)
+			if err != nil {
+				return fmt.Errorf("failed to calculate return distance: %w", err)
+			}
+		}
+	}
+
+	if err := trip.ValidateWithBounds(time.Now(), cfg.MaxFutureDays); err != nil {
+		return err
+	}
+	if err := trip.ValidateMaxMiles(cfg.MaxTripMiles); err != nil {
+		return err
+	}
+
+	if err := store.Update(func(data *model.StorageData) error {
+		data.Trips = append(data.Trips, trip)
+		model.CalculateAndUpdateWeeklySummaries(data, cfg.RatePerMile, cfg.RoundingMode, cfg.WeekStartDay)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save trip: %w", err)
+	}
+
+	return printJSON(out, trip)
+}
+
+// addExpense validates and saves a single expense and prints it as JSON
+func addExpense(args []string, cfg *config.Config, store *storage.FileStorage, out io.Writer) error {
+	fs := flag.NewFlagSet("add-expense", flag.ContinueOnError)
+	date := fs.String("date", model.FormatDate(time.Now().Format("2006-01-02"), cfg.DateFormat), "Expense date ("+model.DateFormatPattern(cfg.DateFormat)+")")
+	amount := fs.Float64("amount", 0, "Amount in dollars")
+	description := fs.String("description", "", "Brief description of the expense")
+	category := fs.String("category", model.DefaultExpenseCategory, "Category: "+strings.Join(model.ExpenseCategories, ", "))
+	family := fs.String("family", "", "Family the expense is billed to")
+	personal := fs.Bool("personal", false, "Record the expense as personal rather than reimbursable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	expenseDate, err := model.ParseDate(*date, cfg.DateFormat)
+	if err != nil {
+		return err
+	}
+	expense := model.Expense{
+		Date:        expenseDate,
+		Amount:      *amount,
+		Description: strings.TrimSpace(*description),
+		Category:    strings.ToLower(strings.TrimSpace(*category)),
+		Family:      strings.TrimSpace(*family),
+	}
+	if *personal {
+		reimbursable := false
+		expense.Reimbursable = &reimbursable
+	}
+	expense.Category = expense.CategoryOrDefault()
+	expense.Family = expense.FamilyOrDefault()
+	if err := expense.Validate(); err != nil {
+		return err
+	}
+	if !cfg.IsKnownFamily(expense.Family) {
+		return fmt.Errorf("unknown family: %s", expense.Family)
+	}
+
+	if err := store.Update(func(data *model.StorageData) error {
+		data.Expenses = append(data.Expenses, expense)
+		model.CalculateAndUpdateWeeklySummaries(data, cfg.RatePerMile, cfg.RoundingMode, cfg.WeekStartDay)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to save expense: %w", err)
+	}
+
+	return printJSON(out, expense)
+}
+
+// recalcTrips looks up the distance of the trip at the given index, or of every trip
+// with -all, again and saves the new miles, printing the updated trips as JSON
+func recalcTrips(args []string, cfg *config.Config, store *storage.FileStorage, newClient func() (maps.DistanceCalculator, error), out io.Writer) error {
+	fs := flag.NewFlagSet("recalc", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Recalculate every trip")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: recalc <trip index> | recalc -all")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var index int
+	switch {
+	case *all && fs.NArg() == 0:
+	case !*all && fs.NArg() == 1:
+		var err error
+		if index, err = strconv.Atoi(fs.Arg(0)); err != nil {
+			return fmt.Errorf("invalid trip index %q", fs.Arg(0))
+		}
+	default:
+		return errors.New("expected a trip index or -all")
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize Google Maps client: %w", err)
+	}
+
+	trips := make([]model.Trip, 0)
+	if err := store.Update(func(data *model.StorageData) error {
+		indexes := []int{index}
+		if *all {
+			// Custom trips keep the miles entered for them
+			indexes = make([]int, 0, len(data.Trips))
+			for i, t := range data.Trips {
+				if !t.IsCustom() {
+					indexes = append(indexes, i)
+				}
+			}
+		} else if index >= 0 && index < len(data.Trips) && data.Trips[index].IsCustom() {
+			return errors.New("custom trips keep their entered miles and can't be recalculated")
+		}
+		if err := data.RecalculateTripMiles(context.Background(), client, indexes); err != nil {
+			return err
+		}
+		for _, i := range indexes {
+			trips = append(trips, data.Trips[i])
+		}
+		model.CalculateAndUpdateWeeklySummaries(data, cfg.RatePerMile, cfg.RoundingMode, cfg.WeekStartDay)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to recalculate trips: %w", err)
+	}
+
+	return printJSON(out, trips)
+}
+
+// printConfig prints the configuration in effect as JSON, with the Google Maps API key redacted
+func printConfig(args []string, cfg *config.Config, out io.Writer) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return printJSON(out, cfg.Settings())
+}
+
+// printJSON writes v to out as indented JSON
+func printJSON(out io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -16,9 +17,15 @@ import (
 
 func main() {
 	// Parse command line flags
-	var showVersion bool
+	var showVersion, repair, noMaps bool
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.BoolVar(&showVersion, "v", false, "Show version information")
+	flag.BoolVar(&repair, "repair", false, "Recompute weekly summaries from trips and expenses, save, and exit")
+	flag.BoolVar(&noMaps, "no-maps", false, "Enter trip miles manually instead of using Google Maps (overrides NANNYTRACKER_MANUAL_MILES)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [add-trip|add-expense|recalc|config [command flags]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	// Show version if requested
@@ -35,21 +42,69 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	if noMaps {
+		cfg.ManualMiles = true
+	}
+	if err := cfg.ApplyPreferences(); err != nil {
+		log.Printf("Warning: could not read remembered preferences: %v", err)
+	}
 
 	// Initialize storage
 	store := storage.New(cfg.DataPath())
+	store.Strict = cfg.StrictData
+
+	if repair {
+		data, err := store.LoadAndRepair(cfg.RatePerMile, cfg.RoundingMode, cfg.WeekStartDay)
+		if err != nil {
+			log.Fatalf("Failed to load data: %v", err)
+		}
+		if err := store.SaveData(data); err != nil {
+			log.Fatalf("Failed to save repaired data: %v", err)
+		}
+		fmt.Printf("Recomputed %d weekly summaries in %s\n", len(data.WeeklySummaries), cfg.DataPath())
+		os.Exit(0)
+	}
+
+	newClient := func() (maps.DistanceCalculator, error) {
+		return newDistanceCalculator(cfg)
+	}
+
+	// Subcommands record a trip or expense, or fix up saved data, without starting the interface
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:], cfg, store, newClient, os.Stdout); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(0)
+			}
+			log.Fatalf("%s: %v", flag.Arg(0), err)
+		}
+		os.Exit(0)
+	}
 
 	// Initialize Google Maps client
-	mapsClient, err := maps.NewClient()
+	mapsClient, err := newClient()
 	if err != nil {
 		log.Fatalf("Failed to initialize Google Maps client: %v", err)
 	}
 
 	// Initialize UI with Google Maps client
-	model, err := tui.NewWithClient(store, cfg.RatePerMile, mapsClient)
+	model, err := tui.NewWithClient(store, cfg.RatePerMile, mapsClient, cfg.PageSize)
 	if err != nil {
 		log.Fatalf("Failed to initialize UI: %v", err)
 	}
+	model.HomeAddress = cfg.HomeAddress
+	model.DataDir = cfg.DataDir
+	model.MaxFutureDays = cfg.MaxFutureDays
+	model.MaxTripMiles = cfg.MaxTripMiles
+	model.WeeklyTarget = cfg.WeeklyMileageTarget
+	model.Families = cfg.Families
+	model.SetRoundingMode(cfg.RoundingMode)
+	model.SetWeekStartDay(cfg.WeekStartDay)
+	model.SetDateFormat(cfg.DateFormat)
+	if cfg.RememberPageSize {
+		model.SavePageSize = func(size int) error {
+			return cfg.SavePreferences(config.Preferences{PageSize: size})
+		}
+	}
 
 	// Start the application
 	p := tea.NewProgram(model)
@@ -58,3 +113,23 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newDistanceCalculator creates the Google Maps client, wrapped in the distance cache
+// when it is available so repeated routes don't re-hit the API. With manual miles
+// configured it returns a client that leaves the miles to the user instead.
+func newDistanceCalculator(cfg *config.Config) (maps.DistanceCalculator, error) {
+	if cfg.ManualMiles {
+		return maps.NewManualClient(), nil
+	}
+	realClient, err := maps.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	cachedClient, err := maps.NewCachedClient(realClient, cfg.DistanceCachePath())
+	if err != nil {
+		log.Printf("Distance cache not available, using Google Maps directly: %v", err)
+		return realClient, nil
+	}
+	return cachedClient, nil
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -53,7 +58,7 @@ func TestTripCreation(t *testing.T) {
 	store := storage.New(cfg.DataPath())
 	mockClient := maps.NewMockClient()
 
-	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient)
+	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient, cfg.PageSize)
 	if err != nil {
 		t.Fatalf("Failed to create UI model: %v", err)
 	}
@@ -99,6 +104,12 @@ func TestTripCreation(t *testing.T) {
 	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*ui.Model)
 
+	// Skip the optional notes and tags steps
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*ui.Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*ui.Model)
+
 	if len(uiModel.Trips) != 1 {
 		t.Errorf("Expected 1 trip, got %d", len(uiModel.Trips))
 	}
@@ -155,7 +166,7 @@ func TestTotalMilesCalculation(t *testing.T) {
 	store := storage.New(cfg.DataPath())
 	mockClient := maps.NewMockClient()
 
-	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient)
+	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient, cfg.PageSize)
 	if err != nil {
 		t.Fatalf("Failed to create UI model: %v", err)
 	}
@@ -348,7 +359,7 @@ func TestAddingTrip(t *testing.T) {
 	store := storage.New(cfg.DataPath())
 	mockClient := maps.NewMockClient()
 
-	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient)
+	uiModel, err := ui.NewWithClient(store, cfg.RatePerMile, mockClient, cfg.PageSize)
 	if err != nil {
 		t.Fatalf("Failed to create UI model: %v", err)
 	}
@@ -367,7 +378,7 @@ func TestAddingTrip(t *testing.T) {
 		t.Errorf("Expected 1 trip, got %d", len(uiModel.Trips))
 	}
 
-	if uiModel.Trips[0] != trip {
+	if !reflect.DeepEqual(uiModel.Trips[0], trip) {
 		t.Errorf("Trip data doesn't match. Expected %+v, got %+v", trip, uiModel.Trips[0])
 	}
 
@@ -390,3 +401,255 @@ func TestAddingTrip(t *testing.T) {
 		t.Errorf("Expected total amount %.4f, got %.4f", expectedAmount, summary.TotalAmount)
 	}
 }
+
+func TestAddTripCommand(t *testing.T) {
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70, HomeAddress: "Home"}
+
+	clientCalls := 0
+	newClient := func() (maps.DistanceCalculator, error) {
+		clientCalls++
+		return maps.NewMockClient(), nil
+	}
+
+	var out bytes.Buffer
+	args := []string{"-date", "2024-03-20", "-destination", "Work", "-type", "round", "-tags", "school"}
+	if err := runCommand("add-trip", args, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("add-trip failed: %v", err)
+	}
+
+	var printed core.Trip
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("Expected the trip as JSON, got %q: %v", out.String(), err)
+	}
+	want := core.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "round", Tags: []string{"school"}, Family: core.DefaultFamily}
+	if !reflect.DeepEqual(printed, want) {
+		t.Errorf("Expected printed trip %+v, got %+v", want, printed)
+	}
+
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 1 || len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalMiles != 20 {
+		t.Errorf("Expected the trip and its weekly summary to be saved, got %+v", data)
+	}
+
+	// Supplying the miles skips the distance calculation
+	out.Reset()
+	args = []string{"-date", "2024-03-21", "-destination", "Park", "-miles", "3.5"}
+	if err := runCommand("add-trip", args, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("add-trip with miles failed: %v", err)
+	}
+	if clientCalls != 1 {
+		t.Errorf("Expected the distance client to be used once, got %d", clientCalls)
+	}
+
+	// A round trip can return by way of another stop instead of retracing its route
+	out.Reset()
+	args = []string{"-date", "2024-03-21", "-destination", "School", "-miles", "4", "-type", "round", "-return-to", "Store", "-return-miles", "2.5"}
+	if err := runCommand("add-trip", args, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("add-trip with a return destination failed: %v", err)
+	}
+	printed = core.Trip{}
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("Expected the trip as JSON, got %q: %v", out.String(), err)
+	}
+	if printed.ReturnDestination != "Store" || printed.EffectiveMiles() != 6.5 {
+		t.Errorf("Expected a 6.5 mile trip returning via Store, got %+v", printed)
+	}
+
+	// Invalid trips are rejected before anything is saved
+	invalid := [][]string{
+		{"-date", "2024-03-22"},
+		{"-date", "2024-03-22", "-destination", "Work", "-type", "oneway"},
+		{"-date", "2024-03-22", "-destination", "Work", "-return-to", "Store"},
+		{"-date", "03/22/2024", "-destination", "Work"},
+		{"-destination", "Work", "extra"},
+	}
+	for _, args := range invalid {
+		if err := runCommand("add-trip", args, cfg, store, newClient, &out); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+	if data, _ := store.LoadData(); len(data.Trips) != 3 {
+		t.Errorf("Expected 3 saved trips, got %d", len(data.Trips))
+	}
+	if clientCalls != 1 {
+		t.Errorf("Expected invalid trips not to reach the distance client, got %d calls", clientCalls)
+	}
+}
+
+func TestAddExpenseCommand(t *testing.T) {
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70}
+	noClient := func() (maps.DistanceCalculator, error) {
+		return nil, errors.New("not needed")
+	}
+
+	var out bytes.Buffer
+	args := []string{"-date", "2024-03-20", "-amount", "12.50", "-description", "Lunch", "-category", "food"}
+	if err := runCommand("add-expense", args, cfg, store, noClient, &out); err != nil {
+		t.Fatalf("add-expense failed: %v", err)
+	}
+	var printed core.Expense
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("Expected the expense as JSON, got %q: %v", out.String(), err)
+	}
+	if printed.Amount != 12.50 || printed.Category != "food" || !printed.IsReimbursable() {
+		t.Errorf("Expected a reimbursable $12.50 food expense, got %+v", printed)
+	}
+
+	args = []string{"-date", "2024-03-20", "-amount", "4", "-description", "Coffee", "-personal"}
+	if err := runCommand("add-expense", args, cfg, store, noClient, &out); err != nil {
+		t.Fatalf("add-expense -personal failed: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"-date", "2024-03-20", "-description", "No amount"},
+		{"-date", "2024-03-20", "-amount", "5", "-description", "Toy", "-category", "toys"},
+	} {
+		if err := runCommand("add-expense", args, cfg, store, noClient, &out); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != 2 || data.Expenses[1].IsReimbursable() {
+		t.Fatalf("Expected a reimbursable and a personal expense, got %+v", data.Expenses)
+	}
+	if summary := data.WeeklySummaries[0]; summary.TotalExpenses != 12.50 || summary.TotalPersonalExpenses != 4 {
+		t.Errorf("Expected $12.50 billable and $4.00 personal, got %+v", summary)
+	}
+
+	if err := runCommand("add-mileage", nil, cfg, store, noClient, &out); err == nil {
+		t.Error("Expected an error for an unknown command")
+	}
+}
+
+func TestAddExpenseCommandWithDateFormat(t *testing.T) {
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70, DateFormat: core.DateFormatEU}
+	noClient := func() (maps.DistanceCalculator, error) {
+		return nil, errors.New("not needed")
+	}
+
+	var out bytes.Buffer
+	args := []string{"-date", "20/03/2024", "-amount", "12.50", "-description", "Lunch"}
+	if err := runCommand("add-expense", args, cfg, store, noClient, &out); err != nil {
+		t.Fatalf("add-expense failed: %v", err)
+	}
+	var printed core.Expense
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("Expected the expense as JSON, got %q: %v", out.String(), err)
+	}
+	if printed.Date != "2024-03-20" {
+		t.Errorf("Expected the date to be stored as 2024-03-20, got %q", printed.Date)
+	}
+
+	args = []string{"-date", "03/20/2024", "-amount", "4", "-description", "Coffee"}
+	if err := runCommand("add-expense", args, cfg, store, noClient, &out); err == nil {
+		t.Error("Expected a US date to be rejected in EU format")
+	}
+}
+
+func TestAddTripCommandWithManualMiles(t *testing.T) {
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70, HomeAddress: "Home", ManualMiles: true}
+	newClient := func() (maps.DistanceCalculator, error) {
+		return newDistanceCalculator(cfg)
+	}
+
+	var out bytes.Buffer
+	if err := runCommand("add-trip", []string{"-date", "2024-03-20", "-destination", "Work"}, cfg, store, newClient, &out); !errors.Is(err, maps.ErrManualMiles) {
+		t.Errorf("Expected a trip without miles to need them entered, got %v", err)
+	}
+	if err := runCommand("add-trip", []string{"-date", "2024-03-20", "-destination", "Work", "-miles", "6"}, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("add-trip with miles failed: %v", err)
+	}
+	if data, _ := store.LoadData(); len(data.Trips) != 1 {
+		t.Errorf("Expected 1 saved trip, got %d", len(data.Trips))
+	}
+}
+
+func TestConfigCommandRedactsAPIKey(t *testing.T) {
+	t.Setenv("GOOGLE_MAPS_API_KEY", "secret-maps-key")
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70, DataDir: t.TempDir(), DataFile: "trips.json", PageSize: 10, HomeAddress: "Home"}
+	noClient := func() (maps.DistanceCalculator, error) {
+		return nil, errors.New("not needed")
+	}
+
+	var out bytes.Buffer
+	if err := runCommand("config", nil, cfg, store, noClient, &out); err != nil {
+		t.Fatalf("config failed: %v", err)
+	}
+	if strings.Contains(out.String(), "secret-maps-key") {
+		t.Fatalf("Expected the API key to be redacted, got %s", out.String())
+	}
+	var printed config.Settings
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("Expected the configuration as JSON, got %q: %v", out.String(), err)
+	}
+	if printed.RatePerMile != 0.70 || printed.HomeAddress != "Home" || printed.MapsAPIKey != config.Redacted {
+		t.Errorf("Unexpected configuration %+v", printed)
+	}
+}
+
+func TestRecalcCommand(t *testing.T) {
+	store := storage.New(filepath.Join(t.TempDir(), "trips.json"))
+	cfg := &config.Config{RatePerMile: 0.70}
+	if err := store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 3, Type: "single"},
+		{Date: "2024-03-21", Origin: "Home", Destination: "School", Miles: 4, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	mock := maps.NewMockClient()
+	newClient := func() (maps.DistanceCalculator, error) {
+		return mock, nil
+	}
+	milesByDestination := func() map[string]float64 {
+		data, err := store.LoadData()
+		if err != nil {
+			t.Fatalf("Failed to load data: %v", err)
+		}
+		miles := make(map[string]float64)
+		for _, trip := range data.Trips {
+			miles[trip.Destination] = trip.Miles
+		}
+		return miles
+	}
+
+	var out bytes.Buffer
+	if err := runCommand("recalc", []string{"1"}, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("recalc failed: %v", err)
+	}
+	if miles := milesByDestination(); miles["School"] != 10 || miles["Work"] != 3 {
+		t.Errorf("Expected only the School trip overwritten with the mock's 10 miles, got %v", miles)
+	}
+	// Trips keep their stored order, so the same index names the same trip next time
+	if data, err := store.LoadData(); err != nil || data.Trips[1].Destination != "School" {
+		t.Errorf("Expected the School trip to stay at index 1, got %+v (%v)", data, err)
+	}
+
+	if err := runCommand("recalc", []string{"-all"}, cfg, store, newClient, &out); err != nil {
+		t.Fatalf("recalc -all failed: %v", err)
+	}
+	if miles := milesByDestination(); miles["School"] != 10 || miles["Work"] != 10 {
+		t.Errorf("Expected every trip overwritten with 10 miles, got %v", miles)
+	}
+	data, _ := store.LoadData()
+	if len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalMiles != 20 {
+		t.Errorf("Expected the summary recomputed to 20 miles, got %+v", data.WeeklySummaries)
+	}
+
+	for _, args := range [][]string{nil, {"5"}, {"x"}, {"-all", "1"}} {
+		if err := runCommand("recalc", args, cfg, store, newClient, &out); err == nil {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
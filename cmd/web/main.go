@@ -1,61 +1,124 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/laurendc/nannytracker/pkg/config"
 	model "github.com/laurendc/nannytracker/pkg/core"
+	"github.com/laurendc/nannytracker/pkg/core/audit"
+	"github.com/laurendc/nannytracker/pkg/core/export"
 	"github.com/laurendc/nannytracker/pkg/core/maps"
 	"github.com/laurendc/nannytracker/pkg/core/storage"
 	"github.com/laurendc/nannytracker/pkg/version"
 )
 
+// shutdownTimeout is how long active requests get to finish when the server is stopped
+const shutdownTimeout = 15 * time.Second
+
+// defaultPageSize is the number of items returned per page when pageSize is not given
+const defaultPageSize = 50
+
 type Server struct {
-	store      *storage.FileStorage
+	// store holds the data in memory, writing changes to disk every cfg.SaveInterval
+	store      *storage.BufferedStorage
 	cfg        *config.Config
 	mapsClient maps.DistanceCalculator
+	// usingMockMaps is true when Google Maps was unavailable and distances are estimated
+	usingMockMaps bool
+	// audit records each change; nil when no audit log is configured
+	audit *audit.Logger
 }
 
 func NewServer(cfg *config.Config) (*Server, error) {
-	store := storage.New(cfg.DataPath())
+	file := storage.New(cfg.DataPath())
+	file.Strict = cfg.StrictData
+	store := storage.NewBuffered(file, cfg.SaveInterval)
+	var auditLog *audit.Logger
+	if cfg.AuditLogPath != "" {
+		auditLog = audit.New(cfg.AuditLogPath)
+	}
 
 	// Initialize Google Maps client
 	var mapsClient maps.DistanceCalculator
+	usingMockMaps := false
+	if cfg.ManualMiles {
+		// Trips must supply their own miles
+		return &Server{store: store, cfg: cfg, mapsClient: maps.NewManualClient(), audit: auditLog}, nil
+	}
 	realClient, err := maps.NewClient()
 	if err != nil {
 		// Fall back to mock client if Google Maps API is not available
-		log.Printf("Google Maps API not available, using mock client: %v", err)
+		slog.Warn("Google Maps API not available, trip distances will be estimated by the mock client", "error", err)
 		mapsClient = maps.NewMockClient()
+		usingMockMaps = true
 	} else {
 		mapsClient = realClient
+		// Cache distances so repeated routes don't re-hit the API
+		cachedClient, err := maps.NewCachedClient(realClient, cfg.DistanceCachePath())
+		if err != nil {
+			slog.Warn("Distance cache not available, using Google Maps directly", "error", err)
+		} else {
+			mapsClient = cachedClient
+		}
 	}
 
 	return &Server{
-		store:      store,
-		cfg:        cfg,
-		mapsClient: mapsClient,
+		store:         store,
+		cfg:           cfg,
+		mapsClient:    mapsClient,
+		usingMockMaps: usingMockMaps,
+		audit:         auditLog,
 	}, nil
 }
 
+// Shutdown writes any changes still held in memory to disk. Call it once the HTTP server
+// has stopped handling requests.
+func (s *Server) Shutdown() error {
+	return s.store.Close()
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	mapsStatus := "live"
+	if s.cfg.ManualMiles {
+		mapsStatus = "manual"
+	} else if s.usingMockMaps {
+		mapsStatus = "mock"
+	}
+
+	// A full or read-only disk loses data silently, so report it as unhealthy
+	status, storageStatus, code := "healthy", "ok", http.StatusOK
+	if err := s.store.Check(); err != nil {
+		status, storageStatus, code = "unhealthy", fmt.Sprintf("error: %v", err), http.StatusServiceUnavailable
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
+		"status":  status,
 		"service": "nannytracker-api",
+		"maps":    mapsStatus,
+		"storage": storageStatus,
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
@@ -75,124 +138,330 @@ func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) handleTrips(w http.ResponseWriter, r *http.Request) {
+// handleConfig reports the configuration the server is running with. Secrets such as the
+// Google Maps and server API keys are left out entirely.
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	settings := s.cfg.Settings()
+	settings.MapsAPIKey = ""
+	settings.APIKey = ""
+
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// rateResponse is the mileage rate in effect on a date. EffectiveFrom is the date that
+// rate took effect, left out when the default rate applies.
+type rateResponse struct {
+	Date          string  `json:"date"`
+	RatePerMile   float64 `json:"ratePerMile"`
+	EffectiveFrom string  `json:"effectiveFrom,omitempty"`
+	Default       bool    `json:"default"`
+}
+
+// handleRate looks up the mileage rate for ?date=YYYY-MM-DD, defaulting to today
+func (s *Server) handleRate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	// Handle CORS preflight
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if err := model.ValidateDate(date); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid date: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	rate, effectiveFrom := s.cfg.RateOn(date)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rateResponse{
+		Date:          date,
+		RatePerMile:   rate,
+		EffectiveFrom: effectiveFrom,
+		Default:       effectiveFrom == "",
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
+}
+
+func (s *Server) handleTrips(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
 	switch r.Method {
 	case http.MethodGet:
-		s.getTrips(w, r)
+		// GET /api/trips/{index} returns a single item
+		if strings.HasPrefix(r.URL.Path, "/api/trips/") && r.URL.Path != "/api/trips/" {
+			s.getTrip(w, r)
+		} else {
+			s.getTrips(w, r)
+		}
 	case http.MethodPost:
-		s.createTrip(w, r)
+		// POST /api/trips/{index}/recalc and /api/trips/recalc look distances up again
+		// POST /api/trips/{index}/archive and /unarchive hide or restore a trip
+		if strings.HasSuffix(r.URL.Path, "/recalc") {
+			s.recalcTrips(w, r)
+		} else if strings.HasSuffix(r.URL.Path, "/archive") {
+			s.setTripArchived(w, r, true)
+		} else if strings.HasSuffix(r.URL.Path, "/unarchive") {
+			s.setTripArchived(w, r, false)
+		} else {
+			s.createTrip(w, r)
+		}
 	case http.MethodPut:
 		s.updateTrip(w, r)
 	case http.MethodDelete:
-		s.deleteTrip(w, r)
+		// DELETE /api/trips?from=...&to=... removes every trip in the range
+		if r.URL.Query().Has("from") || r.URL.Query().Has("to") {
+			s.deleteTripsInRange(w, r)
+		} else {
+			s.deleteTrip(w, r)
+		}
 	default:
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
 
+// tripResponse is a trip as returned by the API, with the miles it contributes to
+// totals (doubled for round trips) so clients don't have to work them out
+type tripResponse struct {
+	model.Trip
+	EffectiveMiles float64 `json:"effectiveMiles"`
+	// MilesEstimated flags trips whose distance came from the mock maps client
+	MilesEstimated bool `json:"milesEstimated,omitempty"`
+	// Passengers is always present, reporting 1 for trips saved without a count
+	Passengers int `json:"passengers"`
+	// Index is the trip's position in storage, which GET, PUT and DELETE /api/trips/{index}
+	// take; lists are filtered and sorted, so it differs from the trip's place in them
+	Index *int `json:"index,omitempty"`
+}
+
+func newTripResponse(trip model.Trip) tripResponse {
+	return tripResponse{Trip: trip, EffectiveMiles: trip.EffectiveMiles(), Passengers: trip.PassengersOrDefault()}
+}
+
+// newIndexedTripResponse is newTripResponse for the trip stored at index
+func newIndexedTripResponse(trip model.Trip, index int) tripResponse {
+	response := newTripResponse(trip)
+	response.Index = &index
+	return response
+}
+
 func (s *Server) getTrips(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	includeArchived := false
+	if value := r.URL.Query().Get("includeArchived"); value != "" {
+		if includeArchived, err = strconv.ParseBool(value); err != nil {
+			http.Error(w, "Invalid includeArchived value", http.StatusBadRequest)
+			return
+		}
+	}
+
 	data, err := s.store.LoadData()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("ETag", dataETag(data))
+
+	// Archived trips are left out unless asked for. Filter by family, tag and date range,
+	// keeping each trip's stored index, then sort in descending order (most recent first),
+	// matching the TUI
+	family, tag := r.URL.Query().Get("family"), r.URL.Query().Get("tag")
+	var indexes []int
+	for i, trip := range data.Trips {
+		if trip.Archived && !includeArchived {
+			continue
+		}
+		if family != "" && trip.FamilyOrDefault() != family {
+			continue
+		}
+		if tag != "" && !trip.HasTag(tag) {
+			continue
+		}
+		if inDateRange(trip.Date, from, to) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return data.Trips[indexes[i]].Date > data.Trips[indexes[j]].Date
+	})
+
+	start, end, totalPages := pageBounds(len(indexes), page, pageSize)
+	pageTrips := make([]tripResponse, 0, end-start)
+	for _, index := range indexes[start:end] {
+		pageTrips = append(pageTrips, newIndexedTripResponse(data.Trips[index], index))
+	}
 
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"trips": data.Trips,
-		"count": len(data.Trips),
+		"trips":      pageTrips,
+		"count":      len(pageTrips),
+		"total":      len(indexes),
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": totalPages,
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) createTrip(w http.ResponseWriter, r *http.Request) {
-	// Create a struct for the incoming trip data without miles
-	var tripData struct {
-		Date        string `json:"date"`
-		Origin      string `json:"origin"`
-		Destination string `json:"destination"`
-		Type        string `json:"type"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&tripData); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+func (s *Server) getTrip(w http.ResponseWriter, r *http.Request) {
+	// Extract index from URL path
+	index, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/trips/"))
+	if err != nil {
+		http.Error(w, "Invalid trip index", http.StatusBadRequest)
 		return
 	}
 
-	// Basic validation of required fields
-	if tripData.Date == "" {
-		http.Error(w, "Date is required", http.StatusBadRequest)
-		return
-	}
-	if tripData.Origin == "" {
-		http.Error(w, "Origin is required", http.StatusBadRequest)
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
 		return
 	}
-	if tripData.Destination == "" {
-		http.Error(w, "Destination is required", http.StatusBadRequest)
+	w.Header().Set("ETag", dataETag(data))
+
+	if index < 0 || index >= len(data.Trips) {
+		http.Error(w, "Trip not found", http.StatusNotFound)
 		return
 	}
-	if tripData.Type == "" {
-		http.Error(w, "Type is required", http.StatusBadRequest)
+
+	if err := json.NewEncoder(w).Encode(newIndexedTripResponse(data.Trips[index], index)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
-	if tripData.Type != "single" && tripData.Type != "round" {
-		http.Error(w, "Type must be 'single' or 'round'", http.StatusBadRequest)
-		return
+}
+
+func (s *Server) createTrip(w http.ResponseWriter, r *http.Request) {
+	// Create a struct for the incoming trip data; miles is an optional manual override
+	var tripData struct {
+		Date              string   `json:"date"`
+		Origin            string   `json:"origin"`
+		Destination       string   `json:"destination"`
+		Type              string   `json:"type"`
+		Notes             string   `json:"notes"`
+		Miles             float64  `json:"miles"`
+		Family            string   `json:"family"`
+		Tags              []string `json:"tags"`
+		Passengers        int      `json:"passengers"`
+		ReturnDestination string   `json:"return_destination"`
+		ReturnMiles       float64  `json:"return_miles"`
 	}
 
-	// Calculate miles using Google Maps API
-	distance, err := s.mapsClient.CalculateDistance(context.Background(), tripData.Origin, tripData.Destination)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to calculate distance: %v", err), http.StatusInternalServerError)
+	if err := json.NewDecoder(r.Body).Decode(&tripData); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Create the complete trip with calculated miles
-	trip := model.Trip{
-		Date:        tripData.Date,
-		Origin:      tripData.Origin,
-		Destination: tripData.Destination,
-		Type:        tripData.Type,
-		Miles:       distance,
+	if tripData.Family == "" {
+		tripData.Family = model.DefaultFamily
 	}
-
-	// Validate the complete trip
-	if err := trip.Validate(); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid trip data: %v", err), http.StatusBadRequest)
+	trip := model.Trip{
+		Date:              tripData.Date,
+		Origin:            tripData.Origin,
+		Destination:       tripData.Destination,
+		Type:              tripData.Type,
+		Notes:             tripData.Notes,
+		Miles:             tripData.Miles,
+		Family:            tripData.Family,
+		Tags:              tripData.Tags,
+		Passengers:        tripData.Passengers,
+		ReturnDestination: tripData.ReturnDestination,
+		ReturnMiles:       tripData.ReturnMiles,
+	}
+	trip.Normalize()
+	if trip.Origin == "" {
+		trip.Origin = s.cfg.HomeAddress
+	}
+
+	// Report every problem at once before looking anything up. Missing miles are
+	// calculated below, so stand-ins keep them from being reported here; custom trips
+	// are never looked up, so they must come with their miles.
+	probe := trip
+	if probe.Miles == 0 && !probe.IsCustom() {
+		probe.Miles = 1
+	}
+	if probe.Type == "round" && probe.ReturnDestination != "" && probe.ReturnMiles == 0 {
+		probe.ReturnMiles = 1
+	}
+	if errs := s.tripValidationErrors(probe); len(errs) > 0 {
+		writeValidationErrors(w, errs)
 		return
 	}
 
-	// Load existing data
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	// Use the supplied miles when given, otherwise calculate them using Google Maps API
+	milesEstimated := false
+	if trip.Miles == 0 {
+		distance, err := s.mapsClient.CalculateDistance(context.Background(), trip.Origin, trip.Destination)
+		if errors.Is(err, maps.ErrManualMiles) {
+			writeValidationErrors(w, []*model.ValidationError{{Field: "miles", Message: "Miles are required when distance lookups are disabled"}})
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to calculate distance: %v", err), http.StatusInternalServerError)
+			return
+		}
+		trip.Miles = distance
+		milesEstimated = s.usingMockMaps
+	}
+	if trip.Type == "round" && trip.ReturnDestination != "" && trip.ReturnMiles == 0 {
+		returnMiles, err := s.mapsClient.CalculateDistance(context.Background(), trip.Destination, trip.ReturnDestination)
+		if errors.Is(err, maps.ErrManualMiles) {
+			writeValidationErrors(w, []*model.ValidationError{{Field: "return_miles", Message: "Return miles are required when distance lookups are disabled"}})
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to calculate return distance: %v", err), http.StatusInternalServerError)
+			return
+		}
+		trip.ReturnMiles = returnMiles
+		milesEstimated = milesEstimated || s.usingMockMaps
+	}
+
+	// Check the calculated distances too
+	if errs := s.tripValidationErrors(trip); len(errs) > 0 {
+		writeValidationErrors(w, errs)
 		return
 	}
 
-	// Add the new trip
-	data.Trips = append(data.Trips, trip)
-
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
+	// Add the new trip under the storage lock so concurrent requests are not lost
+	var index int
+	if err := s.store.Update(func(data *model.StorageData) error {
+		data.Trips = append(data.Trips, trip)
+		index = len(data.Trips) - 1
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	s.audit.Record(audit.ActionCreate, audit.EntityTrip, audit.TripSummary(trip))
+
+	// Flag trips whose distance came from the mock client so callers can double-check them
+	response := newIndexedTripResponse(trip, index)
+	response.MilesEstimated = milesEstimated
 
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(trip); err != nil {
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
@@ -217,271 +486,1816 @@ func (s *Server) updateTrip(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
+	trip.Normalize()
 
 	// Validate the trip
-	if err := trip.Validate(); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid trip data: %v", err), http.StatusBadRequest)
+	if err := trip.ValidateWithBounds(time.Now(), s.cfg.MaxFutureDays); err != nil {
+		writeValidationError(w, err)
 		return
 	}
-
-	// Load existing data
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	if err := trip.ValidateMaxMiles(s.cfg.MaxTripMiles); err != nil {
+		writeValidationError(w, err)
 		return
 	}
-
-	// Update the trip
-	if err := data.EditTrip(index, trip); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update trip: %v", err), http.StatusBadRequest)
+	trip.Family = trip.FamilyOrDefault()
+	if !s.cfg.IsKnownFamily(trip.Family) {
+		writeValidationError(w, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", trip.Family)})
 		return
 	}
 
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
+	var previous model.Trip
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.Trips) {
+			previous = d.Trips[index]
+		}
+		if err := d.EditTrip(index, trip); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to update trip: %v", err)}
+		}
+		trip = d.Trips[index]
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	s.audit.Record(audit.ActionEdit, audit.EntityTrip, fmt.Sprintf("%s (was %s)", audit.TripSummary(trip), audit.TripSummary(previous)))
+	w.Header().Set("ETag", dataETag(data))
 
-	if err := json.NewEncoder(w).Encode(trip); err != nil {
+	if err := json.NewEncoder(w).Encode(newIndexedTripResponse(trip, index)); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) deleteTrip(w http.ResponseWriter, r *http.Request) {
-	// Extract index from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/api/trips/")
-	if path == "" {
-		http.Error(w, "Trip index is required", http.StatusBadRequest)
+// recalcTrips looks up the distance of one trip, or of every trip when no index is
+// given, again and saves the new miles
+func (s *Server) recalcTrips(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/trips"), "/recalc")
+	all := path == ""
+	var index int
+	if !all {
+		var err error
+		if index, err = strconv.Atoi(strings.TrimPrefix(path, "/")); err != nil {
+			http.Error(w, "Invalid trip index", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var trip model.Trip
+	var recalculated int
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		indexes := []int{index}
+		if all {
+			// Custom trips keep the miles entered for them
+			indexes = make([]int, 0, len(d.Trips))
+			for i, t := range d.Trips {
+				if !t.IsCustom() {
+					indexes = append(indexes, i)
+				}
+			}
+		} else if index < 0 || index >= len(d.Trips) {
+			return &requestError{http.StatusBadRequest, "Invalid trip index"}
+		} else if d.Trips[index].IsCustom() {
+			return &requestError{http.StatusBadRequest, "Custom trips keep their entered miles and can't be recalculated"}
+		}
+		if err := d.RecalculateTripMiles(r.Context(), s.mapsClient, indexes); err != nil {
+			if errors.Is(err, maps.ErrManualMiles) {
+				return &requestError{http.StatusConflict, "Distance lookups are disabled"}
+			}
+			return &requestError{http.StatusBadGateway, fmt.Sprintf("Failed to recalculate trips: %v", err)}
+		}
+		if !all {
+			trip = d.Trips[index]
+		}
+		recalculated = len(indexes)
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	if all {
+		s.audit.Record(audit.ActionEdit, audit.EntityTrip, fmt.Sprintf("Recalculated the miles of %d trips", recalculated))
+	} else {
+		s.audit.Record(audit.ActionEdit, audit.EntityTrip, "Recalculated miles: "+audit.TripSummary(trip))
+	}
+	w.Header().Set("ETag", dataETag(data))
 
-	index, err := strconv.Atoi(path)
-	if err != nil {
-		http.Error(w, "Invalid trip index", http.StatusBadRequest)
+	var response interface{} = map[string]interface{}{"recalculated": recalculated}
+	if !all {
+		// Flag distances that came from the mock client so callers can double-check them
+		tripResp := newTripResponse(trip)
+		tripResp.MilesEstimated = s.usingMockMaps
+		response = tripResp
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
+}
 
-	// Load existing data
-	data, err := s.store.LoadData()
+// setTripArchived archives or restores the trip at the index in the path
+func (s *Server) setTripArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/trips/")
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/unarchive"), "/archive")
+	index, err := strconv.Atoi(path)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		http.Error(w, "Invalid trip index", http.StatusBadRequest)
 		return
 	}
 
-	// Delete the trip
-	if err := data.DeleteTrip(index); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to delete trip: %v", err), http.StatusBadRequest)
+	var trip model.Trip
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		update := d.UnarchiveTrip
+		if archived {
+			update = d.ArchiveTrip
+		}
+		if err := update(index); err != nil {
+			return &requestError{http.StatusBadRequest, err.Error()}
+		}
+		trip = d.Trips[index]
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	if archived {
+		s.audit.Record(audit.ActionEdit, audit.EntityTrip, "Archived trip: "+audit.TripSummary(trip))
+	} else {
+		s.audit.Record(audit.ActionEdit, audit.EntityTrip, "Restored trip: "+audit.TripSummary(trip))
+	}
+	w.Header().Set("ETag", dataETag(data))
 
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(newTripResponse(trip)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
-
-	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) handleExpenses(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// duplicateGroup is a set of trips that share a date, route, type and family
+type duplicateGroup struct {
+	Indexes []int        `json:"indexes"`
+	Trip    tripResponse `json:"trip"`
+}
 
-	// Handle CORS preflight
-	if r.Method == http.MethodOptions {
-		w.WriteHeader(http.StatusOK)
+// handleTripDuplicates lists groups of duplicate trips
+func (s *Server) handleTripDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	switch r.Method {
-	case http.MethodGet:
-		s.getExpenses(w, r)
-	case http.MethodPost:
-		s.createExpense(w, r)
-	case http.MethodPut:
-		s.updateExpense(w, r)
-	case http.MethodDelete:
-		s.deleteExpense(w, r)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-	}
-}
+	w.Header().Set("Content-Type", "application/json")
 
-func (s *Server) getExpenses(w http.ResponseWriter, r *http.Request) {
 	data, err := s.store.LoadData()
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("ETag", dataETag(data))
+
+	groups := make([]duplicateGroup, 0)
+	extra := 0
+	for _, indexes := range data.FindDuplicateTrips() {
+		groups = append(groups, duplicateGroup{Indexes: indexes, Trip: newTripResponse(data.Trips[indexes[0]])})
+		extra += len(indexes) - 1
+	}
 
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"expenses": data.Expenses,
-		"count":    len(data.Expenses),
+		"duplicates": groups,
+		"count":      extra,
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) createExpense(w http.ResponseWriter, r *http.Request) {
-	var expense model.Expense
-	if err := json.NewDecoder(r.Body).Decode(&expense); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
-		return
-	}
+// handleDedupeTrips keeps the first trip of each duplicate group and removes the rest
+func (s *Server) handleDedupeTrips(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Validate the expense
-	if err := expense.Validate(); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid expense data: %v", err), http.StatusBadRequest)
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Load existing data
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	var removed int
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		removed = d.MergeDuplicates()
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
-
-	// Add the new expense
-	data.Expenses = append(data.Expenses, expense)
-
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
-		return
+	if removed > 0 {
+		s.audit.Record(audit.ActionDelete, audit.EntityTrip, fmt.Sprintf("Merged away %d duplicate trips", removed))
 	}
+	w.Header().Set("ETag", dataETag(data))
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(expense); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"removed": removed,
+	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) updateExpense(w http.ResponseWriter, r *http.Request) {
-	// Extract index from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/api/expenses/")
-	if path == "" {
-		http.Error(w, "Expense index is required", http.StatusBadRequest)
-		return
-	}
+// tripUpdate is one entry of a batch trip edit
+type tripUpdate struct {
+	Index int        `json:"index"`
+	Trip  model.Trip `json:"trip"`
+}
 
-	index, err := strconv.Atoi(path)
-	if err != nil {
-		http.Error(w, "Invalid expense index", http.StatusBadRequest)
+// batchItemError reports why one entry of a batch edit was rejected
+type batchItemError struct {
+	Index   int    `json:"index"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// handleBatchEditTrips applies several trip edits at once. Either every edit is saved
+// together or, if any is rejected, nothing changes and each problem is reported.
+func (s *Server) handleBatchEditTrips(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var expense model.Expense
-	if err := json.NewDecoder(r.Body).Decode(&expense); err != nil {
+	var updates []tripUpdate
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
 		return
 	}
-
-	// Validate the expense
-	if err := expense.Validate(); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid expense data: %v", err), http.StatusBadRequest)
+	if len(updates) == 0 {
+		http.Error(w, "At least one trip update is required", http.StatusBadRequest)
 		return
 	}
 
-	// Load existing data
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	var itemErrs []batchItemError
+	batch := make(map[int]model.Trip, len(updates))
+	for _, update := range updates {
+		if _, ok := batch[update.Index]; ok {
+			itemErrs = append(itemErrs, batchItemError{Index: update.Index, Message: "Trip is updated more than once"})
+			continue
+		}
+		update.Trip.Family = update.Trip.FamilyOrDefault()
+		for _, verr := range s.tripValidationErrors(update.Trip) {
+			itemErrs = append(itemErrs, batchItemError{Index: update.Index, Field: verr.Field, Message: verr.Message})
+		}
+		batch[update.Index] = update.Trip
+	}
+
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		// Nothing is saved unless this returns nil, so a rejected batch leaves the data untouched
+		if err := d.EditTrips(batch); err != nil {
+			var editErrs model.TripEditErrors
+			if !errors.As(err, &editErrs) {
+				return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to update trips: %v", err)}
+			}
+			itemErrs = appendTripEditErrors(itemErrs, editErrs)
+		}
+		if len(itemErrs) > 0 {
+			return errBatchRejected
+		}
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		if errors.Is(err, errBatchRejected) {
+			sort.SliceStable(itemErrs, func(i, j int) bool { return itemErrs[i].Index < itemErrs[j].Index })
+			writeBatchErrors(w, itemErrs)
+			return
+		}
+		writeUpdateError(w, err)
 		return
 	}
-
-	// Update the expense
-	if err := data.EditExpense(index, expense); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update expense: %v", err), http.StatusBadRequest)
-		return
+	w.Header().Set("ETag", dataETag(data))
+	indexes := make([]int, 0, len(batch))
+	for index := range batch {
+		indexes = append(indexes, index)
 	}
-
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
-		return
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		s.audit.Record(audit.ActionEdit, audit.EntityTrip, audit.TripSummary(batch[index]))
 	}
 
-	if err := json.NewEncoder(w).Encode(expense); err != nil {
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"updated": len(batch),
+	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func (s *Server) deleteExpense(w http.ResponseWriter, r *http.Request) {
+// errBatchRejected aborts a storage update when any entry of a batch edit is invalid
+var errBatchRejected = errors.New("batch rejected")
+
+// appendTripEditErrors adds the model's errors for trips that were not already reported
+func appendTripEditErrors(items []batchItemError, errs model.TripEditErrors) []batchItemError {
+	reported := make(map[int]bool, len(items))
+	for _, item := range items {
+		reported[item.Index] = true
+	}
+	for index, err := range errs {
+		if reported[index] {
+			continue
+		}
+		item := batchItemError{Index: index, Message: err.Error()}
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			item.Field = verr.Field
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// writeBatchErrors responds 422 with every rejected entry of a batch edit
+func writeBatchErrors(w http.ResponseWriter, errs []batchItemError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": errs,
+	}); err != nil {
+		slog.Error("Failed to encode batch errors", "error", err)
+	}
+}
+
+func (s *Server) deleteTrip(w http.ResponseWriter, r *http.Request) {
 	// Extract index from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/api/expenses/")
+	path := strings.TrimPrefix(r.URL.Path, "/api/trips/")
 	if path == "" {
-		http.Error(w, "Expense index is required", http.StatusBadRequest)
+		http.Error(w, "Trip index is required", http.StatusBadRequest)
 		return
 	}
 
 	index, err := strconv.Atoi(path)
 	if err != nil {
-		http.Error(w, "Invalid expense index", http.StatusBadRequest)
+		http.Error(w, "Invalid trip index", http.StatusBadRequest)
 		return
 	}
 
-	// Load existing data
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	var deleted model.Trip
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.Trips) {
+			deleted = d.Trips[index]
+		}
+		if err := d.DeleteTrip(index); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete trip: %v", err)}
+		}
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	s.audit.Record(audit.ActionDelete, audit.EntityTrip, audit.TripSummary(deleted))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	// Delete the expense
-	if err := data.DeleteExpense(index); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to delete expense: %v", err), http.StatusBadRequest)
+func (s *Server) deleteTripsInRange(w http.ResponseWriter, r *http.Request) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		http.Error(w, "Both from and to dates are required", http.StatusBadRequest)
 		return
 	}
 
-	// Save the updated data
-	if err := s.store.SaveData(data); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
+	var deleted int
+	if err := s.store.Update(func(data *model.StorageData) error {
+		var err error
+		deleted, err = data.DeleteTripsInRange(from, to)
+		if err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete trips: %v", err)}
+		}
+		model.CalculateAndUpdateWeeklySummaries(data, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
 		return
 	}
+	if deleted > 0 {
+		s.audit.Record(audit.ActionDelete, audit.EntityTrip, fmt.Sprintf("Deleted %d trips from %s to %s", deleted, from, to))
+	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted": deleted,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
 }
 
-func (s *Server) handleWeeklySummaries(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+func (s *Server) handleExtendRecurring(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-
-	data, err := s.store.LoadData()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+	var request struct {
+		EndDate string `json:"end_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := model.ValidateDate(request.EndDate); err != nil {
+		writeValidationError(w, &model.ValidationError{Field: "end_date", Message: err.Error()})
 		return
 	}
 
-	// Calculate weekly summaries
-	summaries := model.CalculateWeeklySummaries(data.Trips, data.Expenses, s.cfg.RatePerMile)
+	var created int
+	if err := s.store.Update(func(data *model.StorageData) error {
+		var err error
+		created, err = data.ExtendRecurringTrips(request.EndDate)
+		if err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to extend recurring trips: %v", err)}
+		}
+		model.CalculateAndUpdateWeeklySummaries(data, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionEdit, audit.EntityRecurringTrip, fmt.Sprintf("Extended active recurring trips to %s, creating %d trips", request.EndDate, created))
 
 	if err := json.NewEncoder(w).Encode(map[string]interface{}{
-		"summaries": summaries,
-		"count":     len(summaries),
+		"created": created,
 	}); err != nil {
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
-func main() {
-	// Parse command line flags
-	var showVersion bool
-	flag.BoolVar(&showVersion, "version", false, "Show version information")
-	flag.BoolVar(&showVersion, "v", false, "Show version information")
+func (s *Server) handleRecurring(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getRecurringTrips(w, r)
+	case http.MethodPost:
+		s.createRecurringTrip(w, r)
+	case http.MethodPut:
+		s.updateRecurringTrip(w, r)
+	case http.MethodDelete:
+		s.deleteRecurringTrip(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// recurringTripResponse is a recurring trip as listed by GET /api/recurring
+type recurringTripResponse struct {
+	model.RecurringTrip
+	// Index is the recurring trip's position in storage, which PUT and DELETE
+	// /api/recurring/{index} take; it differs from its place in a family's list
+	Index int `json:"index"`
+}
+
+func (s *Server) getRecurringTrips(w http.ResponseWriter, r *http.Request) {
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	family := r.URL.Query().Get("family")
+	recurring := make([]recurringTripResponse, 0, len(data.RecurringTrips))
+	for index, rt := range data.RecurringTrips {
+		if family != "" && (model.Trip{Family: rt.Family}).FamilyOrDefault() != family {
+			continue
+		}
+		recurring = append(recurring, recurringTripResponse{RecurringTrip: rt, Index: index})
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"recurringTrips": recurring,
+		"count":          len(recurring),
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// prepareRecurringTrip checks the family of a recurring trip from a request, looks up
+// its miles when none are given and validates it. It writes the error response and
+// returns false when the trip can't be saved.
+func (s *Server) prepareRecurringTrip(w http.ResponseWriter, rt *model.RecurringTrip) bool {
+	if family := (model.Trip{Family: rt.Family}).FamilyOrDefault(); !s.cfg.IsKnownFamily(family) {
+		writeValidationError(w, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", family)})
+		return false
+	}
+	if rt.Miles == 0 && rt.Origin != "" && rt.Destination != "" {
+		distance, err := s.mapsClient.CalculateDistance(context.Background(), rt.Origin, rt.Destination)
+		if errors.Is(err, maps.ErrManualMiles) {
+			writeValidationError(w, &model.ValidationError{Field: "miles", Message: "Miles are required when distance lookups are disabled"})
+			return false
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to calculate distance: %v", err), http.StatusInternalServerError)
+			return false
+		}
+		rt.Miles = distance
+	}
+	if err := rt.Validate(); err != nil {
+		writeValidationError(w, err)
+		return false
+	}
+	return true
+}
+
+func (s *Server) createRecurringTrip(w http.ResponseWriter, r *http.Request) {
+	var rt model.RecurringTrip
+	if err := json.NewDecoder(r.Body).Decode(&rt); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !s.prepareRecurringTrip(w, &rt) {
+		return
+	}
+
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		if err := d.AddRecurringTrip(rt); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to add recurring trip: %v", err)}
+		}
+		if err := d.GenerateTripsFromRecurring(); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to generate trips: %v", err)}
+		}
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionCreate, audit.EntityRecurringTrip, audit.RecurringTripSummary(rt))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(rt); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// recurringIndex reads the recurring trip index from the request path, writing the
+// error response and returning false when it is missing or malformed
+func recurringIndex(w http.ResponseWriter, r *http.Request) (int, bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/recurring/")
+	if path == "" || path == r.URL.Path {
+		http.Error(w, "Recurring trip index is required", http.StatusBadRequest)
+		return 0, false
+	}
+	index, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Invalid recurring trip index", http.StatusBadRequest)
+		return 0, false
+	}
+	return index, true
+}
+
+func (s *Server) updateRecurringTrip(w http.ResponseWriter, r *http.Request) {
+	index, ok := recurringIndex(w, r)
+	if !ok {
+		return
+	}
+
+	var rt model.RecurringTrip
+	if err := json.NewDecoder(r.Body).Decode(&rt); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !s.prepareRecurringTrip(w, &rt) {
+		return
+	}
+
+	var previous model.RecurringTrip
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.RecurringTrips) {
+			previous = d.RecurringTrips[index]
+		}
+		// Remove the trips generated from the old pattern before generating the new ones
+		if _, err := d.DeleteGeneratedTrips(index); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to update recurring trip: %v", err)}
+		}
+		if err := d.EditRecurringTrip(index, rt); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to update recurring trip: %v", err)}
+		}
+		if err := d.GenerateTripsFromRecurring(); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to generate trips: %v", err)}
+		}
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionEdit, audit.EntityRecurringTrip, fmt.Sprintf("%s (was %s)", audit.RecurringTripSummary(rt), audit.RecurringTripSummary(previous)))
+	w.Header().Set("ETag", dataETag(data))
+
+	if err := json.NewEncoder(w).Encode(rt); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) deleteRecurringTrip(w http.ResponseWriter, r *http.Request) {
+	index, ok := recurringIndex(w, r)
+	if !ok {
+		return
+	}
+
+	var deleted model.RecurringTrip
+	var removed int
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.RecurringTrips) {
+			deleted = d.RecurringTrips[index]
+		}
+		// Remove the generated trips first, while the pattern is still there to match them
+		var err error
+		if removed, err = d.DeleteGeneratedTrips(index); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete recurring trip: %v", err)}
+		}
+		if err := d.DeleteRecurringTrip(index); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete recurring trip: %v", err)}
+		}
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionDelete, audit.EntityRecurringTrip, fmt.Sprintf("%s and its %d generated trips", audit.RecurringTripSummary(deleted), removed))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleExpenses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		// GET /api/expenses/{index} returns a single item
+		if strings.HasPrefix(r.URL.Path, "/api/expenses/") && r.URL.Path != "/api/expenses/" {
+			s.getExpense(w, r)
+		} else {
+			s.getExpenses(w, r)
+		}
+	case http.MethodPost:
+		if r.URL.Path == "/api/expenses/import" {
+			s.importExpenses(w, r)
+		} else {
+			s.createExpense(w, r)
+		}
+	case http.MethodPut:
+		s.updateExpense(w, r)
+	case http.MethodDelete:
+		s.deleteExpense(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// indexedExpenseResponse is an expense as listed by GET /api/expenses
+type indexedExpenseResponse struct {
+	model.Expense
+	// Index is the expense's position in storage, which GET, PUT and DELETE
+	// /api/expenses/{index} take; lists are filtered and sorted, so it differs from the
+	// expense's place in them
+	Index int `json:"index"`
+}
+
+func (s *Server) getExpenses(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	// Filter by family and date range, keeping each expense's stored index, then sort
+	// in descending order (most recent first) like trips
+	family := r.URL.Query().Get("family")
+	var indexes []int
+	for i, expense := range data.Expenses {
+		if family != "" && expense.FamilyOrDefault() != family {
+			continue
+		}
+		if inDateRange(expense.Date, from, to) {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return data.Expenses[indexes[i]].Date > data.Expenses[indexes[j]].Date
+	})
+
+	start, end, totalPages := pageBounds(len(indexes), page, pageSize)
+	pageExpenses := make([]indexedExpenseResponse, 0, end-start)
+	for _, index := range indexes[start:end] {
+		pageExpenses = append(pageExpenses, indexedExpenseResponse{Expense: data.Expenses[index], Index: index})
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"expenses":   pageExpenses,
+		"count":      len(pageExpenses),
+		"total":      len(indexes),
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": totalPages,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) getExpense(w http.ResponseWriter, r *http.Request) {
+	// Extract index from URL path
+	index, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/expenses/"))
+	if err != nil {
+		http.Error(w, "Invalid expense index", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	if index < 0 || index >= len(data.Expenses) {
+		http.Error(w, "Expense not found", http.StatusNotFound)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(data.Expenses[index]); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) createExpense(w http.ResponseWriter, r *http.Request) {
+	var expense model.Expense
+	if err := json.NewDecoder(r.Body).Decode(&expense); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the expense
+	if err := expense.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	expense.Category = expense.CategoryOrDefault()
+	expense.Family = expense.FamilyOrDefault()
+	if !s.cfg.IsKnownFamily(expense.Family) {
+		writeValidationError(w, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", expense.Family)})
+		return
+	}
+
+	// Add the new expense under the storage lock so concurrent requests are not lost
+	var linkErr error
+	if err := s.store.Update(func(data *model.StorageData) error {
+		if linkErr = linkExpenseTrip(data, &expense); linkErr != nil {
+			return linkErr
+		}
+		data.Expenses = append(data.Expenses, expense)
+		return nil
+	}); err != nil {
+		if linkErr != nil {
+			writeValidationError(w, linkErr)
+			return
+		}
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionCreate, audit.EntityExpense, audit.ExpenseSummary(expense))
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(expense); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// maxImportSize caps the size of an uploaded expense CSV
+const maxImportSize = 10 << 20
+
+// importExpenses appends expenses parsed from a CSV upload, sent either as the raw
+// request body or as the "file" field of a multipart form
+func (s *Server) importExpenses(w http.ResponseWriter, r *http.Request) {
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxImportSize); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid multipart form: %v", err), http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "Multipart form must include a \"file\" field", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	expenses, err := export.ImportExpensesCSV(body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid CSV: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(expenses) == 0 {
+		http.Error(w, "CSV contains no expenses", http.StatusBadRequest)
+		return
+	}
+	for i := range expenses {
+		expenses[i].Category = expenses[i].CategoryOrDefault()
+		expenses[i].Family = expenses[i].FamilyOrDefault()
+	}
+
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		d.Expenses = append(d.Expenses, expenses...)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	for _, expense := range expenses {
+		s.audit.Record(audit.ActionCreate, audit.EntityExpense, audit.ExpenseSummary(expense))
+	}
+
+	// Summaries sort their inputs in place, so work on copies to keep indexes stable
+	summaries := model.CalculateWeeklySummaries(
+		append([]model.Trip(nil), data.Trips...),
+		append([]model.Expense(nil), data.Expenses...),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	summaries = model.ApplyAdjustments(summaries, data.Adjustments, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported":   len(expenses),
+		"expenses":   expenses,
+		"grandTotal": model.CalculateGrandTotal(summaries),
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// linkExpenseTrip links an expense from a request to the trip it names, by trip_date and
+// trip_index or by the trip_id of a trip already linked to, returning a validation error
+// when data has no such trip
+func linkExpenseTrip(data *model.StorageData, expense *model.Expense) error {
+	if expense.TripDate != "" {
+		if !expense.LinkByDate(data.Trips) {
+			return &model.ValidationError{Field: "trip_index", Message: fmt.Sprintf("No trip %d for %s on %s", expense.TripIndex, expense.Family, expense.TripDate)}
+		}
+		return nil
+	}
+	if expense.IsTripLinked() {
+		if _, ok := expense.LinkedTrip(data.Trips); !ok {
+			return &model.ValidationError{Field: "trip_id", Message: fmt.Sprintf("No trip with ID %s", expense.TripID)}
+		}
+	}
+	return nil
+}
+
+func (s *Server) updateExpense(w http.ResponseWriter, r *http.Request) {
+	// Extract index from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/api/expenses/")
+	if path == "" {
+		http.Error(w, "Expense index is required", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Invalid expense index", http.StatusBadRequest)
+		return
+	}
+
+	var expense model.Expense
+	if err := json.NewDecoder(r.Body).Decode(&expense); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate the expense
+	if err := expense.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	expense.Category = expense.CategoryOrDefault()
+	expense.Family = expense.FamilyOrDefault()
+	if !s.cfg.IsKnownFamily(expense.Family) {
+		writeValidationError(w, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", expense.Family)})
+		return
+	}
+
+	var previous model.Expense
+	var data *model.StorageData
+	var linkErr error
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if linkErr = linkExpenseTrip(d, &expense); linkErr != nil {
+			return linkErr
+		}
+		if index >= 0 && index < len(d.Expenses) {
+			previous = d.Expenses[index]
+		}
+		if err := d.EditExpense(index, expense); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to update expense: %v", err)}
+		}
+		data = d
+		return nil
+	}); err != nil {
+		if linkErr != nil {
+			writeValidationError(w, linkErr)
+			return
+		}
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionEdit, audit.EntityExpense, fmt.Sprintf("%s (was %s)", audit.ExpenseSummary(expense), audit.ExpenseSummary(previous)))
+	w.Header().Set("ETag", dataETag(data))
+
+	if err := json.NewEncoder(w).Encode(expense); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) deleteExpense(w http.ResponseWriter, r *http.Request) {
+	// Extract index from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/api/expenses/")
+	if path == "" {
+		http.Error(w, "Expense index is required", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Invalid expense index", http.StatusBadRequest)
+		return
+	}
+
+	var deleted model.Expense
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.Expenses) {
+			deleted = d.Expenses[index]
+		}
+		if err := d.DeleteExpense(index); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete expense: %v", err)}
+		}
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionDelete, audit.EntityExpense, audit.ExpenseSummary(deleted))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// expenseDuplicateGroup is a set of expenses that share a date, amount, description and family
+type expenseDuplicateGroup struct {
+	Indexes []int         `json:"indexes"`
+	Expense model.Expense `json:"expense"`
+}
+
+// handleExpenseDuplicates lists groups of duplicate expenses
+func (s *Server) handleExpenseDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	groups := make([]expenseDuplicateGroup, 0)
+	extra := 0
+	for _, indexes := range data.FindDuplicateExpenses() {
+		groups = append(groups, expenseDuplicateGroup{Indexes: indexes, Expense: data.Expenses[indexes[0]]})
+		extra += len(indexes) - 1
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"duplicates": groups,
+		"count":      extra,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleDedupeExpenses keeps the first expense of each duplicate group and removes the rest
+func (s *Server) handleDedupeExpenses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var removed int
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		removed = d.MergeDuplicateExpenses()
+		model.CalculateAndUpdateWeeklySummaries(d, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	if removed > 0 {
+		s.audit.Record(audit.ActionDelete, audit.EntityExpense, fmt.Sprintf("Merged away %d duplicate expenses", removed))
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"removed": removed,
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleAdjustments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getAdjustments(w, r)
+	case http.MethodPost:
+		s.createAdjustment(w, r)
+	case http.MethodDelete:
+		s.deleteAdjustment(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) getAdjustments(w http.ResponseWriter, r *http.Request) {
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	adjustments := append(make([]model.Adjustment, 0, len(data.Adjustments)),
+		model.FilterAdjustmentsByFamily(data.Adjustments, r.URL.Query().Get("family"))...)
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"adjustments": adjustments,
+		"count":       len(adjustments),
+		"totalMiles":  model.CalculateAdjustmentMiles(adjustments),
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) createAdjustment(w http.ResponseWriter, r *http.Request) {
+	var adjustment model.Adjustment
+	if err := json.NewDecoder(r.Body).Decode(&adjustment); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := adjustment.Validate(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	adjustment.Family = adjustment.FamilyOrDefault()
+	if !s.cfg.IsKnownFamily(adjustment.Family) {
+		writeValidationError(w, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", adjustment.Family)})
+		return
+	}
+
+	// The week's miles are checked under the storage lock so a concurrent change can't
+	// take them below zero
+	var addErr error
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		if addErr = d.AddAdjustment(adjustment, s.cfg.WeekStartDay); addErr != nil {
+			return addErr
+		}
+		data = d
+		return nil
+	}); err != nil {
+		if addErr != nil {
+			writeValidationError(w, addErr)
+			return
+		}
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionCreate, audit.EntityAdjustment, audit.AdjustmentSummary(adjustment))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(adjustment); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) deleteAdjustment(w http.ResponseWriter, r *http.Request) {
+	// Extract index from URL path
+	path := strings.TrimPrefix(r.URL.Path, "/api/adjustments/")
+	if path == "" || path == r.URL.Path {
+		http.Error(w, "Adjustment index is required", http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, "Invalid adjustment index", http.StatusBadRequest)
+		return
+	}
+
+	var deleted model.Adjustment
+	var data *model.StorageData
+	if err := s.store.Update(func(d *model.StorageData) error {
+		// Reject the change if the data was modified since the client last read it
+		if !matchesETag(r, d) {
+			return &requestError{http.StatusPreconditionFailed, "Data has changed since it was last read"}
+		}
+		if index >= 0 && index < len(d.Adjustments) {
+			deleted = d.Adjustments[index]
+		}
+		if err := d.DeleteAdjustment(index, s.cfg.WeekStartDay); err != nil {
+			return &requestError{http.StatusBadRequest, fmt.Sprintf("Failed to delete adjustment: %v", err)}
+		}
+		data = d
+		return nil
+	}); err != nil {
+		writeUpdateError(w, err)
+		return
+	}
+	s.audit.Record(audit.ActionDelete, audit.EntityAdjustment, audit.AdjustmentSummary(deleted))
+	w.Header().Set("ETag", dataETag(data))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// weeklySummaryResponse is a weekly summary with its change from the previous week,
+// omitted when nothing was recorded that week
+type weeklySummaryResponse struct {
+	model.WeeklySummary
+	Delta *model.SummaryDelta `json:"delta,omitempty"`
+	// TargetPercent is the week's miles as a percentage of the weekly mileage target, when one is set
+	TargetPercent *float64 `json:"targetPercent,omitempty"`
+}
+
+func (s *Server) handleWeeklySummaries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	// Calculate weekly summaries
+	family := r.URL.Query().Get("family")
+	var summaries []model.WeeklySummary
+	if until := r.URL.Query().Get("projectUntil"); until != "" {
+		// Forecast upcoming weeks from the family's recurring trips
+		familyData := &model.StorageData{
+			Trips:          model.FilterTripsByFamily(data.Trips, family),
+			Expenses:       model.FilterExpensesByFamily(data.Expenses, family),
+			Adjustments:    model.FilterAdjustmentsByFamily(data.Adjustments, family),
+			RecurringTrips: model.FilterRecurringTripsByFamily(data.RecurringTrips, family),
+			ReferenceDate:  data.ReferenceDate,
+		}
+		summaries, err = model.CalculateWeeklySummariesWithProjections(familyData,
+			s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay, until)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid projectUntil: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else {
+		summaries = model.CalculateWeeklySummaries(
+			model.FilterTripsByFamily(data.Trips, family),
+			model.FilterExpensesByFamily(data.Expenses, family),
+			s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+		summaries = model.ApplyAdjustments(summaries, model.FilterAdjustmentsByFamily(data.Adjustments, family),
+			s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	}
+
+	withDeltas := make([]weeklySummaryResponse, len(summaries))
+	for i, summary := range summaries {
+		withDeltas[i].WeeklySummary = summary
+		if prev, ok := model.PreviousWeek(summaries, i); ok {
+			delta := summary.CompareTo(prev)
+			withDeltas[i].Delta = &delta
+		}
+		if s.cfg.WeeklyMileageTarget > 0 {
+			percent := summary.TargetPercent(s.cfg.WeeklyMileageTarget)
+			withDeltas[i].TargetPercent = &percent
+		}
+	}
+
+	response := map[string]interface{}{
+		"summaries":  withDeltas,
+		"count":      len(summaries),
+		"grandTotal": model.CalculateGrandTotal(summaries),
+	}
+	if s.cfg.WeeklyMileageTarget > 0 {
+		response["weeklyMileageTarget"] = s.cfg.WeeklyMileageTarget
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleWeekSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Path is /api/summaries/{week-start}
+	weekStart := strings.TrimPrefix(r.URL.Path, "/api/summaries/")
+	if _, err := time.Parse("2006-01-02", weekStart); err != nil {
+		http.Error(w, "Invalid week start, expected YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	family := r.URL.Query().Get("family")
+	summaries := model.CalculateWeeklySummaries(
+		model.FilterTripsByFamily(data.Trips, family),
+		model.FilterExpensesByFamily(data.Expenses, family),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	summaries = model.ApplyAdjustments(summaries, model.FilterAdjustmentsByFamily(data.Adjustments, family),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	summary, ok := model.SummaryByWeekStart(summaries, weekStart)
+	if !ok {
+		http.Error(w, "No trips or expenses recorded for that week", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", dataETag(data))
+	if err := export.ExportWeekJSON(w, summary); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleYearlySummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Default to the current year when none is given
+	year := time.Now().Year()
+	if value := r.URL.Query().Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		year = parsed
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", dataETag(data))
+
+	family := r.URL.Query().Get("family")
+	summary := model.CalculateYearlySummary(
+		model.FilterTripsByFamily(data.Trips, family),
+		model.FilterExpensesByFamily(data.Expenses, family),
+		s.cfg.RatePerMile, year)
+
+	// Round each month, then total the rounded months so the breakdown adds up
+	summary.TotalAmount = 0
+	for i := range summary.Months {
+		summary.Months[i].TotalAmount = model.RoundAmount(summary.Months[i].TotalAmount, s.cfg.RoundingMode)
+		summary.TotalAmount += summary.Months[i].TotalAmount
+	}
+	summary.TotalAmount = model.RoundAmount(summary.TotalAmount, s.cfg.RoundingMode)
+
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleMonthlySummaryPDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Path is /api/summaries/monthly/{yyyy-mm}/pdf
+	path := strings.TrimPrefix(r.URL.Path, "/api/summaries/monthly/")
+	month, format, found := strings.Cut(path, "/")
+	if !found || format != "pdf" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	family := r.URL.Query().Get("family")
+	summary, err := model.CalculateMonthlySummary(
+		model.FilterTripsByFamily(data.Trips, family),
+		model.FilterExpensesByFamily(data.Expenses, family),
+		s.cfg.RatePerMile, month)
+	if err != nil {
+		http.Error(w, "Invalid month, expected YYYY-MM", http.StatusBadRequest)
+		return
+	}
+	summary.TotalAmount = model.RoundAmount(summary.TotalAmount, s.cfg.RoundingMode)
+
+	// Render into a buffer so a failure can still be reported as an error response
+	var buf bytes.Buffer
+	if err := export.ExportSummaryPDF(&buf, summary, s.cfg.DateFormat); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to generate PDF: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=nannytracker-%s.pdf", month))
+	w.Write(buf.Bytes())
+}
+
+// handleSummariesExport serves the weekly summaries as a downloadable spreadsheet
+func (s *Server) handleSummariesExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, fmt.Sprintf("Unsupported export format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	family := r.URL.Query().Get("family")
+	summaries := model.CalculateWeeklySummaries(
+		model.FilterTripsByFamily(data.Trips, family),
+		model.FilterExpensesByFamily(data.Expenses, family),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	summaries = model.ApplyAdjustments(summaries, model.FilterAdjustmentsByFamily(data.Adjustments, family),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+
+	var buf bytes.Buffer
+	if err := export.ExportWeeklySummariesCSV(&buf, summaries); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to generate CSV: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=nannytracker-weekly-%s.csv", time.Now().Format("2006-01-02")))
+	w.Write(buf.Bytes())
+}
+
+// statsResponse holds all-time aggregate numbers for a dashboard
+type statsResponse struct {
+	TripCount          int     `json:"tripCount"`
+	RecurringTripCount int     `json:"recurringTripCount"`
+	ExpenseCount       int     `json:"expenseCount"`
+	TotalMiles         float64 `json:"totalMiles"`
+	TotalReimbursement float64 `json:"totalReimbursement"`
+	EarliestTripDate   string  `json:"earliestTripDate,omitempty"`
+	LatestTripDate     string  `json:"latestTripDate,omitempty"`
+	// AverageMilesPerTrip and AverageMilesPerActiveDay are zero when there are no trips
+	AverageMilesPerTrip      float64 `json:"averageMilesPerTrip"`
+	AverageMilesPerActiveDay float64 `json:"averageMilesPerActiveDay"`
+	// ThisWeek and LastWeek summarize the week containing today and the one before it,
+	// zeroed when nothing was recorded
+	ThisWeek model.WeeklySummary `json:"thisWeek"`
+	LastWeek model.WeeklySummary `json:"lastWeek"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Archived trips don't count towards any of the figures
+	trips := model.UnarchivedTrips(data.Trips)
+	stats := statsResponse{
+		TripCount:          len(trips),
+		RecurringTripCount: len(data.RecurringTrips),
+		ExpenseCount:       len(data.Expenses),
+		TotalMiles:         model.CalculateTotalMiles(trips),
+		TotalReimbursement: model.RoundAmount(model.CalculateReimbursement(trips, s.cfg.RatePerMile), s.cfg.RoundingMode),
+		// Averages use effective miles, so a round trip counts both ways
+		AverageMilesPerTrip:      model.AverageMilesPerTrip(trips),
+		AverageMilesPerActiveDay: model.AverageMilesPerActiveDay(trips),
+	}
+	for _, trip := range trips {
+		if stats.EarliestTripDate == "" || trip.Date < stats.EarliestTripDate {
+			stats.EarliestTripDate = trip.Date
+		}
+		if trip.Date > stats.LatestTripDate {
+			stats.LatestTripDate = trip.Date
+		}
+	}
+
+	// Summaries sort their inputs in place, so work on copies
+	summaries := model.CalculateWeeklySummaries(
+		append([]model.Trip(nil), data.Trips...),
+		append([]model.Expense(nil), data.Expenses...),
+		s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	summaries = model.ApplyAdjustments(summaries, data.Adjustments, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+	now := time.Now()
+	stats.ThisWeek = model.SummaryForWeek(summaries, now, s.cfg.WeekStartDay)
+	stats.LastWeek = model.SummaryForWeek(summaries, now.AddDate(0, 0, -7), s.cfg.WeekStartDay)
+
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := s.store.LoadData()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to load data: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Make sure the backup carries up-to-date weekly summaries
+	model.CalculateAndUpdateWeeklySummaries(data, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=nannytracker-backup.json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	var data model.StorageData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Upgrade exports from older versions before checking them
+	if err := storage.Migrate(&data); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid import data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Reject the whole document if any record is invalid
+	if err := data.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid import data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	model.CalculateAndUpdateWeeklySummaries(&data, s.cfg.RatePerMile, s.cfg.RoundingMode, s.cfg.WeekStartDay)
+
+	// Replace the stored data
+	if err := s.store.SaveData(&data); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to save data: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.audit.Record(audit.ActionEdit, audit.EntityData, fmt.Sprintf("Replaced all data from an import: %d trips, %d recurring trips, %d expenses, %d templates",
+		len(data.Trips), len(data.RecurringTrips), len(data.Expenses), len(data.TripTemplates)))
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"trips":           len(data.Trips),
+		"recurring_trips": len(data.RecurringTrips),
+		"expenses":        len(data.Expenses),
+		"trip_templates":  len(data.TripTemplates),
+	}); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// dataETag returns the entity tag identifying the current version of the stored data
+func dataETag(data *model.StorageData) string {
+	return fmt.Sprintf("\"%d\"", data.UpdatedAt.UnixNano())
+}
+
+// matchesETag reports whether the request's If-Match header, if any, matches the stored data
+func matchesETag(r *http.Request, data *model.StorageData) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+
+	etag := dataETag(data)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePagination reads the page and pageSize query parameters, applying defaults when absent
+func parsePagination(r *http.Request) (page, pageSize int, err error) {
+	page = 0
+	pageSize = defaultPageSize
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 0 {
+			return 0, 0, fmt.Errorf("page must be a non-negative integer")
+		}
+	}
+	if value := r.URL.Query().Get("pageSize"); value != "" {
+		pageSize, err = strconv.Atoi(value)
+		if err != nil || pageSize <= 0 {
+			return 0, 0, fmt.Errorf("pageSize must be a positive integer")
+		}
+	}
+
+	return page, pageSize, nil
+}
+
+// parseDateRange reads the optional from and to query parameters (YYYY-MM-DD).
+// A missing bound is returned as an empty string and treated as open-ended.
+func parseDateRange(r *http.Request) (from, to string, err error) {
+	from = r.URL.Query().Get("from")
+	to = r.URL.Query().Get("to")
+
+	if from != "" {
+		if err := model.ValidateDate(from); err != nil {
+			return "", "", fmt.Errorf("invalid from date: %v", err)
+		}
+	}
+	if to != "" {
+		if err := model.ValidateDate(to); err != nil {
+			return "", "", fmt.Errorf("invalid to date: %v", err)
+		}
+	}
+
+	return from, to, nil
+}
+
+// inDateRange reports whether date falls within the inclusive range, where an empty bound is open-ended
+func inDateRange(date, from, to string) bool {
+	if from != "" && date < from {
+		return false
+	}
+	if to != "" && date > to {
+		return false
+	}
+	return true
+}
+
+// pageBounds returns the slice bounds for the requested page and the total number of pages
+func pageBounds(total, page, pageSize int) (start, end, totalPages int) {
+	totalPages = (total + pageSize - 1) / pageSize
+	if page >= totalPages {
+		return total, total, totalPages
+	}
+	start = page * pageSize
+	end = start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end, totalPages
+}
+
+// resolvePort picks the listening port: the -port flag, then the PORT environment
+// variable, then 8080
+func resolvePort(flagPort string) string {
+	if flagPort != "" {
+		return flagPort
+	}
+	if port := os.Getenv("PORT"); port != "" {
+		return port
+	}
+	return "8080"
+}
+
+// writeValidationError responds 400 with a JSON {field, message} body when err is a
+// model.ValidationError, so clients can highlight the offending input. Other errors
+// fall back to a plain-text 400.
+func writeValidationError(w http.ResponseWriter, err error) {
+	var verr *model.ValidationError
+	if !errors.As(err, &verr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(verr); err != nil {
+		slog.Error("Failed to encode validation error", "error", err)
+	}
+}
+
+// writeValidationErrors responds with 422 and every failing field, so a form can
+// show them all at once
+func writeValidationErrors(w http.ResponseWriter, errs []*model.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": errs,
+	}); err != nil {
+		slog.Error("Failed to encode validation errors", "error", err)
+	}
+}
+
+// tripValidationErrors lists every problem with a new trip, including the configured
+// families and the future date and distance limits
+func (s *Server) tripValidationErrors(trip model.Trip) []*model.ValidationError {
+	errs := trip.ValidationErrors()
+	if !s.cfg.IsKnownFamily(trip.Family) {
+		errs = append(errs, &model.ValidationError{Field: "family", Message: fmt.Sprintf("Unknown family: %s", trip.Family)})
+	}
+	for _, err := range []error{
+		trip.ValidateFutureDate(time.Now(), s.cfg.MaxFutureDays),
+		trip.ValidateMaxMiles(s.cfg.MaxTripMiles),
+	} {
+		var verr *model.ValidationError
+		if errors.As(err, &verr) {
+			errs = append(errs, verr)
+		}
+	}
+	return errs
+}
+
+// requestError rejects a request from inside a storage update with a specific status
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// writeUpdateError reports a failed storage update. Rejections from the update function
+// keep their status; anything else means the data could not be loaded or saved.
+func writeUpdateError(w http.ResponseWriter, err error) {
+	var rerr *requestError
+	if errors.As(err, &rerr) {
+		http.Error(w, rerr.message, rerr.status)
+		return
+	}
+	http.Error(w, fmt.Sprintf("Failed to update data: %v", err), http.StatusInternalServerError)
+}
+
+func main() {
+	// Parse command line flags
+	var showVersion bool
+	var portFlag, dataFlag string
+	var noMaps bool
+	flag.BoolVar(&showVersion, "version", false, "Show version information")
+	flag.BoolVar(&showVersion, "v", false, "Show version information")
+	flag.StringVar(&portFlag, "port", "", "Port to listen on (overrides PORT)")
+	flag.StringVar(&dataFlag, "data", "", "Path to the data file (overrides NANNYTRACKER_DATA_PATH)")
+	flag.BoolVar(&noMaps, "no-maps", false, "Require trip miles instead of using Google Maps (overrides NANNYTRACKER_MANUAL_MILES)")
 	flag.Parse()
 
 	// Show version if requested
@@ -496,52 +2310,250 @@ func main() {
 	// Load configuration
 	cfg, err := config.New()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
+	}
+	slog.SetDefault(newLogger(os.Stderr, cfg.LogLevel))
+	if dataFlag != "" {
+		if err := cfg.SetDataPath(dataFlag); err != nil {
+			fatal("Failed to load configuration", err)
+		}
+	}
+	if noMaps {
+		cfg.ManualMiles = true
 	}
 
 	// Create server
 	server, err := NewServer(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+		fatal("Failed to create server", err)
 	}
 
 	// Set up routes
 	http.HandleFunc("/health", server.handleHealth)
 	http.HandleFunc("/version", server.handleVersion)
+	http.HandleFunc("/api/config", server.handleConfig)
+	http.HandleFunc("/api/rate", server.handleRate)
 	http.HandleFunc("/api/trips", server.handleTrips)
 	http.HandleFunc("/api/trips/", server.handleTrips) // Handle /api/trips/{index}
+	http.HandleFunc("/api/trips/batch", server.handleBatchEditTrips)
+	http.HandleFunc("/api/trips/duplicates", server.handleTripDuplicates)
+	http.HandleFunc("/api/trips/dedupe", server.handleDedupeTrips)
 	http.HandleFunc("/api/expenses", server.handleExpenses)
 	http.HandleFunc("/api/expenses/", server.handleExpenses) // Handle /api/expenses/{index}
+	http.HandleFunc("/api/expenses/duplicates", server.handleExpenseDuplicates)
+	http.HandleFunc("/api/expenses/dedupe", server.handleDedupeExpenses)
+	http.HandleFunc("/api/adjustments", server.handleAdjustments)
+	http.HandleFunc("/api/adjustments/", server.handleAdjustments) // Handle /api/adjustments/{index}
+	http.HandleFunc("/api/recurring", server.handleRecurring)
+	http.HandleFunc("/api/recurring/", server.handleRecurring) // Handle /api/recurring/{index}
+	http.HandleFunc("/api/recurring/extend", server.handleExtendRecurring)
 	http.HandleFunc("/api/summaries", server.handleWeeklySummaries)
+	http.HandleFunc("/api/summaries/", server.handleWeekSummary) // Handle /api/summaries/{week-start}
+	http.HandleFunc("/api/summaries/yearly", server.handleYearlySummary)
+	http.HandleFunc("/api/summaries/export", server.handleSummariesExport)
+	http.HandleFunc("/api/summaries/monthly/", server.handleMonthlySummaryPDF) // Handle /api/summaries/monthly/{yyyy-mm}/pdf
+	http.HandleFunc("/api/stats", server.handleStats)
+	http.HandleFunc("/api/export", server.handleExport)
+	http.HandleFunc("/api/import", server.handleImport)
+
+	port := resolvePort(portFlag)
+
+	slog.Info("Starting NannyTracker API server", "port", port, "data_file", cfg.DataPath())
+	if cfg.SaveInterval > 0 {
+		slog.Info("Saving changes to disk periodically", "interval", cfg.SaveInterval)
+	}
+	slog.Info("Allowed origins", "origins", strings.Join(cfg.AllowedOrigins, ", "))
+	if cfg.APIKey != "" {
+		slog.Info("API requests require an API key")
+	}
+	// The endpoint list is only useful when debugging
+	slog.Debug("API endpoints:")
+	slog.Debug("  GET  /health")
+	slog.Debug("  GET  /version")
+	slog.Debug("  GET  /api/rate?date=YYYY-MM-DD")
+	slog.Debug("  GET  /api/trips (optional ?tag= filter, ?includeArchived=true)")
+	slog.Debug("  GET  /api/trips/{index}")
+	slog.Debug("  POST /api/trips (optional \"miles\" > 0 skips distance calculation)")
+	slog.Debug("  PUT  /api/trips/{index}")
+	slog.Debug("  POST /api/trips/{index}/archive")
+	slog.Debug("  POST /api/trips/{index}/unarchive")
+	slog.Debug("  DELETE /api/trips/{index}")
+	slog.Debug("  DELETE /api/trips?from=YYYY-MM-DD&to=YYYY-MM-DD")
+	slog.Debug("  GET  /api/expenses")
+	slog.Debug("  GET  /api/expenses/{index}")
+	slog.Debug("  POST /api/expenses")
+	slog.Debug("  POST /api/expenses/import (CSV with date, amount, description columns)")
+	slog.Debug("  PUT  /api/expenses/{index}")
+	slog.Debug("  DELETE /api/expenses/{index}")
+	slog.Debug("  GET  /api/expenses/duplicates")
+	slog.Debug("  POST /api/expenses/dedupe")
+	slog.Debug("  GET  /api/adjustments")
+	slog.Debug("  POST /api/adjustments (negative miles remove miles from a week)")
+	slog.Debug("  DELETE /api/adjustments/{index}")
+	slog.Debug("  GET  /api/summaries (optional ?projectUntil=YYYY-MM-DD)")
+	slog.Debug("  GET  /api/summaries/yearly?year=YYYY")
+	slog.Debug("  GET  /api/summaries/monthly/{yyyy-mm}/pdf")
+	slog.Debug("  GET  /api/summaries/export?format=csv")
+	slog.Debug("  GET  /api/stats")
+	slog.Debug("  GET  /api/export")
+	slog.Debug("  POST /api/import")
+
+	handler := allowCORS(cfg.AllowedOrigins, requireAPIKey(cfg.APIKey, http.DefaultServeMux))
+	srv := newHTTPServer(":"+port, logRequests(handler), cfg)
+
+	// Stop on Ctrl+C or SIGTERM, letting in-flight requests finish their saves
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := serve(ctx, srv, shutdownTimeout)
+	// Save changes held in memory even if shutdown was not clean
+	if err := server.Shutdown(); err != nil {
+		slog.Error("Failed to save data", "error", err)
+	}
+	if serveErr != nil {
+		fatal("Server error", serveErr)
+	}
+}
 
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	log.Printf("Starting NannyTracker API server on port %s", port)
-	log.Printf("API endpoints:")
-	log.Printf("  GET  /health")
-	log.Printf("  GET  /version")
-	log.Printf("  GET  /api/trips")
-	log.Printf("  POST /api/trips")
-	log.Printf("  PUT  /api/trips/{index}")
-	log.Printf("  DELETE /api/trips/{index}")
-	log.Printf("  GET  /api/expenses")
-	log.Printf("  POST /api/expenses")
-	log.Printf("  PUT  /api/expenses/{index}")
-	log.Printf("  DELETE /api/expenses/{index}")
-	log.Printf("  GET  /api/summaries")
-
-	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      nil,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// fatal logs err at error level and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newLogger returns a logger writing text lines to w, leaving out anything less severe than level
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// responseWriter records the status code written by a handler
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the method, path, status code and duration of each request, at
+// error level when the server failed and info level otherwise
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		level := slog.LevelInfo
+		if rw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(r.Context(), level, "request", "method", r.Method, "path", r.URL.Path, "status", rw.status, "duration", time.Since(start))
+	})
+}
+
+// allowCORS lets browsers on the allowed origins call the API from another site. The
+// request's origin is echoed back only when it is allowed, or "*" is sent when any
+// origin is. Preflight requests are answered here without reaching next.
+func allowCORS(allowedOrigins []string, next http.Handler) http.Handler {
+	allowAny := false
+	allowed := make(map[string]bool)
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		switch {
+		case allowAny:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		case allowed[origin]:
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if !allowAny {
+			// Responses differ by origin, so caches must not share them
+			w.Header().Add("Vary", "Origin")
+		}
+		if w.Header().Get("Access-Control-Allow-Origin") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-Match, Authorization, X-API-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+		}
+
+		// Handle CORS preflight
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAPIKey rejects /api/ requests that don't carry key, either as a bearer token in
+// the Authorization header or in X-API-Key. Other paths such as /health stay open, as
+// does everything when key is empty.
+func requireAPIKey(key string, next http.Handler) http.Handler {
+	if key == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		given := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			given = bearer
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(key)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newHTTPServer builds the server for addr with the configured timeouts
+func newHTTPServer(addr string, handler http.Handler, cfg *config.Config) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
 	}
 }
+
+// serve runs srv until ctx is cancelled, then shuts it down, waiting up to
+// timeout for active requests to complete
+func serve(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down server, waiting for active requests", "timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+	slog.Info("Server stopped")
+	return nil
+}
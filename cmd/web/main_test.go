@@ -2,17 +2,27 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"math"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/laurendc/nannytracker/pkg/config"
 	core "github.com/laurendc/nannytracker/pkg/core"
+	"github.com/laurendc/nannytracker/pkg/core/audit"
+	"github.com/laurendc/nannytracker/pkg/core/maps"
+	"github.com/laurendc/nannytracker/pkg/core/storage"
 )
 
 func setupTestServer(t *testing.T) (*Server, string, func()) {
@@ -74,6 +84,10 @@ func TestHealthEndpoint(t *testing.T) {
 		t.Errorf("Expected service 'nannytracker-api', got '%s'", response["service"])
 	}
 
+	if response["storage"] != "ok" {
+		t.Errorf("Expected storage 'ok', got '%s'", response["storage"])
+	}
+
 	// Test wrong method
 	req = httptest.NewRequest(http.MethodPost, "/health", nil)
 	w = httptest.NewRecorder()
@@ -84,6 +98,644 @@ func TestHealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestHealthEndpointStorageError(t *testing.T) {
+	server, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Point storage at a directory that doesn't exist so nothing can be written
+	server.store = storage.NewBuffered(storage.New(filepath.Join(tempDir, "missing", "trips.json")), 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	server.handleHealth(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var response map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response["status"] != "unhealthy" {
+		t.Errorf("Expected status 'unhealthy', got '%s'", response["status"])
+	}
+	if !strings.HasPrefix(response["storage"], "error: ") {
+		t.Errorf("Expected storage error, got '%s'", response["storage"])
+	}
+}
+
+func TestConfigEndpointOmitsAPIKey(t *testing.T) {
+	t.Setenv("GOOGLE_MAPS_API_KEY", "secret-maps-key")
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.cfg.HomeAddress = "Home"
+	server.cfg.APIKey = "secret-server-key"
+
+	req := httptest.NewRequest(http.MethodGet, "/api/config", nil)
+	w := httptest.NewRecorder()
+	server.handleConfig(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "secret-maps-key") || strings.Contains(body, "maps_api_key") ||
+		strings.Contains(body, "secret-server-key") || strings.Contains(body, "api_key") {
+		t.Fatalf("Expected no API key in the response, got %s", body)
+	}
+	var settings config.Settings
+	if err := json.Unmarshal([]byte(body), &settings); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if settings.HomeAddress != "Home" || settings.RatePerMile != server.cfg.RatePerMile {
+		t.Errorf("Unexpected configuration %+v", settings)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/config", nil)
+	w = httptest.NewRecorder()
+	server.handleConfig(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestRateEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.cfg.RatePerMile = 0.70
+	server.cfg.RateSchedule = []core.RateChange{
+		{EffectiveFrom: "2024-01-01", RatePerMile: 0.67},
+		{EffectiveFrom: "2025-01-01", RatePerMile: 0.72},
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected rateResponse
+	}{
+		{"before the first change falls back to the default", "?date=2023-12-31", rateResponse{Date: "2023-12-31", RatePerMile: 0.70, Default: true}},
+		{"on the day a change takes effect", "?date=2024-01-01", rateResponse{Date: "2024-01-01", RatePerMile: 0.67, EffectiveFrom: "2024-01-01"}},
+		{"between changes", "?date=2024-03-20", rateResponse{Date: "2024-03-20", RatePerMile: 0.67, EffectiveFrom: "2024-01-01"}},
+		{"after the last change", "?date=2025-06-01", rateResponse{Date: "2025-06-01", RatePerMile: 0.72, EffectiveFrom: "2025-01-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/rate"+tt.query, nil)
+			w := httptest.NewRecorder()
+			server.handleRate(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			var response rateResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, response)
+			}
+		})
+	}
+
+	// Without a date, today's rate is returned
+	req := httptest.NewRequest(http.MethodGet, "/api/rate", nil)
+	w := httptest.NewRecorder()
+	server.handleRate(w, req)
+	var response rateResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Date != time.Now().Format("2006-01-02") {
+		t.Errorf("Expected today's date, got %s", response.Date)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/rate?date=03/20/2024", nil)
+	w = httptest.NewRecorder()
+	server.handleRate(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid date, got %d", w.Code)
+	}
+}
+
+func TestMapsClientStatus(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name          string
+		usingMock     bool
+		wantMaps      string
+		wantEstimated bool
+	}{
+		{"mock client", true, "mock", true},
+		{"live client", false, "live", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.mapsClient = maps.NewMockClient()
+			server.usingMockMaps = tt.usingMock
+
+			// Health reports which client is active
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			w := httptest.NewRecorder()
+			server.handleHealth(w, req)
+
+			var health map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if health["maps"] != tt.wantMaps {
+				t.Errorf("Expected maps '%s', got '%s'", tt.wantMaps, health["maps"])
+			}
+
+			// Created trips are flagged when the distance was estimated
+			tripJSON, _ := json.Marshal(core.Trip{
+				Date:        "2024-12-18",
+				Origin:      "Home",
+				Destination: "Work",
+				Type:        "single",
+			})
+			req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+			req.Header.Set("Content-Type", "application/json")
+			w = httptest.NewRecorder()
+			server.handleTrips(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d", w.Code)
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			estimated, _ := response["milesEstimated"].(bool)
+			if estimated != tt.wantEstimated {
+				t.Errorf("Expected milesEstimated %v, got %v", tt.wantEstimated, response["milesEstimated"])
+			}
+			if response["origin"] != "Home" {
+				t.Errorf("Expected trip fields in response, got %v", response)
+			}
+		})
+	}
+}
+
+func TestManualMiles(t *testing.T) {
+	_, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server, err := NewServer(&config.Config{
+		DataDir:     filepath.Join(tempDir, ".nannytracker"),
+		DataFile:    "trips.json",
+		RatePerMile: 0.70,
+		ManualMiles: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	server.handleHealth(w, req)
+	var health map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&health); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if health["maps"] != "manual" {
+		t.Errorf("Expected maps 'manual', got '%s'", health["maps"])
+	}
+
+	// Trips without miles are rejected instead of being looked up
+	tests := []struct {
+		name      string
+		trip      core.Trip
+		wantCode  int
+		wantField string
+	}{
+		{"missing miles", core.Trip{Date: "2024-12-18", Origin: "Home", Destination: "Work", Type: "single"}, http.StatusUnprocessableEntity, "miles"},
+		{"missing return miles", core.Trip{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 5, Type: "round", ReturnDestination: "Store"}, http.StatusUnprocessableEntity, "return_miles"},
+		{"miles supplied", core.Trip{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 5, Type: "single"}, http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tripJSON, _ := json.Marshal(tt.trip)
+			req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+			w := httptest.NewRecorder()
+			server.handleTrips(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var verrs validationErrorsBody
+			if err := json.NewDecoder(w.Body).Decode(&verrs); err != nil || len(verrs.Errors) != 1 || verrs.Errors[0].Field != tt.wantField {
+				t.Errorf("Expected a %s validation error, got %+v (err %v)", tt.wantField, verrs.Errors, err)
+			}
+		})
+	}
+}
+
+func TestCreateTripMilesOverride(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	mock := maps.NewMockClient()
+	mock.MockDistance = 10.0
+	server.mapsClient = mock
+	server.usingMockMaps = true
+
+	tests := []struct {
+		name          string
+		body          string
+		wantStatus    int
+		wantMiles     float64
+		wantEstimated bool
+	}{
+		{
+			name:          "calculated miles",
+			body:          `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single"}`,
+			wantStatus:    http.StatusCreated,
+			wantMiles:     10.0,
+			wantEstimated: true,
+		},
+		{
+			name:          "zero miles is calculated",
+			body:          `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":0}`,
+			wantStatus:    http.StatusCreated,
+			wantMiles:     10.0,
+			wantEstimated: true,
+		},
+		{
+			name:       "manual miles override",
+			body:       `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":42.5}`,
+			wantStatus: http.StatusCreated,
+			wantMiles:  42.5,
+		},
+		{
+			name:       "negative miles",
+			body:       `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":-5}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "custom trip keeps entered miles",
+			body:       `{"date":"2024-12-18","origin":"Home","destination":"Park","type":"custom","miles":23}`,
+			wantStatus: http.StatusCreated,
+			wantMiles:  23,
+		},
+		{
+			name:       "custom trip without miles",
+			body:       `{"date":"2024-12-18","origin":"Home","destination":"Park","type":"custom"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			server.handleTrips(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response["miles"] != tt.wantMiles {
+				t.Errorf("Expected miles %.1f, got %v", tt.wantMiles, response["miles"])
+			}
+			estimated, _ := response["milesEstimated"].(bool)
+			if estimated != tt.wantEstimated {
+				t.Errorf("Expected milesEstimated %v, got %v", tt.wantEstimated, response["milesEstimated"])
+			}
+		})
+	}
+}
+
+func TestCreateTripPassengers(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		body           string
+		wantStatus     int
+		wantPassengers float64
+	}{
+		{
+			name:           "passengers given",
+			body:           `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":10,"passengers":3}`,
+			wantStatus:     http.StatusCreated,
+			wantPassengers: 3,
+		},
+		{
+			name:           "passengers default to 1",
+			body:           `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":10}`,
+			wantStatus:     http.StatusCreated,
+			wantPassengers: 1,
+		},
+		{
+			name:       "negative passengers",
+			body:       `{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":10,"passengers":-1}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			server.handleTrips(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response["passengers"] != tt.wantPassengers {
+				t.Errorf("Expected passengers %v, got %v", tt.wantPassengers, response["passengers"])
+			}
+		})
+	}
+}
+
+func TestCreateTripHomeAddressDefault(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	body := `{"date":"2024-12-18","destination":"Work","type":"single"}`
+
+	// Without a home address an empty origin is rejected
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", w.Code)
+	}
+
+	// With a home address the origin defaults to it
+	server.cfg.HomeAddress = "123 Home St"
+	req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	var responseTrip core.Trip
+	if err := json.NewDecoder(w.Body).Decode(&responseTrip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if responseTrip.Origin != "123 Home St" {
+		t.Errorf("Expected origin '123 Home St', got '%s'", responseTrip.Origin)
+	}
+
+	// An origin of only spaces counts as missing, and other addresses are normalized
+	body = `{"date":"2024-12-18","origin":"  ","destination":"  Main   Office ","type":"single"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if err := json.NewDecoder(w.Body).Decode(&responseTrip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if responseTrip.Origin != "123 Home St" || responseTrip.Destination != "Main Office" {
+		t.Errorf("Expected route '123 Home St' -> 'Main Office', got '%s' -> '%s'", responseTrip.Origin, responseTrip.Destination)
+	}
+}
+
+func TestChangesAreAudited(t *testing.T) {
+	server, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	auditPath := filepath.Join(tempDir, "audit.jsonl")
+	server.audit = audit.New(auditPath)
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	update := `{"date":"2024-12-18","origin":"Home","destination":"Library","miles":6,"type":"single"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBufferString(update))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A rejected change is not audited
+	req = httptest.NewRequest(http.MethodDelete, "/api/trips/5", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	content, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d: %s", len(lines), content)
+	}
+	var entry audit.Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Failed to decode audit entry: %v", err)
+	}
+	if entry.Action != audit.ActionEdit || entry.Entity != audit.EntityTrip {
+		t.Errorf("Expected a trip edit, got %+v", entry)
+	}
+	if !strings.Contains(entry.Summary, "Library") || !strings.Contains(entry.Summary, "was 2024-12-18 Home → Work") {
+		t.Errorf("Expected the summary to show the new and old trip, got %q", entry.Summary)
+	}
+	if entry.Time.IsZero() {
+		t.Error("Expected the entry to be timestamped")
+	}
+
+	// A log that cannot be written does not fail the save
+	server.audit = audit.New(filepath.Join(tempDir, "missing", "audit.jsonl"))
+	req = httptest.NewRequest(http.MethodDelete, "/api/trips/0", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 despite the audit failure, got %d", w.Code)
+	}
+}
+
+func TestETagConcurrency(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Create a trip to work with
+	tripJSON, _ := json.Marshal(core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Home",
+		Destination: "Work",
+		Type:        "single",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// GET exposes the current version as an ETag
+	req = httptest.NewRequest(http.MethodGet, "/api/trips", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header on GET response")
+	}
+
+	update := `{"date":"2024-12-19","origin":"Home","destination":"Work","miles":12,"type":"single"}`
+
+	// A matching If-Match succeeds and returns the new version
+	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBufferString(update))
+	req.Header.Set("If-Match", etag)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	newETag := w.Header().Get("ETag")
+	if newETag == "" || newETag == etag {
+		t.Errorf("Expected a new ETag after update, got %q (was %q)", newETag, etag)
+	}
+
+	// The stale ETag is now rejected for updates and deletes
+	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBufferString(update))
+	req.Header.Set("If-Match", etag)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for stale update, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/trips/0", nil)
+	req.Header.Set("If-Match", etag)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for stale delete, got %d", w.Code)
+	}
+
+	// Expenses honor If-Match too
+	expenseJSON := `{"date":"2024-12-18","amount":10,"description":"Lunch"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBufferString(expenseJSON))
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/expenses/0", bytes.NewBufferString(expenseJSON))
+	req.Header.Set("If-Match", newETag)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for stale expense update, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/expenses/0", nil)
+	req.Header.Set("If-Match", newETag)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for stale expense delete, got %d", w.Code)
+	}
+
+	// Requests without If-Match behave as before
+	req = httptest.NewRequest(http.MethodDelete, "/api/expenses/0", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 without If-Match, got %d", w.Code)
+	}
+}
+
+func TestCreateTripRejectsFarFutureDate(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.MaxFutureDays = 365
+
+	farFuture := time.Now().AddDate(2, 0, 0).Format("2006-01-02")
+	body := fmt.Sprintf(`{"date":"%s","origin":"Home","destination":"Work","type":"single"}`, farFuture)
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for far future date, got %d", w.Code)
+	}
+
+	nextWeek := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	body = fmt.Sprintf(`{"date":"%s","origin":"Home","destination":"Work","type":"single"}`, nextWeek)
+	req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for near future date, got %d", w.Code)
+	}
+}
+
+func TestCreateTripRejectsExcessiveMiles(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.MaxTripMiles = 100
+
+	body := `{"date":"2024-03-20","origin":"Home","destination":"Work","type":"single","miles":2400}`
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422 for excessive miles, got %d", w.Code)
+	}
+	var verrs validationErrorsBody
+	if err := json.NewDecoder(w.Body).Decode(&verrs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(verrs.Errors) != 1 || verrs.Errors[0].Field != "miles" {
+		t.Errorf("Expected a miles error, got %+v", verrs.Errors)
+	}
+
+	body = `{"date":"2024-03-20","origin":"Home","destination":"Work","type":"single","miles":24}`
+	req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for trip within the limit, got %d", w.Code)
+	}
+}
+
 func TestTripsEndpoint(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -154,7 +806,7 @@ func TestTripsEndpoint(t *testing.T) {
 	// Test CORS preflight
 	req = httptest.NewRequest(http.MethodOptions, "/api/trips", nil)
 	w = httptest.NewRecorder()
-	server.handleTrips(w, req)
+	allowCORS([]string{"*"}, http.HandlerFunc(server.handleTrips)).ServeHTTP(w, req)
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200 for OPTIONS, got %d", w.Code)
@@ -189,8 +841,8 @@ func TestTripsValidation(t *testing.T) {
 	w := httptest.NewRecorder()
 	server.handleTrips(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400 for invalid data, got %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for invalid data, got %d", w.Code)
 	}
 
 	// Test invalid JSON
@@ -204,6 +856,68 @@ func TestTripsValidation(t *testing.T) {
 	}
 }
 
+func TestServerSavesBufferedChanges(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	file := storage.New(server.cfg.DataPath())
+	server.store = storage.NewBuffered(file, time.Hour)
+
+	postExpense := func(description string) {
+		t.Helper()
+		body, _ := json.Marshal(core.Expense{Date: "2024-12-18", Amount: 12.00, Description: description})
+		req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	postExpense("Snacks")
+
+	// The change is served from memory but not yet on disk
+	req := httptest.NewRequest(http.MethodGet, "/api/expenses", nil)
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	var listed map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed["count"] != float64(1) {
+		t.Errorf("Expected 1 expense from memory, got %v", listed["count"])
+	}
+	if data, _ := file.LoadData(); len(data.Expenses) != 0 {
+		t.Fatalf("Expected nothing on disk before the save interval, got %d expenses", len(data.Expenses))
+	}
+
+	if err := server.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if data, _ := file.LoadData(); len(data.Expenses) != 1 {
+		t.Fatalf("Expected the expense to be saved on shutdown, got %d expenses", len(data.Expenses))
+	}
+
+	// With a short interval, changes reach the disk on their own
+	server.store = storage.NewBuffered(file, 20*time.Millisecond)
+	defer server.Shutdown()
+	postExpense("Parking")
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := file.LoadData()
+		if err != nil {
+			t.Fatalf("Failed to load data: %v", err)
+		}
+		if len(data.Expenses) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the second expense to be saved after the interval, got %d expenses", len(data.Expenses))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestExpensesEndpoint(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -270,6 +984,124 @@ func TestExpensesEndpoint(t *testing.T) {
 	}
 }
 
+func TestExpensesIncludeStoredIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.Families = []string{"Smith", "Jones"}
+	if err := server.store.SaveData(&core.StorageData{Expenses: []core.Expense{
+		{Date: "2024-12-16", Amount: 4, Description: "Snacks", Family: "Smith"},
+		{Date: "2024-12-17", Amount: 9, Description: "Museum", Family: "Jones"},
+		{Date: "2024-12-18", Amount: 6.5, Description: "Parking", Family: "Smith"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	// The list is filtered, sorted newest first and paged, so each expense reports where it is stored
+	req := httptest.NewRequest(http.MethodGet, "/api/expenses?family=Smith&pageSize=1", nil)
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	var response struct {
+		Expenses []indexedExpenseResponse `json:"expenses"`
+		Total    int                      `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != 2 || len(response.Expenses) != 1 {
+		t.Fatalf("Expected one page of the 2 Smith expenses, got %+v", response)
+	}
+	if expense := response.Expenses[0]; expense.Description != "Parking" || expense.Index != 2 {
+		t.Errorf("Expected the Parking expense at index 2, got %+v", expense)
+	}
+
+	// The returned index addresses the same expense
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/expenses/%d", response.Expenses[0].Index), nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	for _, expense := range data.Expenses {
+		if expense.Description == "Parking" {
+			t.Errorf("Expected the Parking expense to be deleted, still stored")
+		}
+	}
+}
+
+func TestCreateExpenseLinkedToTrip(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-18", Origin: "Home", Destination: "Zoo", Miles: 8.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/expenses", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		return w
+	}
+
+	if w := create(`{"date":"2024-12-18","amount":6.5,"description":"Parking","trip_date":"2024-12-18"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"date":"2024-12-18","amount":6.5,"description":"Parking","trip_date":"2024-12-19"}`,
+		`{"date":"2024-12-18","amount":6.5,"description":"Parking","trip_date":"2024-12-18","trip_index":1}`,
+	} {
+		w := create(body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			continue
+		}
+		var verr core.ValidationError
+		if err := json.NewDecoder(w.Body).Decode(&verr); err != nil || verr.Field != "trip_index" {
+			t.Errorf("Expected a trip_index error, got %+v (%v)", verr, err)
+		}
+	}
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != 1 || !data.Expenses[0].IsTripLinked() {
+		t.Fatalf("Expected only the linked expense to be saved, got %+v", data.Expenses)
+	}
+	if data.Trips[0].ID == "" || data.Expenses[0].TripID != data.Trips[0].ID {
+		t.Errorf("Expected the expense to be linked by the trip's ID, got %+v and %+v", data.Expenses[0], data.Trips[0])
+	}
+
+	// An update that sends the expense back keeps its link; an unknown trip is rejected
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/expenses/0", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		return w
+	}
+	if w := update(fmt.Sprintf(`{"date":"2024-12-18","amount":7,"description":"Parking","trip_id":%q}`, data.Trips[0].ID)); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w := update(`{"date":"2024-12-18","amount":7,"description":"Parking","trip_id":"missing"}`)
+	var verr core.ValidationError
+	if err := json.NewDecoder(w.Body).Decode(&verr); w.Code != http.StatusBadRequest || err != nil || verr.Field != "trip_id" {
+		t.Errorf("Expected a trip_id error, got %d: %+v (%v)", w.Code, verr, err)
+	}
+	if data, err = server.store.LoadData(); err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if i, ok := data.Expenses[0].LinkedTrip(data.Trips); !ok || i != 0 || data.Expenses[0].Amount != 7 {
+		t.Errorf("Expected the updated expense to stay linked, got %+v", data.Expenses[0])
+	}
+}
+
 func TestExpensesValidation(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -290,6 +1122,165 @@ func TestExpensesValidation(t *testing.T) {
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("Expected status 400 for invalid data, got %d", w.Code)
 	}
+
+	var verr core.ValidationError
+	if err := json.NewDecoder(w.Body).Decode(&verr); err != nil {
+		t.Fatalf("Expected a JSON validation error body: %v", err)
+	}
+	if verr.Field != "amount" || verr.Message != "amount must be greater than 0" {
+		t.Errorf("Expected amount validation error, got %+v", verr)
+	}
+}
+
+func TestTripValidationErrorBody(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name      string
+		body      string
+		wantField string
+	}{
+		{"missing destination", `{"date":"2024-12-16","origin":"Home","type":"single","miles":5}`, "destination"},
+		{"bad date", `{"date":"12/16/2024","origin":"Home","destination":"Work","type":"single","miles":5}`, "date"},
+		{"bad type", `{"date":"2024-12-16","origin":"Home","destination":"Work","type":"oneway","miles":5}`, "type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			server.handleTrips(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("Expected status 422, got %d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected JSON content type, got %q", ct)
+			}
+			var verrs validationErrorsBody
+			if err := json.NewDecoder(w.Body).Decode(&verrs); err != nil {
+				t.Fatalf("Failed to decode error body: %v", err)
+			}
+			if len(verrs.Errors) != 1 || verrs.Errors[0].Field != tt.wantField || verrs.Errors[0].Message == "" {
+				t.Errorf("Expected field %q with a message, got %+v", tt.wantField, verrs.Errors)
+			}
+		})
+	}
+}
+
+// validationErrorsBody is the response to a trip that fails validation
+type validationErrorsBody struct {
+	Errors []core.ValidationError `json:"errors"`
+}
+
+func TestCreateTripReportsEveryInvalidField(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	server.cfg.Families = []string{"Smith"}
+
+	body := `{"date":"12/16/2024","origin":"Home","type":"oneway","miles":-5,"passengers":-1,"family":"Brown","tags":[" park"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var verrs validationErrorsBody
+	if err := json.NewDecoder(w.Body).Decode(&verrs); err != nil {
+		t.Fatalf("Failed to decode error body: %v", err)
+	}
+
+	var fields []string
+	for _, verr := range verrs.Errors {
+		if verr.Message == "" {
+			t.Errorf("Expected a message for %s", verr.Field)
+		}
+		fields = append(fields, verr.Field)
+	}
+	want := []string{"destination", "miles", "date", "type", "passengers", "tags", "family"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected errors for %v, got %v", want, fields)
+	}
+
+	if data, _ := server.store.LoadData(); len(data.Trips) != 0 {
+		t.Errorf("Expected nothing to be saved, got %d trips", len(data.Trips))
+	}
+}
+
+func TestExpensesImportCSV(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	csvData := "date,amount,description,category\n2024-12-16,12.50,Lunch,food\n2024-12-17,8.00,Crayons,\n"
+
+	// Raw CSV body
+	req := httptest.NewRequest(http.MethodPost, "/api/expenses/import", strings.NewReader(csvData))
+	req.Header.Set("Content-Type", "text/csv")
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		Imported   int             `json:"imported"`
+		GrandTotal core.GrandTotal `json:"grandTotal"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Imported != 2 {
+		t.Errorf("Expected 2 imported expenses, got %d", response.Imported)
+	}
+	if response.GrandTotal.TotalExpenses != 20.5 {
+		t.Errorf("Expected total expenses 20.50, got %.2f", response.GrandTotal.TotalExpenses)
+	}
+
+	// Multipart upload appends to the existing expenses
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "receipts.csv")
+	if err != nil {
+		t.Fatalf("Failed to create form file: %v", err)
+	}
+	part.Write([]byte("2024-12-18,4.00,Snacks\n"))
+	mw.Close()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/expenses/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for multipart upload, got %d: %s", w.Code, w.Body.String())
+	}
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != 3 {
+		t.Fatalf("Expected 3 stored expenses, got %d", len(data.Expenses))
+	}
+	if data.Expenses[1].Category != "other" {
+		t.Errorf("Expected blank category to default to other, got %q", data.Expenses[1].Category)
+	}
+
+	// An invalid row rejects the whole file
+	req = httptest.NewRequest(http.MethodPost, "/api/expenses/import", strings.NewReader("2024-12-19,5,Juice\n2024-12-20,-3,Refund\n"))
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid row, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "line 2") {
+		t.Errorf("Expected error to name line 2, got %q", w.Body.String())
+	}
+	data, _ = server.store.LoadData()
+	if len(data.Expenses) != 3 {
+		t.Errorf("Expected failed import to leave 3 expenses, got %d", len(data.Expenses))
+	}
 }
 
 func TestWeeklySummariesEndpoint(t *testing.T) {
@@ -324,225 +1315,2268 @@ func TestWeeklySummariesEndpoint(t *testing.T) {
 	}
 }
 
-func TestWeeklySummariesWithData(t *testing.T) {
+func TestWeeklySummariesPersonalExpenses(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// Add some test data
-	data, err := server.store.LoadData()
-	if err != nil {
-		t.Fatalf("Failed to load data: %v", err)
-	}
-
-	// Add a trip
-	trip := core.Trip{
-		Date:        "2024-12-18",
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       10.0,
-		Type:        "single",
-	}
-	data.Trips = append(data.Trips, trip)
-
-	// Add an expense
-	expense := core.Expense{
-		Date:        "2024-12-18",
-		Amount:      25.50,
-		Description: "Lunch",
-	}
-	data.Expenses = append(data.Expenses, expense)
-
-	if err := server.store.SaveData(data); err != nil {
-		t.Fatalf("Failed to save data: %v", err)
+	for _, body := range []string{
+		`{"date":"2024-12-18","amount":25.50,"description":"Lunch"}`,
+		`{"date":"2024-12-19","amount":4.25,"description":"My coffee","reimbursable":false}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
 	}
 
-	// Test GET /api/summaries (with data)
 	req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
 	w := httptest.NewRecorder()
 	server.handleWeeklySummaries(w, req)
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+	var response struct {
+		Summaries  []core.WeeklySummary `json:"summaries"`
+		GrandTotal core.GrandTotal      `json:"grandTotal"`
 	}
-
-	var response map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-
-	summariesInterface, ok := response["summaries"]
-	if !ok {
-		t.Fatal("Expected 'summaries' key in response")
+	if len(response.Summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(response.Summaries))
+	}
+	if summary := response.Summaries[0]; summary.TotalExpenses != 25.50 || summary.TotalPersonalExpenses != 4.25 {
+		t.Errorf("Expected $25.50 billable and $4.25 personal, got $%.2f and $%.2f", summary.TotalExpenses, summary.TotalPersonalExpenses)
+	}
+	if response.GrandTotal.TotalExpenses != 25.50 || response.GrandTotal.TotalPersonalExpenses != 4.25 {
+		t.Errorf("Expected grand total $25.50 billable and $4.25 personal, got %+v", response.GrandTotal)
 	}
+}
 
-	summaries, ok := summariesInterface.([]interface{})
-	if !ok {
-		t.Fatal("Expected summaries to be an array")
+func TestWeeklySummariesMileageTarget(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 42.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	if len(summaries) == 0 {
-		t.Error("Expected at least one weekly summary")
+	type summariesBody struct {
+		Summaries []struct {
+			TargetPercent *float64 `json:"targetPercent"`
+		} `json:"summaries"`
+		WeeklyMileageTarget *float64 `json:"weeklyMileageTarget"`
+	}
+	get := func() summariesBody {
+		req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+		w := httptest.NewRecorder()
+		server.handleWeeklySummaries(w, req)
+		var body summariesBody
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return body
 	}
 
-	// Check that the summary contains the expected data
-	summaryInterface, ok := summaries[0].(map[string]interface{})
-	if !ok {
-		t.Fatal("Expected summary to be a map")
+	if body := get(); body.WeeklyMileageTarget != nil || body.Summaries[0].TargetPercent != nil {
+		t.Errorf("Expected no target without one configured, got %+v", body)
 	}
 
-	if summaryInterface["TotalMiles"] == nil {
-		t.Error("Expected TotalMiles in summary")
+	server.cfg.WeeklyMileageTarget = 50
+	body := get()
+	if body.WeeklyMileageTarget == nil || *body.WeeklyMileageTarget != 50 {
+		t.Errorf("Expected a weekly mileage target of 50, got %v", body.WeeklyMileageTarget)
+	}
+	if percent := body.Summaries[0].TargetPercent; percent == nil || *percent != 84 {
+		t.Errorf("Expected 84%% of the target, got %v", percent)
 	}
 }
 
-func TestCORSHeaders(t *testing.T) {
+func TestWeeklySummariesProjections(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// Test CORS headers on trips endpoint
-	req := httptest.NewRequest(http.MethodGet, "/api/trips", nil)
-	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
-
-	headers := w.Header()
-	if headers.Get("Access-Control-Allow-Origin") != "*" {
-		t.Error("Expected CORS Access-Control-Allow-Origin header")
+	if err := server.store.SaveData(&core.StorageData{
+		ReferenceDate: "2024-12-18",
+		Trips: []core.Trip{
+			{Date: "2024-12-16", Origin: "Home", Destination: "School", Miles: 5.0, Type: "single", IsRecurring: true},
+		},
+		RecurringTrips: []core.RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 5.0, StartDate: "2024-12-16", Type: "single", Weekday: int(time.Monday)},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	if headers.Get("Access-Control-Allow-Methods") == "" {
-		t.Error("Expected CORS Access-Control-Allow-Methods header")
+	type summariesBody struct {
+		Summaries []struct {
+			WeekStart      string
+			TotalMiles     float64
+			ProjectedMiles float64
+			Trips          []core.Trip
+		} `json:"summaries"`
 	}
-
-	if headers.Get("Access-Control-Allow-Headers") == "" {
-		t.Error("Expected CORS Access-Control-Allow-Headers header")
+	get := func(query string) (int, summariesBody) {
+		req := httptest.NewRequest(http.MethodGet, "/api/summaries"+query, nil)
+		w := httptest.NewRecorder()
+		server.handleWeeklySummaries(w, req)
+		var body summariesBody
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w.Code, body
 	}
-}
 
-func TestServerCreation(t *testing.T) {
-	// Test server creation with valid config
-	cfg := &config.Config{
-		DataDir:     "/tmp/test",
-		DataFile:    "test.json",
-		RatePerMile: 0.70,
+	if _, body := get(""); len(body.Summaries) != 1 || body.Summaries[0].ProjectedMiles != 0 {
+		t.Errorf("Expected only the recorded week without projectUntil, got %+v", body.Summaries)
 	}
 
-	server, err := NewServer(cfg)
-	if err != nil {
-		t.Fatalf("Failed to create server: %v", err)
+	code, body := get("?projectUntil=2024-12-31")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
 	}
-	if server == nil {
-		t.Fatal("Expected server to be created")
-		return
+	if len(body.Summaries) != 3 {
+		t.Fatalf("Expected 3 weeks with projections, got %+v", body.Summaries)
 	}
-
-	// Verify server components are properly initialized
-	if server.store == nil {
-		t.Fatal("Expected storage to be initialized")
+	latest := body.Summaries[0]
+	if latest.WeekStart != "2024-12-29" || latest.ProjectedMiles != 5.0 || len(latest.Trips) != 1 || !latest.Trips[0].Projected {
+		t.Errorf("Expected a projected trip on 2024-12-30, got %+v", latest)
 	}
-
-	if server.cfg != cfg {
-		t.Error("Expected config to be set")
+	if recorded := body.Summaries[2]; recorded.ProjectedMiles != 0 || recorded.Trips[0].Projected {
+		t.Errorf("Expected the recorded week left unprojected, got %+v", recorded)
 	}
-}
 
-func setupBenchmarkServer(b *testing.B) (*Server, func()) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "nannytracker-web-bench")
+	data, err := server.store.LoadData()
 	if err != nil {
-		b.Fatalf("Failed to create temp dir: %v", err)
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 1 {
+		t.Errorf("Expected projections not to be saved, got %d trips", len(data.Trips))
 	}
 
-	// Create data directory
-	dataDir := filepath.Join(tempDir, ".nannytracker")
-	if err := os.MkdirAll(dataDir, 0750); err != nil {
-		b.Fatalf("Failed to create data dir: %v", err)
+	if code, _ := get("?projectUntil=soon"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid projectUntil, got %d", code)
 	}
+}
 
-	// Create config with test data path
-	cfg := &config.Config{
+func TestWeeklySummariesActivityFlags(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{
+		Trips:    []core.Trip{{Date: "2024-12-11", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}},
+		Expenses: []core.Expense{{Date: "2024-12-18", Amount: 9.75, Description: "Craft supplies"}},
+	}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w := httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+
+	var response struct {
+		Summaries []core.WeeklySummary `json:"summaries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Summaries) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d", len(response.Summaries))
+	}
+	if expenseOnly := response.Summaries[0]; expenseOnly.HasTrips || !expenseOnly.HasExpenses {
+		t.Errorf("Expected the week of %s to have only expenses, got HasTrips=%v HasExpenses=%v", expenseOnly.WeekStart, expenseOnly.HasTrips, expenseOnly.HasExpenses)
+	}
+	if tripOnly := response.Summaries[1]; !tripOnly.HasTrips || tripOnly.HasExpenses {
+		t.Errorf("Expected the week of %s to have only trips, got HasTrips=%v HasExpenses=%v", tripOnly.WeekStart, tripOnly.HasTrips, tripOnly.HasExpenses)
+	}
+}
+
+func TestAdjustments(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{
+		Trips: []core.Trip{{Date: "2024-12-11", Origin: "Home", Destination: "School", Miles: 10.0, Type: "round"}},
+	}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/adjustments", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleAdjustments(w, req)
+		return w
+	}
+
+	w := post(`{"date": "2024-12-12", "miles": -25, "reason": "Logged twice"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an adjustment below zero miles, got %d: %s", w.Code, w.Body.String())
+	}
+	var verr core.ValidationError
+	if err := json.NewDecoder(w.Body).Decode(&verr); err != nil || verr.Field != "miles" {
+		t.Errorf("Expected a miles validation error, got %+v (%v)", verr, err)
+	}
+	if w := post(`{"date": "2024-12-12", "miles": -5}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a reason, got %d", w.Code)
+	}
+
+	w = post(`{"date": "2024-12-12", "miles": -5, "reason": "Logged twice"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("Expected an ETag on the created adjustment")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/adjustments", nil)
+	w = httptest.NewRecorder()
+	server.handleAdjustments(w, req)
+	var listed struct {
+		Adjustments []core.Adjustment `json:"adjustments"`
+		Count       int               `json:"count"`
+		TotalMiles  float64           `json:"totalMiles"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed.Count != 1 || listed.TotalMiles != -5 || listed.Adjustments[0].Family != core.DefaultFamily {
+		t.Errorf("Expected one -5 mile adjustment for the default family, got %+v", listed)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w = httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+	var summaries struct {
+		Summaries []core.WeeklySummary `json:"summaries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&summaries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(summaries.Summaries) != 1 || summaries.Summaries[0].TotalMiles != 15 || summaries.Summaries[0].AdjustmentMiles != -5 {
+		t.Errorf("Expected the week to total 15 miles after the adjustment, got %+v", summaries.Summaries)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/adjustments/3", nil)
+	w = httptest.NewRecorder()
+	server.handleAdjustments(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a missing adjustment, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/adjustments/0", nil)
+	w = httptest.NewRecorder()
+	server.handleAdjustments(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Adjustments) != 0 {
+		t.Errorf("Expected the adjustment to be deleted, got %+v", data.Adjustments)
+	}
+}
+
+func TestWeeklySummariesDelta(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	data.Trips = append(data.Trips,
+		core.Trip{Date: "2024-12-11", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "single"},
+		core.Trip{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 25.0, Type: "single"},
+	)
+	data.Expenses = append(data.Expenses, core.Expense{Date: "2024-12-11", Amount: 5.0, Description: "Snack"})
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w := httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+
+	var response struct {
+		Summaries []struct {
+			WeekStart string
+			Delta     *core.SummaryDelta `json:"delta"`
+		} `json:"summaries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Summaries) != 2 {
+		t.Fatalf("Expected 2 summaries, got %d", len(response.Summaries))
+	}
+
+	latest := response.Summaries[0]
+	if latest.Delta == nil {
+		t.Fatal("Expected a delta for the week with a previous week")
+	}
+	if latest.Delta.Miles != 15.0 || latest.Delta.Expenses != -5.0 {
+		t.Errorf("Expected +15 miles and -$5 expenses, got %+v", *latest.Delta)
+	}
+	if earliest := response.Summaries[1]; earliest.Delta != nil {
+		t.Errorf("Expected no delta for the first week, got %+v", *earliest.Delta)
+	}
+}
+
+func TestWeekSummary(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	data.Trips = append(data.Trips, core.Trip{Date: "2024-12-18", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "single"})
+	data.Expenses = append(data.Expenses, core.Expense{Date: "2024-12-19", Amount: 25.50, Description: "Lunch"})
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries/2024-12-15", nil)
+	w := httptest.NewRecorder()
+	server.handleWeekSummary(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary core.WeeklySummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if summary.WeekEnd != "2024-12-21" || summary.TotalMiles != 10.0 || summary.TotalExpenses != 25.50 {
+		t.Errorf("Unexpected week totals: %+v", summary)
+	}
+	if len(summary.Trips) != 1 || len(summary.Expenses) != 1 {
+		t.Errorf("Expected the week's trip and expense, got %d trips and %d expenses", len(summary.Trips), len(summary.Expenses))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"week without records", http.MethodGet, "/api/summaries/2024-12-08", http.StatusNotFound},
+		{"invalid week start", http.MethodGet, "/api/summaries/last-week", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/api/summaries/2024-12-15", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.handleWeekSummary(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestWeeklySummariesWithData(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Add some test data
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+
+	// Add a trip
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       10.0,
+		Type:        "single",
+	}
+	data.Trips = append(data.Trips, trip)
+
+	// Add an expense
+	expense := core.Expense{
+		Date:        "2024-12-18",
+		Amount:      25.50,
+		Description: "Lunch",
+	}
+	data.Expenses = append(data.Expenses, expense)
+
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	// Test GET /api/summaries (with data)
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w := httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	summariesInterface, ok := response["summaries"]
+	if !ok {
+		t.Fatal("Expected 'summaries' key in response")
+	}
+
+	summaries, ok := summariesInterface.([]interface{})
+	if !ok {
+		t.Fatal("Expected summaries to be an array")
+	}
+
+	if len(summaries) == 0 {
+		t.Error("Expected at least one weekly summary")
+	}
+
+	// Check that the summary contains the expected data
+	summaryInterface, ok := summaries[0].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected summary to be a map")
+	}
+
+	if summaryInterface["TotalMiles"] == nil {
+		t.Error("Expected TotalMiles in summary")
+	}
+	if summaryInterface["SingleTripCount"] != 1.0 || summaryInterface["RoundTripCount"] != 0.0 {
+		t.Errorf("Expected 1 single and 0 round trips, got %v and %v", summaryInterface["SingleTripCount"], summaryInterface["RoundTripCount"])
+	}
+
+	grandTotal, ok := response["grandTotal"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected 'grandTotal' object in response")
+	}
+	if grandTotal["TotalMiles"] != 10.0 {
+		t.Errorf("Expected grand total miles 10.0, got %v", grandTotal["TotalMiles"])
+	}
+	if grandTotal["TotalExpenses"] != 25.5 {
+		t.Errorf("Expected grand total expenses 25.5, got %v", grandTotal["TotalExpenses"])
+	}
+}
+
+func TestWeeklySummariesRounding(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.RoundingMode = core.RoundingCent
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	data.Trips = append(data.Trips, core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       37.425,
+		Type:        "single",
+	})
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w := httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+
+	var response struct {
+		Summaries  []core.WeeklySummary `json:"summaries"`
+		GrandTotal core.GrandTotal      `json:"grandTotal"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(response.Summaries))
+	}
+	if response.Summaries[0].TotalAmount != 26.20 {
+		t.Errorf("Expected TotalAmount rounded to 26.20, got %v", response.Summaries[0].TotalAmount)
+	}
+	if response.GrandTotal.TotalAmount != 26.20 {
+		t.Errorf("Expected grand total amount 26.20, got %v", response.GrandTotal.TotalAmount)
+	}
+}
+
+func TestMonthlySummaryPDFEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	data.Trips = append(data.Trips, core.Trip{Date: "2024-02-10", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	data.Expenses = append(data.Expenses, core.Expense{Date: "2024-02-12", Amount: 15, Description: "Zoo"})
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries/monthly/2024-02/pdf", nil)
+	w := httptest.NewRecorder()
+	server.handleMonthlySummaryPDF(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/pdf" {
+		t.Errorf("Expected Content-Type application/pdf, got %s", contentType)
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "nannytracker-2024-02.pdf") {
+		t.Errorf("Expected attachment filename for the month, got %s", w.Header().Get("Content-Disposition"))
+	}
+	if !strings.HasPrefix(w.Body.String(), "%PDF-") {
+		t.Error("Expected response body to be a PDF document")
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"invalid month", http.MethodGet, "/api/summaries/monthly/2024-13/pdf", http.StatusBadRequest},
+		{"unknown format", http.MethodGet, "/api/summaries/monthly/2024-02/csv", http.StatusNotFound},
+		{"missing format", http.MethodGet, "/api/summaries/monthly/2024-02", http.StatusNotFound},
+		{"wrong method", http.MethodPost, "/api/summaries/monthly/2024-02/pdf", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			server.handleMonthlySummaryPDF(w, req)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestSummariesCSVExport(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &core.StorageData{
+		Trips: []core.Trip{
+			{Date: "2024-12-02", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+			{Date: "2024-12-16", Origin: "Home", Destination: "Work", Miles: 10, Type: "round"},
+		},
+		Expenses: []core.Expense{
+			{Date: "2024-12-17", Amount: 12.5, Description: "Lunch"},
+		},
+	}
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	server.handleSummariesExport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Expected Content-Type text/csv, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=nannytracker-weekly-") {
+		t.Errorf("Expected attachment Content-Disposition, got %q", cd)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 weeks, got %d lines: %q", len(lines), lines)
+	}
+	if lines[0] != "week_start,week_end,total_miles,total_mileage_amount,total_expenses" {
+		t.Errorf("Unexpected header row: %q", lines[0])
+	}
+	// Rate is 0.70 in the test config; the round trip counts 20 miles
+	if lines[1] != "2024-12-15,2024-12-21,20.00,14.00,12.50" {
+		t.Errorf("Unexpected first data row: %q", lines[1])
+	}
+	if lines[2] != "2024-12-01,2024-12-07,10.00,7.00,0.00" {
+		t.Errorf("Unexpected second data row: %q", lines[2])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/summaries/export?format=xlsx", nil)
+	w = httptest.NewRecorder()
+	server.handleSummariesExport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unsupported format, got %d", w.Code)
+	}
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &core.StorageData{
+		Trips: []core.Trip{
+			{Date: "2024-12-16", Origin: "Home", Destination: "Work", Miles: 10, Type: "round"},
+			{Date: "2024-11-02", Origin: "Home", Destination: "Park", Miles: 5, Type: "single"},
+			{Date: "2025-01-08", Origin: "Home", Destination: "School", Miles: 5, Type: "single"},
+		},
+		RecurringTrips: []core.RecurringTrip{
+			{StartDate: "2024-11-04", Origin: "Home", Destination: "School", Miles: 5, Type: "single", Weekday: 1},
+		},
+		Expenses: []core.Expense{
+			{Date: "2024-12-17", Amount: 12.5, Description: "Lunch"},
+			{Date: "2024-12-18", Amount: 8, Description: "Snacks"},
+		},
+	}
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	w := httptest.NewRecorder()
+	server.handleStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"tripCount":          3.0,
+		"recurringTripCount": 1.0,
+		"expenseCount":       2.0,
+		"totalMiles":         30.0,
+		"earliestTripDate":   "2024-11-02",
+		"latestTripDate":     "2025-01-08",
+		// 30 effective miles over 3 trips on 3 different days
+		"averageMilesPerTrip":      10.0,
+		"averageMilesPerActiveDay": 10.0,
+	}
+	for key, want := range expected {
+		if stats[key] != want {
+			t.Errorf("Expected %s to be %v, got %v", key, want, stats[key])
+		}
+	}
+	if got, ok := stats["totalReimbursement"].(float64); !ok || math.Abs(got-21.0) > 0.001 {
+		t.Errorf("Expected totalReimbursement 21.00, got %v", stats["totalReimbursement"])
+	}
+}
+
+func TestStatsCurrentWeeks(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	getStats := func() statsResponse {
+		req := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+		w := httptest.NewRecorder()
+		server.handleStats(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var stats statsResponse
+		if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return stats
+	}
+
+	// With no data both weeks are zeroed but still span the right dates
+	now := time.Now()
+	stats := getStats()
+	weekStart := now.AddDate(0, 0, -int(now.Weekday())).Format("2006-01-02")
+	if stats.ThisWeek.WeekStart != weekStart || stats.ThisWeek.TotalMiles != 0 {
+		t.Errorf("Expected an empty week starting %s, got %+v", weekStart, stats.ThisWeek)
+	}
+	lastWeekStart := now.AddDate(0, 0, -int(now.Weekday())-7).Format("2006-01-02")
+	if stats.LastWeek.WeekStart != lastWeekStart || stats.LastWeek.TotalMiles != 0 {
+		t.Errorf("Expected an empty week starting %s, got %+v", lastWeekStart, stats.LastWeek)
+	}
+	if stats.AverageMilesPerTrip != 0 || stats.AverageMilesPerActiveDay != 0 {
+		t.Errorf("Expected zero averages without trips, got %.2f and %.2f", stats.AverageMilesPerTrip, stats.AverageMilesPerActiveDay)
+	}
+
+	data := &core.StorageData{
+		Trips: []core.Trip{
+			{Date: now.Format("2006-01-02"), Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+			{Date: now.AddDate(0, 0, -7).Format("2006-01-02"), Origin: "Home", Destination: "Park", Miles: 4, Type: "round"},
+		},
+	}
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save test data: %v", err)
+	}
+
+	stats = getStats()
+	if stats.ThisWeek.TotalMiles != 10 {
+		t.Errorf("Expected 10 miles this week, got %.2f", stats.ThisWeek.TotalMiles)
+	}
+	if stats.LastWeek.TotalMiles != 8 {
+		t.Errorf("Expected 8 miles last week, got %.2f", stats.LastWeek.TotalMiles)
+	}
+}
+
+func TestRoundTripEffectiveMiles(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(
+		`{"date":"2024-12-16","origin":"Home","destination":"Work","type":"round","miles":10}`))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var created map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created["miles"] != 10.0 || created["effectiveMiles"] != 20.0 {
+		t.Errorf("Expected one-way miles 10 and effective miles 20, got %v and %v", created["miles"], created["effectiveMiles"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/0", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	var fetched map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&fetched); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if fetched["effectiveMiles"] != 20.0 {
+		t.Errorf("Expected effective miles 20 from GET, got %v", fetched["effectiveMiles"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/summaries", nil)
+	w = httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+	var summaries struct {
+		GrandTotal core.GrandTotal `json:"grandTotal"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&summaries); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if summaries.GrandTotal.TotalMiles != 20 {
+		t.Errorf("Expected the round trip to count 20 miles in summaries, got %.2f", summaries.GrandTotal.TotalMiles)
+	}
+}
+
+func TestYearlySummaryEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	data.Trips = append(data.Trips,
+		core.Trip{Date: "2024-02-10", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		core.Trip{Date: "2023-11-10", Origin: "Home", Destination: "Work", Miles: 30, Type: "single"},
+	)
+	data.Expenses = append(data.Expenses, core.Expense{Date: "2024-06-01", Amount: 15, Description: "Zoo"})
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summaries/yearly?year=2024", nil)
+	w := httptest.NewRecorder()
+	server.handleYearlySummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var summary core.YearlySummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if summary.Year != 2024 {
+		t.Errorf("Expected year 2024, got %d", summary.Year)
+	}
+	if summary.TotalMiles != 10 {
+		t.Errorf("Expected 10 miles (other years excluded), got %.2f", summary.TotalMiles)
+	}
+	if summary.TotalExpenses != 15 {
+		t.Errorf("Expected 15.00 in expenses, got %.2f", summary.TotalExpenses)
+	}
+	if len(summary.Months) != 12 || summary.Months[1].TotalMiles != 10 {
+		t.Errorf("Expected February breakdown with 10 miles, got %+v", summary.Months)
+	}
+
+	// Non-numeric year
+	req = httptest.NewRequest(http.MethodGet, "/api/summaries/yearly?year=abc", nil)
+	w = httptest.NewRecorder()
+	server.handleYearlySummary(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+
+	// Wrong method
+	req = httptest.NewRequest(http.MethodPost, "/api/summaries/yearly", nil)
+	w = httptest.NewRecorder()
+	server.handleYearlySummary(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestTripsTagFilter(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{"date":"2024-12-16","origin":"Home","destination":"Zoo","type":"round","miles":12,"tags":["field-trip","rainy-day"]}`,
+		`{"date":"2024-12-17","origin":"Home","destination":"Work","type":"single","miles":5}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/trips?tag=field-trip", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	var response struct {
+		Trips []core.Trip `json:"trips"`
+		Total int         `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != 1 || response.Trips[0].Destination != "Zoo" {
+		t.Fatalf("Expected only the Zoo trip, got %+v", response.Trips)
+	}
+	if len(response.Trips[0].Tags) != 2 || response.Trips[0].Tags[0] != "field-trip" {
+		t.Errorf("Expected tags to be returned, got %v", response.Trips[0].Tags)
+	}
+
+	// Tags must be trimmed and non-empty
+	req = httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(
+		`{"date":"2024-12-18","origin":"Home","destination":"Park","type":"single","miles":3,"tags":[" park"]}`))
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for untrimmed tag, got %d", w.Code)
+	}
+}
+
+func TestFamilyFilter(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.Families = []string{"Smith", "Jones"}
+
+	for _, body := range []string{
+		`{"date":"2024-12-16","origin":"Home","destination":"Smiths","type":"single","miles":5,"family":"Smith"}`,
+		`{"date":"2024-12-17","origin":"Home","destination":"Joneses","type":"single","miles":7,"family":"Jones"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// Unknown families are rejected
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(
+		`{"date":"2024-12-18","origin":"Home","destination":"Work","type":"single","miles":5,"family":"Brown"}`))
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for unknown family, got %d", w.Code)
+	}
+
+	// The list endpoint filters by family
+	req = httptest.NewRequest(http.MethodGet, "/api/trips?family=Smith", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	var tripsResponse struct {
+		Trips []core.Trip `json:"trips"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&tripsResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(tripsResponse.Trips) != 1 || tripsResponse.Trips[0].Family != "Smith" {
+		t.Errorf("Expected only the Smith trip, got %+v", tripsResponse.Trips)
+	}
+
+	// Summaries are computed per family
+	req = httptest.NewRequest(http.MethodGet, "/api/summaries?family=Jones", nil)
+	w = httptest.NewRecorder()
+	server.handleWeeklySummaries(w, req)
+
+	var summariesResponse struct {
+		GrandTotal core.GrandTotal `json:"grandTotal"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&summariesResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if summariesResponse.GrandTotal.TotalMiles != 7 {
+		t.Errorf("Expected 7 miles for Jones, got %.2f", summariesResponse.GrandTotal.TotalMiles)
+	}
+}
+
+func TestTripsIncludeStoredIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	server.cfg.Families = []string{"Smith", "Jones"}
+
+	for _, body := range []string{
+		`{"date":"2024-12-16","origin":"Home","destination":"Library","type":"single","miles":5,"family":"Smith"}`,
+		`{"date":"2024-12-17","origin":"Home","destination":"Joneses","type":"single","miles":7,"family":"Jones"}`,
+		`{"date":"2024-12-18","origin":"Home","destination":"Park","type":"single","miles":3,"family":"Smith"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	// The list is filtered and newest first, so each trip reports where it is stored
+	req := httptest.NewRequest(http.MethodGet, "/api/trips?family=Smith", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	var response struct {
+		Trips []struct {
+			Destination string `json:"destination"`
+			Index       *int   `json:"index"`
+		} `json:"trips"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Trips) != 2 {
+		t.Fatalf("Expected 2 Smith trips, got %+v", response.Trips)
+	}
+	want := map[string]int{"Park": 2, "Library": 0}
+	for _, trip := range response.Trips {
+		if trip.Index == nil || *trip.Index != want[trip.Destination] {
+			t.Errorf("Expected %s at index %d, got %v", trip.Destination, want[trip.Destination], trip.Index)
+		}
+	}
+
+	// The returned index addresses the same trip
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/trips/%d", *response.Trips[0].Index), nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	for _, trip := range data.Trips {
+		if trip.Destination == response.Trips[0].Destination {
+			t.Errorf("Expected %s to be deleted, still stored", trip.Destination)
+		}
+	}
+}
+
+func TestCORSHeaders(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		wantOrigin     string
+	}{
+		{name: "allowed origin is echoed", allowedOrigins: []string{"https://nanny.example.com"}, origin: "https://nanny.example.com", wantOrigin: "https://nanny.example.com"},
+		{name: "disallowed origin", allowedOrigins: []string{"https://nanny.example.com"}, origin: "https://evil.example.com"},
+		{name: "no origin", allowedOrigins: []string{"https://nanny.example.com"}},
+		{name: "wildcard", allowedOrigins: []string{"*"}, origin: "https://anywhere.example.com", wantOrigin: "*"},
+		{name: "nothing allowed", origin: "http://localhost:3000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := allowCORS(tt.allowedOrigins, http.HandlerFunc(server.handleTrips))
+			req := httptest.NewRequest(http.MethodGet, "/api/trips", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			headers := w.Header()
+			if got := headers.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.wantOrigin, got)
+			}
+			if tt.wantOrigin == "" {
+				if headers.Get("Access-Control-Allow-Methods") != "" {
+					t.Error("Expected no CORS headers for a disallowed origin")
+				}
+				return
+			}
+			if headers.Get("Access-Control-Allow-Methods") == "" {
+				t.Error("Expected CORS Access-Control-Allow-Methods header")
+			}
+			if headers.Get("Access-Control-Allow-Headers") == "" {
+				t.Error("Expected CORS Access-Control-Allow-Headers header")
+			}
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", w.Code)
+			}
+		})
+	}
+
+	// Preflight requests are answered without reaching the handler
+	req := httptest.NewRequest(http.MethodOptions, "/api/trips/dedupe", nil)
+	req.Header.Set("Origin", "https://nanny.example.com")
+	w := httptest.NewRecorder()
+	allowCORS([]string{"https://nanny.example.com"}, http.HandlerFunc(server.handleDedupeTrips)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://nanny.example.com" {
+		t.Errorf("Expected an allowed preflight, got status %d and origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", server.handleHealth)
+	mux.HandleFunc("/api/trips", server.handleTrips)
+
+	tests := []struct {
+		name       string
+		key        string
+		path       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "bearer token", key: "secret", path: "/api/trips", headers: map[string]string{"Authorization": "Bearer secret"}, wantStatus: http.StatusOK},
+		{name: "X-API-Key header", key: "secret", path: "/api/trips", headers: map[string]string{"X-API-Key": "secret"}, wantStatus: http.StatusOK},
+		{name: "missing key", key: "secret", path: "/api/trips", wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", key: "secret", path: "/api/trips", headers: map[string]string{"Authorization": "Bearer guess"}, wantStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", key: "secret", path: "/api/trips", headers: map[string]string{"Authorization": "Basic secret"}, wantStatus: http.StatusUnauthorized},
+		{name: "health stays open", key: "secret", path: "/health", wantStatus: http.StatusOK},
+		{name: "unconfigured", path: "/api/trips", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			requireAPIKey(tt.key, mux).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("Expected a WWW-Authenticate challenge, got %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestServerCreation(t *testing.T) {
+	// Test server creation with valid config
+	cfg := &config.Config{
+		DataDir:     "/tmp/test",
+		DataFile:    "test.json",
+		RatePerMile: 0.70,
+	}
+
+	server, err := NewServer(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if server == nil {
+		t.Fatal("Expected server to be created")
+		return
+	}
+
+	// Verify server components are properly initialized
+	if server.store == nil {
+		t.Fatal("Expected storage to be initialized")
+	}
+
+	if server.cfg != cfg {
+		t.Error("Expected config to be set")
+	}
+}
+
+func TestNewHTTPServerTimeouts(t *testing.T) {
+	cfg := &config.Config{
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 2 * time.Minute,
+		IdleTimeout:  90 * time.Second,
+	}
+
+	srv := newHTTPServer(":8080", http.NotFoundHandler(), cfg)
+	if srv.Addr != ":8080" {
+		t.Errorf("Expected address :8080, got %s", srv.Addr)
+	}
+	if srv.ReadTimeout != cfg.ReadTimeout {
+		t.Errorf("Expected read timeout %s, got %s", cfg.ReadTimeout, srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != cfg.WriteTimeout {
+		t.Errorf("Expected write timeout %s, got %s", cfg.WriteTimeout, srv.WriteTimeout)
+	}
+	if srv.IdleTimeout != cfg.IdleTimeout {
+		t.Errorf("Expected idle timeout %s, got %s", cfg.IdleTimeout, srv.IdleTimeout)
+	}
+}
+
+func setupBenchmarkServer(b *testing.B) (*Server, func()) {
+	// Create temporary directory
+	tempDir, err := os.MkdirTemp("", "nannytracker-web-bench")
+	if err != nil {
+		b.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	// Create data directory
+	dataDir := filepath.Join(tempDir, ".nannytracker")
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		b.Fatalf("Failed to create data dir: %v", err)
+	}
+
+	// Create config with test data path
+	cfg := &config.Config{
 		DataDir:     dataDir,
 		DataFile:    "trips.json",
 		RatePerMile: 0.70,
 	}
 
-	// Create server
-	server, err := NewServer(cfg)
-	if err != nil {
-		b.Fatalf("Failed to create server: %v", err)
+	// Create server
+	server, err := NewServer(cfg)
+	if err != nil {
+		b.Fatalf("Failed to create server: %v", err)
+	}
+
+	cleanup := func() {
+		os.RemoveAll(tempDir)
+	}
+
+	return server, cleanup
+}
+
+func BenchmarkTripsEndpoint(b *testing.B) {
+	server, cleanup := setupBenchmarkServer(b)
+	defer cleanup()
+
+	// Add some test data
+	data, err := server.store.LoadData()
+	if err != nil {
+		b.Fatalf("Failed to load data: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		trip := core.Trip{
+			Date:        fmt.Sprintf("2024-12-%02d", i%30+1),
+			Origin:      fmt.Sprintf("Home %d", i),
+			Destination: fmt.Sprintf("Work %d", i),
+			Miles:       float64(i) + 1.0,
+			Type:        "single",
+		}
+		data.Trips = append(data.Trips, trip)
+	}
+
+	if err := server.store.SaveData(data); err != nil {
+		b.Fatalf("Failed to save data: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/trips", nil)
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+	}
+}
+
+func TestResolvePort(t *testing.T) {
+	tests := []struct {
+		name     string
+		flagPort string
+		envPort  string
+		want     string
+	}{
+		{"default", "", "", "8080"},
+		{"env", "", "9090", "9090"},
+		{"flag overrides env", "9191", "9090", "9191"},
+		{"flag without env", "9191", "", "9191"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PORT", tt.envPort)
+			if got := resolvePort(tt.flagPort); got != tt.want {
+				t.Errorf("Expected port %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPServerConfig(t *testing.T) {
+	port := "12345"
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      nil,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	if srv.ReadTimeout != 10*time.Second {
+		t.Errorf("Expected ReadTimeout to be 10s, got %v", srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != 10*time.Second {
+		t.Errorf("Expected WriteTimeout to be 10s, got %v", srv.WriteTimeout)
+	}
+	if srv.IdleTimeout != 60*time.Second {
+		t.Errorf("Expected IdleTimeout to be 60s, got %v", srv.IdleTimeout)
+	}
+}
+
+func TestTripsUpdateEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create a trip
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Miles:       5.0,
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Now update the trip
+	updatedTrip := core.Trip{
+		Date:        "2024-12-19",
+		Origin:      "Updated Home",
+		Destination: "Updated Work",
+		Miles:       10.0,
+		Type:        "round",
+	}
+
+	updatedTripJSON, _ := json.Marshal(updatedTrip)
+	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBuffer(updatedTripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var responseTrip core.Trip
+	if err := json.NewDecoder(w.Body).Decode(&responseTrip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if responseTrip.Date != updatedTrip.Date {
+		t.Errorf("Expected date %s, got %s", updatedTrip.Date, responseTrip.Date)
+	}
+	if responseTrip.Origin != updatedTrip.Origin {
+		t.Errorf("Expected origin %s, got %s", updatedTrip.Origin, responseTrip.Origin)
+	}
+	if responseTrip.Miles != updatedTrip.Miles {
+		t.Errorf("Expected miles %f, got %f", updatedTrip.Miles, responseTrip.Miles)
+	}
+}
+
+func TestTripsUpdateInvalidIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Miles:       5.0,
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPut, "/api/trips/999", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTripsUpdateInvalidData(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create a trip
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Miles:       5.0,
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Try to update with invalid data
+	invalidTrip := map[string]interface{}{
+		"date":        "invalid-date",
+		"origin":      "",
+		"destination": "Test Work",
+		"miles":       -5.0,
+		"type":        "invalid",
+	}
+
+	invalidTripJSON, _ := json.Marshal(invalidTrip)
+	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBuffer(invalidTripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestBatchEditTrips(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	original := []core.Trip{
+		{Date: "2024-12-16", Origin: "Home", Destination: "Wrok", Miles: 5.0, Type: "single"},
+		{Date: "2024-12-17", Origin: "Home", Destination: "Scool", Miles: 3.0, Type: "single"},
+	}
+	if err := server.store.SaveData(&core.StorageData{Trips: original}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	batch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/trips/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		server.handleBatchEditTrips(w, req)
+		return w
+	}
+
+	// One bad update rejects the whole batch
+	w := batch(`[
+		{"index": 0, "trip": {"date": "2024-12-16", "origin": "Home", "destination": "Work", "miles": 5, "type": "single"}},
+		{"index": 1, "trip": {"date": "2024-12-17", "origin": "Home", "destination": "", "miles": 3, "type": "single"}},
+		{"index": 7, "trip": {"date": "2024-12-18", "origin": "Home", "destination": "Gym", "miles": 2, "type": "single"}}
+	]`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Errors []batchItemError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Errors) != 2 || body.Errors[0].Index != 1 || body.Errors[0].Field != "destination" || body.Errors[1].Index != 7 {
+		t.Errorf("Expected errors for trips 1 and 7, got %+v", body.Errors)
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if data.Trips[0].Destination != "Wrok" || data.Trips[1].Destination != "Scool" {
+		t.Errorf("Expected no trips to change, got %+v", data.Trips)
+	}
+
+	// A clean batch saves every update and recalculates the summaries
+	w = batch(`[
+		{"index": 0, "trip": {"date": "2024-12-16", "origin": "Home", "destination": "Work", "miles": 6, "type": "single"}},
+		{"index": 1, "trip": {"date": "2024-12-17", "origin": "Home", "destination": "School", "miles": 3, "type": "single"}}
+	]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data, err = server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	// Each trip stays at the index it was edited by
+	if len(data.Trips) != 2 || data.Trips[0].Destination != "Work" || data.Trips[1].Destination != "School" {
+		t.Errorf("Expected both destinations corrected in place, got %+v", data.Trips)
+	}
+	if len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalMiles != 9 {
+		t.Errorf("Expected one week of 9 miles, got %+v", data.WeeklySummaries)
+	}
+
+	if w := batch(`[{"index": 0, "trip": {}}, {"index": 0, "trip": {}}]`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for a repeated index, got %d", w.Code)
+	}
+	if w := batch(`[]`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty batch, got %d", w.Code)
+	}
+}
+
+func TestTripsRecalc(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-16", Origin: "Home", Destination: "Work", Miles: 3.0, Type: "single"},
+		{Date: "2024-12-17", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	recalc := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		return w
+	}
+
+	w := recalc("/api/trips/1/recalc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var trip tripResponse
+	if err := json.NewDecoder(w.Body).Decode(&trip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if trip.Destination != "School" || trip.Miles != 10.0 || !trip.MilesEstimated {
+		t.Errorf("Expected the School trip overwritten with the mock's 10 miles, got %+v", trip)
+	}
+	// Trips keep their stored order, so the same index names the same trip next time
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if data.Trips[1].Destination != "School" {
+		t.Errorf("Expected the School trip to stay at index 1, got %+v", data.Trips)
+	}
+
+	// A stale If-Match is rejected without looking anything up
+	req := httptest.NewRequest(http.MethodPost, "/api/trips/recalc", nil)
+	req.Header.Set("If-Match", `"stale"`)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412 for a stale If-Match, got %d", w.Code)
+	}
+
+	w = recalc("/api/trips/recalc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if data, err = server.store.LoadData(); err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	for _, trip := range data.Trips {
+		if trip.Miles != 10.0 {
+			t.Errorf("Expected %s overwritten with 10 miles, got %.2f", trip.Destination, trip.Miles)
+		}
+	}
+	if len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalMiles != 20 {
+		t.Errorf("Expected the summary recomputed to 20 miles, got %+v", data.WeeklySummaries)
+	}
+
+	if w := recalc("/api/trips/9/recalc"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid index, got %d", w.Code)
+	}
+	server.mapsClient = maps.NewManualClient()
+	if w := recalc("/api/trips/0/recalc"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 without distance lookups, got %d", w.Code)
+	}
+}
+
+func TestTripsArchive(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-16", Origin: "Home", Destination: "Work", Miles: 3.0, Type: "single"},
+		{Date: "2024-12-17", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		return w
+	}
+	listTotal := func(path string) int {
+		w := do(http.MethodGet, path)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var response struct {
+			Total int `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Total
+	}
+
+	w := do(http.MethodPost, "/api/trips/0/archive")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var trip tripResponse
+	if err := json.NewDecoder(w.Body).Decode(&trip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if trip.Destination != "Work" || !trip.Archived {
+		t.Errorf("Expected the Work trip archived, got %+v", trip)
+	}
+
+	if total := listTotal("/api/trips"); total != 1 {
+		t.Errorf("Expected the archived trip left out of the list, got %d trips", total)
+	}
+	if total := listTotal("/api/trips?includeArchived=true"); total != 2 {
+		t.Errorf("Expected both trips with includeArchived, got %d", total)
+	}
+	if w := do(http.MethodGet, "/api/trips?includeArchived=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid includeArchived, got %d", w.Code)
+	}
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 2 {
+		t.Errorf("Expected archiving to keep the trip, got %d trips", len(data.Trips))
+	}
+	if len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalMiles != 4 {
+		t.Errorf("Expected the summary to leave out the archived trip, got %+v", data.WeeklySummaries)
+	}
+
+	statsReq := httptest.NewRequest(http.MethodGet, "/api/stats", nil)
+	statsW := httptest.NewRecorder()
+	server.handleStats(statsW, statsReq)
+	var stats statsResponse
+	if err := json.NewDecoder(statsW.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats.TripCount != 1 || stats.TotalMiles != 4 {
+		t.Errorf("Expected stats to leave out the archived trip, got %+v", stats)
+	}
+
+	// Archiving leaves the stored order alone, so the Work trip is still at index 0
+	if data.Trips[0].Destination != "Work" {
+		t.Errorf("Expected the Work trip to keep index 0, got %+v", data.Trips)
+	}
+	if w := do(http.MethodPost, "/api/trips/0/unarchive"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if total := listTotal("/api/trips"); total != 2 {
+		t.Errorf("Expected the restored trip back in the list, got %d trips", total)
+	}
+
+	if w := do(http.MethodPost, "/api/trips/9/archive"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid index, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/trips/x/archive"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-numeric index, got %d", w.Code)
+	}
+}
+
+func TestTripDuplicatesAndDedupe(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-12-18", Origin: "Home", Destination: "School", Miles: 5.0, Type: "round"},
+		{Date: "2024-12-18", Origin: "Home", Destination: "School", Miles: 5.0, Type: "round", IsRecurring: true},
+		{Date: "2024-12-18", Origin: "Home", Destination: "School", Miles: 5.0, Type: "single"},
+		{Date: "2024-12-19", Origin: "Home", Destination: "Zoo", Miles: 8.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/trips/duplicates", nil)
+	w := httptest.NewRecorder()
+	server.handleTripDuplicates(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var listed struct {
+		Duplicates []duplicateGroup `json:"duplicates"`
+		Count      int              `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed.Count != 1 || len(listed.Duplicates) != 1 || !reflect.DeepEqual(listed.Duplicates[0].Indexes, []int{0, 1}) {
+		t.Errorf("Expected trips 0 and 1 as duplicates, got %+v", listed)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/trips/dedupe", nil)
+	w = httptest.NewRecorder()
+	server.handleDedupeTrips(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var merged map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&merged); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if merged["removed"] != 1 {
+		t.Errorf("Expected 1 trip removed, got %v", merged)
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 3 {
+		t.Fatalf("Expected the round trip, the single trip and the Zoo trip to remain, got %+v", data.Trips)
+	}
+	// The remaining trips keep their stored order rather than being sorted by date
+	if data.Trips[0].Type != "round" || data.Trips[2].Destination != "Zoo" {
+		t.Errorf("Expected the remaining trips in stored order, got %+v", data.Trips)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/dedupe", nil)
+	w = httptest.NewRecorder()
+	server.handleDedupeTrips(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestExpenseDuplicatesAndDedupe(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := server.store.SaveData(&core.StorageData{Expenses: []core.Expense{
+		{Date: "2024-12-18", Amount: 8.75, Description: "Parking"},
+		{Date: "2024-12-18", Amount: 8.75, Description: "Snacks"},
+		{Date: "2024-12-18", Amount: 8.75, Description: "Parking"},
+		{Date: "2024-12-19", Amount: 3, Description: "Toll"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/expenses/duplicates", nil)
+	w := httptest.NewRecorder()
+	server.handleExpenseDuplicates(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var listed struct {
+		Duplicates []expenseDuplicateGroup `json:"duplicates"`
+		Count      int                     `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed.Count != 1 || len(listed.Duplicates) != 1 || !reflect.DeepEqual(listed.Duplicates[0].Indexes, []int{0, 2}) {
+		t.Errorf("Expected expenses 0 and 2 as duplicates, got %+v", listed)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/expenses/dedupe", nil)
+	w = httptest.NewRecorder()
+	server.handleDedupeExpenses(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var merged map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&merged); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if merged["removed"] != 1 {
+		t.Errorf("Expected 1 expense removed, got %v", merged)
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != 3 {
+		t.Fatalf("Expected the parking, snacks and toll expenses to remain, got %+v", data.Expenses)
+	}
+	// The remaining expenses keep their stored order rather than being sorted by date
+	if data.Expenses[0].Description != "Parking" || data.Expenses[2].Description != "Toll" {
+		t.Errorf("Expected the remaining expenses in stored order, got %+v", data.Expenses)
+	}
+	if len(data.WeeklySummaries) != 1 || data.WeeklySummaries[0].TotalExpenses != 20.50 {
+		t.Errorf("Expected the summary recomputed to $20.50 of expenses, got %+v", data.WeeklySummaries)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/expenses/dedupe", nil)
+	w = httptest.NewRecorder()
+	server.handleDedupeExpenses(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+}
+
+func TestTripsDeleteEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create a trip
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Miles:       5.0,
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Now delete the trip
+	req = httptest.NewRequest(http.MethodDelete, "/api/trips/0", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+
+	// Verify the trip is gone
+	req = httptest.NewRequest(http.MethodGet, "/api/trips", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response["count"] != float64(0) {
+		t.Errorf("Expected count 0, got %v", response["count"])
+	}
+}
+
+func TestTripsDeleteInvalidIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/trips/999", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestExpensesUpdateEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create an expense
+	expense := core.Expense{
+		Date:        "2024-12-18",
+		Amount:      25.50,
+		Description: "Test expense",
+	}
+
+	expenseJSON, _ := json.Marshal(expense)
+	req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Now update the expense
+	updatedExpense := core.Expense{
+		Date:        "2024-12-19",
+		Amount:      35.75,
+		Description: "Updated test expense",
+	}
+
+	updatedExpenseJSON, _ := json.Marshal(updatedExpense)
+	req = httptest.NewRequest(http.MethodPut, "/api/expenses/0", bytes.NewBuffer(updatedExpenseJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var responseExpense core.Expense
+	if err := json.NewDecoder(w.Body).Decode(&responseExpense); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if responseExpense.Date != updatedExpense.Date {
+		t.Errorf("Expected date %s, got %s", updatedExpense.Date, responseExpense.Date)
+	}
+	if responseExpense.Amount != updatedExpense.Amount {
+		t.Errorf("Expected amount %f, got %f", updatedExpense.Amount, responseExpense.Amount)
+	}
+	if responseExpense.Description != updatedExpense.Description {
+		t.Errorf("Expected description %s, got %s", updatedExpense.Description, responseExpense.Description)
+	}
+}
+
+func TestExpensesUpdateInvalidIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	expense := core.Expense{
+		Date:        "2024-12-18",
+		Amount:      25.50,
+		Description: "Test expense",
+	}
+
+	expenseJSON, _ := json.Marshal(expense)
+	req := httptest.NewRequest(http.MethodPut, "/api/expenses/999", bytes.NewBuffer(expenseJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestExpensesDeleteEndpoint(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create an expense
+	expense := core.Expense{
+		Date:        "2024-12-18",
+		Amount:      25.50,
+		Description: "Test expense",
+	}
+
+	expenseJSON, _ := json.Marshal(expense)
+	req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Now delete the expense
+	req = httptest.NewRequest(http.MethodDelete, "/api/expenses/0", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+
+	// Verify the expense is gone
+	req = httptest.NewRequest(http.MethodGet, "/api/expenses", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response["count"] != float64(0) {
+		t.Errorf("Expected count 0, got %v", response["count"])
+	}
+}
+
+func TestExpensesDeleteInvalidIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/expenses/999", nil)
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTripsUpdateNoIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Miles:       5.0,
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPut, "/api/trips/", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTripsDeleteNoIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/trips/", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTripsGetByIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create a trip
+	trip := core.Trip{
+		Date:        "2024-12-18",
+		Origin:      "Test Home",
+		Destination: "Test Work",
+		Type:        "single",
+	}
+
+	tripJSON, _ := json.Marshal(trip)
+	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	// Fetch the trip by index
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/0", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var responseTrip core.Trip
+	if err := json.NewDecoder(w.Body).Decode(&responseTrip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if responseTrip.Origin != trip.Origin || responseTrip.Destination != trip.Destination {
+		t.Errorf("Expected trip %s → %s, got %s → %s", trip.Origin, trip.Destination, responseTrip.Origin, responseTrip.Destination)
+	}
+
+	// Out of range index
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/5", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+
+	// Non-numeric index
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/abc", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestExpensesGetByIndex(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// First create an expense
+	expense := core.Expense{
+		Date:        "2024-12-18",
+		Amount:      25.50,
+		Description: "Test expense",
+	}
+
+	expenseJSON, _ := json.Marshal(expense)
+	req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
 	}
 
-	cleanup := func() {
-		os.RemoveAll(tempDir)
+	// Fetch the expense by index
+	req = httptest.NewRequest(http.MethodGet, "/api/expenses/0", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
 	}
 
-	return server, cleanup
-}
+	var responseExpense core.Expense
+	if err := json.NewDecoder(w.Body).Decode(&responseExpense); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if responseExpense.Description != expense.Description {
+		t.Errorf("Expected description %s, got %s", expense.Description, responseExpense.Description)
+	}
 
-func BenchmarkTripsEndpoint(b *testing.B) {
-	server, cleanup := setupBenchmarkServer(b)
-	defer cleanup()
+	// Out of range index
+	req = httptest.NewRequest(http.MethodGet, "/api/expenses/-1", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
 
-	// Add some test data
-	data, err := server.store.LoadData()
-	if err != nil {
-		b.Fatalf("Failed to load data: %v", err)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
 	}
+}
 
-	for i := 0; i < 100; i++ {
+func TestTripsPagination(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	// Create five trips on consecutive days
+	for day := 1; day <= 5; day++ {
 		trip := core.Trip{
-			Date:        fmt.Sprintf("2024-12-%02d", i%30+1),
-			Origin:      fmt.Sprintf("Home %d", i),
-			Destination: fmt.Sprintf("Work %d", i),
-			Miles:       float64(i) + 1.0,
+			Date:        fmt.Sprintf("2024-03-0%d", day),
+			Origin:      "Home",
+			Destination: "Work",
 			Type:        "single",
 		}
-		data.Trips = append(data.Trips, trip)
+		tripJSON, _ := json.Marshal(trip)
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
 	}
 
-	if err := server.store.SaveData(data); err != nil {
-		b.Fatalf("Failed to save data: %v", err)
+	req := httptest.NewRequest(http.MethodGet, "/api/trips?page=1&pageSize=2", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		req := httptest.NewRequest(http.MethodGet, "/api/trips", nil)
+	var response struct {
+		Trips      []core.Trip `json:"trips"`
+		Count      int         `json:"count"`
+		Total      int         `json:"total"`
+		Page       int         `json:"page"`
+		TotalPages int         `json:"totalPages"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if response.Total != 5 {
+		t.Errorf("Expected total 5, got %d", response.Total)
+	}
+	if response.Page != 1 {
+		t.Errorf("Expected page 1, got %d", response.Page)
+	}
+	if response.TotalPages != 3 {
+		t.Errorf("Expected 3 total pages, got %d", response.TotalPages)
+	}
+	if response.Count != 2 || len(response.Trips) != 2 {
+		t.Fatalf("Expected 2 trips on page, got %d", len(response.Trips))
+	}
+	// Sorted by date descending, so page 1 holds the 3rd and 2nd of March
+	if response.Trips[0].Date != "2024-03-03" || response.Trips[1].Date != "2024-03-02" {
+		t.Errorf("Expected trips 2024-03-03 and 2024-03-02, got %s and %s", response.Trips[0].Date, response.Trips[1].Date)
+	}
+
+	// Past the last page returns an empty list
+	req = httptest.NewRequest(http.MethodGet, "/api/trips?page=10&pageSize=2", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Trips) != 0 {
+		t.Errorf("Expected no trips past the last page, got %d", len(response.Trips))
+	}
+}
+
+func TestPaginationInvalidParams(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for _, query := range []string{"page=-1", "page=abc", "pageSize=-5", "pageSize=0", "pageSize=ten"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/trips?"+query, nil)
 		w := httptest.NewRecorder()
 		server.handleTrips(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for trips?%s, got %d", query, w.Code)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/expenses?"+query, nil)
+		w = httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for expenses?%s, got %d", query, w.Code)
+		}
 	}
 }
 
-func TestHTTPServerConfig(t *testing.T) {
-	port := "12345"
-	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      nil,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+func TestTripsDateRangeFilter(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for _, date := range []string{"2024-02-28", "2024-03-01", "2024-03-15", "2024-03-31", "2024-04-01"} {
+		trip := core.Trip{Date: date, Origin: "Home", Destination: "Work", Type: "single"}
+		tripJSON, _ := json.Marshal(trip)
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
 	}
 
-	if srv.ReadTimeout != 10*time.Second {
-		t.Errorf("Expected ReadTimeout to be 10s, got %v", srv.ReadTimeout)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{query: "from=2024-03-01&to=2024-03-31", want: 3},
+		{query: "from=2024-03-15", want: 3},
+		{query: "to=2024-03-01", want: 2},
+		{query: "", want: 5},
 	}
-	if srv.WriteTimeout != 10*time.Second {
-		t.Errorf("Expected WriteTimeout to be 10s, got %v", srv.WriteTimeout)
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/trips?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q, got %d", tt.query, w.Code)
+		}
+
+		var response map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response["total"] != float64(tt.want) {
+			t.Errorf("Expected %d trips for %q, got %v", tt.want, tt.query, response["total"])
+		}
 	}
-	if srv.IdleTimeout != 60*time.Second {
-		t.Errorf("Expected IdleTimeout to be 60s, got %v", srv.IdleTimeout)
+
+	// Malformed dates are rejected
+	for _, query := range []string{"from=03-01-2024", "to=2024-13-01"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/trips?"+query, nil)
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+		}
 	}
 }
 
-func TestTripsUpdateEndpoint(t *testing.T) {
+func TestExpensesDateRangeFilter(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for _, date := range []string{"2024-02-28", "2024-03-10", "2024-04-01"} {
+		expense := core.Expense{Date: date, Amount: 10.0, Description: "Parking"}
+		expenseJSON, _ := json.Marshal(expense)
+		req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
+		w := httptest.NewRecorder()
+		server.handleExpenses(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/expenses?from=2024-03-01&to=2024-03-31", nil)
+	w := httptest.NewRecorder()
+	server.handleExpenses(w, req)
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response["total"] != float64(1) {
+		t.Errorf("Expected 1 expense in March, got %v", response["total"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/expenses?from=bad", nil)
+	w = httptest.NewRecorder()
+	server.handleExpenses(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestTripsNotesRoundTrip(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// First create a trip
 	trip := core.Trip{
 		Date:        "2024-12-18",
 		Origin:      "Test Home",
-		Destination: "Test Work",
-		Miles:       5.0,
-		Type:        "single",
+		Destination: "Test Clinic",
+		Type:        "round",
+		Notes:       "Doctor appointment",
 	}
 
 	tripJSON, _ := json.Marshal(trip)
@@ -555,340 +3589,615 @@ func TestTripsUpdateEndpoint(t *testing.T) {
 		t.Fatalf("Expected status 201, got %d", w.Code)
 	}
 
-	// Now update the trip
-	updatedTrip := core.Trip{
-		Date:        "2024-12-19",
-		Origin:      "Updated Home",
-		Destination: "Updated Work",
-		Miles:       10.0,
-		Type:        "round",
+	var createdTrip core.Trip
+	if err := json.NewDecoder(w.Body).Decode(&createdTrip); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if createdTrip.Notes != trip.Notes {
+		t.Errorf("Expected notes %q, got %q", trip.Notes, createdTrip.Notes)
 	}
 
-	updatedTripJSON, _ := json.Marshal(updatedTrip)
-	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBuffer(updatedTripJSON))
+	// Update the notes
+	createdTrip.Notes = "Ballet practice"
+	updatedJSON, _ := json.Marshal(createdTrip)
+	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBuffer(updatedJSON))
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	server.handleTrips(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		t.Fatalf("Expected status 200, got %d", w.Code)
 	}
 
-	var responseTrip core.Trip
-	if err := json.NewDecoder(w.Body).Decode(&responseTrip); err != nil {
+	// Verify the stored trip carries the updated notes
+	req = httptest.NewRequest(http.MethodGet, "/api/trips/0", nil)
+	w = httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	var storedTrip core.Trip
+	if err := json.NewDecoder(w.Body).Decode(&storedTrip); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
+	if storedTrip.Notes != "Ballet practice" {
+		t.Errorf("Expected stored notes %q, got %q", "Ballet practice", storedTrip.Notes)
+	}
+}
 
-	if responseTrip.Date != updatedTrip.Date {
-		t.Errorf("Expected date %s, got %s", updatedTrip.Date, responseTrip.Date)
+func TestTripsDeleteInRange(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	for _, date := range []string{"2024-02-28", "2024-03-01", "2024-03-15", "2024-04-01"} {
+		trip := core.Trip{Date: date, Origin: "Home", Destination: "Work", Type: "single"}
+		tripJSON, _ := json.Marshal(trip)
+		req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
+		w := httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d", w.Code)
+		}
 	}
-	if responseTrip.Origin != updatedTrip.Origin {
-		t.Errorf("Expected origin %s, got %s", updatedTrip.Origin, responseTrip.Origin)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/trips?from=2024-03-01&to=2024-03-31", nil)
+	w := httptest.NewRecorder()
+	server.handleTrips(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
 	}
-	if responseTrip.Miles != updatedTrip.Miles {
-		t.Errorf("Expected miles %f, got %f", updatedTrip.Miles, responseTrip.Miles)
+
+	var response map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response["deleted"] != float64(2) {
+		t.Errorf("Expected 2 trips deleted, got %v", response["deleted"])
+	}
+
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 2 {
+		t.Errorf("Expected 2 trips remaining, got %d", len(data.Trips))
+	}
+	if len(data.WeeklySummaries) != 2 {
+		t.Errorf("Expected weekly summaries to be recalculated for 2 weeks, got %d", len(data.WeeklySummaries))
+	}
+
+	// Missing or malformed bounds are rejected
+	for _, query := range []string{"from=2024-03-01", "from=bad&to=2024-03-31", "from=2024-04-01&to=2024-03-01"} {
+		req = httptest.NewRequest(http.MethodDelete, "/api/trips?"+query, nil)
+		w = httptest.NewRecorder()
+		server.handleTrips(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, w.Code)
+		}
 	}
 }
 
-func TestTripsUpdateInvalidIndex(t *testing.T) {
+func TestRecurringTripsCRUD(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	trip := core.Trip{
-		Date:        "2024-12-18",
-		Origin:      "Test Home",
-		Destination: "Test Work",
-		Miles:       5.0,
-		Type:        "single",
+	// A manually entered trip must survive the recurring trip's deletion
+	if err := server.store.SaveData(&core.StorageData{Trips: []core.Trip{
+		{Date: "2024-03-02", Origin: "Home", Destination: "Park", Miles: 2.0, Type: "single"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	tripJSON, _ := json.Marshal(trip)
-	req := httptest.NewRequest(http.MethodPut, "/api/trips/999", bytes.NewBuffer(tripJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// Wednesdays in March 2024: the 6th, 13th, 20th and 27th
+	body := `{"origin":"Home","destination":"School","miles":4,"start_date":"2024-03-01","end_date":"2024-03-31","type":"single","weekday":3}`
+	req := httptest.NewRequest(http.MethodPost, "/api/recurring", strings.NewReader(body))
 	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
+	server.handleRecurring(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	saved, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.RecurringTrips) != 1 || len(saved.Trips) != 5 {
+		t.Fatalf("Expected 1 recurring trip generating 4 trips, got %d recurring and %d trips", len(saved.RecurringTrips), len(saved.Trips))
+	}
+	if len(saved.WeeklySummaries) != 5 {
+		t.Errorf("Expected weekly summaries for 5 weeks, got %d", len(saved.WeeklySummaries))
+	}
+	// Generated trips are added after the stored ones, which keep their indexes
+	if saved.Trips[0].Destination != "Park" {
+		t.Errorf("Expected the manual trip to stay at index 0, got %+v", saved.Trips[0])
+	}
 
+	req = httptest.NewRequest(http.MethodGet, "/api/recurring", nil)
+	w = httptest.NewRecorder()
+	server.handleRecurring(w, req)
+	var listed struct {
+		RecurringTrips []core.RecurringTrip `json:"recurringTrips"`
+		Count          int                  `json:"count"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if listed.Count != 1 || listed.RecurringTrips[0].Destination != "School" {
+		t.Errorf("Expected the recurring trip to be listed, got %+v", listed)
+	}
+
+	// Moving the trip to Mondays replaces the generated trips
+	body = `{"origin":"Home","destination":"School","miles":4,"start_date":"2024-03-01","end_date":"2024-03-31","type":"single","weekday":1}`
+	req = httptest.NewRequest(http.MethodPut, "/api/recurring/0", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	server.handleRecurring(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved, err = server.store.LoadData(); err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	for _, trip := range saved.Trips {
+		if trip.IsRecurring && trip.Date == "2024-03-06" {
+			t.Errorf("Expected the Wednesday trips to be replaced, found %+v", trip)
+		}
+	}
+	if len(saved.Trips) != 5 {
+		t.Errorf("Expected 4 Monday trips and the manual trip, got %d trips", len(saved.Trips))
+	}
+	if saved.Trips[0].Destination != "Park" {
+		t.Errorf("Expected the manual trip to stay at index 0, got %+v", saved.Trips[0])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/recurring", strings.NewReader(`{"origin":"Home","miles":4,"start_date":"2024-03-01","type":"single"}`))
+	w = httptest.NewRecorder()
+	server.handleRecurring(w, req)
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+		t.Errorf("Expected status 400 for a missing destination, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/recurring/0", nil)
+	w = httptest.NewRecorder()
+	server.handleRecurring(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved, err = server.store.LoadData(); err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.RecurringTrips) != 0 || len(saved.Trips) != 1 || saved.Trips[0].Destination != "Park" {
+		t.Errorf("Expected only the manual trip to remain, got %+v", saved)
+	}
+	if len(saved.WeeklySummaries) != 1 {
+		t.Errorf("Expected the summaries recalculated to 1 week, got %d", len(saved.WeeklySummaries))
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/recurring/0", nil)
+	w = httptest.NewRecorder()
+	server.handleRecurring(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a missing recurring trip, got %d", w.Code)
 	}
 }
 
-func TestTripsUpdateInvalidData(t *testing.T) {
+func TestRecurringTripsListStoredIndex(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// First create a trip
-	trip := core.Trip{
-		Date:        "2024-12-18",
-		Origin:      "Test Home",
-		Destination: "Test Work",
-		Miles:       5.0,
-		Type:        "single",
+	server.cfg.Families = []string{"Smith", "Jones"}
+	if err := server.store.SaveData(&core.StorageData{RecurringTrips: []core.RecurringTrip{
+		{Origin: "Home", Destination: "Joneses", Miles: 7, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 1, Family: "Jones"},
+		{Origin: "Home", Destination: "School", Miles: 4, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3, Family: "Smith"},
+	}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	tripJSON, _ := json.Marshal(trip)
-	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
-	req.Header.Set("Content-Type", "application/json")
+	req := httptest.NewRequest(http.MethodGet, "/api/recurring?family=Smith", nil)
 	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
-
-	if w.Code != http.StatusCreated {
-		t.Fatalf("Expected status 201, got %d", w.Code)
+	server.handleRecurring(w, req)
+	var listed struct {
+		RecurringTrips []struct {
+			Destination string `json:"destination"`
+			Index       int    `json:"index"`
+		} `json:"recurringTrips"`
 	}
-
-	// Try to update with invalid data
-	invalidTrip := map[string]interface{}{
-		"date":        "invalid-date",
-		"origin":      "",
-		"destination": "Test Work",
-		"miles":       -5.0,
-		"type":        "invalid",
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed.RecurringTrips) != 1 || listed.RecurringTrips[0].Index != 1 {
+		t.Fatalf("Expected the Smith trip at index 1, got %+v", listed.RecurringTrips)
 	}
 
-	invalidTripJSON, _ := json.Marshal(invalidTrip)
-	req = httptest.NewRequest(http.MethodPut, "/api/trips/0", bytes.NewBuffer(invalidTripJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// The listed index deletes the listed trip, not the first stored one
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/recurring/%d", listed.RecurringTrips[0].Index), nil)
 	w = httptest.NewRecorder()
-	server.handleTrips(w, req)
-
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	server.handleRecurring(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d: %s", w.Code, w.Body.String())
+	}
+	saved, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.RecurringTrips) != 1 || saved.RecurringTrips[0].Destination != "Joneses" {
+		t.Errorf("Expected only the Jones trip to remain, got %+v", saved.RecurringTrips)
 	}
 }
 
-func TestTripsDeleteEndpoint(t *testing.T) {
+func TestCreateRecurringTripLooksUpRouteOnce(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// First create a trip
-	trip := core.Trip{
-		Date:        "2024-12-18",
-		Origin:      "Test Home",
-		Destination: "Test Work",
-		Miles:       5.0,
-		Type:        "single",
-	}
+	mock := maps.NewMockClient()
+	server.mapsClient = mock
 
-	tripJSON, _ := json.Marshal(trip)
-	req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBuffer(tripJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// Mondays over six months reuse the one looked-up distance
+	body := `{"origin":"Home","destination":"School","start_date":"2024-01-01","end_date":"2024-06-30","type":"single","weekday":1}`
+	req := httptest.NewRequest(http.MethodPost, "/api/recurring", strings.NewReader(body))
 	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
-
+	server.handleRecurring(w, req)
 	if w.Code != http.StatusCreated {
-		t.Fatalf("Expected status 201, got %d", w.Code)
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
 	}
 
-	// Now delete the trip
-	req = httptest.NewRequest(http.MethodDelete, "/api/trips/0", nil)
-	w = httptest.NewRecorder()
-	server.handleTrips(w, req)
+	if mock.Calls() != 1 {
+		t.Errorf("Expected a single distance lookup, got %d", mock.Calls())
+	}
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 26 {
+		t.Errorf("Expected 26 Mondays of trips, got %d", len(data.Trips))
+	}
+	for _, trip := range data.Trips {
+		if trip.Miles != 10.0 {
+			t.Errorf("Expected the trip on %s to reuse 10.0 miles, got %.2f", trip.Date, trip.Miles)
+		}
+	}
+}
 
-	if w.Code != http.StatusNoContent {
-		t.Errorf("Expected status 204, got %d", w.Code)
+func TestExtendRecurring(t *testing.T) {
+	server, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	data := &core.StorageData{
+		RecurringTrips: []core.RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3},
+		},
+	}
+	if err := server.store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	// Verify the trip is gone
-	req = httptest.NewRequest(http.MethodGet, "/api/trips", nil)
-	w = httptest.NewRecorder()
-	server.handleTrips(w, req)
+	req := httptest.NewRequest(http.MethodPost, "/api/recurring/extend", strings.NewReader(`{"end_date": "2024-04-30"}`))
+	w := httptest.NewRecorder()
+	server.handleExtendRecurring(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
-
 	var response map[string]interface{}
 	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 		t.Fatalf("Failed to decode response: %v", err)
 	}
-
-	if response["count"] != float64(0) {
-		t.Errorf("Expected count 0, got %v", response["count"])
+	if response["created"] != float64(4) {
+		t.Errorf("Expected 4 trips created, got %v", response["created"])
 	}
-}
-
-func TestTripsDeleteInvalidIndex(t *testing.T) {
-	server, _, cleanup := setupTestServer(t)
-	defer cleanup()
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/trips/999", nil)
-	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
+	saved, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if saved.RecurringTrips[0].EndDate != "2024-04-30" || len(saved.Trips) != 4 {
+		t.Errorf("Expected the recurring trip extended with 4 new trips, got %+v", saved)
+	}
+	if len(saved.WeeklySummaries) != 4 {
+		t.Errorf("Expected weekly summaries for the 4 new trips, got %d", len(saved.WeeklySummaries))
+	}
 
+	req = httptest.NewRequest(http.MethodPost, "/api/recurring/extend", strings.NewReader(`{"end_date": "April 30"}`))
+	w = httptest.NewRecorder()
+	server.handleExtendRecurring(w, req)
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+		t.Errorf("Expected status 400 for a malformed date, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/recurring/extend", nil)
+	w = httptest.NewRecorder()
+	server.handleExtendRecurring(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
 	}
 }
 
-func TestExpensesUpdateEndpoint(t *testing.T) {
+func TestExportImport(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// First create an expense
-	expense := core.Expense{
-		Date:        "2024-12-18",
-		Amount:      25.50,
-		Description: "Test expense",
+	original := &core.StorageData{
+		Trips:         []core.Trip{{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}},
+		Expenses:      []core.Expense{{Date: "2024-03-20", Amount: 12.5, Description: "Lunch"}},
+		TripTemplates: []core.TripTemplate{{Name: "Commute", Origin: "Home", Destination: "Work", TripType: "single"}},
+	}
+	if err := server.store.SaveData(original); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	expenseJSON, _ := json.Marshal(expense)
-	req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// Export
+	req := httptest.NewRequest(http.MethodGet, "/api/export", nil)
 	w := httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	server.handleExport(w, req)
 
-	if w.Code != http.StatusCreated {
-		t.Fatalf("Expected status 201, got %d", w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename=nannytracker-backup.json" {
+		t.Errorf("Unexpected Content-Disposition: %s", disposition)
 	}
+	backup := w.Body.Bytes()
 
-	// Now update the expense
-	updatedExpense := core.Expense{
-		Date:        "2024-12-19",
-		Amount:      35.75,
-		Description: "Updated test expense",
+	var exported core.StorageData
+	if err := json.Unmarshal(backup, &exported); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if len(exported.Trips) != 1 || len(exported.Expenses) != 1 || len(exported.TripTemplates) != 1 {
+		t.Errorf("Export is missing records: %+v", exported)
+	}
+	if len(exported.WeeklySummaries) != 1 {
+		t.Errorf("Expected export to include 1 weekly summary, got %d", len(exported.WeeklySummaries))
 	}
 
-	updatedExpenseJSON, _ := json.Marshal(updatedExpense)
-	req = httptest.NewRequest(http.MethodPut, "/api/expenses/0", bytes.NewBuffer(updatedExpenseJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// Wipe the data, then restore from the backup
+	if err := server.store.SaveData(&core.StorageData{}); err != nil {
+		t.Fatalf("Failed to clear data: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewBuffer(backup))
 	w = httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	server.handleImport(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	var responseExpense core.Expense
-	if err := json.NewDecoder(w.Body).Decode(&responseExpense); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
+	restored, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
 	}
-
-	if responseExpense.Date != updatedExpense.Date {
-		t.Errorf("Expected date %s, got %s", updatedExpense.Date, responseExpense.Date)
+	if len(restored.Trips) != 1 || restored.Trips[0].Origin != "Home" {
+		t.Errorf("Expected trip to be restored, got %+v", restored.Trips)
 	}
-	if responseExpense.Amount != updatedExpense.Amount {
-		t.Errorf("Expected amount %f, got %f", updatedExpense.Amount, responseExpense.Amount)
+	if len(restored.Expenses) != 1 || len(restored.TripTemplates) != 1 {
+		t.Errorf("Expected expenses and templates to be restored, got %+v", restored)
 	}
-	if responseExpense.Description != updatedExpense.Description {
-		t.Errorf("Expected description %s, got %s", updatedExpense.Description, responseExpense.Description)
+
+	// Wrong methods
+	req = httptest.NewRequest(http.MethodPost, "/api/export", nil)
+	w = httptest.NewRecorder()
+	server.handleExport(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/import", nil)
+	w = httptest.NewRecorder()
+	server.handleImport(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
 	}
 }
 
-func TestExpensesUpdateInvalidIndex(t *testing.T) {
+func TestImportRejectsInvalidRecords(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	expense := core.Expense{
-		Date:        "2024-12-18",
-		Amount:      25.50,
-		Description: "Test expense",
+	existing := &core.StorageData{
+		Trips: []core.Trip{{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}},
+	}
+	if err := server.store.SaveData(existing); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
 	}
 
-	expenseJSON, _ := json.Marshal(expense)
-	req := httptest.NewRequest(http.MethodPut, "/api/expenses/999", bytes.NewBuffer(expenseJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// One valid trip and one invalid expense
+	payload := `{
+		"trips": [{"date": "2024-04-01", "origin": "A", "destination": "B", "miles": 3, "type": "single"}],
+		"expenses": [{"date": "2024-04-01", "amount": -1, "description": "Bad"}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewBufferString(payload))
 	w := httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	server.handleImport(w, req)
 
 	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+
+	// Stored data is untouched
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 1 || data.Trips[0].Date != "2024-03-20" {
+		t.Errorf("Expected existing data to be unchanged, got %+v", data.Trips)
+	}
+
+	// Malformed JSON
+	req = httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewBufferString("not json"))
+	w = httptest.NewRecorder()
+	server.handleImport(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for malformed JSON, got %d", w.Code)
 	}
 }
 
-func TestExpensesDeleteEndpoint(t *testing.T) {
+func TestImportOpenEndedRecurringTrip(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	// First create an expense
-	expense := core.Expense{
-		Date:        "2024-12-18",
-		Amount:      25.50,
-		Description: "Test expense",
-	}
-
-	expenseJSON, _ := json.Marshal(expense)
-	req := httptest.NewRequest(http.MethodPost, "/api/expenses", bytes.NewBuffer(expenseJSON))
-	req.Header.Set("Content-Type", "application/json")
+	// A backup written before recurring trips needed an end date or occurrence count
+	payload := `{
+		"trips": [{"date": "2024-03-18", "origin": "Home", "destination": "School", "miles": 5, "type": "single"}],
+		"recurring_trips": [{"origin": "Home", "destination": "School", "miles": 5, "start_date": "2024-03-18", "end_date": "", "type": "single", "weekday": 1}]
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/api/import", bytes.NewBufferString(payload))
 	w := httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	server.handleImport(w, req)
 
-	if w.Code != http.StatusCreated {
-		t.Fatalf("Expected status 201, got %d", w.Code)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
 	}
 
-	// Now delete the expense
-	req = httptest.NewRequest(http.MethodDelete, "/api/expenses/0", nil)
-	w = httptest.NewRecorder()
-	server.handleExpenses(w, req)
-
-	if w.Code != http.StatusNoContent {
-		t.Errorf("Expected status 204, got %d", w.Code)
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.RecurringTrips) != 1 || data.RecurringTrips[0].EndDate == "" {
+		t.Fatalf("Expected the recurring trip to be given an end date, got %+v", data.RecurringTrips)
 	}
 
-	// Verify the expense is gone
-	req = httptest.NewRequest(http.MethodGet, "/api/expenses", nil)
+	// The imported trip can be edited without having to pick an end date
+	body, _ := json.Marshal(data.RecurringTrips[0])
+	req = httptest.NewRequest(http.MethodPut, "/api/recurring/0", bytes.NewBuffer(body))
 	w = httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	server.handleRecurring(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("Expected status 200, got %d", w.Code)
+		t.Errorf("Expected status 200 editing the imported trip, got %d: %s", w.Code, w.Body.String())
 	}
+}
 
-	var response map[string]interface{}
-	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
-		t.Fatalf("Failed to decode response: %v", err)
+func TestServeGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{Addr: "127.0.0.1:18089", Handler: mux}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serve(ctx, srv, 5*time.Second)
+	}()
+
+	// Start a request that is still running when shutdown begins
+	respCh := make(chan int, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Get("http://127.0.0.1:18089/slow")
+			if err == nil {
+				resp.Body.Close()
+				respCh <- resp.StatusCode
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		respCh <- 0
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Request never reached the server")
 	}
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
 
-	if response["count"] != float64(0) {
-		t.Errorf("Expected count 0, got %v", response["count"])
+	if status := <-respCh; status != http.StatusOK {
+		t.Errorf("Expected in-flight request to complete with 200, got %d", status)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
 	}
 }
 
-func TestExpensesDeleteInvalidIndex(t *testing.T) {
-	server, _, cleanup := setupTestServer(t)
-	defer cleanup()
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(newLogger(&buf, slog.LevelInfo))
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/expenses/999", nil)
+	handler := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not found", http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/trips/99?ignored=1", nil)
 	w := httptest.NewRecorder()
-	server.handleExpenses(w, req)
+	handler.ServeHTTP(w, req)
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected wrapped handler status 404, got %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), "level=INFO msg=request method=GET path=/api/trips/99 status=404 ") {
+		t.Errorf("Expected method, path and status in log, got %q", buf.String())
 	}
-}
-
-func TestTripsUpdateNoIndex(t *testing.T) {
-	server, _, cleanup := setupTestServer(t)
-	defer cleanup()
 
-	trip := core.Trip{
-		Date:        "2024-12-18",
-		Origin:      "Test Home",
-		Destination: "Test Work",
-		Miles:       5.0,
-		Type:        "single",
+	// Handlers that never call WriteHeader are logged as 200
+	buf.Reset()
+	handler = logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/health", nil))
+	if !strings.Contains(buf.String(), "method=POST path=/health status=200 ") {
+		t.Errorf("Expected implicit 200 in log, got %q", buf.String())
 	}
+}
 
-	tripJSON, _ := json.Marshal(trip)
-	req := httptest.NewRequest(http.MethodPut, "/api/trips/", bytes.NewBuffer(tripJSON))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
+func TestLogLevelSuppressesInfo(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(newLogger(&buf, slog.LevelError))
+
+	status := http.StatusOK
+	handler := logRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/trips", nil))
+	slog.Info("Starting NannyTracker API server")
+	slog.Warn("Google Maps API not available")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info and warning lines suppressed at error level, got %q", buf.String())
+	}
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	// Server errors are still logged
+	status = http.StatusInternalServerError
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/trips", nil))
+	if !strings.Contains(buf.String(), "level=ERROR msg=request method=GET path=/api/trips status=500 ") {
+		t.Errorf("Expected the failed request logged at error level, got %q", buf.String())
 	}
 }
 
-func TestTripsDeleteNoIndex(t *testing.T) {
+func TestConcurrentTripCreation(t *testing.T) {
 	server, _, cleanup := setupTestServer(t)
 	defer cleanup()
 
-	req := httptest.NewRequest(http.MethodDelete, "/api/trips/", nil)
-	w := httptest.NewRecorder()
-	server.handleTrips(w, req)
+	const requests = 40
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"date":"2024-03-20","origin":"Home","destination":"Stop %d","type":"single","miles":5}`, i)
+			req := httptest.NewRequest(http.MethodPost, "/api/trips", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			server.handleTrips(w, req)
+			if w.Code != http.StatusCreated {
+				t.Errorf("Expected status 201, got %d", w.Code)
+			}
+		}(i)
+	}
+	wg.Wait()
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", w.Code)
+	data, err := server.store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != requests {
+		t.Errorf("Expected %d trips, got %d", requests, len(data.Trips))
 	}
 }
@@ -2,7 +2,11 @@ package ui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -12,10 +16,27 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	model "github.com/laurendc/nannytracker/pkg/core"
+	"github.com/laurendc/nannytracker/pkg/core/export"
 	"github.com/laurendc/nannytracker/pkg/core/maps"
 	"github.com/laurendc/nannytracker/pkg/core/storage"
 )
 
+// defaultPageSize is used when no positive page size is configured
+const defaultPageSize = 10
+
+// The + and - keys change the page size in steps of pageSizeStep within these bounds
+const (
+	minPageSize  = 5
+	maxPageSize  = 50
+	pageSizeStep = 5
+)
+
+// maxUndoDepth is the number of destructive actions that can be undone
+const maxUndoDepth = 10
+
+// maxRoutePicks is how many frequent routes are offered while entering a trip
+const maxRoutePicks = 5
+
 // Model represents the UI state
 type Model struct {
 	TextInput         textinput.Model
@@ -24,8 +45,10 @@ type Model struct {
 	CurrentTrip       model.Trip
 	CurrentRecurring  model.RecurringTrip
 	CurrentExpense    model.Expense
-	Mode              string // "date", "origin", "destination", "type", "edit", "delete", "delete_confirm", "expense_date", "expense_amount", "expense_description", "expense_edit", "expense_delete_confirm", "search", "recurring_date", "recurring_weekday", "recurring_end_date", "convert_to_recurring", "template_name", "template_origin", "template_destination", "template_type", "template_notes", "template_edit", "template_delete_confirm"
+	CurrentAdjustment model.Adjustment
+	Mode              string // "date", "origin", "destination", "type", "return_destination", "notes", "tags", "miles", "return_miles", "edit", "delete", "delete_confirm", "expense_date", "expense_amount", "expense_description", "expense_category", "expense_reimbursable", "expense_edit_date", "expense_edit_amount", "expense_edit_description", "expense_delete_confirm", "search", "recurring_date", "recurring_frequency", "recurring_weekday", "recurring_day_of_month", "recurring_excluded_dates", "recurring_end_date", "recurring_edit_date", "recurring_edit_weekday", "recurring_edit_origin", "recurring_edit_destination", "recurring_edit_type", "recurring_edit_end_date", "convert_to_recurring", "template_name", "template_origin", "template_destination", "template_type", "template_notes", "template_edit", "template_delete_confirm", "bulk_delete_from", "bulk_delete_to", "bulk_delete_confirm", "recurring_delete_confirm", "recurring_extend", "dedupe_confirm", "expense_dedupe_confirm", "adjustment_date", "adjustment_miles", "adjustment_reason", "week_jump"
 	Err               error
+	StatusMessage     string // Transient confirmation shown until the next keypress
 	Storage           storage.Storage
 	RatePerMile       float64
 	MapsClient        maps.DistanceCalculator
@@ -39,11 +62,29 @@ type Model struct {
 	ActiveTab         int                  // Index of the active tab (0: Weekly Summaries, 1: Trips, 2: Expenses, 3: Templates)
 	SelectedWeek      int                  // Index of the currently selected week in WeeklySummaries
 	PageSize          int                  // Number of items to show per page
+	SavePageSize      func(int) error      // Remembers a page size chosen with +/-; nil when it isn't kept
+	HomeAddress       string               // Default origin prefilled for new trips
+	DataDir           string               // Directory that exported weeks are written to
+	RoundingMode      string               // How weekly mileage amounts are rounded (see model.RoundingNone etc.)
+	WeekStartDay      time.Weekday         // Day weekly summaries begin on; the zero value is Sunday
+	DateFormat        string               // How dates are typed and shown (see model.DateFormatISO etc.); empty means ISO
+	MaxFutureDays     int                  // Furthest a trip may be dated past today; zero disables the check
+	MaxTripMiles      float64              // Longest plausible one-way trip; zero disables the check
+	WeeklyTarget      float64              // Miles aimed for each week, shown as progress; zero hides it
+	Families          []string             // Known families that can be switched between with Ctrl+G
+	ActiveFamily      string               // Family whose trips, expenses and summaries are shown; empty shows all
 	CurrentPage       int                  // Current page number (0-based)
+	SortAscending     bool                 // Whether the Trips tab lists the oldest trip first
+	HideRecurring     bool                 // Whether the weekly summary trip list leaves out generated trips
+	ShowArchived      bool                 // Whether the Trips tab also lists archived trips
 	TripTemplates     []model.TripTemplate // List of saved trip templates
 	SelectedTemplate  int                  // Index of selected template for operations
 	CurrentTemplate   model.TripTemplate   // Current template being edited
+	TemplatesByUsage  bool                 // Whether the Templates tab lists the most-used template first
 	JustChangedMode   bool                 // Flag to prevent double-processing after mode change
+	BulkDeleteFrom    string               // Start date of the range being bulk deleted
+	BulkDeleteTo      string               // End date of the range being bulk deleted
+	UndoStack         []*model.StorageData // Snapshots of Data taken before each delete or edit
 	Width             int                  // Terminal width in characters
 	// Phase 2: Help System
 	HelpVisible bool // Whether help overlay is visible
@@ -65,7 +106,7 @@ func New(storage storage.Storage, ratePerMile float64) (*Model, error) {
 	}
 
 	// Calculate weekly summaries after loading data
-	model.CalculateAndUpdateWeeklySummaries(data, ratePerMile)
+	model.CalculateAndUpdateWeeklySummaries(data, ratePerMile, model.RoundingNone, time.Sunday)
 
 	ti := textinput.New()
 	ti.Placeholder = "Enter date (YYYY-MM-DD)..."
@@ -87,16 +128,21 @@ func New(storage storage.Storage, ratePerMile float64) (*Model, error) {
 		SelectedExpense:   -1,
 		SelectedRecurring: -1,
 		SelectedTemplate:  -1,
-		PageSize:          10, // Default page size
-		CurrentPage:       0,  // Start at first page
+		PageSize:          defaultPageSize,
+		CurrentPage:       0, // Start at first page
 		TripTemplates:     data.TripTemplates,
 	}
 	m.SelectedWeek = m.getCurrentWeekIndex()
 	return m, nil
 }
 
-// NewWithClient creates a new UI model with a provided maps client (useful for testing)
-func NewWithClient(storage storage.Storage, ratePerMile float64, mapsClient maps.DistanceCalculator) (*Model, error) {
+// NewWithClient creates a new UI model with a provided maps client (useful for testing).
+// A pageSize of zero or less falls back to the default page size.
+func NewWithClient(storage storage.Storage, ratePerMile float64, mapsClient maps.DistanceCalculator, pageSize int) (*Model, error) {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
 	data, err := storage.LoadData()
 	if err != nil {
 		// Initialize empty data if loading fails
@@ -127,8 +173,8 @@ func NewWithClient(storage storage.Storage, ratePerMile float64, mapsClient maps
 		SelectedExpense:   -1,
 		SelectedRecurring: -1,
 		SelectedTemplate:  -1,
-		PageSize:          10, // Default page size
-		CurrentPage:       0,  // Start at first page
+		PageSize:          pageSize,
+		CurrentPage:       0, // Start at first page
 		TripTemplates:     data.TripTemplates,
 	}
 	m.SelectedWeek = m.getCurrentWeekIndex()
@@ -157,6 +203,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Handle key messages
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Confirmations only last until the next keypress
+		m.StatusMessage = ""
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			if m.HelpVisible {
@@ -164,6 +212,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.HelpVisible = false
 				return m, cmd
 			}
+			m.UndoStack = nil
 			return m, tea.Quit
 		case tea.KeyF1:
 			m.HelpVisible = true
@@ -178,12 +227,26 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.HelpLevel = 3
 			return m, cmd
 		case tea.KeyCtrlE:
-			if m.ActiveTab == TabTrips && m.SelectedTrip >= 0 {
+			if tripIndex := m.selectedTripIndex(); m.ActiveTab == TabTrips && tripIndex >= 0 {
 				m.Mode = "edit"
 				m.EditIndex = 0
-				m.CurrentTrip = m.Trips[m.SelectedTrip]
-				m.TextInput.SetValue(m.CurrentTrip.Date)
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.CurrentTrip = m.Trips[tripIndex]
+				m.TextInput.SetValue(m.formatDate(m.CurrentTrip.Date))
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+			} else if m.ActiveTab == TabTrips && m.SelectedRecurring >= 0 && m.SelectedRecurring < len(m.RecurringTrips) {
+				m.Mode = "recurring_edit_date"
+				m.EditIndex = m.SelectedRecurring
+				m.CurrentRecurring = m.RecurringTrips[m.SelectedRecurring]
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.formatDate(m.CurrentRecurring.StartDate))
+				m.TextInput.Placeholder = fmt.Sprintf("Enter start date (%s)...", m.datePattern())
+			} else if expenseIndex := m.selectedExpenseIndex(); m.ActiveTab == TabExpenses && expenseIndex >= 0 {
+				m.Mode = "expense_edit_date"
+				m.EditIndex = expenseIndex
+				m.CurrentExpense = m.Data.Expenses[expenseIndex]
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.formatDate(m.CurrentExpense.Date))
+				m.TextInput.Placeholder = fmt.Sprintf("Enter expense date (%s)...", m.datePattern())
 			} else if m.ActiveTab == TabTemplates && m.SelectedTemplate >= 0 {
 				m.Mode = "template_edit"
 				m.EditIndex = m.SelectedTemplate
@@ -192,16 +255,78 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.TextInput.Placeholder = "Enter template name..."
 			}
 		case tea.KeyCtrlD:
-			if m.ActiveTab == TabTrips && m.SelectedTrip >= 0 {
+			if tripIndex := m.selectedTripIndex(); m.ActiveTab == TabTrips && tripIndex >= 0 && !m.Trips[tripIndex].Archived {
+				// Trips are archived rather than deleted, so a slip of the key loses nothing
+				m.setSelectedTripArchived(true)
+			} else if m.ActiveTab == TabTrips && tripIndex >= 0 {
 				m.Mode = "delete_confirm"
 				m.TextInput.Reset()
+				m.TextInput.Placeholder = "Type 'yes' and press Enter to permanently delete this archived trip, or anything else to cancel."
+			} else if m.ActiveTab == TabTrips && m.SelectedRecurring >= 0 && m.SelectedRecurring < len(m.RecurringTrips) {
+				count, err := m.Data.CountGeneratedTrips(m.SelectedRecurring)
+				if err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.Mode = "recurring_delete_confirm"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Type 'yes' and press Enter to delete this recurring trip and the %d trip(s) generated from it, or anything else to cancel.", count)
+			} else if m.ActiveTab == TabExpenses && m.selectedExpenseIndex() >= 0 {
+				m.Mode = "expense_delete_confirm"
+				m.TextInput.Reset()
 				m.TextInput.Placeholder = "Type 'yes' and press Enter to confirm deletion, or anything else to cancel."
 			} else if m.ActiveTab == TabTemplates && m.SelectedTemplate >= 0 {
 				m.Mode = "template_delete_confirm"
 				m.TextInput.Reset()
 				m.TextInput.Placeholder = "Type 'yes' and press Enter to confirm deletion, or anything else to cancel."
 			}
+		case tea.KeyCtrlN:
+			// Prefill today's date so it can be confirmed with Enter
+			if m.Mode == "date" || m.Mode == "expense_date" {
+				m.TextInput.SetValue(m.formatDate(m.today()))
+			}
+			return m, cmd
+		case tea.KeyCtrlY:
+			if tripIndex := m.selectedTripIndex(); m.ActiveTab == TabTrips && tripIndex >= 0 {
+				// Duplicate the selected trip, reusing its miles instead of recalculating them
+				trip := m.Trips[tripIndex]
+				m.CurrentTrip = model.Trip{
+					Origin:            trip.Origin,
+					Destination:       trip.Destination,
+					Type:              trip.Type,
+					Notes:             trip.Notes,
+					Miles:             trip.Miles,
+					Family:            trip.Family,
+					ReturnDestination: trip.ReturnDestination,
+					ReturnMiles:       trip.ReturnMiles,
+				}
+				m.EditIndex = -1
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.formatDate(trip.Date))
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				m.SelectedTrip = -1
+			}
+			return m, cmd
+		case tea.KeyCtrlG:
+			m.cycleFamily()
+			return m, cmd
+		case tea.KeyCtrlZ:
+			m.undo()
+			return m, cmd
+		case tea.KeyCtrlB:
+			if m.ActiveTab == TabTrips {
+				m.Mode = "bulk_delete_from"
+				m.BulkDeleteFrom = ""
+				m.BulkDeleteTo = ""
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter first date (%s) of trips to delete...", m.datePattern())
+			}
 		case tea.KeyEnter:
+			if err := m.canonicalizeDateInput(); err != nil {
+				m.Err = err
+				return m, cmd
+			}
 			if m.Mode == "date" {
 				if m.TextInput.Value() == "" {
 					return m, cmd
@@ -223,16 +348,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				// Otherwise, continue normal flow
-				m.TextInput.Reset()
-				m.Mode = "origin"
-				m.TextInput.Placeholder = "Enter origin location..."
+				m.startOriginInput()
 				return m, cmd
 			}
 			if m.Mode == "origin" {
-				if m.TextInput.Value() == "" {
+				if model.NormalizeAddress(m.TextInput.Value()) == "" {
 					return m, cmd
 				}
-				m.CurrentTrip.Origin = m.TextInput.Value()
+				// A quick-pick fills in the whole route, leaving the destination to confirm
+				if route, ok := m.pickedRoute(); ok {
+					if route.Destination != m.CurrentTrip.Destination {
+						m.CurrentTrip.Miles = 0
+						m.CurrentTrip.ReturnMiles = 0
+					}
+					m.CurrentTrip.Destination = route.Destination
+					m.TextInput.SetValue(route.Origin)
+				}
+				// A changed route needs its miles recalculated
+				origin := model.NormalizeAddress(m.TextInput.Value())
+				if origin != m.CurrentTrip.Origin {
+					m.CurrentTrip.Miles = 0
+				}
+				m.CurrentTrip.Origin = origin
 				m.TextInput.Reset()
 				// If destination is already set (from template), pre-fill it
 				if m.CurrentTrip.Destination != "" {
@@ -245,10 +382,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, cmd
 			}
 			if m.Mode == "destination" {
-				if m.TextInput.Value() == "" {
+				if model.NormalizeAddress(m.TextInput.Value()) == "" {
 					return m, cmd
 				}
-				m.CurrentTrip.Destination = m.TextInput.Value()
+				if route, ok := m.pickedRoute(); ok {
+					m.TextInput.SetValue(route.Destination)
+				}
+				destination := model.NormalizeAddress(m.TextInput.Value())
+				if destination != m.CurrentTrip.Destination {
+					m.CurrentTrip.Miles = 0
+					m.CurrentTrip.ReturnMiles = 0
+				}
+				m.CurrentTrip.Destination = destination
 				m.TextInput.Reset()
 				// If type is already set (from template), pre-fill it
 				if m.CurrentTrip.Type != "" {
@@ -257,7 +402,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.TextInput.SetValue("")
 				}
 				m.Mode = "type"
-				m.TextInput.Placeholder = "Enter trip type (single/round)..."
+				m.TextInput.Placeholder = "Enter trip type (single/round/custom)..."
 				return m, cmd
 			} else if m.Mode == "edit" {
 				if m.TextInput.Value() == "" {
@@ -271,8 +416,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.EditIndex = 1
 				return m, cmd
 			} else if m.Mode == "edit_origin" {
-				if m.TextInput.Value() != "" {
-					m.CurrentTrip.Origin = m.TextInput.Value()
+				if origin := model.NormalizeAddress(m.TextInput.Value()); origin != "" {
+					m.CurrentTrip.Origin = origin
 				}
 				m.TextInput.Reset()
 				m.Mode = "edit_destination"
@@ -281,33 +426,53 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.EditIndex = 2
 				return m, cmd
 			} else if m.Mode == "edit_destination" {
-				if m.TextInput.Value() != "" {
-					m.CurrentTrip.Destination = m.TextInput.Value()
+				if destination := model.NormalizeAddress(m.TextInput.Value()); destination != "" {
+					m.CurrentTrip.Destination = destination
 				}
 				m.TextInput.Reset()
 				m.Mode = "edit_type"
-				m.TextInput.Placeholder = "Enter trip type (single/round)..."
+				m.TextInput.Placeholder = "Enter trip type (single/round/custom)..."
 				m.TextInput.SetValue(m.CurrentTrip.Type)
 				m.EditIndex = 3
 				return m, cmd
 			} else if m.Mode == "edit_type" {
 				if m.TextInput.Value() != "" {
 					tripType := strings.ToLower(m.TextInput.Value())
-					if tripType != "single" && tripType != "round" {
-						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single' or 'round'", tripType)
+					if !model.IsValidTripType(tripType) {
+						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single', 'round' or 'custom'", tripType)
 						return m, cmd
 					}
 					m.CurrentTrip.Type = tripType
 				}
+				if m.CurrentTrip.Type != "round" {
+					m.CurrentTrip.ReturnDestination = ""
+					m.CurrentTrip.ReturnMiles = 0
+				}
+				// A corrected route needs its distance looked up again; custom trips keep
+				// their entered miles
+				tripIndex := m.selectedTripIndex()
+				if tripIndex >= 0 && !m.CurrentTrip.IsCustom() {
+					original := m.Trips[tripIndex]
+					if m.CurrentTrip.Origin != original.Origin || m.CurrentTrip.Destination != original.Destination {
+						m.CurrentTrip.Miles = 0
+						if m.CurrentTrip.Destination != original.Destination {
+							m.CurrentTrip.ReturnMiles = 0
+						}
+						m.EditIndex = tripIndex
+						m.completeTrip()
+						return m, cmd
+					}
+				}
 				// Save edited trip
-				if err := m.CurrentTrip.Validate(); err != nil {
+				if err := m.validateTrip(m.CurrentTrip); err != nil {
 					m.Err = fmt.Errorf("invalid trip: %w", err)
 					return m, cmd
 				}
-				if m.SelectedTrip >= 0 && m.SelectedTrip < len(m.Trips) {
-					m.Trips[m.SelectedTrip] = m.CurrentTrip
+				if tripIndex >= 0 {
+					m.pushUndo()
+					m.Trips[tripIndex] = m.CurrentTrip
 					m.Data.Trips = m.Trips
-					model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+					m.updateWeeklySummaries()
 					if err := m.Storage.SaveData(m.Data); err != nil {
 						m.Err = fmt.Errorf("failed to save trip: %w", err)
 						return m, cmd
@@ -317,7 +482,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.CurrentTrip = model.Trip{}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 				return m, cmd
 			} else if m.Mode == "template_edit" {
 				// Name input
@@ -371,6 +536,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, cmd
 				}
 				if m.EditIndex >= 0 {
+					m.pushUndo()
 					if err := m.Data.EditTripTemplate(m.EditIndex, m.CurrentTemplate); err != nil {
 						m.Err = err
 						return m, cmd
@@ -385,7 +551,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.CurrentTemplate = model.TripTemplate{}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 				return m, cmd
 			} else if m.Mode == "template_name" {
 				if m.TextInput.Value() == "" {
@@ -449,6 +615,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 				if m.EditIndex >= 0 {
 					// Update existing template
+					m.pushUndo()
 					if err := m.Data.EditTripTemplate(m.EditIndex, m.CurrentTemplate); err != nil {
 						m.Err = err
 						return m, cmd
@@ -474,7 +641,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.CurrentTemplate = model.TripTemplate{}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 			} else if m.Mode == "convert_to_recurring" {
 				weekday, err := strconv.Atoi(m.TextInput.Value())
 				if err != nil || weekday < 0 || weekday > 6 {
@@ -504,7 +671,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 
 				// Delete the original trip first
-				if err := m.Data.DeleteTrip(m.SelectedTrip); err != nil {
+				if err := m.Data.DeleteTrip(m.selectedTripIndex()); err != nil {
 					m.Err = err
 					return m, cmd
 				}
@@ -525,7 +692,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Trips = m.Data.Trips
 
 				// Update weekly summaries
-				model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+				m.updateWeeklySummaries()
 				if err := m.Storage.SaveData(m.Data); err != nil {
 					m.Err = err
 					return m, cmd
@@ -535,9 +702,110 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.CurrentRecurring = model.RecurringTrip{}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 			} else if m.Mode == "search" {
 				m.SearchQuery = m.TextInput.Value()
+			} else if m.Mode == "recurring_edit_date" {
+				if m.TextInput.Value() != "" {
+					// Create a temporary recurring trip to validate the date
+					tempTrip := model.RecurringTrip{
+						StartDate:   m.TextInput.Value(),
+						Origin:      "temp",   // Dummy value for validation
+						Destination: "temp",   // Dummy value for validation
+						Miles:       1.0,      // Dummy value for validation
+						Type:        "single", // Dummy value for validation
+						Weekday:     0,        // Dummy value for validation
+						Occurrences: 1,        // Dummy value for validation
+					}
+					if err := tempTrip.Validate(); err != nil {
+						m.Err = err
+						return m, cmd
+					}
+					m.CurrentRecurring.StartDate = m.TextInput.Value()
+				}
+				m.TextInput.Reset()
+				if m.CurrentRecurring.Frequency == "monthly" {
+					m.TextInput.SetValue(strconv.Itoa(m.CurrentRecurring.DayOfMonth))
+					m.TextInput.Placeholder = "Enter day of month (1-31)..."
+				} else {
+					m.TextInput.SetValue(strconv.Itoa(m.CurrentRecurring.Weekday))
+					m.TextInput.Placeholder = "Enter weekday (0-6, where 0 is Sunday)..."
+				}
+				m.Mode = "recurring_edit_weekday"
+			} else if m.Mode == "recurring_edit_weekday" {
+				if m.TextInput.Value() != "" {
+					value, err := strconv.Atoi(m.TextInput.Value())
+					if m.CurrentRecurring.Frequency == "monthly" {
+						if err != nil || value < 1 || value > 31 {
+							m.Err = fmt.Errorf("invalid day of month: must be between 1 and 31")
+							return m, cmd
+						}
+						m.CurrentRecurring.DayOfMonth = value
+					} else {
+						if err != nil || value < 0 || value > 6 {
+							m.Err = fmt.Errorf("invalid weekday: must be between 0 and 6")
+							return m, cmd
+						}
+						m.CurrentRecurring.Weekday = value
+					}
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.CurrentRecurring.Origin)
+				m.TextInput.Placeholder = "Enter origin location..."
+				m.Mode = "recurring_edit_origin"
+			} else if m.Mode == "recurring_edit_origin" {
+				// A changed route needs its miles recalculated
+				if value := m.TextInput.Value(); value != "" && value != m.CurrentRecurring.Origin {
+					m.CurrentRecurring.Origin = value
+					m.CurrentRecurring.Miles = 0
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.CurrentRecurring.Destination)
+				m.TextInput.Placeholder = "Enter destination location..."
+				m.Mode = "recurring_edit_destination"
+			} else if m.Mode == "recurring_edit_destination" {
+				if value := m.TextInput.Value(); value != "" && value != m.CurrentRecurring.Destination {
+					m.CurrentRecurring.Destination = value
+					m.CurrentRecurring.Miles = 0
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.CurrentRecurring.Type)
+				m.TextInput.Placeholder = "Enter trip type (single/round)..."
+				m.Mode = "recurring_edit_type"
+			} else if m.Mode == "recurring_edit_type" {
+				if m.TextInput.Value() != "" {
+					tripType := strings.ToLower(m.TextInput.Value())
+					if tripType != "single" && tripType != "round" {
+						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single' or 'round'", tripType)
+						return m, cmd
+					}
+					m.CurrentRecurring.Type = tripType
+				}
+				m.TextInput.Reset()
+				if m.CurrentRecurring.EndDate == "" && m.CurrentRecurring.Occurrences > 0 {
+					m.TextInput.SetValue(strconv.Itoa(m.CurrentRecurring.Occurrences))
+				} else {
+					m.TextInput.SetValue(m.formatDate(m.CurrentRecurring.EndDate))
+				}
+				m.TextInput.Placeholder = fmt.Sprintf("Enter end date (%s) or number of trips...", m.datePattern())
+				m.Mode = "recurring_edit_end_date"
+			} else if m.Mode == "recurring_edit_end_date" {
+				if err := setRecurringLimit(&m.CurrentRecurring, m.TextInput.Value()); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				if err := m.saveRecurringEdit(); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+
+				// Reset state
+				m.EditIndex = -1
+				m.SelectedRecurring = -1
+				m.CurrentRecurring = model.RecurringTrip{}
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 			} else if m.Mode == "recurring_date" {
 				// Create a temporary recurring trip to validate the date
 				tempTrip := model.RecurringTrip{
@@ -547,6 +815,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Miles:       1.0,      // Dummy value for validation
 					Type:        "single", // Dummy value for validation
 					Weekday:     0,        // Dummy value for validation
+					Occurrences: 1,        // Dummy value for validation
 				}
 				if err := tempTrip.Validate(); err != nil {
 					m.Err = err
@@ -554,8 +823,34 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.CurrentRecurring.StartDate = m.TextInput.Value()
 				m.TextInput.Reset()
-				m.Mode = "recurring_weekday"
-				m.TextInput.Placeholder = "Enter weekday (0-6, where 0 is Sunday)..."
+				m.Mode = "recurring_frequency"
+				m.TextInput.Placeholder = "Enter frequency (weekly/biweekly/monthly)..."
+			} else if m.Mode == "recurring_frequency" {
+				frequency := strings.ToLower(strings.TrimSpace(m.TextInput.Value()))
+				if frequency == "" {
+					frequency = "weekly"
+				}
+				if frequency != "weekly" && frequency != "biweekly" && frequency != "monthly" {
+					m.Err = fmt.Errorf("invalid frequency: %s. Must be 'weekly', 'biweekly', or 'monthly'", frequency)
+					return m, cmd
+				}
+				m.CurrentRecurring.Frequency = frequency
+				m.TextInput.Reset()
+				if frequency == "monthly" {
+					m.Mode = "recurring_day_of_month"
+					m.TextInput.Placeholder = "Enter day of month (1-31)..."
+				} else {
+					m.Mode = "recurring_weekday"
+					m.TextInput.Placeholder = "Enter weekday (0-6, where 0 is Sunday)..."
+				}
+			} else if m.Mode == "recurring_day_of_month" {
+				day, err := strconv.Atoi(m.TextInput.Value())
+				if err != nil || day < 1 || day > 31 {
+					m.Err = fmt.Errorf("invalid day of month: must be between 1 and 31")
+					return m, cmd
+				}
+				m.CurrentRecurring.DayOfMonth = day
+				m.startExcludedDatesInput()
 			} else if m.Mode == "recurring_weekday" {
 				weekday, err := strconv.Atoi(m.TextInput.Value())
 				if err != nil || weekday < 0 || weekday > 6 {
@@ -563,42 +858,51 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, cmd
 				}
 				m.CurrentRecurring.Weekday = weekday
-				m.TextInput.Reset()
-				m.Mode = "origin"
-				m.TextInput.Placeholder = "Enter origin location..."
-			} else if m.Mode == "recurring_end_date" {
-				if m.TextInput.Value() != "" {
-					// Create a temporary recurring trip to validate the end date
-					tempTrip := model.RecurringTrip{
-						StartDate:   m.CurrentRecurring.StartDate,
-						EndDate:     m.TextInput.Value(),
-						Origin:      "temp",   // Dummy value for validation
-						Destination: "temp",   // Dummy value for validation
-						Miles:       1.0,      // Dummy value for validation
-						Type:        "single", // Dummy value for validation
-						Weekday:     0,        // Dummy value for validation
-					}
-					if err := tempTrip.Validate(); err != nil {
-						m.Err = err
-						return m, cmd
+				m.startExcludedDatesInput()
+			} else if m.Mode == "recurring_excluded_dates" {
+				var excluded []string
+				for _, date := range strings.Split(m.TextInput.Value(), ",") {
+					if date = strings.TrimSpace(date); date != "" {
+						excluded = append(excluded, date)
 					}
-					m.CurrentRecurring.EndDate = m.TextInput.Value()
 				}
+				// Create a temporary recurring trip to validate the excluded dates
+				tempTrip := model.RecurringTrip{
+					StartDate:     m.CurrentRecurring.StartDate,
+					ExcludedDates: excluded,
+					Origin:        "temp",   // Dummy value for validation
+					Destination:   "temp",   // Dummy value for validation
+					Miles:         1.0,      // Dummy value for validation
+					Type:          "single", // Dummy value for validation
+					Weekday:       0,        // Dummy value for validation
+					Occurrences:   1,        // Dummy value for validation
+				}
+				if err := tempTrip.Validate(); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.CurrentRecurring.ExcludedDates = excluded
 				m.TextInput.Reset()
-				m.Mode = "origin"
-				m.TextInput.Placeholder = "Enter origin location..."
+				m.Mode = "recurring_end_date"
+				m.TextInput.Placeholder = fmt.Sprintf("Enter end date (%s) or number of trips...", m.datePattern())
+			} else if m.Mode == "recurring_end_date" {
+				if err := setRecurringLimit(&m.CurrentRecurring, m.TextInput.Value()); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.startOriginInput()
 			} else if m.Mode == "type" {
 				if m.TextInput.Value() == "" && m.EditIndex >= 0 {
 					// Keep existing value if no new input
 					tripType := m.CurrentTrip.Type
-					if tripType != "single" && tripType != "round" {
-						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single' or 'round'", tripType)
+					if !model.IsValidTripType(tripType) {
+						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single', 'round' or 'custom'", tripType)
 						return m, cmd
 					}
 				} else {
 					tripType := strings.ToLower(m.TextInput.Value())
-					if tripType != "single" && tripType != "round" {
-						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single' or 'round'", tripType)
+					if !model.IsValidTripType(tripType) {
+						m.Err = fmt.Errorf("invalid trip type: %s. Must be 'single', 'round' or 'custom'", tripType)
 						return m, cmd
 					}
 					if strings.HasPrefix(m.Mode, "recurring_") {
@@ -625,6 +929,9 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					} else {
 						// Add new recurring trip
 						newTrip := m.CurrentRecurring // Create a copy to avoid reference issues
+						if newTrip.Family == "" {
+							newTrip.Family = m.familyForNewRecords()
+						}
 						m.Data.RecurringTrips = append(m.Data.RecurringTrips, newTrip)
 						m.RecurringTrips = m.Data.RecurringTrips
 					}
@@ -639,7 +946,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.Trips = m.Data.Trips
 
 					// Update weekly summaries
-					model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+					m.updateWeeklySummaries()
 					if err := m.Storage.SaveData(m.Data); err != nil {
 						m.Err = err
 						return m, cmd
@@ -650,59 +957,85 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.CurrentRecurring = model.RecurringTrip{}
 					m.Mode = "date"
 					m.TextInput.Reset()
-					m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+					m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 				} else {
-					// Calculate miles if not already set
-					if m.CurrentTrip.Miles == 0 {
-						distance, err := m.MapsClient.CalculateDistance(context.Background(), m.CurrentTrip.Origin, m.CurrentTrip.Destination)
-						if err != nil {
-							m.Err = fmt.Errorf("failed to calculate distance: %w", err)
-							return m, cmd
+					if m.MaxTripMiles > 0 && !m.CurrentTrip.IsCustom() {
+						// Check the distance now so a bad address is caught before notes and tags
+						if m.CurrentTrip.Miles == 0 {
+							distance, err := m.MapsClient.CalculateDistance(context.Background(), m.CurrentTrip.Origin, m.CurrentTrip.Destination)
+							// Manually entered miles are checked once they are entered
+							if err != nil && !errors.Is(err, maps.ErrManualMiles) {
+								m.Err = fmt.Errorf("failed to calculate distance: %w", err)
+								return m, cmd
+							}
+							m.CurrentTrip.Miles = distance
 						}
-						m.CurrentTrip.Miles = distance
-					}
-
-					// Validate the trip before saving
-					if err := m.CurrentTrip.Validate(); err != nil {
-						m.Err = fmt.Errorf("invalid trip: %w", err)
-						return m, cmd
-					}
-
-					if m.EditIndex >= 0 {
-						// Update existing trip
-						if err := m.Data.EditTrip(m.EditIndex, m.CurrentTrip); err != nil {
-							m.Err = err
+						if err := m.CurrentTrip.ValidateMaxMiles(m.MaxTripMiles); err != nil {
+							m.CurrentTrip.Miles = 0
+							m.Err = fmt.Errorf("invalid trip: %w", err)
 							return m, cmd
 						}
-						m.Trips[m.EditIndex] = m.CurrentTrip
-					} else {
-						// Add new trip
-						newTrip := m.CurrentTrip // Create a copy to avoid reference issues
-						m.Data.Trips = append(m.Data.Trips, newTrip)
-						m.Trips = m.Data.Trips
-					}
-
-					model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
-					if err := m.Storage.SaveData(m.Data); err != nil {
-						m.Err = err
-						return m, cmd
 					}
-
-					// Reset state
-					m.EditIndex = -1
-					m.CurrentTrip = model.Trip{}
-					m.Mode = "date"
 					m.TextInput.Reset()
-					m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+					if m.CurrentTrip.Type == "round" {
+						m.TextInput.SetValue(m.CurrentTrip.ReturnDestination)
+						m.Mode = "return_destination"
+						m.TextInput.Placeholder = "Enter return destination (optional, press Enter to return the same way)..."
+					} else {
+						m.CurrentTrip.ReturnDestination = ""
+						m.CurrentTrip.ReturnMiles = 0
+						m.TextInput.SetValue(m.CurrentTrip.Notes)
+						m.Mode = "notes"
+						m.TextInput.Placeholder = "Enter notes (optional, press Enter to skip)..."
+					}
+				}
+				return m, cmd
+			} else if m.Mode == "return_destination" {
+				// A different return leg needs its miles recalculated
+				if value := model.NormalizeAddress(m.TextInput.Value()); value != m.CurrentTrip.ReturnDestination {
+					m.CurrentTrip.ReturnDestination = value
+					m.CurrentTrip.ReturnMiles = 0
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.CurrentTrip.Notes)
+				m.Mode = "notes"
+				m.TextInput.Placeholder = "Enter notes (optional, press Enter to skip)..."
+				return m, cmd
+			} else if m.Mode == "notes" {
+				m.CurrentTrip.Notes = strings.TrimSpace(m.TextInput.Value())
+				m.TextInput.Reset()
+				m.TextInput.SetValue(strings.Join(m.CurrentTrip.Tags, ", "))
+				m.Mode = "tags"
+				m.TextInput.Placeholder = "Enter tags, comma separated (optional, press Enter to skip)..."
+				return m, cmd
+			} else if m.Mode == "tags" {
+				m.CurrentTrip.Tags = model.ParseTags(m.TextInput.Value())
+				m.completeTrip()
+				return m, cmd
+			} else if m.Mode == "miles" || m.Mode == "return_miles" {
+				miles, err := strconv.ParseFloat(strings.TrimSpace(m.TextInput.Value()), 64)
+				if err != nil || miles <= 0 {
+					m.Err = fmt.Errorf("miles must be a number greater than 0")
+					return m, cmd
+				}
+				if m.Mode == "miles" {
+					m.CurrentTrip.Miles = miles
+				} else {
+					m.CurrentTrip.ReturnMiles = miles
 				}
+				m.completeTrip()
 				return m, cmd
 			} else if m.Mode == "delete_confirm" {
 				if m.TextInput.Value() == "yes" {
-					if m.SelectedTrip >= 0 && m.SelectedTrip < len(m.Trips) {
-						// Remove the trip
-						m.Trips = append(m.Trips[:m.SelectedTrip], m.Trips[m.SelectedTrip+1:]...)
-						m.Data.Trips = m.Trips
-						model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+					if tripIndex := m.selectedTripIndex(); tripIndex >= 0 {
+						// Remove the trip, unlinking its expenses
+						m.pushUndo()
+						if err := m.Data.DeleteTrip(tripIndex); err != nil {
+							m.Err = err
+							return m, cmd
+						}
+						m.Trips = m.Data.Trips
+						m.updateWeeklySummaries()
 						if err := m.Storage.SaveData(m.Data); err != nil {
 							m.Err = fmt.Errorf("failed to save after deletion: %w", err)
 							return m, cmd
@@ -712,81 +1045,393 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 				return m, cmd
-			} else if m.Mode == "expense_date" {
-				if m.TextInput.Value() == "" {
-					return m, cmd
-				}
-				// Create a temporary expense to validate the date
-				tempExpense := model.Expense{
-					Date:        m.TextInput.Value(),
-					Amount:      1.0,    // Dummy value for validation
-					Description: "temp", // Dummy value for validation
-				}
-				if err := tempExpense.Validate(); err != nil {
-					m.Err = err
-					return m, cmd
+			} else if m.Mode == "expense_delete_confirm" {
+				if m.TextInput.Value() == "yes" {
+					if expenseIndex := m.selectedExpenseIndex(); expenseIndex >= 0 {
+						// Remove the expense shown at the selected row
+						m.pushUndo()
+						if err := m.Data.DeleteExpense(expenseIndex); err != nil {
+							m.Err = err
+							return m, cmd
+						}
+						m.updateWeeklySummaries()
+						if err := m.Storage.SaveData(m.Data); err != nil {
+							m.Err = fmt.Errorf("failed to save after deletion: %w", err)
+							return m, cmd
+						}
+						m.SelectedExpense = -1
+					}
 				}
-				m.CurrentExpense.Date = m.TextInput.Value()
+				m.Mode = "date"
 				m.TextInput.Reset()
-				m.Mode = "expense_amount"
-				m.TextInput.Placeholder = "Enter expense amount..."
-			} else if m.Mode == "expense_amount" {
-				if m.TextInput.Value() == "" && m.EditIndex >= 0 {
-					// Keep existing value if no new input
-					m.TextInput.Reset()
-					m.Mode = "expense_description"
-					m.TextInput.Placeholder = "Enter expense description..."
-				} else {
-					amount, err := strconv.ParseFloat(m.TextInput.Value(), 64)
-					if err != nil {
-						m.Err = fmt.Errorf("invalid amount: %w", err)
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "recurring_delete_confirm" {
+				if m.TextInput.Value() == "yes" && m.SelectedRecurring >= 0 && m.SelectedRecurring < len(m.RecurringTrips) {
+					// Remove the generated trips first, while the pattern is still there to match them
+					m.pushUndo()
+					if _, err := m.Data.DeleteGeneratedTrips(m.SelectedRecurring); err != nil {
+						m.Err = err
 						return m, cmd
 					}
-					if amount <= 0 {
-						m.Err = fmt.Errorf("amount must be greater than 0")
+					if err := m.Data.DeleteRecurringTrip(m.SelectedRecurring); err != nil {
+						m.Err = err
 						return m, cmd
 					}
-					m.CurrentExpense.Amount = amount
-					m.TextInput.Reset()
-					m.Mode = "expense_description"
-					m.TextInput.Placeholder = "Enter expense description..."
-				}
-			} else if m.Mode == "expense_description" {
-				if m.TextInput.Value() == "" {
-					m.Err = fmt.Errorf("description cannot be empty")
-					return m, cmd
+					m.Trips = m.Data.Trips
+					m.RecurringTrips = m.Data.RecurringTrips
+					m.updateWeeklySummaries()
+					if err := m.Storage.SaveData(m.Data); err != nil {
+						m.Err = fmt.Errorf("failed to save after deletion: %w", err)
+						return m, cmd
+					}
+					m.SelectedRecurring = -1
 				}
-				m.CurrentExpense.Description = m.TextInput.Value()
-
-				// Validate the expense before saving
-				if err := m.CurrentExpense.Validate(); err != nil {
-					m.Err = fmt.Errorf("invalid expense: %w", err)
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "bulk_delete_from" {
+				if err := model.ValidateDate(m.TextInput.Value()); err != nil {
+					m.Err = err
 					return m, cmd
 				}
-
-				// Add new expense
-				if err := m.Data.AddExpense(m.CurrentExpense); err != nil {
+				m.BulkDeleteFrom = m.TextInput.Value()
+				m.TextInput.Reset()
+				m.Mode = "bulk_delete_to"
+				m.TextInput.Placeholder = fmt.Sprintf("Enter last date (%s) of trips to delete...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "bulk_delete_to" {
+				if err := model.ValidateDate(m.TextInput.Value()); err != nil {
 					m.Err = err
 					return m, cmd
 				}
-
-				model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
-				if err := m.Storage.SaveData(m.Data); err != nil {
-					m.Err = err
+				if m.TextInput.Value() < m.BulkDeleteFrom {
+					m.Err = fmt.Errorf("last date must not be before first date")
 					return m, cmd
 				}
-
-				// Reset state
-				m.CurrentExpense = model.Expense{}
+				m.BulkDeleteTo = m.TextInput.Value()
+				count := 0
+				for _, trip := range m.Trips {
+					if trip.Date >= m.BulkDeleteFrom && trip.Date <= m.BulkDeleteTo {
+						count++
+					}
+				}
+				m.TextInput.Reset()
+				m.Mode = "bulk_delete_confirm"
+				m.TextInput.Placeholder = fmt.Sprintf("Type 'yes' to delete %d trip(s) from %s to %s, or anything else to cancel.", count, m.BulkDeleteFrom, m.BulkDeleteTo)
+				return m, cmd
+			} else if m.Mode == "bulk_delete_confirm" {
+				if m.TextInput.Value() == "yes" {
+					m.Data.Trips = m.Trips
+					m.pushUndo()
+					if _, err := m.Data.DeleteTripsInRange(m.BulkDeleteFrom, m.BulkDeleteTo); err != nil {
+						m.Err = err
+						return m, cmd
+					}
+					m.Trips = m.Data.Trips
+					m.updateWeeklySummaries()
+					if err := m.Storage.SaveData(m.Data); err != nil {
+						m.Err = fmt.Errorf("failed to save after deletion: %w", err)
+						return m, cmd
+					}
+					m.SelectedTrip = -1
+				}
+				m.BulkDeleteFrom = ""
+				m.BulkDeleteTo = ""
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "dedupe_confirm" {
+				if m.TextInput.Value() == "yes" {
+					m.Data.Trips = m.Trips
+					m.pushUndo()
+					removed := m.Data.MergeDuplicates()
+					m.Trips = m.Data.Trips
+					m.updateWeeklySummaries()
+					if err := m.Storage.SaveData(m.Data); err != nil {
+						m.Err = fmt.Errorf("failed to save after merging duplicates: %w", err)
+						return m, cmd
+					}
+					m.SelectedTrip = -1
+					m.StatusMessage = fmt.Sprintf("Merged %d duplicate trip(s)", removed)
+				}
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "expense_dedupe_confirm" {
+				if m.TextInput.Value() == "yes" {
+					m.pushUndo()
+					removed := m.Data.MergeDuplicateExpenses()
+					m.updateWeeklySummaries()
+					if err := m.Storage.SaveData(m.Data); err != nil {
+						m.Err = fmt.Errorf("failed to save after merging duplicates: %w", err)
+						return m, cmd
+					}
+					m.SelectedExpense = -1
+					m.StatusMessage = fmt.Sprintf("Merged %d duplicate expense(s)", removed)
+				}
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "recurring_extend" {
+				if err := model.ValidateDate(m.TextInput.Value()); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				endDate := m.TextInput.Value()
+				m.Data.Trips = m.Trips
+				m.pushUndo()
+				created, err := m.Data.ExtendRecurringTrips(endDate)
+				if err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.Trips = m.Data.Trips
+				m.RecurringTrips = m.Data.RecurringTrips
+				m.updateWeeklySummaries()
+				if err := m.Storage.SaveData(m.Data); err != nil {
+					m.Err = fmt.Errorf("failed to save after extending recurring trips: %w", err)
+					return m, cmd
+				}
+				m.StatusMessage = fmt.Sprintf("Extended recurring trips to %s: %d new trip(s)", endDate, created)
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "week_jump" {
+				if err := model.ValidateDate(m.TextInput.Value()); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				date, _ := time.Parse("2006-01-02", m.TextInput.Value())
+				index := model.WeekIndexContaining(m.Data.WeeklySummaries, date)
+				if index < 0 {
+					m.Err = fmt.Errorf("no weekly summary covers %s", m.formatDate(m.TextInput.Value()))
+					return m, cmd
+				}
+				m.SelectedWeek = index
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "adjustment_date" {
+				if err := model.ValidateDate(m.TextInput.Value()); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.CurrentAdjustment.Date = m.TextInput.Value()
+				m.Mode = "adjustment_miles"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = "Enter miles to add, or negative miles to remove (e.g. -12.5)..."
+			} else if m.Mode == "adjustment_miles" {
+				miles, err := strconv.ParseFloat(strings.TrimSpace(m.TextInput.Value()), 64)
+				if err != nil {
+					m.Err = fmt.Errorf("invalid miles: %s", m.TextInput.Value())
+					return m, cmd
+				}
+				if miles == 0 {
+					m.Err = fmt.Errorf("miles cannot be zero")
+					return m, cmd
+				}
+				m.CurrentAdjustment.Miles = miles
+				m.Mode = "adjustment_reason"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = "Enter the reason for the adjustment..."
+			} else if m.Mode == "adjustment_reason" {
+				m.CurrentAdjustment.Reason = strings.TrimSpace(m.TextInput.Value())
+				m.CurrentAdjustment.Family = m.familyForNewRecords()
+				m.Data.Trips = m.Trips
+				m.pushUndo()
+				if err := m.Data.AddAdjustment(m.CurrentAdjustment, m.WeekStartDay); err != nil {
+					// Nothing changed, so there is nothing to undo
+					m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+					m.Err = fmt.Errorf("invalid adjustment: %w", err)
+					return m, cmd
+				}
+				m.updateWeeklySummaries()
+				if err := m.Storage.SaveData(m.Data); err != nil {
+					m.Err = fmt.Errorf("failed to save adjustment: %w", err)
+					return m, cmd
+				}
+				m.StatusMessage = fmt.Sprintf("Adjusted the week of %s by %+.2f miles", m.formatDate(m.CurrentAdjustment.Date), m.CurrentAdjustment.Miles)
+				m.CurrentAdjustment = model.Adjustment{}
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+				return m, cmd
+			} else if m.Mode == "expense_edit_date" {
+				if m.TextInput.Value() != "" {
+					// Create a temporary expense to validate the date
+					tempExpense := model.Expense{
+						Date:        m.TextInput.Value(),
+						Amount:      1.0,    // Dummy value for validation
+						Description: "temp", // Dummy value for validation
+					}
+					if err := tempExpense.Validate(); err != nil {
+						m.Err = err
+						return m, cmd
+					}
+					m.CurrentExpense.Date = m.TextInput.Value()
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(strconv.FormatFloat(m.CurrentExpense.Amount, 'f', 2, 64))
+				m.TextInput.Placeholder = "Enter expense amount..."
+				m.Mode = "expense_edit_amount"
+			} else if m.Mode == "expense_edit_amount" {
+				if m.TextInput.Value() != "" {
+					amount, err := strconv.ParseFloat(m.TextInput.Value(), 64)
+					if err != nil {
+						m.Err = fmt.Errorf("invalid amount: %w", err)
+						return m, cmd
+					}
+					if amount <= 0 {
+						m.Err = fmt.Errorf("amount must be greater than 0")
+						return m, cmd
+					}
+					m.CurrentExpense.Amount = amount
+				}
+				m.TextInput.Reset()
+				m.TextInput.SetValue(m.CurrentExpense.Description)
+				m.TextInput.Placeholder = "Enter expense description..."
+				m.Mode = "expense_edit_description"
+			} else if m.Mode == "expense_edit_description" {
+				if m.TextInput.Value() != "" {
+					m.CurrentExpense.Description = m.TextInput.Value()
+				}
+
+				// Validate the expense before saving
+				if err := m.CurrentExpense.Validate(); err != nil {
+					m.Err = fmt.Errorf("invalid expense: %w", err)
+					return m, cmd
+				}
+				m.pushUndo()
+				if err := m.Data.EditExpense(m.EditIndex, m.CurrentExpense); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.updateWeeklySummaries()
+				if err := m.Storage.SaveData(m.Data); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+
+				// Reset state
+				m.EditIndex = -1
+				m.SelectedExpense = -1
+				m.CurrentExpense = model.Expense{}
+				m.Mode = "date"
+				m.TextInput.Reset()
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+			} else if m.Mode == "expense_date" {
+				if m.TextInput.Value() == "" {
+					return m, cmd
+				}
+				// Create a temporary expense to validate the date
+				tempExpense := model.Expense{
+					Date:        m.TextInput.Value(),
+					Amount:      1.0,    // Dummy value for validation
+					Description: "temp", // Dummy value for validation
+				}
+				if err := tempExpense.Validate(); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+				m.CurrentExpense.Date = m.TextInput.Value()
+				m.TextInput.Reset()
+				m.Mode = "expense_amount"
+				m.TextInput.Placeholder = "Enter expense amount..."
+			} else if m.Mode == "expense_amount" {
+				if m.TextInput.Value() == "" && m.EditIndex >= 0 {
+					// Keep existing value if no new input
+					m.TextInput.Reset()
+					m.Mode = "expense_description"
+					m.TextInput.Placeholder = "Enter expense description..."
+				} else {
+					amount, err := strconv.ParseFloat(m.TextInput.Value(), 64)
+					if err != nil {
+						m.Err = fmt.Errorf("invalid amount: %w", err)
+						return m, cmd
+					}
+					if amount <= 0 {
+						m.Err = fmt.Errorf("amount must be greater than 0")
+						return m, cmd
+					}
+					m.CurrentExpense.Amount = amount
+					m.TextInput.Reset()
+					m.Mode = "expense_description"
+					m.TextInput.Placeholder = "Enter expense description..."
+				}
+			} else if m.Mode == "expense_description" {
+				if m.TextInput.Value() == "" {
+					m.Err = fmt.Errorf("description cannot be empty")
+					return m, cmd
+				}
+				m.CurrentExpense.Description = m.TextInput.Value()
+				m.TextInput.Reset()
+				m.Mode = "expense_category"
+				m.TextInput.Placeholder = "Enter category (food/activities/supplies/other, Enter for other)..."
+			} else if m.Mode == "expense_category" {
+				category := strings.ToLower(strings.TrimSpace(m.TextInput.Value()))
+				if category == "" {
+					category = model.DefaultExpenseCategory
+				}
+				if !model.IsValidExpenseCategory(category) {
+					m.Err = fmt.Errorf("invalid category: %s. Must be one of %s", category, strings.Join(model.ExpenseCategories, ", "))
+					return m, cmd
+				}
+				m.CurrentExpense.Category = category
+				m.TextInput.Reset()
+				m.Mode = "expense_reimbursable"
+				m.TextInput.Placeholder = "Reimbursable by the family? (Y/n)..."
+			} else if m.Mode == "expense_reimbursable" {
+				switch strings.ToLower(strings.TrimSpace(m.TextInput.Value())) {
+				case "", "y", "yes":
+					m.CurrentExpense.Reimbursable = nil
+				case "n", "no":
+					personal := false
+					m.CurrentExpense.Reimbursable = &personal
+				default:
+					m.Err = fmt.Errorf("answer y or n")
+					return m, cmd
+				}
+				if m.CurrentExpense.Family == "" {
+					m.CurrentExpense.Family = m.familyForNewRecords()
+				}
+
+				// Validate the expense before saving
+				if err := m.CurrentExpense.Validate(); err != nil {
+					m.Err = fmt.Errorf("invalid expense: %w", err)
+					return m, cmd
+				}
+
+				// Add new expense
+				if err := m.Data.AddExpense(m.CurrentExpense); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+
+				m.updateWeeklySummaries()
+				if err := m.Storage.SaveData(m.Data); err != nil {
+					m.Err = err
+					return m, cmd
+				}
+
+				// Reset state
+				m.CurrentExpense = model.Expense{}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 			} else if m.Mode == "template_delete_confirm" {
 				if m.SelectedTemplate >= 0 && m.SelectedTemplate < len(m.TripTemplates) {
 					if m.TextInput.Value() == "yes" {
 						// Remove the template
+						m.pushUndo()
 						m.TripTemplates = append(m.TripTemplates[:m.SelectedTemplate], m.TripTemplates[m.SelectedTemplate+1:]...)
 						m.Data.TripTemplates = m.TripTemplates
 						if err := m.Storage.SaveData(m.Data); err != nil {
@@ -798,13 +1443,31 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.Mode = "date"
 				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
+				m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
 			}
 		case tea.KeyCtrlX:
 			// Enter expense mode
+			m.CurrentExpense = model.Expense{}
 			m.Mode = "expense_date"
 			m.TextInput.Reset()
-			m.TextInput.Placeholder = "Enter expense date (YYYY-MM-DD)..."
+			m.TextInput.Placeholder = fmt.Sprintf("Enter expense date (%s)...", m.datePattern())
+			if tripIndex := m.selectedTripIndex(); m.ActiveTab == TabTrips && tripIndex >= 0 {
+				// Attach the expense to the selected trip, starting from the trip's date
+				trip := m.Trips[tripIndex]
+				m.CurrentExpense.LinkToTrip(m.Trips, tripIndex)
+				m.TextInput.SetValue(m.formatDate(trip.Date))
+				m.TextInput.Placeholder = fmt.Sprintf("Enter expense date (%s) for the trip to %s...", m.datePattern(), trip.Destination)
+			}
+			return m, cmd
+		case tea.KeyCtrlA:
+			// Enter a mileage adjustment, starting from the week selected on the summaries tab
+			m.CurrentAdjustment = model.Adjustment{}
+			m.Mode = "adjustment_date"
+			m.TextInput.Reset()
+			m.TextInput.Placeholder = fmt.Sprintf("Enter a date (%s) in the week to adjust...", m.datePattern())
+			if m.ActiveTab == TabWeeklySummaries && m.SelectedWeek >= 0 && m.SelectedWeek < len(m.Data.WeeklySummaries) {
+				m.TextInput.SetValue(m.formatDate(m.Data.WeeklySummaries[m.SelectedWeek].WeekStart))
+			}
 			return m, cmd
 		case tea.KeyCtrlT:
 			// Enter template creation mode
@@ -819,104 +1482,66 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		case tea.KeyUp:
 			if m.ActiveTab == TabTrips {
-				if len(m.Trips) == 0 {
+				count := len(m.displayTrips())
+				if count == 0 {
 					return m, cmd
 				}
-				if m.SelectedTrip <= 0 {
-					m.SelectedTrip = len(m.Trips) - 1
+				if m.SelectedTrip <= 0 || m.SelectedTrip >= count {
+					m.SelectedTrip = count - 1
 				} else {
 					m.SelectedTrip--
 				}
 				m.SelectedExpense = -1
 				m.SelectedTemplate = -1
+				m.SelectedRecurring = -1
 			} else if m.ActiveTab == TabExpenses {
-				if len(m.Data.Expenses) == 0 {
+				count := len(m.displayExpenseIndexes())
+				if count == 0 {
 					return m, cmd
 				}
-				if m.SelectedExpense <= 0 {
-					m.SelectedExpense = len(m.Data.Expenses) - 1
+				if m.SelectedExpense <= 0 || m.SelectedExpense >= count {
+					m.SelectedExpense = count - 1
 				} else {
 					m.SelectedExpense--
 				}
 				m.SelectedTrip = -1
 				m.SelectedTemplate = -1
 			} else if m.ActiveTab == TabTemplates {
-				if len(m.TripTemplates) == 0 {
+				displayTemplates, originalIndexes := m.displayTemplates()
+				if len(displayTemplates) == 0 {
 					return m, cmd
 				}
-				// For templates, we need to navigate through the sorted display order
-				// Create a sorted copy to determine the next/previous template
-				displayTemplates := make([]model.TripTemplate, len(m.TripTemplates))
-				copy(displayTemplates, m.TripTemplates)
-				sort.Slice(displayTemplates, func(i, j int) bool {
-					return strings.ToLower(displayTemplates[i].Name) < strings.ToLower(displayTemplates[j].Name)
-				})
-
-				// Find current selection in sorted order
-				currentSortedIndex := -1
-				for i, displayTemplate := range displayTemplates {
-					if m.SelectedTemplate >= 0 && m.SelectedTemplate < len(m.TripTemplates) {
-						originalTemplate := m.TripTemplates[m.SelectedTemplate]
-						if displayTemplate.Name == originalTemplate.Name &&
-							displayTemplate.Origin == originalTemplate.Origin &&
-							displayTemplate.Destination == originalTemplate.Destination &&
-							displayTemplate.TripType == originalTemplate.TripType {
-							currentSortedIndex = i
-							break
-						}
-					}
-				}
-
-				// Navigate in sorted order
-				if currentSortedIndex <= 0 {
-					// Go to last template
-					if len(displayTemplates) > 0 {
-						lastTemplate := displayTemplates[len(displayTemplates)-1]
-						// Find original index of last template
-						for i, originalTemplate := range m.TripTemplates {
-							if lastTemplate.Name == originalTemplate.Name &&
-								lastTemplate.Origin == originalTemplate.Origin &&
-								lastTemplate.Destination == originalTemplate.Destination &&
-								lastTemplate.TripType == originalTemplate.TripType {
-								m.SelectedTemplate = i
-								break
-							}
-						}
-					}
+				// Navigate in sorted display order, wrapping to the last template
+				position := indexOf(originalIndexes, m.SelectedTemplate)
+				if position <= 0 {
+					position = len(originalIndexes) - 1
 				} else {
-					// Go to previous template
-					prevTemplate := displayTemplates[currentSortedIndex-1]
-					// Find original index of previous template
-					for i, originalTemplate := range m.TripTemplates {
-						if prevTemplate.Name == originalTemplate.Name &&
-							prevTemplate.Origin == originalTemplate.Origin &&
-							prevTemplate.Destination == originalTemplate.Destination &&
-							prevTemplate.TripType == originalTemplate.TripType {
-							m.SelectedTemplate = i
-							break
-						}
-					}
+					position--
 				}
+				m.SelectedTemplate = originalIndexes[position]
 				m.SelectedTrip = -1
 				m.SelectedExpense = -1
 			}
 		case tea.KeyDown:
 			if m.ActiveTab == TabTrips {
-				if len(m.Trips) == 0 {
+				count := len(m.displayTrips())
+				if count == 0 {
 					return m, cmd
 				}
-				if m.SelectedTrip >= len(m.Trips)-1 {
+				if m.SelectedTrip >= count-1 {
 					m.SelectedTrip = 0
 				} else {
 					m.SelectedTrip++
 				}
 				m.SelectedExpense = -1
 				m.SelectedTemplate = -1
+				m.SelectedRecurring = -1
 			} else if m.ActiveTab == TabExpenses {
-				if len(m.Data.Expenses) == 0 {
+				count := len(m.displayExpenseIndexes())
+				if count == 0 {
 					return m, cmd
 				}
-				if m.SelectedExpense >= len(m.Data.Expenses)-1 {
+				if m.SelectedExpense >= count-1 {
 					m.SelectedExpense = 0
 				} else {
 					m.SelectedExpense++
@@ -924,65 +1549,45 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.SelectedTrip = -1
 				m.SelectedTemplate = -1
 			} else if m.ActiveTab == TabTemplates {
-				if len(m.TripTemplates) == 0 {
+				displayTemplates, originalIndexes := m.displayTemplates()
+				if len(displayTemplates) == 0 {
 					return m, cmd
 				}
-				// For templates, we need to navigate through the sorted display order
-				// Create a sorted copy to determine the next/previous template
-				displayTemplates := make([]model.TripTemplate, len(m.TripTemplates))
-				copy(displayTemplates, m.TripTemplates)
-				sort.Slice(displayTemplates, func(i, j int) bool {
-					return strings.ToLower(displayTemplates[i].Name) < strings.ToLower(displayTemplates[j].Name)
-				})
-
-				// Find current selection in sorted order
-				currentSortedIndex := -1
-				for i, displayTemplate := range displayTemplates {
-					if m.SelectedTemplate >= 0 && m.SelectedTemplate < len(m.TripTemplates) {
-						originalTemplate := m.TripTemplates[m.SelectedTemplate]
-						if displayTemplate.Name == originalTemplate.Name &&
-							displayTemplate.Origin == originalTemplate.Origin &&
-							displayTemplate.Destination == originalTemplate.Destination &&
-							displayTemplate.TripType == originalTemplate.TripType {
-							currentSortedIndex = i
-							break
-						}
-					}
-				}
-
-				// Navigate in sorted order
-				if currentSortedIndex >= len(displayTemplates)-1 {
-					// Go to first template
-					if len(displayTemplates) > 0 {
-						firstTemplate := displayTemplates[0]
-						// Find original index of first template
-						for i, originalTemplate := range m.TripTemplates {
-							if firstTemplate.Name == originalTemplate.Name &&
-								firstTemplate.Origin == originalTemplate.Origin &&
-								firstTemplate.Destination == originalTemplate.Destination &&
-								firstTemplate.TripType == originalTemplate.TripType {
-								m.SelectedTemplate = i
-								break
-							}
-						}
-					}
+				// Navigate in sorted display order, wrapping to the first template
+				position := indexOf(originalIndexes, m.SelectedTemplate)
+				if position >= len(originalIndexes)-1 {
+					position = 0
 				} else {
-					// Go to next template
-					nextTemplate := displayTemplates[currentSortedIndex+1]
-					// Find original index of next template
-					for i, originalTemplate := range m.TripTemplates {
-						if nextTemplate.Name == originalTemplate.Name &&
-							nextTemplate.Origin == originalTemplate.Origin &&
-							nextTemplate.Destination == originalTemplate.Destination &&
-							nextTemplate.TripType == originalTemplate.TripType {
-							m.SelectedTemplate = i
-							break
-						}
-					}
+					position++
 				}
+				m.SelectedTemplate = originalIndexes[position]
 				m.SelectedTrip = -1
 				m.SelectedExpense = -1
 			}
+		case tea.KeyHome:
+			m.jumpToItem(false)
+		case tea.KeyEnd:
+			m.jumpToItem(true)
+		case tea.KeyShiftUp:
+			if m.ActiveTab == TabTrips && len(m.RecurringTrips) > 0 {
+				if m.SelectedRecurring <= 0 {
+					m.SelectedRecurring = len(m.RecurringTrips) - 1
+				} else {
+					m.SelectedRecurring--
+				}
+				m.SelectedTrip = -1
+			}
+			return m, cmd
+		case tea.KeyShiftDown:
+			if m.ActiveTab == TabTrips && len(m.RecurringTrips) > 0 {
+				if m.SelectedRecurring >= len(m.RecurringTrips)-1 {
+					m.SelectedRecurring = 0
+				} else {
+					m.SelectedRecurring++
+				}
+				m.SelectedTrip = -1
+			}
+			return m, cmd
 		case tea.KeyLeft:
 			if m.ActiveTab == TabWeeklySummaries && len(m.Data.WeeklySummaries) > 0 {
 				if m.SelectedWeek > 0 {
@@ -1020,14 +1625,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.SelectedWeek++
 				}
 			} else if m.ActiveTab == TabTrips {
-				displayTrips := m.Trips
-				if m.SearchMode {
-					displayTrips = m.filterBySearch()
-				}
-				// Sort trips in descending order (most recent first)
-				sort.Slice(displayTrips, func(i, j int) bool {
-					return displayTrips[i].Date > displayTrips[j].Date
-				})
+				displayTrips := m.displayTrips()
 				if m.CurrentPage < (len(displayTrips)-1)/m.PageSize {
 					m.CurrentPage++
 					// Adjust selected trip to stay within the current page
@@ -1036,7 +1634,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			} else if m.ActiveTab == TabExpenses {
-				if m.CurrentPage < (len(m.Data.Expenses)-1)/m.PageSize {
+				displayExpenses := m.displayExpenses()
+				if m.CurrentPage < (len(displayExpenses)-1)/m.PageSize {
 					m.CurrentPage++
 					// Adjust selected expense to stay within the current page
 					if m.SelectedExpense >= 0 {
@@ -1044,7 +1643,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			} else if m.ActiveTab == TabTemplates {
-				if m.CurrentPage < (len(m.TripTemplates)-1)/m.PageSize {
+				displayTemplates, _ := m.displayTemplates()
+				if m.CurrentPage < (len(displayTemplates)-1)/m.PageSize {
 					m.CurrentPage++
 					// Adjust selected template to stay within the current page
 					if m.SelectedTemplate >= 0 {
@@ -1061,8 +1661,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		case tea.KeyCtrlR:
 			if m.ActiveTab == TabTrips {
-				if m.SelectedTrip >= 0 && m.SelectedTrip < len(m.Trips) {
-					trip := m.Trips[m.SelectedTrip]
+				if tripIndex := m.selectedTripIndex(); tripIndex >= 0 {
+					trip := m.Trips[tripIndex]
 					m.Mode = "convert_to_recurring"
 					m.CurrentRecurring = model.RecurringTrip{
 						Origin:      trip.Origin,
@@ -1070,6 +1670,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						Miles:       trip.Miles,
 						StartDate:   trip.Date,
 						Type:        trip.Type,
+						Family:      trip.Family,
 					}
 					m.TextInput.Reset()
 					m.TextInput.Placeholder = "Enter weekday (0=Sunday, 6=Saturday)..."
@@ -1078,7 +1679,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.Mode = "recurring_date"
 					m.CurrentRecurring = model.RecurringTrip{}
 					m.TextInput.Reset()
-					m.TextInput.Placeholder = "Enter start date (YYYY-MM-DD)..."
+					m.TextInput.Placeholder = fmt.Sprintf("Enter start date (%s)...", m.datePattern())
 					return m, cmd
 				}
 			}
@@ -1094,7 +1695,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case TabTemplates:
 				m.ActiveTab = TabWeeklySummaries
 				// Refresh weekly summaries when switching to Weekly Summaries tab
-				model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+				m.updateWeeklySummaries()
 			}
 			// Reset selections when changing tabs
 			m.CurrentPage = 0
@@ -1112,6 +1713,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.SelectedTemplate = -1
 			return m, cmd
 		case tea.KeyShiftTab:
+			// While entering a trip, step back to fix the previous field instead
+			if m.previousTripField() {
+				return m, cmd
+			}
 			// Cycle backward through tabs: Weekly Summaries -> Templates -> Expenses -> Trips -> Weekly Summaries
 			switch m.ActiveTab {
 			case TabWeeklySummaries:
@@ -1123,7 +1728,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case TabTrips:
 				m.ActiveTab = TabWeeklySummaries
 				// Refresh weekly summaries when switching to Weekly Summaries tab
-				model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+				m.updateWeeklySummaries()
 			}
 			// Reset selections when changing tabs
 			m.CurrentPage = 0
@@ -1138,97 +1743,785 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		case tea.KeyCtrlU:
 			if m.ActiveTab == TabTemplates && m.SelectedTemplate >= 0 {
-				// Create a new trip from the selected template
-				template := m.TripTemplates[m.SelectedTemplate]
-				m.CurrentTrip = model.Trip{
-					Origin:      template.Origin,
-					Destination: template.Destination,
-					Type:        template.TripType,
-					Miles:       0, // Will be calculated when the trip is saved
-				}
-				m.Mode = "date"
-				m.TextInput.Reset()
-				m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
-				// Switch to trips tab
-				m.ActiveTab = TabTrips
-				m.SelectedTrip = -1
-				m.SelectedTemplate = -1
+				m.useSelectedTemplate()
 				return m, cmd
 			}
 		case tea.KeyRunes:
 			// Handle single key presses like "U" for template usage
 			// Only process these shortcuts when NOT actively typing in a text input field
 			activeInputModes := []string{
-				"origin", "destination", "type", "edit_origin", "edit_destination", "edit_type",
+				"origin", "destination", "type", "return_destination", "notes", "tags", "miles", "return_miles", "edit_origin", "edit_destination", "edit_type",
 				"template_name", "template_origin", "template_destination", "template_type", "template_notes",
 				"template_edit", "template_edit_origin", "template_edit_destination", "template_edit_type", "template_edit_notes",
-				"expense_date", "expense_amount", "expense_description", "recurring_date", "convert_to_recurring",
-				"search", "delete_confirm", "template_delete_confirm",
+				"expense_date", "expense_amount", "expense_description", "expense_category", "expense_reimbursable", "expense_edit_date", "expense_edit_amount", "expense_edit_description", "recurring_date", "recurring_frequency", "recurring_day_of_month", "recurring_excluded_dates", "recurring_end_date", "convert_to_recurring",
+				"recurring_edit_date", "recurring_edit_weekday", "recurring_edit_origin", "recurring_edit_destination", "recurring_edit_type", "recurring_edit_end_date",
+				"search", "delete_confirm", "expense_delete_confirm", "recurring_delete_confirm", "template_delete_confirm",
+				"bulk_delete_from", "bulk_delete_to", "bulk_delete_confirm", "recurring_extend", "dedupe_confirm", "expense_dedupe_confirm",
+				"adjustment_date", "adjustment_miles", "adjustment_reason", "week_jump",
+			}
+
+			isActivelyTyping := false
+			for _, inputMode := range activeInputModes {
+				if m.Mode == inputMode {
+					isActivelyTyping = true
+					break
+				}
+			}
+
+			// Allow shortcuts in "date" and "edit" modes when not actively entering text
+			if !isActivelyTyping && len(msg.Runes) == 1 {
+				switch msg.Runes[0] {
+				case 'w', 'W', 'm', 'M':
+					if m.ActiveTab == TabWeeklySummaries && len(m.Data.WeeklySummaries) > 0 {
+						if msg.Runes[0] == 'w' || msg.Runes[0] == 'W' {
+							m.SelectedWeek = m.getCurrentWeekIndex()
+						} else {
+							m.SelectedWeek = m.getCurrentMonthWeekIndex()
+						}
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+				case 'g', 'G':
+					if m.ActiveTab == TabWeeklySummaries && len(m.Data.WeeklySummaries) > 0 {
+						m.Mode = "week_jump"
+						m.TextInput.Reset()
+						m.TextInput.Placeholder = fmt.Sprintf("Enter a date (%s) to jump to its week...", m.datePattern())
+						return m, cmd
+					}
+				case 'e', 'E':
+					if m.ActiveTab == TabWeeklySummaries && m.SelectedWeek >= 0 && m.SelectedWeek < len(m.Data.WeeklySummaries) {
+						if path, err := m.exportSelectedWeek(); err != nil {
+							m.Err = err
+						} else {
+							m.StatusMessage = "Exported week to " + path
+						}
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+				case 'p', 'P':
+					// The key is a shortcut here, not input, so leave it out of the printout
+					m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+					if path, err := m.printCurrentView(); err != nil {
+						m.Err = err
+					} else {
+						m.StatusMessage = "Printed view to " + path
+					}
+					return m, cmd
+				case 'r', 'R':
+					if m.ActiveTab == TabWeeklySummaries {
+						m.HideRecurring = !m.HideRecurring
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+					if tripIndex := m.selectedTripIndex(); m.ActiveTab == TabTrips && tripIndex >= 0 && m.Trips[tripIndex].Archived {
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						m.setSelectedTripArchived(false)
+						return m, cmd
+					}
+				case 'a', 'A':
+					if m.ActiveTab == TabTrips {
+						m.ShowArchived = !m.ShowArchived
+						m.SelectedTrip = -1
+						m.CurrentPage = 0
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+				case 'x', 'X':
+					if m.ActiveTab == TabTrips {
+						m.Mode = "recurring_extend"
+						m.TextInput.Reset()
+						m.TextInput.Placeholder = fmt.Sprintf("Enter new end date (%s) for active recurring trips...", m.datePattern())
+						return m, cmd
+					}
+				case '+', '-':
+					// A leading '-' could still be the start of typed input, so only act on an empty field
+					if m.ActiveTab != TabWeeklySummaries && strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)) == "" {
+						m.changePageSize(msg.Runes[0] == '+')
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue("")
+						return m, cmd
+					}
+				case 'd', 'D':
+					if count := m.duplicateTripCount(); m.ActiveTab == TabTrips && count > 0 {
+						m.Mode = "dedupe_confirm"
+						m.TextInput.Reset()
+						m.TextInput.Placeholder = fmt.Sprintf("Type 'yes' and press Enter to merge %d duplicate trip(s), or anything else to cancel.", count)
+						return m, cmd
+					}
+					if count := m.duplicateExpenseCount(); m.ActiveTab == TabExpenses && count > 0 {
+						m.Mode = "expense_dedupe_confirm"
+						m.TextInput.Reset()
+						m.TextInput.Placeholder = fmt.Sprintf("Type 'yes' and press Enter to merge %d duplicate expense(s), or anything else to cancel.", count)
+						return m, cmd
+					}
+				case 'o', 'O':
+					if m.ActiveTab == TabTrips {
+						m.toggleTripSortOrder()
+						// The key is a shortcut here, not input
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+					if m.ActiveTab == TabTemplates {
+						// Selection holds a stored index, so it survives the reorder
+						m.TemplatesByUsage = !m.TemplatesByUsage
+						m.CurrentPage = 0
+						m.TextInput.SetValue(strings.TrimSuffix(m.TextInput.Value(), string(msg.Runes)))
+						return m, cmd
+					}
+				case 'u', 'U':
+					if m.ActiveTab == TabTemplates && m.SelectedTemplate >= 0 {
+						m.useSelectedTemplate()
+						return m, cmd
+					}
+				}
+			}
+		}
+
+		// Handle search input
+		if m.Mode == "search" {
+			m.SearchQuery = m.TextInput.Value()
+		}
+	}
+
+	// Only return early for edit modes after handling key events
+	if m.Mode == "edit" || m.Mode == "edit_origin" || m.Mode == "edit_destination" || m.Mode == "edit_type" {
+		return m, tea.Batch(cmds...)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// completeTrip saves CurrentTrip once its details are entered, looking up any missing
+// miles first. When distance lookups are disabled, or the trip is custom, it asks for
+// the miles instead.
+func (m *Model) completeTrip() {
+	// Custom trips, such as outings with several stops, have their total miles entered
+	if m.CurrentTrip.IsCustom() && m.CurrentTrip.Miles == 0 {
+		m.promptForMiles("miles", fmt.Sprintf("Enter the total miles for the trip from %s to %s...", m.CurrentTrip.Origin, m.CurrentTrip.Destination))
+		return
+	}
+	// Calculate miles if not already set
+	if m.CurrentTrip.Miles == 0 {
+		distance, err := m.MapsClient.CalculateDistance(context.Background(), m.CurrentTrip.Origin, m.CurrentTrip.Destination)
+		if errors.Is(err, maps.ErrManualMiles) {
+			m.promptForMiles("miles", fmt.Sprintf("Enter miles from %s to %s...", m.CurrentTrip.Origin, m.CurrentTrip.Destination))
+			return
+		}
+		if err != nil {
+			m.Err = fmt.Errorf("failed to calculate distance: %w", err)
+			return
+		}
+		m.CurrentTrip.Miles = distance
+	}
+	if m.CurrentTrip.ReturnDestination != "" && m.CurrentTrip.ReturnMiles == 0 {
+		distance, err := m.MapsClient.CalculateDistance(context.Background(), m.CurrentTrip.Destination, m.CurrentTrip.ReturnDestination)
+		if errors.Is(err, maps.ErrManualMiles) {
+			m.promptForMiles("return_miles", fmt.Sprintf("Enter miles from %s to %s...", m.CurrentTrip.Destination, m.CurrentTrip.ReturnDestination))
+			return
+		}
+		if err != nil {
+			m.Err = fmt.Errorf("failed to calculate return distance: %w", err)
+			return
+		}
+		m.CurrentTrip.ReturnMiles = distance
+	}
+
+	// Validate the trip before saving
+	if err := m.validateTrip(m.CurrentTrip); err != nil {
+		m.Err = fmt.Errorf("invalid trip: %w", err)
+		return
+	}
+
+	if m.EditIndex >= 0 {
+		// Update existing trip
+		m.pushUndo()
+		if err := m.Data.EditTrip(m.EditIndex, m.CurrentTrip); err != nil {
+			m.Err = err
+			return
+		}
+		m.Trips[m.EditIndex] = m.CurrentTrip
+	} else {
+		// Add new trip
+		newTrip := m.CurrentTrip // Create a copy to avoid reference issues
+		if newTrip.Family == "" {
+			newTrip.Family = m.familyForNewRecords()
+		}
+		m.Data.Trips = append(m.Data.Trips, newTrip)
+		m.Trips = m.Data.Trips
+	}
+
+	m.updateWeeklySummaries()
+	if err := m.Storage.SaveData(m.Data); err != nil {
+		m.Err = err
+		return
+	}
+
+	// Confirm what was saved so a wrong distance is easy to spot
+	verb := "Added"
+	if m.EditIndex >= 0 {
+		verb = "Updated"
+	}
+	m.StatusMessage = tripConfirmation(verb, m.CurrentTrip)
+
+	// Reset state
+	m.EditIndex = -1
+	m.CurrentTrip = model.Trip{}
+	m.Mode = "date"
+	m.TextInput.Reset()
+	m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+}
+
+// promptForMiles switches to a manual miles entry mode
+func (m *Model) promptForMiles(mode, placeholder string) {
+	m.Mode = mode
+	m.TextInput.Reset()
+	m.TextInput.Placeholder = placeholder
+}
+
+// saveRecurringEdit replaces the recurring trip at EditIndex with CurrentRecurring,
+// removing the trips generated from the old pattern before generating the new ones
+func (m *Model) saveRecurringEdit() error {
+	if m.CurrentRecurring.Miles == 0 {
+		distance, err := m.MapsClient.CalculateDistance(context.Background(), m.CurrentRecurring.Origin, m.CurrentRecurring.Destination)
+		if err != nil {
+			return fmt.Errorf("failed to calculate distance: %w", err)
+		}
+		m.CurrentRecurring.Miles = distance
+	}
+	if err := m.CurrentRecurring.Validate(); err != nil {
+		return fmt.Errorf("invalid recurring trip: %w", err)
+	}
+
+	m.pushUndo()
+	if _, err := m.Data.DeleteGeneratedTrips(m.EditIndex); err != nil {
+		return err
+	}
+	if err := m.Data.EditRecurringTrip(m.EditIndex, m.CurrentRecurring); err != nil {
+		return err
+	}
+	if err := m.Data.GenerateTripsFromRecurring(); err != nil {
+		return err
+	}
+	m.Trips = m.Data.Trips
+	m.RecurringTrips = m.Data.RecurringTrips
+
+	m.updateWeeklySummaries()
+	return m.Storage.SaveData(m.Data)
+}
+
+// exportSelectedWeek writes the selected week's trips, expenses and totals to a JSON
+// file in DataDir named after the week's start date, returning the file's path
+func (m *Model) exportSelectedWeek() (string, error) {
+	if m.DataDir == "" {
+		return "", fmt.Errorf("no data directory configured for exports")
+	}
+	summary := m.Data.WeeklySummaries[m.SelectedWeek]
+	path := filepath.Join(m.DataDir, fmt.Sprintf("week-%s.json", summary.WeekStart))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create week export: %w", err)
+	}
+	if err := export.ExportWeekJSON(file, summary); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write week export: %w", err)
+	}
+	return path, nil
+}
+
+// printCurrentView writes the screen as it is currently shown, without styling, to a
+// text file in DataDir named after the active tab, returning the file's path
+func (m *Model) printCurrentView() (string, error) {
+	if m.DataDir == "" {
+		return "", fmt.Errorf("no data directory configured for exports")
+	}
+	name := "view"
+	switch m.ActiveTab {
+	case TabWeeklySummaries:
+		name = "weekly-summary"
+		if m.SelectedWeek >= 0 && m.SelectedWeek < len(m.Data.WeeklySummaries) {
+			name = "week-" + m.Data.WeeklySummaries[m.SelectedWeek].WeekStart
+		}
+	case TabTrips:
+		name = "trips"
+	case TabExpenses:
+		name = "expenses"
+	case TabTemplates:
+		name = "templates"
+	}
+	path := filepath.Join(m.DataDir, name+".txt")
+
+	lines := strings.Split(StripANSI(m.View()), "\n")
+	for i, line := range lines {
+		// Styles pad lines out to a width with spaces
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+		return "", fmt.Errorf("failed to write printout: %w", err)
+	}
+	return path, nil
+}
+
+// ansiPattern matches terminal escape sequences: CSI sequences such as colors and
+// cursor movement, and OSC sequences such as window titles and hyperlinks
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// StripANSI removes terminal escape sequences from s, leaving plain text
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// pushUndo snapshots Data so the next destructive action can be undone with Ctrl+Z
+func (m *Model) pushUndo() {
+	m.UndoStack = append(m.UndoStack, m.Data.Clone())
+	if len(m.UndoStack) > maxUndoDepth {
+		m.UndoStack = m.UndoStack[len(m.UndoStack)-maxUndoDepth:]
+	}
+}
+
+// undo restores the most recent snapshot from the undo stack and saves it
+func (m *Model) undo() {
+	if len(m.UndoStack) == 0 {
+		return
+	}
+	m.Data = m.UndoStack[len(m.UndoStack)-1]
+	m.UndoStack = m.UndoStack[:len(m.UndoStack)-1]
+
+	m.Trips = m.Data.Trips
+	m.RecurringTrips = m.Data.RecurringTrips
+	m.TripTemplates = m.Data.TripTemplates
+	m.SelectedTrip = -1
+	m.SelectedExpense = -1
+	m.SelectedTemplate = -1
+	m.SelectedRecurring = -1
+	m.EditIndex = -1
+
+	m.updateWeeklySummaries()
+	if err := m.Storage.SaveData(m.Data); err != nil {
+		m.Err = fmt.Errorf("failed to save after undo: %w", err)
+	}
+}
+
+// SetRoundingMode sets how weekly mileage amounts are rounded and recalculates the summaries
+func (m *Model) SetRoundingMode(mode string) {
+	m.RoundingMode = mode
+	m.updateWeeklySummaries()
+}
+
+// SetDateFormat sets how dates are typed and shown, updating the current prompt
+func (m *Model) SetDateFormat(format string) {
+	m.DateFormat = format
+	if m.Mode == "date" {
+		m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+	}
+}
+
+// datePattern describes the configured date format for prompts, such as MM/DD/YYYY
+func (m *Model) datePattern() string {
+	return model.DateFormatPattern(m.DateFormat)
+}
+
+// formatDate shows a stored YYYY-MM-DD date in the configured format
+func (m *Model) formatDate(date string) string {
+	return model.FormatDate(date, m.DateFormat)
+}
+
+// formatDates shows stored dates in the configured format
+func (m *Model) formatDates(dates []string) []string {
+	formatted := make([]string, len(dates))
+	for i, date := range dates {
+		formatted[i] = m.formatDate(date)
+	}
+	return formatted
+}
+
+// canonicalizeDateInput rewrites a date typed in the configured format as YYYY-MM-DD,
+// so the mode's own validation and storage only ever see the canonical form
+func (m *Model) canonicalizeDateInput() error {
+	value := strings.TrimSpace(m.TextInput.Value())
+	if value == "" {
+		return nil
+	}
+
+	switch m.Mode {
+	case "date", "edit", "expense_date", "expense_edit_date", "recurring_date", "recurring_edit_date",
+		"bulk_delete_from", "bulk_delete_to", "recurring_extend", "adjustment_date", "week_jump":
+		date, err := model.ParseDate(value, m.DateFormat)
+		if err != nil {
+			return err
+		}
+		m.TextInput.SetValue(date)
+	case "recurring_end_date", "recurring_edit_end_date":
+		// These also take a number of trips, which is left for setRecurringLimit
+		if date, err := model.ParseDate(value, m.DateFormat); err == nil {
+			m.TextInput.SetValue(date)
+		}
+	case "recurring_excluded_dates":
+		var dates []string
+		for _, date := range strings.Split(value, ",") {
+			if canonical, err := model.ParseDate(date, m.DateFormat); err == nil {
+				date = canonical
 			}
+			dates = append(dates, strings.TrimSpace(date))
+		}
+		m.TextInput.SetValue(strings.Join(dates, ", "))
+	}
+	return nil
+}
+
+// SetWeekStartDay sets the day weekly summaries begin on, regroups the summaries and
+// selects the week containing today under the new boundary
+func (m *Model) SetWeekStartDay(day time.Weekday) {
+	m.WeekStartDay = day
+	m.updateWeeklySummaries()
+	m.SelectedWeek = m.getCurrentWeekIndex()
+}
+
+// updateWeeklySummaries recalculates the weekly summaries for the active family
+func (m *Model) updateWeeklySummaries() {
+	m.Data.WeeklySummaries = model.CalculateWeeklySummaries(
+		model.FilterTripsByFamily(m.Data.Trips, m.ActiveFamily),
+		model.FilterExpensesByFamily(m.Data.Expenses, m.ActiveFamily),
+		m.RatePerMile, m.RoundingMode, m.WeekStartDay)
+	m.Data.WeeklySummaries = model.ApplyAdjustments(m.Data.WeeklySummaries,
+		model.FilterAdjustmentsByFamily(m.Data.Adjustments, m.ActiveFamily),
+		m.RatePerMile, m.RoundingMode, m.WeekStartDay)
+}
+
+// changePageSize steps the number of rows per page up or down within the bounds,
+// returning to the first page so the view starts from the top
+func (m *Model) changePageSize(larger bool) {
+	size := (m.PageSize/pageSizeStep + 1) * pageSizeStep
+	if !larger {
+		size = (m.PageSize - 1) / pageSizeStep * pageSizeStep
+	}
+	size = max(minPageSize, min(maxPageSize, size))
+	if size == m.PageSize {
+		return
+	}
+	m.PageSize = size
+	m.CurrentPage = 0
+	m.StatusMessage = fmt.Sprintf("Showing %d rows per page", size)
+	if m.SavePageSize != nil {
+		if err := m.SavePageSize(size); err != nil {
+			m.Err = fmt.Errorf("failed to remember page size: %w", err)
+		}
+	}
+}
+
+// sortTripsByDate orders trips in place by date, most recent first unless ascending,
+// with archived trips after all the others
+func sortTripsByDate(trips []model.Trip, ascending bool) {
+	sort.SliceStable(trips, func(i, j int) bool {
+		if trips[i].Archived != trips[j].Archived {
+			return !trips[i].Archived
+		}
+		if ascending {
+			return trips[i].Date < trips[j].Date
+		}
+		return trips[i].Date > trips[j].Date
+	})
+}
+
+// setSelectedTripArchived archives or restores the selected trip and saves the change.
+// Either can be undone with Ctrl+Z.
+func (m *Model) setSelectedTripArchived(archived bool) {
+	index := m.selectedTripIndex()
+	m.Data.Trips = m.Trips
+	m.pushUndo()
+	update, verb := m.Data.UnarchiveTrip, "Restored"
+	if archived {
+		update, verb = m.Data.ArchiveTrip, "Archived"
+	}
+	if err := update(index); err != nil {
+		m.Err = err
+		return
+	}
+	trip := m.Data.Trips[index]
+	m.Trips = m.Data.Trips
+	m.updateWeeklySummaries()
+	if err := m.Storage.SaveData(m.Data); err != nil {
+		m.Err = fmt.Errorf("failed to save trip: %w", err)
+		return
+	}
+	// The trip moves in the list, so nothing stays selected
+	m.SelectedTrip = -1
+	m.StatusMessage = tripConfirmation(verb, trip) + " (Ctrl+Z to undo)"
+}
+
+// displayTrips returns the trips listed on the Trips tab in display order
+func (m *Model) displayTrips() []model.Trip {
+	indexes := m.displayTripIndexes()
+	trips := make([]model.Trip, len(indexes))
+	for i, index := range indexes {
+		trips[i] = m.Trips[index]
+	}
+	return trips
+}
+
+// displayTripIndexes returns the index in m.Trips of each trip listed on the Trips tab,
+// in display order. m.Trips is sorted in place first, so the list keeps its storage
+// order and, with nothing filtered out, list positions equal indexes. Archived trips
+// sort after the rest, so hiding them leaves those positions unchanged.
+func (m *Model) displayTripIndexes() []int {
+	sortTripsByDate(m.Trips, m.SortAscending)
+	var indexes []int
+	for i, trip := range m.Trips {
+		if trip.Archived && !m.ShowArchived {
+			continue
+		}
+		if m.ActiveFamily != "" && trip.FamilyOrDefault() != m.ActiveFamily {
+			continue
+		}
+		if m.SearchMode && !m.tripMatchesSearch(trip) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
 
-			isActivelyTyping := false
-			for _, inputMode := range activeInputModes {
-				if m.Mode == inputMode {
-					isActivelyTyping = true
-					break
-				}
-			}
+// selectedTripIndex returns the index in m.Trips of the trip selected on the Trips tab,
+// which SelectedTrip holds as a position in the displayed list, or -1 when none is
+func (m *Model) selectedTripIndex() int {
+	indexes := m.displayTripIndexes()
+	if m.SelectedTrip < 0 || m.SelectedTrip >= len(indexes) {
+		return -1
+	}
+	return indexes[m.SelectedTrip]
+}
 
-			// Allow shortcuts in "date" and "edit" modes when not actively entering text
-			if !isActivelyTyping && len(msg.Runes) == 1 {
-				switch msg.Runes[0] {
-				case 'u', 'U':
-					if m.ActiveTab == TabTemplates && m.SelectedTemplate >= 0 {
-						// Create a new trip from the selected template
-						template := m.TripTemplates[m.SelectedTemplate]
-						m.CurrentTrip = model.Trip{
-							Origin:      template.Origin,
-							Destination: template.Destination,
-							Type:        template.TripType,
-							Miles:       0, // Will be calculated when the trip is saved
-						}
-						m.Mode = "date"
-						m.TextInput.Reset()
-						m.TextInput.Placeholder = "Enter date (YYYY-MM-DD)..."
-						// Switch to trips tab
-						m.ActiveTab = TabTrips
-						m.SelectedTrip = -1
-						m.SelectedTemplate = -1
-						return m, cmd
-					}
-				}
-			}
+// toggleTripSortOrder flips the Trips tab between newest-first and oldest-first.
+// m.Trips is reordered to match so list positions keep lining up with SelectedTrip,
+// and the page jumps to wherever the selected trip landed.
+func (m *Model) toggleTripSortOrder() {
+	selectedIndex := m.selectedTripIndex()
+	m.SortAscending = !m.SortAscending
+
+	order := make([]int, len(m.Trips))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if m.Trips[order[a]].Archived != m.Trips[order[b]].Archived {
+			return !m.Trips[order[a]].Archived
 		}
+		if m.SortAscending {
+			return m.Trips[order[a]].Date < m.Trips[order[b]].Date
+		}
+		return m.Trips[order[a]].Date > m.Trips[order[b]].Date
+	})
+
+	sorted := make([]model.Trip, len(m.Trips))
+	movedIndex := -1
+	for pos, i := range order {
+		sorted[pos] = m.Trips[i]
+		if i == selectedIndex {
+			movedIndex = pos
+		}
+	}
+	// Copy in place so m.Data.Trips, which shares the backing array, stays in step
+	copy(m.Trips, sorted)
 
-		// Handle search input
-		if m.Mode == "search" {
-			m.SearchQuery = m.TextInput.Value()
+	m.SelectedTrip = -1
+	if movedIndex >= 0 {
+		m.SelectedTrip = indexOf(m.displayTripIndexes(), movedIndex)
+	}
+	m.CurrentPage = 0
+	if m.SelectedTrip >= 0 {
+		m.CurrentPage = m.SelectedTrip / m.PageSize
+	}
+}
+
+// jumpToItem selects the first or last item in the active tab's displayed order and
+// moves to the page that shows it
+func (m *Model) jumpToItem(last bool) {
+	position := func(count int) int {
+		if last {
+			return count - 1
 		}
+		return 0
 	}
 
-	// Only return early for edit modes after handling key events
-	if m.Mode == "edit" || m.Mode == "edit_origin" || m.Mode == "edit_destination" || m.Mode == "edit_type" {
-		return m, tea.Batch(cmds...)
+	switch m.ActiveTab {
+	case TabTrips:
+		displayTrips := m.displayTrips()
+		if len(displayTrips) == 0 {
+			return
+		}
+		m.SelectedTrip = position(len(displayTrips))
+		m.CurrentPage = m.SelectedTrip / m.PageSize
+		m.SelectedExpense = -1
+		m.SelectedTemplate = -1
+		m.SelectedRecurring = -1
+	case TabExpenses:
+		displayExpenses := m.displayExpenses()
+		if len(displayExpenses) == 0 {
+			return
+		}
+		m.SelectedExpense = position(len(displayExpenses))
+		m.CurrentPage = m.SelectedExpense / m.PageSize
+		m.SelectedTrip = -1
+		m.SelectedTemplate = -1
+	case TabTemplates:
+		// Templates are displayed sorted by name, but selected by their stored index
+		_, order := m.displayTemplates()
+		if len(order) == 0 {
+			return
+		}
+		sortedIndex := position(len(order))
+		m.SelectedTemplate = order[sortedIndex]
+		m.CurrentPage = sortedIndex / m.PageSize
+		m.SelectedTrip = -1
+		m.SelectedExpense = -1
 	}
+}
 
-	return m, tea.Batch(cmds...)
+// cycleFamily switches to the next configured family, wrapping back around to all families
+func (m *Model) cycleFamily() {
+	next := ""
+	if m.ActiveFamily == "" {
+		if len(m.Families) > 0 {
+			next = m.Families[0]
+		}
+	} else {
+		for i, family := range m.Families {
+			if family == m.ActiveFamily && i+1 < len(m.Families) {
+				next = m.Families[i+1]
+				break
+			}
+		}
+	}
+
+	m.ActiveFamily = next
+	m.CurrentPage = 0
+	m.SelectedTrip = -1
+	m.SelectedExpense = -1
+	m.updateWeeklySummaries()
+	m.SelectedWeek = m.getCurrentWeekIndex()
+}
+
+// familyForNewRecords returns the family new trips and expenses are assigned to
+func (m *Model) familyForNewRecords() string {
+	if m.ActiveFamily != "" {
+		return m.ActiveFamily
+	}
+	return model.DefaultFamily
+}
+
+// startExcludedDatesInput prompts for the dates a new recurring trip should skip
+func (m *Model) startExcludedDatesInput() {
+	m.TextInput.Reset()
+	m.Mode = "recurring_excluded_dates"
+	m.TextInput.Placeholder = "Enter dates to skip, comma-separated (optional, press Enter to skip)..."
+}
+
+// startOriginInput switches to origin mode, prefilling the configured home address
+func (m *Model) startOriginInput() {
+	m.TextInput.Reset()
+	// An origin entered before stepping back to the date is kept
+	if m.CurrentTrip.Origin != "" {
+		m.TextInput.SetValue(m.CurrentTrip.Origin)
+	} else {
+		m.TextInput.SetValue(m.HomeAddress)
+	}
+	m.Mode = "origin"
+	m.TextInput.Placeholder = "Enter origin location..."
+}
+
+// previousTripField steps trip entry back one field (type → destination → origin → date),
+// in both the create and edit flows, putting the value already entered there back into
+// the input. Whatever was typed into the current field is discarded. It reports false
+// outside those fields.
+func (m *Model) previousTripField() bool {
+	var value string
+	switch m.Mode {
+	case "origin":
+		m.Mode = "date"
+		value = m.formatDate(m.CurrentTrip.Date)
+		m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+	case "destination":
+		m.Mode = "origin"
+		value = m.CurrentTrip.Origin
+		m.TextInput.Placeholder = "Enter origin location..."
+	case "type":
+		m.Mode = "destination"
+		value = m.CurrentTrip.Destination
+		m.TextInput.Placeholder = "Enter destination location..."
+	case "edit_origin":
+		m.Mode = "edit"
+		m.EditIndex = 0
+		value = m.formatDate(m.CurrentTrip.Date)
+		m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+	case "edit_destination":
+		m.Mode = "edit_origin"
+		m.EditIndex = 1
+		value = m.CurrentTrip.Origin
+		m.TextInput.Placeholder = "Enter origin location..."
+	case "edit_type":
+		m.Mode = "edit_destination"
+		m.EditIndex = 2
+		value = m.CurrentTrip.Destination
+		m.TextInput.Placeholder = "Enter destination location..."
+	default:
+		return false
+	}
+	m.TextInput.Reset()
+	m.TextInput.SetValue(value)
+	return true
+}
+
+// routePicks returns the frequent routes offered as numbered quick-picks while a trip's
+// origin or destination is entered. Once the origin is chosen, only routes from it are offered.
+func (m *Model) routePicks() []maps.Route {
+	switch m.Mode {
+	case "origin":
+		return m.Data.TopRoutes(maxRoutePicks)
+	case "destination":
+		var picks []maps.Route
+		for _, route := range m.Data.TopRoutes(0) {
+			if route.Origin == m.CurrentTrip.Origin {
+				picks = append(picks, route)
+			}
+			if len(picks) == maxRoutePicks {
+				break
+			}
+		}
+		return picks
+	}
+	return nil
+}
+
+// pickedRoute returns the quick-pick whose number has been typed, if any
+func (m *Model) pickedRoute() (maps.Route, bool) {
+	picks := m.routePicks()
+	choice, err := strconv.Atoi(strings.TrimSpace(m.TextInput.Value()))
+	if err != nil || choice < 1 || choice > len(picks) {
+		return maps.Route{}, false
+	}
+	return picks[choice-1], true
 }
 
 // filterBySearch filters trips based on the search query
 func (m *Model) filterBySearch() []model.Trip {
+	trips := model.FilterTripsByFamily(m.Trips, m.ActiveFamily)
 	if m.SearchQuery == "" {
-		return m.Trips
+		return trips
 	}
 
-	query := strings.ToLower(m.SearchQuery)
 	var filteredTrips []model.Trip
 
 	// Filter trips
-	for _, trip := range m.Trips {
-		if strings.Contains(strings.ToLower(trip.Origin), query) ||
-			strings.Contains(strings.ToLower(trip.Destination), query) ||
-			strings.Contains(strings.ToLower(trip.Date), query) ||
-			strings.Contains(strings.ToLower(trip.Type), query) {
+	for _, trip := range trips {
+		if m.tripMatchesSearch(trip) {
 			filteredTrips = append(filteredTrips, trip)
 		}
 	}
@@ -1236,6 +2529,147 @@ func (m *Model) filterBySearch() []model.Trip {
 	return filteredTrips
 }
 
+// tripMatchesSearch reports whether the trip's route, date, type or tags match the search query
+func (m *Model) tripMatchesSearch(trip model.Trip) bool {
+	fields := append([]string{trip.Origin, trip.Destination, trip.Date, trip.Type}, trip.Tags...)
+	return matchesSearch(m.SearchQuery, fields...)
+}
+
+// filterExpensesBySearch filters expenses by description and date based on the search query
+func (m *Model) filterExpensesBySearch() []model.Expense {
+	expenses := model.FilterExpensesByFamily(m.Data.Expenses, m.ActiveFamily)
+	if m.SearchQuery == "" {
+		return expenses
+	}
+
+	var filteredExpenses []model.Expense
+	for _, expense := range expenses {
+		if matchesSearch(m.SearchQuery, expense.Description, expense.Date) {
+			filteredExpenses = append(filteredExpenses, expense)
+		}
+	}
+
+	return filteredExpenses
+}
+
+// displayExpenses returns the expenses listed on the Expenses tab in display order
+func (m *Model) displayExpenses() []model.Expense {
+	indexes := m.displayExpenseIndexes()
+	expenses := make([]model.Expense, len(indexes))
+	for i, index := range indexes {
+		expenses[i] = m.Data.Expenses[index]
+	}
+	return expenses
+}
+
+// displayExpenseIndexes returns the index in m.Data.Expenses of each expense listed on the
+// Expenses tab, in display order. m.Data.Expenses is sorted newest first in place, so the
+// list keeps its storage order.
+func (m *Model) displayExpenseIndexes() []int {
+	sort.SliceStable(m.Data.Expenses, func(i, j int) bool {
+		return m.Data.Expenses[i].Date > m.Data.Expenses[j].Date
+	})
+	var indexes []int
+	for i, expense := range m.Data.Expenses {
+		if m.ActiveFamily != "" && expense.FamilyOrDefault() != m.ActiveFamily {
+			continue
+		}
+		if m.SearchMode && !matchesSearch(m.SearchQuery, expense.Description, expense.Date) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// selectedExpenseIndex returns the index in m.Data.Expenses of the expense selected on the
+// Expenses tab, which SelectedExpense holds as a position in the displayed list, or -1
+func (m *Model) selectedExpenseIndex() int {
+	indexes := m.displayExpenseIndexes()
+	if m.SelectedExpense < 0 || m.SelectedExpense >= len(indexes) {
+		return -1
+	}
+	return indexes[m.SelectedExpense]
+}
+
+// displayTemplates returns the templates shown on the Templates tab, sorted by name (or
+// by usage, most used first, when TemplatesByUsage is set) and narrowed to those matching
+// the search query in search mode, along with each one's index in TripTemplates
+func (m *Model) displayTemplates() ([]model.TripTemplate, []int) {
+	var indexes []int
+	for i, template := range m.TripTemplates {
+		if !m.SearchMode || m.SearchQuery == "" || matchesSearch(m.SearchQuery, template.Name, template.Origin, template.Destination) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	// Sort alphabetically by name (case-insensitive), breaking usage ties by name
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := m.TripTemplates[indexes[i]], m.TripTemplates[indexes[j]]
+		if m.TemplatesByUsage && a.UsageCount != b.UsageCount {
+			return a.UsageCount > b.UsageCount
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+
+	templates := make([]model.TripTemplate, len(indexes))
+	for i, index := range indexes {
+		templates[i] = m.TripTemplates[index]
+	}
+	return templates, indexes
+}
+
+// useSelectedTemplate starts a new trip from the selected template on the Trips tab,
+// counting the use so templates can be listed by how often they're used
+func (m *Model) useSelectedTemplate() {
+	template := m.TripTemplates[m.SelectedTemplate]
+	m.Data.TripTemplates = m.TripTemplates
+	if err := m.Data.UseTripTemplate(m.SelectedTemplate); err != nil {
+		m.Err = err
+		return
+	}
+	if err := m.Storage.SaveData(m.Data); err != nil {
+		m.Err = fmt.Errorf("failed to save template: %w", err)
+		return
+	}
+
+	m.CurrentTrip = model.Trip{
+		Origin:      template.Origin,
+		Destination: template.Destination,
+		Type:        template.TripType,
+		Notes:       template.Notes,
+		Miles:       0, // Will be calculated when the trip is saved
+	}
+	m.Mode = "date"
+	m.TextInput.Reset()
+	m.TextInput.Placeholder = fmt.Sprintf("Enter date (%s)...", m.datePattern())
+	// Switch to trips tab
+	m.ActiveTab = TabTrips
+	m.SelectedTrip = -1
+	m.SelectedTemplate = -1
+}
+
+// indexOf returns the position of value in values, or -1 when it is absent
+func indexOf(values []int, value int) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchesSearch reports whether any of the given fields contains the query, ignoring case
+func matchesSearch(query string, fields ...string) bool {
+	query = strings.ToLower(query)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
 // View renders the UI
 func (m *Model) View() string {
 	var s strings.Builder
@@ -1279,10 +2713,16 @@ func (m *Model) View() string {
 		Bold(true).
 		Padding(0, 1)
 
+	confirmationStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#00FF00")).
+		Padding(0, 1)
+
 	// Show error if any
 	if m.Err != nil {
 		s.WriteString(errorStyle.Render(m.Err.Error()) + "\n\n")
 		m.Err = nil
+	} else if m.StatusMessage != "" {
+		s.WriteString(confirmationStyle.Render(m.StatusMessage) + "\n\n")
 	}
 
 	// Show status bar
@@ -1292,6 +2732,15 @@ func (m *Model) View() string {
 	s.WriteString(headerStyle.Render(fmt.Sprintf("Mode: %s", m.Mode)) + "\n")
 	s.WriteString(m.TextInput.View() + "\n\n")
 
+	// Offer frequent routes while a trip's origin or destination is entered
+	if picks := m.routePicks(); len(picks) > 0 {
+		s.WriteString("Frequent routes (type a number and press Enter):\n")
+		for i, route := range picks {
+			s.WriteString(fmt.Sprintf("  %d. %s → %s\n", i+1, route.Origin, route.Destination))
+		}
+		s.WriteString("\n")
+	}
+
 	// Render tabs
 	tabs := []string{"Weekly Summaries", "Trips", "Expenses", "Trip Templates"}
 	var tabLine strings.Builder
@@ -1311,51 +2760,104 @@ func (m *Model) View() string {
 	switch m.ActiveTab {
 	case TabWeeklySummaries:
 		if m.SelectedWeek >= 0 && m.SelectedWeek < len(m.Data.WeeklySummaries) {
+			grandTotal := model.CalculateGrandTotal(m.Data.WeeklySummaries)
+			s.WriteString(headerStyle.Render(fmt.Sprintf("All Weeks (%d):", len(m.Data.WeeklySummaries))) + "\n")
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Miles:          %.2f", grandTotal.TotalMiles)) + "\n")
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Mileage Amount: $%.2f", grandTotal.TotalAmount)) + "\n")
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Expenses:       $%.2f", grandTotal.TotalExpenses)) + "\n")
+			trips := model.FilterTripsByFamily(m.Data.Trips, m.ActiveFamily)
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Avg Miles per Trip:   %.2f", model.AverageMilesPerTrip(trips))) + "\n")
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Avg Miles per Day:    %.2f", model.AverageMilesPerActiveDay(trips))) + "\n\n")
+
 			summary := m.Data.WeeklySummaries[m.SelectedWeek]
-			s.WriteString(headerStyle.Render(fmt.Sprintf("Week of %s to %s (Week %d of %d):", summary.WeekStart, summary.WeekEnd, m.SelectedWeek+1, len(m.Data.WeeklySummaries))) + "\n")
+			s.WriteString(headerStyle.Render(fmt.Sprintf("Week of %s to %s (Week %d of %d):", m.formatDate(summary.WeekStart), m.formatDate(summary.WeekEnd), m.SelectedWeek+1, len(m.Data.WeeklySummaries))) + "\n")
+			if summary.ExpensesWithoutMiles() {
+				s.WriteString(errorStyle.Render("    ⚠ Expenses logged but no miles this week") + "\n")
+			}
 			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Miles:          %.2f", summary.TotalMiles)) + "\n")
+			if len(summary.Adjustments) > 0 {
+				s.WriteString(normalStyle.Render(fmt.Sprintf("    Adjustments:          %+.2f miles", summary.AdjustmentMiles)) + "\n")
+			}
+			if m.WeeklyTarget > 0 {
+				s.WriteString(normalStyle.Render(fmt.Sprintf("    Target:               %.2f / %.2f miles (%.0f%%)", summary.TotalMiles, m.WeeklyTarget, summary.TargetPercent(m.WeeklyTarget))) + "\n")
+			}
+			s.WriteString(normalStyle.Render(fmt.Sprintf("    Trips:                %d single, %d round", summary.SingleTripCount, summary.RoundTripCount)) + "\n")
 			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Mileage Amount: $%.2f", summary.TotalAmount)) + "\n")
+			if summary.PerPassengerAmount != summary.TotalAmount {
+				s.WriteString(normalStyle.Render(fmt.Sprintf("    Per Passenger:        $%.2f", summary.PerPassengerAmount)) + "\n")
+			}
 			s.WriteString(normalStyle.Render(fmt.Sprintf("    Total Expenses:       $%.2f", summary.TotalExpenses)) + "\n")
-			s.WriteString(normalStyle.Render(" Trips:") + "\n")
-			for _, trip := range summary.Trips {
-				displayMiles := trip.Miles
-				if trip.Type == "round" {
-					displayMiles *= 2
-				}
-				tripLine := fmt.Sprintf(" %s: %s → %s (%.2f miles) [%s]", trip.Date, trip.Origin, trip.Destination, displayMiles, trip.Type)
+			if summary.TotalPersonalExpenses > 0 {
+				s.WriteString(normalStyle.Render(fmt.Sprintf("    Personal Expenses:    $%.2f", summary.TotalPersonalExpenses)) + "\n")
+			}
+			if prev, ok := model.PreviousWeek(m.Data.WeeklySummaries, m.SelectedWeek); ok {
+				s.WriteString(normalStyle.Render("    vs Previous Week:     "+deltaLabel(summary.CompareTo(prev))) + "\n")
+			}
+			categories := make([]string, 0, len(summary.ExpensesByCategory))
+			for category := range summary.ExpensesByCategory {
+				categories = append(categories, category)
+			}
+			sort.Strings(categories)
+			for _, category := range categories {
+				s.WriteString(normalStyle.Render(fmt.Sprintf("      %-20s $%.2f", category+":", summary.ExpensesByCategory[category])) + "\n")
+			}
+			if m.HideRecurring {
+				s.WriteString(normalStyle.Render(" Trips (recurring hidden):") + "\n")
+			} else {
+				s.WriteString(normalStyle.Render(" Trips:") + "\n")
+			}
+			expensesByTrip, otherExpenses := summary.ExpensesByTrip()
+			for i, trip := range summary.Trips {
+				if m.HideRecurring && trip.IsRecurring {
+					// Keep the expenses of hidden trips in the general list
+					otherExpenses = append(otherExpenses, expensesByTrip[i]...)
+					continue
+				}
+				tripLine := fmt.Sprintf(" %s: %s → %s (%.2f miles) [%s]%s", m.formatDate(trip.Date), trip.Origin, trip.Destination, trip.EffectiveMiles(), trip.Type, recurringLabel(trip))
 				s.WriteString(normalStyle.Render(tripLine) + "\n")
+				for _, exp := range expensesByTrip[i] {
+					s.WriteString(normalStyle.Render(fmt.Sprintf("   └ $%.2f - %s%s", exp.Amount, exp.Description, personalLabel(exp))) + "\n")
+				}
+			}
+			for _, adjustment := range summary.Adjustments {
+				s.WriteString(normalStyle.Render(fmt.Sprintf(" %s: Adjustment %+.2f miles - %s", m.formatDate(adjustment.Date), adjustment.Miles, adjustment.Reason)) + "\n")
 			}
 			s.WriteString("\n")
 			s.WriteString(normalStyle.Render(" Expenses:") + "\n")
-			if len(summary.Expenses) > 0 {
-				for _, exp := range summary.Expenses {
-					s.WriteString(normalStyle.Render(fmt.Sprintf(" %s: $%.2f - %s", exp.Date, exp.Amount, exp.Description)) + "\n")
+			if len(otherExpenses) > 0 {
+				for _, exp := range otherExpenses {
+					s.WriteString(normalStyle.Render(fmt.Sprintf(" %s: $%.2f - %s%s", m.formatDate(exp.Date), exp.Amount, exp.Description, personalLabel(exp))) + "\n")
 				}
 			} else {
 				s.WriteString(normalStyle.Render(" (No expenses available.)") + "\n")
 			}
+		} else if hint := m.emptyStateHint(); hint != "" {
+			s.WriteString(normalStyle.Render(hint) + "\n")
 		} else {
 			s.WriteString(normalStyle.Render(" (No weekly summary available.)") + "\n")
 		}
 
 	case TabTrips:
 		// Get trips to display (filtered or all)
-		displayTrips := m.Trips
-		if m.SearchMode {
-			displayTrips = m.filterBySearch()
-		}
+		displayTrips := m.displayTrips()
 
 		// Show recurring trips
 		if len(m.RecurringTrips) > 0 {
 			s.WriteString(headerStyle.Render("Recurring Trips:") + "\n")
 			for i, trip := range m.RecurringTrips {
-				weekday := time.Weekday(trip.Weekday).String()
-				displayMiles := trip.Miles
-				if trip.Type == "round" {
-					displayMiles *= 2
+				tripLine := fmt.Sprintf("%s → %s (%.2f miles) [%s] - %s",
+					trip.Origin, trip.Destination, trip.EffectiveMiles(), trip.Type, recurrenceLabel(trip))
+				if trip.Occurrences > 0 {
+					tripLine += fmt.Sprintf(" (%d trips)", trip.Occurrences)
+				}
+				if len(trip.ExcludedDates) > 0 {
+					tripLine += fmt.Sprintf(" (skips %s)", strings.Join(m.formatDates(trip.ExcludedDates), ", "))
+				}
+				if next, ok := trip.NextOccurrence(m.todayTime()); ok {
+					tripLine += " next: " + next
+				} else {
+					tripLine += " (ended)"
 				}
-				tripLine := fmt.Sprintf("%s → %s (%.2f miles) [%s] - Every %s",
-					trip.Origin, trip.Destination, displayMiles, trip.Type, weekday)
 
 				if m.EditIndex == i {
 					tripLine = editingStyle.Render("> " + tripLine)
@@ -1371,12 +2873,15 @@ func (m *Model) View() string {
 
 		// Show regular trips with pagination
 		if len(displayTrips) > 0 {
-			s.WriteString(headerStyle.Render("Regular Trips:") + "\n")
+			header := "Regular Trips"
+			if m.SortAscending {
+				header += " (oldest first)"
+			}
+			if m.ShowArchived {
+				header += " (including archived)"
+			}
+			s.WriteString(headerStyle.Render(header+":") + "\n")
 
-			// Sort trips in descending order (most recent first)
-			sort.Slice(displayTrips, func(i, j int) bool {
-				return displayTrips[i].Date > displayTrips[j].Date
-			})
 			startIdx := m.CurrentPage * m.PageSize
 			endIdx := startIdx + m.PageSize
 			if endIdx > len(displayTrips) {
@@ -1386,12 +2891,20 @@ func (m *Model) View() string {
 			// Display trips for current page
 			for i := startIdx; i < endIdx; i++ {
 				trip := displayTrips[i]
-				displayMiles := trip.Miles
-				if trip.Type == "round" {
-					displayMiles *= 2
+				tripLine := fmt.Sprintf("%s: %s → %s%s (%.2f miles) [%s]%s",
+					m.formatDate(trip.Date), trip.Origin, trip.Destination, returnLabel(trip), trip.EffectiveMiles(), trip.Type, recurringLabel(trip))
+				if trip.Archived {
+					tripLine += " [archived]"
+				}
+				if trip.PassengersOrDefault() > 1 {
+					tripLine += fmt.Sprintf(" (%d passengers)", trip.PassengersOrDefault())
+				}
+				if trip.Notes != "" {
+					tripLine += fmt.Sprintf(" - %s", trip.Notes)
+				}
+				if len(trip.Tags) > 0 {
+					tripLine += " #" + strings.Join(trip.Tags, " #")
 				}
-				tripLine := fmt.Sprintf("%s: %s → %s (%.2f miles) [%s]",
-					trip.Date, trip.Origin, trip.Destination, displayMiles, trip.Type)
 
 				if m.EditIndex == i {
 					tripLine = editingStyle.Render("> " + tripLine)
@@ -1410,28 +2923,34 @@ func (m *Model) View() string {
 					m.CurrentPage+1, totalPages, startIdx+1, endIdx, len(displayTrips))
 				s.WriteString(normalStyle.Render(paginationInfo) + "\n")
 			}
+
+			// Totals cover every displayed trip, not just the current page, but never archived ones
+			totalTrips := model.UnarchivedTrips(displayTrips)
+			s.WriteString(normalStyle.Render(fmt.Sprintf("\nTotal: %.2f miles, $%.2f",
+				model.CalculateTotalMiles(totalTrips),
+				model.RoundAmount(model.CalculateReimbursement(totalTrips, m.RatePerMile), m.RoundingMode))) + "\n")
+		} else if hint := m.emptyStateHint(); hint != "" {
+			s.WriteString(normalStyle.Render(hint) + "\n")
 		} else {
 			s.WriteString(normalStyle.Render("No trips available.\n"))
 		}
 
 	case TabExpenses:
-		if len(m.Data.Expenses) > 0 {
-			// Sort expenses by date in descending order
-			sort.Slice(m.Data.Expenses, func(i, j int) bool {
-				return m.Data.Expenses[i].Date > m.Data.Expenses[j].Date
-			})
+		// Get expenses to display (filtered or all), most recent first
+		displayExpenses := m.displayExpenses()
 
+		if len(displayExpenses) > 0 {
 			// Calculate pagination
 			startIdx := m.CurrentPage * m.PageSize
 			endIdx := startIdx + m.PageSize
-			if endIdx > len(m.Data.Expenses) {
-				endIdx = len(m.Data.Expenses)
+			if endIdx > len(displayExpenses) {
+				endIdx = len(displayExpenses)
 			}
 
 			// Display expenses for current page
 			for i := startIdx; i < endIdx; i++ {
-				expense := m.Data.Expenses[i]
-				expenseLine := fmt.Sprintf("%s: $%.2f - %s", expense.Date, expense.Amount, expense.Description)
+				expense := displayExpenses[i]
+				expenseLine := fmt.Sprintf("%s: $%.2f - %s%s", m.formatDate(expense.Date), expense.Amount, expense.Description, personalLabel(expense))
 				if m.SelectedExpense == i {
 					expenseLine = selectedStyle.Render("* " + expenseLine)
 				} else {
@@ -1441,42 +2960,26 @@ func (m *Model) View() string {
 			}
 
 			// Show pagination info
-			totalPages := (len(m.Data.Expenses) + m.PageSize - 1) / m.PageSize
+			totalPages := (len(displayExpenses) + m.PageSize - 1) / m.PageSize
 			if totalPages > 1 {
 				paginationInfo := fmt.Sprintf("\nPage %d of %d (Showing %d-%d of %d expenses)",
-					m.CurrentPage+1, totalPages, startIdx+1, endIdx, len(m.Data.Expenses))
+					m.CurrentPage+1, totalPages, startIdx+1, endIdx, len(displayExpenses))
 				s.WriteString(normalStyle.Render(paginationInfo) + "\n")
 			}
+		} else if hint := m.emptyStateHint(); hint != "" {
+			s.WriteString(normalStyle.Render(hint) + "\n")
 		} else {
 			s.WriteString(normalStyle.Render("No expenses available.\n"))
 		}
 
 	case TabTemplates:
 		// Show templates with pagination
-		if len(m.TripTemplates) > 0 {
-			s.WriteString(headerStyle.Render("Trip Templates:") + "\n")
-
-			// Create a copy of templates for sorting
-			displayTemplates := make([]model.TripTemplate, len(m.TripTemplates))
-			copy(displayTemplates, m.TripTemplates)
-
-			// Sort templates alphabetically by name (case-insensitive)
-			sort.Slice(displayTemplates, func(i, j int) bool {
-				return strings.ToLower(displayTemplates[i].Name) < strings.ToLower(displayTemplates[j].Name)
-			})
-
-			// Create a mapping from sorted display index to original index
-			displayToOriginal := make(map[int]int)
-			for i, displayTemplate := range displayTemplates {
-				for j, originalTemplate := range m.TripTemplates {
-					if displayTemplate.Name == originalTemplate.Name &&
-						displayTemplate.Origin == originalTemplate.Origin &&
-						displayTemplate.Destination == originalTemplate.Destination &&
-						displayTemplate.TripType == originalTemplate.TripType {
-						displayToOriginal[i] = j
-						break
-					}
-				}
+		displayTemplates, originalIndexes := m.displayTemplates()
+		if len(displayTemplates) > 0 {
+			if m.TemplatesByUsage {
+				s.WriteString(headerStyle.Render("Trip Templates (most used first):") + "\n")
+			} else {
+				s.WriteString(headerStyle.Render("Trip Templates:") + "\n")
 			}
 
 			startIdx := m.CurrentPage * m.PageSize
@@ -1493,11 +2996,12 @@ func (m *Model) View() string {
 				if template.Notes != "" {
 					templateLine += fmt.Sprintf(" - %s", template.Notes)
 				}
+				if template.UsageCount > 0 {
+					templateLine += fmt.Sprintf(" (used: %d)", template.UsageCount)
+				}
 
-				// Use the mapping to find the original index for selection highlighting
-				originalIndex := displayToOriginal[i]
-
-				if m.SelectedTemplate == originalIndex {
+				// Selection refers to the template's index in TripTemplates
+				if m.SelectedTemplate == originalIndexes[i] {
 					templateLine = selectedStyle.Render("* " + templateLine)
 				} else {
 					templateLine = normalStyle.Render("  " + templateLine)
@@ -1506,10 +3010,14 @@ func (m *Model) View() string {
 			}
 
 			// Show pagination info if there are multiple pages
-			if len(m.TripTemplates) > m.PageSize {
-				totalPages := (len(m.TripTemplates) + m.PageSize - 1) / m.PageSize
+			if len(displayTemplates) > m.PageSize {
+				totalPages := (len(displayTemplates) + m.PageSize - 1) / m.PageSize
 				s.WriteString(fmt.Sprintf("\nPage %d of %d\n", m.CurrentPage+1, totalPages))
 			}
+		} else if m.SearchMode && len(m.TripTemplates) > 0 {
+			s.WriteString(normalStyle.Render("No templates match the search.\n"))
+		} else if hint := m.emptyStateHint(); hint != "" {
+			s.WriteString(normalStyle.Render(hint) + "\n")
 		} else {
 			s.WriteString(normalStyle.Render("No trip templates available.\n"))
 		}
@@ -1566,9 +3074,15 @@ func (m *Model) getHelpContent() string {
 	content.WriteString(sectionStyle.Render("NAVIGATION") + "\n")
 	content.WriteString(shortcutStyle.Render("↑/↓") + " " + descStyle.Render("Navigate items") + "\n")
 	content.WriteString(shortcutStyle.Render("[Tab]") + " " + descStyle.Render("Switch tabs") + "\n")
-	content.WriteString(shortcutStyle.Render("←/→") + " " + descStyle.Render("Navigate pages") + "\n")
+	content.WriteString(shortcutStyle.Render("←/→") + " " + descStyle.Render(fmt.Sprintf("Navigate pages (%d per page)", m.PageSize)) + "\n")
+	content.WriteString(shortcutStyle.Render("+/-") + " " + descStyle.Render(fmt.Sprintf("Show more or fewer rows per page (%d-%d)", minPageSize, maxPageSize)) + "\n")
 	content.WriteString(shortcutStyle.Render("[Enter]") + " " + descStyle.Render("Select item") + "\n")
 	content.WriteString(shortcutStyle.Render("[Esc]") + " " + descStyle.Render("Cancel/Close") + "\n")
+	content.WriteString(shortcutStyle.Render("[Ctrl+Z]") + " " + descStyle.Render("Undo last delete or edit") + "\n")
+	content.WriteString(shortcutStyle.Render("[P]") + " " + descStyle.Render("Print the current view to a text file") + "\n")
+	if len(m.Families) > 1 {
+		content.WriteString(shortcutStyle.Render("[Ctrl+G]") + " " + descStyle.Render("Switch family") + "\n")
+	}
 
 	if m.HelpLevel >= 2 {
 		content.WriteString(shortcutStyle.Render("[Home]") + " " + descStyle.Render("First item") + "\n")
@@ -1582,28 +3096,41 @@ func (m *Model) getHelpContent() string {
 	case TabWeeklySummaries:
 		content.WriteString(sectionStyle.Render("WEEKLY SUMMARIES") + "\n")
 		content.WriteString(shortcutStyle.Render("←/→") + " " + descStyle.Render("Switch weeks") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+A]") + " " + descStyle.Render("Adjust the week's miles (negative to remove)") + "\n")
 		if m.HelpLevel >= 2 {
 			content.WriteString(shortcutStyle.Render("[W]") + " " + descStyle.Render("Jump to current week") + "\n")
 			content.WriteString(shortcutStyle.Render("[M]") + " " + descStyle.Render("Jump to current month") + "\n")
+			content.WriteString(shortcutStyle.Render("[G]") + " " + descStyle.Render("Go to the week containing a date") + "\n")
+			content.WriteString(shortcutStyle.Render("[R]") + " " + descStyle.Render("Show/hide recurring trips") + "\n")
 		}
 		if m.HelpLevel >= 3 {
-			content.WriteString(shortcutStyle.Render("[P]") + " " + descStyle.Render("Print summary") + "\n")
 			content.WriteString(shortcutStyle.Render("[E]") + " " + descStyle.Render("Export week") + "\n")
 		}
 
 	case TabTrips:
 		content.WriteString(sectionStyle.Render("TRIPS") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+N]") + " " + descStyle.Render("Fill in today's date") + "\n")
+		content.WriteString(shortcutStyle.Render("[Shift+Tab]") + " " + descStyle.Render("Back to the previous field while entering a trip") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+E]") + " " + descStyle.Render("Edit trip") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+Y]") + " " + descStyle.Render("Duplicate trip") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+F]") + " " + descStyle.Render("Search trips") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+T]") + " " + descStyle.Render("Use template") + "\n")
-		content.WriteString(shortcutStyle.Render("[Ctrl+X]") + " " + descStyle.Render("Add expense") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+X]") + " " + descStyle.Render("Add expense (attached to the selected trip)") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+R]") + " " + descStyle.Render("Add recurring trip") + "\n")
-		content.WriteString(shortcutStyle.Render("[Ctrl+D]") + " " + descStyle.Render("Delete trip") + "\n")
+		content.WriteString(shortcutStyle.Render("[Shift+↑/↓]") + " " + descStyle.Render("Select recurring trip (then Ctrl+E to edit, Ctrl+D to delete)") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+D]") + " " + descStyle.Render("Archive trip (deletes it if already archived)") + "\n")
+		content.WriteString(shortcutStyle.Render("[A]") + " " + descStyle.Render("Show/hide archived trips") + "\n")
+		content.WriteString(shortcutStyle.Render("[R]") + " " + descStyle.Render("Restore archived trip") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+B]") + " " + descStyle.Render("Delete trips in a date range") + "\n")
+		content.WriteString(shortcutStyle.Render("[O]") + " " + descStyle.Render("Toggle oldest/newest first") + "\n")
+		content.WriteString(shortcutStyle.Render("[X]") + " " + descStyle.Render("Extend active recurring trips to a new end date") + "\n")
+		content.WriteString(shortcutStyle.Render("[D]") + " " + descStyle.Render("Merge duplicate trips") + "\n")
 
 		if m.HelpLevel >= 2 {
 			content.WriteString("\n" + sectionStyle.Render("TRIP TIPS") + "\n")
 			content.WriteString(tipStyle.Render("• Use templates for common routes") + "\n")
-			content.WriteString(tipStyle.Render("• Search works on origin, destination, date, type") + "\n")
+			content.WriteString(tipStyle.Render("• Type a frequent route's number as the origin to reuse it") + "\n")
+			content.WriteString(tipStyle.Render("• Search works on origin, destination, date, type, tags") + "\n")
 			content.WriteString(tipStyle.Render("• Round trips automatically double mileage") + "\n")
 		}
 
@@ -1618,9 +3145,10 @@ func (m *Model) getHelpContent() string {
 	case TabExpenses:
 		content.WriteString(sectionStyle.Render("EXPENSES") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+E]") + " " + descStyle.Render("Edit expense") + "\n")
-		content.WriteString(shortcutStyle.Render("[Ctrl+F]") + " " + descStyle.Render("Filter expenses") + "\n")
+		content.WriteString(shortcutStyle.Render("[Ctrl+F]") + " " + descStyle.Render("Search expenses") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+X]") + " " + descStyle.Render("Add expense") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+D]") + " " + descStyle.Render("Delete expense") + "\n")
+		content.WriteString(shortcutStyle.Render("[D]") + " " + descStyle.Render("Merge duplicate expenses") + "\n")
 
 		if m.HelpLevel >= 2 {
 			content.WriteString("\n" + sectionStyle.Render("EXPENSE TIPS") + "\n")
@@ -1640,6 +3168,7 @@ func (m *Model) getHelpContent() string {
 		content.WriteString(shortcutStyle.Render("[Ctrl+F]") + " " + descStyle.Render("Search templates") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+T]") + " " + descStyle.Render("Create template") + "\n")
 		content.WriteString(shortcutStyle.Render("[U]") + " " + descStyle.Render("Use template") + "\n")
+		content.WriteString(shortcutStyle.Render("[O]") + " " + descStyle.Render("Toggle sorting by name/most used") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+D]") + " " + descStyle.Render("Delete template") + "\n")
 
 		if m.HelpLevel >= 2 {
@@ -1671,7 +3200,7 @@ func (m *Model) getHelpContent() string {
 		content.WriteString("\n" + sectionStyle.Render("KEYBOARD COMBINATIONS") + "\n")
 		content.WriteString(tipStyle.Render("• [Ctrl+F] + [Enter] = Quick search") + "\n")
 		content.WriteString(tipStyle.Render("• [Ctrl+E] + [Tab] = Edit next field") + "\n")
-		content.WriteString(tipStyle.Render("• [Ctrl+X] + [Ctrl+R] = Add expense to trip") + "\n")
+		content.WriteString(tipStyle.Render("• [↑/↓] + [Ctrl+X] = Add expense to the selected trip") + "\n")
 
 		content.WriteString("\n" + sectionStyle.Render("DEVELOPER TOOLS") + "\n")
 		content.WriteString(shortcutStyle.Render("[Ctrl+Shift+L]") + " " + descStyle.Render("Show logs") + "\n")
@@ -1717,6 +3246,24 @@ func (m *Model) renderHelpOverlay() string {
 	return overlayStyle.Render(helpContent)
 }
 
+// duplicateTripCount returns how many trips MergeDuplicates would remove
+func (m *Model) duplicateTripCount() int {
+	count := 0
+	for _, group := range (&model.StorageData{Trips: m.Trips}).FindDuplicateTrips() {
+		count += len(group) - 1
+	}
+	return count
+}
+
+// duplicateExpenseCount returns how many expenses MergeDuplicateExpenses would remove
+func (m *Model) duplicateExpenseCount() int {
+	count := 0
+	for _, group := range m.Data.FindDuplicateExpenses() {
+		count += len(group) - 1
+	}
+	return count
+}
+
 // renderStatusBar renders the status bar with current mode and context information
 func (m *Model) renderStatusBar() string {
 	// Create status bar styles
@@ -1731,6 +3278,9 @@ func (m *Model) renderStatusBar() string {
 
 	// Build status information
 	statusInfo := fmt.Sprintf("%s | Mode: %s", currentTab, m.Mode)
+	if m.ActiveFamily != "" {
+		statusInfo += fmt.Sprintf(" | Family: %s", m.ActiveFamily)
+	}
 
 	// Add context-specific information
 	switch m.ActiveTab {
@@ -1741,11 +3291,23 @@ func (m *Model) renderStatusBar() string {
 		if len(m.Trips) > 0 {
 			statusInfo += fmt.Sprintf(" | %d trips", len(m.Trips))
 		}
+		if count := m.duplicateTripCount(); count > 0 {
+			statusInfo += fmt.Sprintf(" | ⚠ %d duplicate trips ([D] to merge)", count)
+		}
 	case TabExpenses:
+		if m.SearchMode {
+			statusInfo += fmt.Sprintf(" | Search: \"%s\"", m.SearchQuery)
+		}
 		if len(m.Data.Expenses) > 0 {
 			statusInfo += fmt.Sprintf(" | %d expenses", len(m.Data.Expenses))
 		}
+		if count := m.duplicateExpenseCount(); count > 0 {
+			statusInfo += fmt.Sprintf(" | ⚠ %d duplicate expenses ([D] to merge)", count)
+		}
 	case TabTemplates:
+		if m.SearchMode {
+			statusInfo += fmt.Sprintf(" | Search: \"%s\"", m.SearchQuery)
+		}
 		if len(m.TripTemplates) > 0 {
 			statusInfo += fmt.Sprintf(" | %d templates", len(m.TripTemplates))
 		}
@@ -1805,19 +3367,21 @@ func (m *Model) renderContextualControls() string {
 	// ACTIONS (context-specific)
 	switch m.ActiveTab {
 	case TabWeeklySummaries:
-		s.WriteString(actionStyle.Render("ACTIONS:     ←/→ Switch weeks") + "\n")
+		s.WriteString(actionStyle.Render("ACTIONS:     ←/→ Switch weeks  [G] Go to date") + "\n")
 	case TabTrips:
-		s.WriteString(actionStyle.Render("ACTIONS:     [Ctrl+E] Edit  [Ctrl+F] Search  [Ctrl+T] Template") + "\n")
+		s.WriteString(actionStyle.Render("ACTIONS:     [Ctrl+E] Edit  [Ctrl+Y] Duplicate  [Ctrl+F] Search  [Ctrl+T] Template") + "\n")
 	case TabExpenses:
-		s.WriteString(actionStyle.Render("ACTIONS:     [Ctrl+E] Edit  [Ctrl+F] Filter") + "\n")
-	case TabTemplates:
 		s.WriteString(actionStyle.Render("ACTIONS:     [Ctrl+E] Edit  [Ctrl+F] Search") + "\n")
+	case TabTemplates:
+		s.WriteString(actionStyle.Render("ACTIONS:     [Ctrl+E] Edit  [Ctrl+F] Search  [O] Sort by name/most used") + "\n")
 	}
 
 	// QUICK ADD (context-specific)
 	switch m.ActiveTab {
+	case TabWeeklySummaries:
+		s.WriteString(quickAddStyle.Render("QUICK ADD:   [Ctrl+A] Mileage adjustment") + "\n")
 	case TabTrips:
-		s.WriteString(quickAddStyle.Render("QUICK ADD:   [Ctrl+X] Expense  [Ctrl+R] Recurring") + "\n")
+		s.WriteString(quickAddStyle.Render("QUICK ADD:   [Ctrl+X] Expense  [Ctrl+R] Recurring  [X] Extend recurring") + "\n")
 	case TabExpenses:
 		s.WriteString(quickAddStyle.Render("QUICK ADD:   [Ctrl+X] Add expense") + "\n")
 	case TabTemplates:
@@ -1826,7 +3390,11 @@ func (m *Model) renderContextualControls() string {
 
 	// DELETE (context-specific)
 	switch m.ActiveTab {
-	case TabTrips, TabExpenses, TabTemplates:
+	case TabTrips:
+		s.WriteString(destructiveStyle.Render("DELETE:      [Ctrl+D] Archive (delete if archived)  [Ctrl+B] Delete range  [D] Merge duplicates") + "\n")
+	case TabExpenses:
+		s.WriteString(destructiveStyle.Render("DELETE:      [Ctrl+D] Delete selected  [D] Merge duplicates") + "\n")
+	case TabTemplates:
 		s.WriteString(destructiveStyle.Render("DELETE:      [Ctrl+D] Delete selected") + "\n")
 	}
 
@@ -1856,28 +3424,182 @@ func (m *Model) CalculateReimbursement(trips []model.Trip, ratePerMile float64)
 func (m *Model) AddTrip(trip model.Trip) {
 	m.Trips = append(m.Trips, trip)
 	m.Data.Trips = m.Trips
-	model.CalculateAndUpdateWeeklySummaries(m.Data, m.RatePerMile)
+	m.updateWeeklySummaries()
 	if err := m.Storage.SaveData(m.Data); err != nil {
 		m.Err = err
 	}
 }
 
-// Helper: find the index of the week containing today
-func (m *Model) getCurrentWeekIndex() int {
-	today := time.Now().Format("2006-01-02")
-	todayTime, err := time.Parse("2006-01-02", today)
+// today returns the current date in YYYY-MM-DD format, honoring Data.ReferenceDate when set
+func (m *Model) today() string {
+	if m.Data.ReferenceDate != "" {
+		return m.Data.ReferenceDate
+	}
+	return time.Now().Format("2006-01-02")
+}
+
+// todayTime returns today() as a time, falling back to the clock if the reference date is malformed
+func (m *Model) todayTime() time.Time {
+	now, err := time.Parse("2006-01-02", m.today())
 	if err != nil {
-		return 0
+		return time.Now()
 	}
-	for i, summary := range m.Data.WeeklySummaries {
-		start, err1 := time.Parse("2006-01-02", summary.WeekStart)
-		end, err2 := time.Parse("2006-01-02", summary.WeekEnd)
-		if err1 != nil || err2 != nil {
-			continue
+	return now
+}
+
+// validateTrip validates a trip, rejecting dates too far past today when MaxFutureDays is set
+// and distances over MaxTripMiles when that is set
+func (m *Model) validateTrip(trip model.Trip) error {
+	now := m.todayTime()
+	if err := trip.ValidateWithBounds(now, m.MaxFutureDays); err != nil {
+		return err
+	}
+	return trip.ValidateMaxMiles(m.MaxTripMiles)
+}
+
+// setRecurringLimit sets when a recurring trip stops from user input: either an end date
+// (YYYY-MM-DD) or the number of trips to generate. It replaces any earlier limit.
+func setRecurringLimit(rt *model.RecurringTrip, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("enter an end date (YYYY-MM-DD) or a number of trips")
+	}
+
+	var endDate string
+	var occurrences int
+	if count, err := strconv.Atoi(value); err == nil {
+		if count <= 0 {
+			return fmt.Errorf("number of trips must be greater than 0")
 		}
-		if !todayTime.Before(start) && !todayTime.After(end) {
-			return i
+		occurrences = count
+	} else {
+		endDate = value
+	}
+
+	// Validate the limit against the start date using dummy values for the rest
+	tempTrip := model.RecurringTrip{
+		StartDate:   rt.StartDate,
+		EndDate:     endDate,
+		Occurrences: occurrences,
+		Origin:      "temp",   // Dummy value for validation
+		Destination: "temp",   // Dummy value for validation
+		Miles:       1.0,      // Dummy value for validation
+		Type:        "single", // Dummy value for validation
+	}
+	if err := tempTrip.Validate(); err != nil {
+		return err
+	}
+	rt.EndDate, rt.Occurrences = endDate, occurrences
+	return nil
+}
+
+// tripConfirmation describes a saved trip, including the doubled distance of round trips
+func tripConfirmation(verb string, trip model.Trip) string {
+	message := fmt.Sprintf("%s: %s → %s%s, %.2f mi", verb, trip.Origin, trip.Destination, returnLabel(trip), trip.Miles)
+	if trip.Type == "round" {
+		message += fmt.Sprintf(" (round = %.2f mi)", trip.EffectiveMiles())
+	}
+	return message
+}
+
+// deltaLabel describes a week-over-week change, e.g. "+15.00 miles, +$10.50 mileage, -$5.00 expenses"
+func deltaLabel(delta model.SummaryDelta) string {
+	return fmt.Sprintf("%+.2f miles, %s mileage, %s expenses",
+		delta.Miles, signedDollars(delta.Amount), signedDollars(delta.Expenses))
+}
+
+// signedDollars formats an amount with an explicit sign ahead of the dollar sign
+func signedDollars(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", -amount)
+	}
+	return fmt.Sprintf("+$%.2f", amount)
+}
+
+// returnLabel shows where a round trip returns by way of when it doesn't retrace its route
+func returnLabel(trip model.Trip) string {
+	if trip.ReturnDestination == "" {
+		return ""
+	}
+	return " → " + trip.ReturnDestination
+}
+
+// emptyStateHint tells a new user how to get started on the active tab. It is empty
+// once the tab has anything to show, so filters and searches that match nothing still
+// get the plain "No ... available." message.
+func (m *Model) emptyStateHint() string {
+	switch m.ActiveTab {
+	case TabWeeklySummaries:
+		if len(m.Data.WeeklySummaries) == 0 {
+			return "No weekly summaries yet — they appear here once you add a trip or expense. Press [Tab] to get started."
+		}
+	case TabTrips:
+		if len(m.Data.Trips) == 0 && len(m.RecurringTrips) == 0 {
+			return "No trips yet — press Enter after typing a date to add your first trip, or [Ctrl+R] for one that repeats."
+		}
+	case TabExpenses:
+		if len(m.Data.Expenses) == 0 {
+			return "No expenses yet — press [Ctrl+X] to add your first expense."
+		}
+	case TabTemplates:
+		if len(m.TripTemplates) == 0 {
+			return "No trip templates yet — press [Ctrl+T] to save a trip you make often as a template."
 		}
 	}
+	return ""
+}
+
+// recurringLabel marks trips generated from a recurring trip
+func recurringLabel(trip model.Trip) string {
+	if !trip.IsRecurring {
+		return ""
+	}
+	return " [recurring]"
+}
+
+// personalLabel marks expenses the family does not pay back
+func personalLabel(expense model.Expense) string {
+	if expense.IsReimbursable() {
+		return ""
+	}
+	return " (personal)"
+}
+
+// recurrenceLabel describes how often a recurring trip occurs
+func recurrenceLabel(rt model.RecurringTrip) string {
+	switch rt.Frequency {
+	case "biweekly":
+		return "Every other " + time.Weekday(rt.Weekday).String()
+	case "monthly":
+		return fmt.Sprintf("Monthly on day %d", rt.DayOfMonth)
+	default:
+		return "Every " + time.Weekday(rt.Weekday).String()
+	}
+}
+
+// Helper: find the index of the week containing today
+func (m *Model) getCurrentWeekIndex() int {
+	if i := model.WeekIndexContaining(m.Data.WeeklySummaries, time.Now()); i >= 0 {
+		return i
+	}
 	return 0 // fallback to most recent week
 }
+
+// getCurrentMonthWeekIndex finds the index of the earliest week overlapping the current month
+func (m *Model) getCurrentMonthWeekIndex() int {
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	monthEnd := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+
+	// Summaries are sorted most recent first, so the last overlapping week is the earliest
+	index := -1
+	for i, summary := range m.Data.WeeklySummaries {
+		if summary.WeekEnd >= monthStart && summary.WeekStart <= monthEnd {
+			index = i
+		}
+	}
+	if index < 0 {
+		return 0 // fallback to most recent week
+	}
+	return index
+}
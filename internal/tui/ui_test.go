@@ -1,16 +1,21 @@
 package ui
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	model "github.com/laurendc/nannytracker/pkg/core"
 	"github.com/laurendc/nannytracker/pkg/core/maps"
 	"github.com/laurendc/nannytracker/pkg/core/storage"
+	"github.com/muesli/termenv"
 )
 
 func setupTestUI(t *testing.T) (*Model, func()) {
@@ -25,7 +30,7 @@ func setupTestUI(t *testing.T) (*Model, func()) {
 
 	store := storage.New(storageFile)
 	mockClient := maps.NewMockClient()
-	model, err := NewWithClient(store, 0.655, mockClient)
+	model, err := NewWithClient(store, 0.655, mockClient, 10)
 	if err != nil {
 		os.RemoveAll(tempDir)
 		t.Fatalf("Failed to create UI model: %v", err)
@@ -38,6 +43,131 @@ func setupTestUI(t *testing.T) (*Model, func()) {
 	return model, cleanup
 }
 
+func TestCustomPageSize(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "nannytracker-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store := storage.New(filepath.Join(tempDir, "trips.json"))
+	uiModel, err := NewWithClient(store, 0.655, maps.NewMockClient(), 5)
+	if err != nil {
+		t.Fatalf("Failed to create UI model: %v", err)
+	}
+
+	for i := 1; i <= 12; i++ {
+		uiModel.AddTrip(model.Trip{
+			Date:        fmt.Sprintf("2024-03-%02d", i),
+			Origin:      "Home",
+			Destination: "Work",
+			Miles:       10.0,
+			Type:        "single",
+		})
+	}
+	uiModel.ActiveTab = TabTrips
+
+	view := uiModel.View()
+	if !strings.Contains(view, "Page 1 of 3 (Showing 1-5 of 12 trips)") {
+		t.Errorf("Expected 5 trips per page, got: %s", view)
+	}
+
+	// A non-positive page size falls back to the default
+	uiModel, err = NewWithClient(store, 0.655, maps.NewMockClient(), 0)
+	if err != nil {
+		t.Fatalf("Failed to create UI model: %v", err)
+	}
+	if uiModel.PageSize != defaultPageSize {
+		t.Errorf("Expected default page size %d, got %d", defaultPageSize, uiModel.PageSize)
+	}
+}
+
+func TestChangePageSizeWithKeys(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	for i := 1; i <= 25; i++ {
+		uiModel.AddTrip(model.Trip{Date: fmt.Sprintf("2024-03-%02d", i), Origin: "Home", Destination: "Work", Miles: 10.0, Type: "single"})
+	}
+	uiModel.ActiveTab = TabTrips
+	uiModel.CurrentPage = 2
+	var saved []int
+	uiModel.SavePageSize = func(size int) error {
+		saved = append(saved, size)
+		return nil
+	}
+	if view := uiModel.View(); !strings.Contains(view, "Page 3 of 3") {
+		t.Fatalf("Expected 3 pages of 10 trips, got: %s", view)
+	}
+
+	press := func(r rune) {
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		uiModel = updatedModel.(*Model)
+	}
+
+	press('+')
+	if uiModel.PageSize != 15 || uiModel.CurrentPage != 0 {
+		t.Errorf("Expected 15 rows on the first page, got %d rows on page %d", uiModel.PageSize, uiModel.CurrentPage)
+	}
+	if view := uiModel.View(); !strings.Contains(view, "Page 1 of 2 (Showing 1-15 of 25 trips)") {
+		t.Errorf("Expected the pages recomputed, got: %s", view)
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the key not to be typed, got %q", uiModel.TextInput.Value())
+	}
+
+	for i := 0; i < 10; i++ {
+		press('+')
+	}
+	if uiModel.PageSize != maxPageSize {
+		t.Errorf("Expected the page size capped at %d, got %d", maxPageSize, uiModel.PageSize)
+	}
+	for i := 0; i < 12; i++ {
+		press('-')
+	}
+	if uiModel.PageSize != minPageSize {
+		t.Errorf("Expected the page size floored at %d, got %d", minPageSize, uiModel.PageSize)
+	}
+	if len(saved) == 0 || saved[len(saved)-1] != minPageSize {
+		t.Errorf("Expected the chosen size to be remembered, got %v", saved)
+	}
+
+	// A '-' typed into a date is input, not a shortcut
+	uiModel.TextInput.SetValue("2024")
+	press('-')
+	if uiModel.PageSize != minPageSize || uiModel.TextInput.Value() != "2024-" {
+		t.Errorf("Expected '-' to be typed into the date, got %d rows and %q", uiModel.PageSize, uiModel.TextInput.Value())
+	}
+}
+
+func TestHomeAddressPrefill(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.HomeAddress = "123 Home St"
+
+	uiModel.TextInput.SetValue("2024-03-20")
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "origin" {
+		t.Fatalf("Expected mode to be origin, got %s", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != "123 Home St" {
+		t.Errorf("Expected origin prefilled with home address, got %q", uiModel.TextInput.Value())
+	}
+
+	// Pressing Enter accepts the prefilled home address
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.CurrentTrip.Origin != "123 Home St" {
+		t.Errorf("Expected origin to be home address, got %q", uiModel.CurrentTrip.Origin)
+	}
+	if uiModel.Mode != "destination" {
+		t.Errorf("Expected mode to be destination, got %s", uiModel.Mode)
+	}
+}
+
 func TestTripCreation(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
@@ -83,6 +213,32 @@ func TestTripCreation(t *testing.T) {
 	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*Model)
 
+	if uiModel.Mode != "return_destination" {
+		t.Errorf("Expected mode to be 'return_destination', got '%s'", uiModel.Mode)
+	}
+
+	// Round trips may return a different way; leave it empty to retrace the route
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "notes" {
+		t.Errorf("Expected mode to be 'notes', got '%s'", uiModel.Mode)
+	}
+
+	// Test notes input
+	uiModel.TextInput.SetValue("Ballet practice")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "tags" {
+		t.Errorf("Expected mode to be 'tags', got '%s'", uiModel.Mode)
+	}
+
+	// Test tags input
+	uiModel.TextInput.SetValue(" field-trip, ,rainy-day ")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
 	// Check for errors
 	if uiModel.Err != nil {
 		t.Errorf("Unexpected error: %v", uiModel.Err)
@@ -109,6 +265,12 @@ func TestTripCreation(t *testing.T) {
 	if trip.Type != "round" {
 		t.Errorf("Expected type to be 'round', got '%s'", trip.Type)
 	}
+	if trip.Notes != "Ballet practice" {
+		t.Errorf("Expected notes to be 'Ballet practice', got '%s'", trip.Notes)
+	}
+	if !reflect.DeepEqual(trip.Tags, []string{"field-trip", "rainy-day"}) {
+		t.Errorf("Expected tags [field-trip rainy-day], got %v", trip.Tags)
+	}
 
 	// Verify the trip is valid
 	if err := trip.Validate(); err != nil {
@@ -116,6 +278,59 @@ func TestTripCreation(t *testing.T) {
 	}
 }
 
+func TestTodayShortcut(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-20"
+
+	// Ctrl+N prefills today's date in date mode
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.TextInput.Value() != "2024-03-20" {
+		t.Fatalf("Expected date input to be prefilled with '2024-03-20', got '%s'", uiModel.TextInput.Value())
+	}
+
+	// Complete the trip using the prefilled date
+	steps := []string{"", "Home", "Work", "single", "", ""}
+	for _, value := range steps {
+		if value != "" {
+			uiModel.TextInput.SetValue(value)
+		}
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	if len(uiModel.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+	if uiModel.Trips[0].Date != "2024-03-20" {
+		t.Errorf("Expected trip date to be '2024-03-20', got '%s'", uiModel.Trips[0].Date)
+	}
+
+	// Ctrl+N also works in expense date mode
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	uiModel = updatedModel.(*Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.TextInput.Value() != "2024-03-20" {
+		t.Errorf("Expected expense date input to be prefilled with '2024-03-20', got '%s'", uiModel.TextInput.Value())
+	}
+
+	// Other modes are left alone
+	uiModel.Mode = "origin"
+	uiModel.TextInput.SetValue("Home")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.TextInput.Value() != "Home" {
+		t.Errorf("Expected origin input to be unchanged, got '%s'", uiModel.TextInput.Value())
+	}
+}
+
 func TestInvalidTripType(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
@@ -206,11 +421,27 @@ func TestUIStateTransitions(t *testing.T) {
 		t.Errorf("Expected mode to be 'type' after destination input, got '%s'", uiModel.Mode)
 	}
 
-	// Test transition back to date mode after trip completion
+	// Test transition to notes mode
 	uiModel.TextInput.SetValue("single")
 	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*Model)
 
+	if uiModel.Mode != "notes" {
+		t.Errorf("Expected mode to be 'notes' after type input, got '%s'", uiModel.Mode)
+	}
+
+	// Test transition to tags mode after skipping notes
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "tags" {
+		t.Errorf("Expected mode to be 'tags' after notes input, got '%s'", uiModel.Mode)
+	}
+
+	// Test transition back to date mode after skipping tags
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
 	if uiModel.Mode != "date" {
 		t.Errorf("Expected mode to be 'date' after trip completion, got '%s'", uiModel.Mode)
 	}
@@ -245,7 +476,7 @@ func TestWeeklySummaryDisplay(t *testing.T) {
 		}
 	}
 
-	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile)
+	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile, uiModel.RoundingMode, time.Sunday)
 
 	// Check the most recent week (default selected)
 	view := uiModel.View()
@@ -366,6 +597,7 @@ func TestExpenseEntry(t *testing.T) {
 		Date:        "2024-03-20",
 		Amount:      25.50,
 		Description: "Lunch",
+		Category:    "food",
 	}
 
 	// Simulate entering expense mode
@@ -388,6 +620,23 @@ func TestExpenseEntry(t *testing.T) {
 	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = model.(*Model)
 
+	if uiModel.Mode != "expense_category" {
+		t.Errorf("Expected mode to be 'expense_category', got '%s'", uiModel.Mode)
+	}
+
+	// Enter category
+	uiModel.TextInput.SetValue(expense.Category)
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	if uiModel.Mode != "expense_reimbursable" {
+		t.Errorf("Expected mode to be 'expense_reimbursable', got '%s'", uiModel.Mode)
+	}
+
+	// Expenses are reimbursable by default
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
 	// Verify expense was added
 	if len(uiModel.Data.Expenses) != 1 {
 		t.Errorf("Expected 1 expense, got %d", len(uiModel.Data.Expenses))
@@ -403,6 +652,9 @@ func TestExpenseEntry(t *testing.T) {
 	if addedExpense.Description != expense.Description {
 		t.Errorf("Expected description %s, got %s", expense.Description, addedExpense.Description)
 	}
+	if addedExpense.Category != expense.Category {
+		t.Errorf("Expected category %s, got %s", expense.Category, addedExpense.Category)
+	}
 
 	// Verify weekly summary was updated
 	if len(uiModel.Data.WeeklySummaries) != 1 {
@@ -413,234 +665,1504 @@ func TestExpenseEntry(t *testing.T) {
 	if summary.TotalExpenses != expense.Amount {
 		t.Errorf("Expected total expenses %.2f, got %.2f", expense.Amount, summary.TotalExpenses)
 	}
+	if summary.ExpensesByCategory["food"] != expense.Amount {
+		t.Errorf("Expected food subtotal %.2f, got %.2f", expense.Amount, summary.ExpensesByCategory["food"])
+	}
 }
 
-func TestExpenseValidation(t *testing.T) {
+func TestExpenseCategoryStep(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
 
-	// Enter expense mode first
-	msg := tea.KeyMsg{Type: tea.KeyCtrlX}
-	model, _ := uiModel.Update(msg)
-	uiModel = model.(*Model)
-
-	if uiModel.Mode != "expense_date" {
-		t.Errorf("Expected mode to be 'expense_date', got '%s'", uiModel.Mode)
-	}
+	uiModel.Mode = "expense_category"
+	uiModel.CurrentExpense = model.Expense{Date: "2024-03-20", Amount: 12.00, Description: "Craft kit"}
 
-	// Test invalid date
-	uiModel.TextInput.SetValue("invalid-date")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
+	// Unknown category is rejected
+	uiModel.TextInput.SetValue("toys")
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
 
-	// Verify we're still in expense_date mode and have an error
-	if uiModel.Mode != "expense_date" {
-		t.Errorf("Expected to stay in expense_date mode after invalid date, got '%s'", uiModel.Mode)
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "invalid category") {
+		t.Errorf("Expected error about invalid category, got: %v", uiModel.Err)
 	}
-	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "date must be in YYYY-MM-DD format") {
-		t.Errorf("Expected error about invalid date format, got: %v", uiModel.Err)
+	if len(uiModel.Data.Expenses) != 0 {
+		t.Errorf("Expected no expense to be saved, got %d", len(uiModel.Data.Expenses))
 	}
+	uiModel.Err = nil
 
-	// Test valid date but invalid amount
-	uiModel.TextInput.SetValue("2024-03-20")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
-
-	if uiModel.Mode != "expense_amount" {
-		t.Errorf("Expected mode to be 'expense_amount', got '%s'", uiModel.Mode)
+	// Skipping the category defaults to "other"
+	for _, value := range []string{"", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
 	}
 
-	uiModel.TextInput.SetValue("invalid-amount")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
-
-	// Verify we're still in expense_amount mode and have an error
-	if uiModel.Mode != "expense_amount" {
-		t.Errorf("Expected to stay in expense_amount mode after invalid amount, got '%s'", uiModel.Mode)
+	if len(uiModel.Data.Expenses) != 1 {
+		t.Fatalf("Expected 1 expense, got %d", len(uiModel.Data.Expenses))
 	}
-	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "invalid amount") {
-		t.Errorf("Expected error about invalid amount, got: %v", uiModel.Err)
+	if uiModel.Data.Expenses[0].Category != "other" {
+		t.Errorf("Expected category 'other', got '%s'", uiModel.Data.Expenses[0].Category)
 	}
 
-	// Test negative amount
-	uiModel.TextInput.SetValue("-10.00")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
+	// Weekly summary view shows the category subtotal
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+	view := uiModel.View()
+	if !strings.Contains(view, "other:") || !strings.Contains(view, "$12.00") {
+		t.Errorf("Expected weekly summary to show the 'other' subtotal, got: %s", view)
+	}
+}
 
-	// Verify we're still in expense_amount mode and have an error
-	if uiModel.Mode != "expense_amount" {
-		t.Errorf("Expected to stay in expense_amount mode after negative amount, got '%s'", uiModel.Mode)
+func TestExpenseReimbursableStep(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Mode = "expense_category"
+	uiModel.CurrentExpense = model.Expense{Date: "2024-03-20", Amount: 12.00, Description: "Coffee for me"}
+
+	var updatedModel tea.Model
+	for _, value := range []string{"food", "maybe"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
 	}
-	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "amount must be greater than 0") {
-		t.Errorf("Expected error about negative amount, got: %v", uiModel.Err)
+	if uiModel.Err == nil || uiModel.Mode != "expense_reimbursable" {
+		t.Errorf("Expected an unclear answer to be rejected, got mode %s and error %v", uiModel.Mode, uiModel.Err)
 	}
+	uiModel.Err = nil
 
-	// Test valid amount but empty description
-	uiModel.TextInput.SetValue("25.50")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
+	uiModel.TextInput.SetValue("n")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
 
-	if uiModel.Mode != "expense_description" {
-		t.Errorf("Expected mode to be 'expense_description', got '%s'", uiModel.Mode)
+	if len(uiModel.Data.Expenses) != 1 {
+		t.Fatalf("Expected 1 expense, got %d", len(uiModel.Data.Expenses))
+	}
+	if uiModel.Data.Expenses[0].IsReimbursable() {
+		t.Error("Expected the expense to be personal")
 	}
 
-	uiModel.TextInput.SetValue("")
-	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = model.(*Model)
-
-	// Verify we're still in expense_description mode and have an error
-	if uiModel.Mode != "expense_description" {
-		t.Errorf("Expected to stay in expense_description mode after empty description, got '%s'", uiModel.Mode)
+	// Personal expenses stay out of the billable total
+	summary := uiModel.Data.WeeklySummaries[0]
+	if summary.TotalExpenses != 0 || summary.TotalPersonalExpenses != 12.00 {
+		t.Errorf("Expected $0.00 billable and $12.00 personal, got $%.2f and $%.2f", summary.TotalExpenses, summary.TotalPersonalExpenses)
 	}
-	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "description cannot be empty") {
-		t.Errorf("Expected error about empty description, got: %v", uiModel.Err)
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+	if view := uiModel.View(); !strings.Contains(view, "Personal Expenses:    $12.00") || !strings.Contains(view, "Coffee for me (personal)") {
+		t.Errorf("Expected weekly summary to show the personal expense, got: %s", view)
 	}
 }
 
-func TestEditTrip(t *testing.T) {
+func TestExpenseAttachedToSelectedTrip(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
 
-	// Add a trip first
-	originalTrip := model.Trip{
-		Date:        "2024-03-20",
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       10.5,
-		Type:        "single",
-	}
-	uiModel.AddTrip(originalTrip)
-
-	// Select the trip
-	uiModel.SelectedTrip = 0
-	uiModel.ActiveTab = TabTrips // Ensure we are on the Trips tab
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 8, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Zoo", Destination: "Museum", Miles: 3, Type: "single"})
+	uiModel.ActiveTab = TabTrips
+	uiModel.SelectedTrip = 1
 
-	// Enter edit mode
-	var updatedModel tea.Model
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
 	uiModel = updatedModel.(*Model)
-
-	if uiModel.Mode != "edit" {
-		t.Errorf("Expected mode to be 'edit', got '%s'", uiModel.Mode)
+	if uiModel.Mode != "expense_date" || uiModel.TextInput.Value() != "2024-03-20" {
+		t.Fatalf("Expected the trip's date prefilled, got mode %s and %q", uiModel.Mode, uiModel.TextInput.Value())
+	}
+	for _, value := range []string{"", "6.50", "Museum parking", "", ""} {
+		if value != "" {
+			uiModel.TextInput.SetValue(value)
+		}
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if len(uiModel.Data.Expenses) != 1 {
+		t.Fatalf("Expected 1 expense, got %d", len(uiModel.Data.Expenses))
+	}
+	expense := uiModel.Data.Expenses[0]
+	if i, ok := expense.LinkedTrip(uiModel.Data.Trips); !ok || uiModel.Data.Trips[i].Destination != "Museum" {
+		t.Fatalf("Expected the expense linked to the Museum trip, got %+v", expense)
 	}
 
-	// Verify initial edit state
-	if uiModel.EditIndex != 0 {
-		t.Errorf("Expected EditIndex to be 0, got %d", uiModel.EditIndex)
+	// The expense is listed under its trip rather than with the week's other expenses
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+	view := uiModel.View()
+	museum := strings.Index(view, "Zoo → Museum")
+	parking := strings.Index(view, "└ $6.50 - Museum parking")
+	zoo := strings.Index(view, "Home → Zoo")
+	if museum < 0 || parking < 0 || zoo < 0 {
+		t.Fatalf("Expected both trips and the linked expense, got: %s", view)
 	}
-	if uiModel.CurrentTrip != originalTrip {
-		t.Errorf("Expected CurrentTrip to match original trip")
+	if parking < museum || (zoo > museum && parking > zoo) {
+		t.Errorf("Expected the parking expense directly under the Museum trip, got: %s", view)
 	}
-	if uiModel.TextInput.Value() != originalTrip.Date {
-		t.Errorf("Expected TextInput value to be '%s', got '%s'", originalTrip.Date, uiModel.TextInput.Value())
+	if !strings.Contains(view, "(No expenses available.)") {
+		t.Errorf("Expected no unlinked expenses, got: %s", view)
 	}
+}
 
-	// Edit the date
-	newDate := "2024-03-21"
-	uiModel.TextInput.SetValue(newDate)
+func TestWeeklySummaryShowsTargetProgress(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 42, Type: "single"})
+	uiModel.updateWeeklySummaries()
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+
+	if view := uiModel.View(); strings.Contains(view, "Target:") {
+		t.Errorf("Expected no target progress without a target, got: %s", view)
+	}
+
+	uiModel.WeeklyTarget = 50
+	if view := uiModel.View(); !strings.Contains(view, "42.00 / 50.00 miles (84%)") {
+		t.Errorf("Expected progress toward the weekly target, got: %s", view)
+	}
+}
+
+func TestWeeklySummaryWarnsAboutExpensesWithoutMiles(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-13", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	if err := uiModel.Data.AddExpense(model.Expense{Date: "2024-03-20", Amount: 5, Description: "Snack"}); err != nil {
+		t.Fatalf("Failed to add expense: %v", err)
+	}
+	uiModel.updateWeeklySummaries()
+	uiModel.ActiveTab = TabWeeklySummaries
+
+	uiModel.SelectedWeek = 0
+	if view := uiModel.View(); !strings.Contains(view, "Expenses logged but no miles this week") {
+		t.Errorf("Expected a warning for the expense-only week, got: %s", view)
+	}
+
+	uiModel.SelectedWeek = 1
+	if view := uiModel.View(); strings.Contains(view, "no miles this week") {
+		t.Errorf("Expected no warning for a week with trips, got: %s", view)
+	}
+}
+
+func TestAddMileageAdjustment(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-13", Origin: "Home", Destination: "School", Miles: 15, Type: "round"})
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "adjustment_date" {
+		t.Fatalf("Expected mode to be 'adjustment_date', got '%s'", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != "2024-03-10" {
+		t.Errorf("Expected the selected week's start to be prefilled, got %q", uiModel.TextInput.Value())
+	}
+
+	enter := func(value string) {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	enter("2024-03-14")
+	enter("-40")
+	if uiModel.Mode != "adjustment_reason" {
+		t.Fatalf("Expected mode to be 'adjustment_reason', got '%s'", uiModel.Mode)
+	}
+	enter("School run logged twice")
+	if uiModel.Err == nil || len(uiModel.Data.Adjustments) != 0 || len(uiModel.UndoStack) != 0 {
+		t.Fatalf("Expected an adjustment below zero miles to be rejected, got err=%v adjustments=%v", uiModel.Err, uiModel.Data.Adjustments)
+	}
+
+	uiModel.Err = nil
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlA})
+	uiModel = updatedModel.(*Model)
+	enter("2024-03-14")
+	enter("-15")
+	enter("School run logged twice")
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.Mode != "date" || uiModel.StatusMessage != "Adjusted the week of 2024-03-14 by -15.00 miles" {
+		t.Errorf("Expected to return to date mode with a confirmation, got mode %q and status %q", uiModel.Mode, uiModel.StatusMessage)
+	}
+	if summary := uiModel.Data.WeeklySummaries[0]; summary.TotalMiles != 15 {
+		t.Errorf("Expected 15 miles after the adjustment, got %.2f", summary.TotalMiles)
+	}
+	view := uiModel.View()
+	if !strings.Contains(view, "Adjustments:          -15.00 miles") || !strings.Contains(view, "Adjustment -15.00 miles - School run logged twice") {
+		t.Errorf("Expected the adjustment in the weekly summary, got: %s", view)
+	}
+
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.Adjustments) != 1 || saved.Adjustments[0].Family != model.DefaultFamily {
+		t.Errorf("Expected the adjustment to be saved for the default family, got %+v", saved.Adjustments)
+	}
+
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	uiModel = updatedModel.(*Model)
+	if len(uiModel.Data.Adjustments) != 0 || uiModel.Data.WeeklySummaries[0].TotalMiles != 30 {
+		t.Errorf("Expected undo to remove the adjustment, got %+v", uiModel.Data.Adjustments)
+	}
+}
+
+func TestWeeklySummaryShowsPreviousWeekDelta(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-13", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 25, Type: "single"})
+	if err := uiModel.Data.AddExpense(model.Expense{Date: "2024-03-14", Amount: 5, Description: "Snack"}); err != nil {
+		t.Fatalf("Failed to add expense: %v", err)
+	}
+	uiModel.updateWeeklySummaries()
+	uiModel.ActiveTab = TabWeeklySummaries
+
+	uiModel.SelectedWeek = 0
+	if view := uiModel.View(); !strings.Contains(view, "vs Previous Week:     +15.00 miles") || !strings.Contains(view, "-$5.00 expenses") {
+		t.Errorf("Expected the week-over-week change, got: %s", view)
+	}
+
+	// The oldest week has nothing to compare against
+	uiModel.SelectedWeek = 1
+	if view := uiModel.View(); strings.Contains(view, "vs Previous Week") {
+		t.Errorf("Expected no comparison for the first week, got: %s", view)
+	}
+}
+
+func TestExportSelectedWeek(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.DataDir = t.TempDir()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	if err := uiModel.Data.AddExpense(model.Expense{Date: "2024-03-21", Amount: 5, Description: "Snack"}); err != nil {
+		t.Fatalf("Failed to add expense: %v", err)
+	}
+	uiModel.updateWeeklySummaries()
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+
+	path := filepath.Join(uiModel.DataDir, "week-2024-03-17.json")
+	if !strings.Contains(uiModel.StatusMessage, path) {
+		t.Errorf("Expected the status to show %s, got %q", path, uiModel.StatusMessage)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var summary model.WeeklySummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if summary.TotalMiles != 10 || summary.TotalExpenses != 5 || len(summary.Trips) != 1 || len(summary.Expenses) != 1 {
+		t.Errorf("Expected the week's detail in the export, got %+v", summary)
+	}
+}
+
+func TestPrintCurrentView(t *testing.T) {
+	// Render with colors, as in a terminal, so there are escape sequences to strip
+	profile := lipgloss.ColorProfile()
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	defer lipgloss.SetColorProfile(profile)
+
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.DataDir = t.TempDir()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-21", Origin: "Home", Destination: "School", Miles: 4, Type: "round"})
+	uiModel.ActiveTab = TabTrips
+	if view := uiModel.View(); !strings.Contains(view, "\x1b[") {
+		t.Fatal("Expected the styled view to contain escape sequences")
+	}
+
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+
+	path := filepath.Join(uiModel.DataDir, "trips.txt")
+	if uiModel.StatusMessage != "Printed view to "+path {
+		t.Errorf("Expected the status to show %s, got %q", path, uiModel.StatusMessage)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read printout: %v", err)
+	}
+	text := string(raw)
+	if strings.Contains(text, "\x1b") {
+		t.Errorf("Expected no escape sequences in the printout, got %q", text)
+	}
+	for _, want := range []string{"Home → Work (10.00 miles) [single]", "Home → School (8.00 miles) [round]"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the printout to contain %q, got:\n%s", want, text)
+		}
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to be typed, got %q", uiModel.TextInput.Value())
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"plain text", "plain text"},
+		{"\x1b[1;38;2;0;255;0mTrips\x1b[0m", "Trips"},
+		{"\x1b[2K\x1b[3Aweek", "week"},
+		{"\x1b]8;;https://example.com\x07link\x1b]8;;\x07", "link"},
+	}
+	for _, tt := range tests {
+		if got := StripANSI(tt.input); got != tt.expected {
+			t.Errorf("StripANSI(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestRecurringTripMarker(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-18", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 4, Type: "single", IsRecurring: true})
+	uiModel.updateWeeklySummaries()
+
+	uiModel.ActiveTab = TabTrips
+	if view := uiModel.View(); !strings.Contains(view, "Home → School (4.00 miles) [single] [recurring]") {
+		t.Errorf("Expected the generated trip to be marked, got view:\n%s", view)
+	}
+
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+	if view := uiModel.View(); !strings.Contains(view, "Home → School (4.00 miles) [single] [recurring]") {
+		t.Errorf("Expected the weekly summary to mark the generated trip, got view:\n%s", view)
+	}
+
+	// R hides generated trips from the summary list
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	uiModel = updatedModel.(*Model)
+	if !uiModel.HideRecurring {
+		t.Fatal("Expected R to hide recurring trips")
+	}
+	view := uiModel.View()
+	if strings.Contains(view, "Home → School") || !strings.Contains(view, "Home → Work") {
+		t.Errorf("Expected only the regular trip in the summary, got view:\n%s", view)
+	}
+}
+
+func TestExpenseValidation(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Enter expense mode first
+	msg := tea.KeyMsg{Type: tea.KeyCtrlX}
+	model, _ := uiModel.Update(msg)
+	uiModel = model.(*Model)
+
+	if uiModel.Mode != "expense_date" {
+		t.Errorf("Expected mode to be 'expense_date', got '%s'", uiModel.Mode)
+	}
+
+	// Test invalid date
+	uiModel.TextInput.SetValue("invalid-date")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	// Verify we're still in expense_date mode and have an error
+	if uiModel.Mode != "expense_date" {
+		t.Errorf("Expected to stay in expense_date mode after invalid date, got '%s'", uiModel.Mode)
+	}
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "date must be in YYYY-MM-DD format") {
+		t.Errorf("Expected error about invalid date format, got: %v", uiModel.Err)
+	}
+
+	// Test valid date but invalid amount
+	uiModel.TextInput.SetValue("2024-03-20")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	if uiModel.Mode != "expense_amount" {
+		t.Errorf("Expected mode to be 'expense_amount', got '%s'", uiModel.Mode)
+	}
+
+	uiModel.TextInput.SetValue("invalid-amount")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	// Verify we're still in expense_amount mode and have an error
+	if uiModel.Mode != "expense_amount" {
+		t.Errorf("Expected to stay in expense_amount mode after invalid amount, got '%s'", uiModel.Mode)
+	}
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "invalid amount") {
+		t.Errorf("Expected error about invalid amount, got: %v", uiModel.Err)
+	}
+
+	// Test negative amount
+	uiModel.TextInput.SetValue("-10.00")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	// Verify we're still in expense_amount mode and have an error
+	if uiModel.Mode != "expense_amount" {
+		t.Errorf("Expected to stay in expense_amount mode after negative amount, got '%s'", uiModel.Mode)
+	}
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "amount must be greater than 0") {
+		t.Errorf("Expected error about negative amount, got: %v", uiModel.Err)
+	}
+
+	// Test valid amount but empty description
+	uiModel.TextInput.SetValue("25.50")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	if uiModel.Mode != "expense_description" {
+		t.Errorf("Expected mode to be 'expense_description', got '%s'", uiModel.Mode)
+	}
+
+	uiModel.TextInput.SetValue("")
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	// Verify we're still in expense_description mode and have an error
+	if uiModel.Mode != "expense_description" {
+		t.Errorf("Expected to stay in expense_description mode after empty description, got '%s'", uiModel.Mode)
+	}
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "description cannot be empty") {
+		t.Errorf("Expected error about empty description, got: %v", uiModel.Err)
+	}
+}
+
+func TestEditTrip(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Add a trip first
+	originalTrip := model.Trip{
+		Date:        "2024-03-20",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       10.5,
+		Type:        "single",
+	}
+	uiModel.AddTrip(originalTrip)
+
+	// Select the trip
+	uiModel.SelectedTrip = 0
+	uiModel.ActiveTab = TabTrips // Ensure we are on the Trips tab
+
+	// Enter edit mode
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "edit" {
+		t.Errorf("Expected mode to be 'edit', got '%s'", uiModel.Mode)
+	}
+
+	// Verify initial edit state
+	if uiModel.EditIndex != 0 {
+		t.Errorf("Expected EditIndex to be 0, got %d", uiModel.EditIndex)
+	}
+	if !reflect.DeepEqual(uiModel.CurrentTrip, originalTrip) {
+		t.Errorf("Expected CurrentTrip to match original trip")
+	}
+	if uiModel.TextInput.Value() != originalTrip.Date {
+		t.Errorf("Expected TextInput value to be '%s', got '%s'", originalTrip.Date, uiModel.TextInput.Value())
+	}
+
+	// Edit the date
+	newDate := "2024-03-21"
+	uiModel.TextInput.SetValue(newDate)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Edit origin
+	uiModel.TextInput.SetValue("Updated Home")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Edit destination
+	uiModel.TextInput.SetValue("Updated Work")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Edit trip type
+	uiModel.TextInput.SetValue("round")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Verify final state
+	if len(uiModel.Trips) != 1 {
+		t.Errorf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+
+	editedTrip := uiModel.Trips[0]
+	if editedTrip.Date != newDate {
+		t.Errorf("Expected final date to be '%s', got '%s'", newDate, editedTrip.Date)
+	}
+	if editedTrip.Origin != "Updated Home" {
+		t.Errorf("Expected origin to be 'Updated Home', got '%s'", editedTrip.Origin)
+	}
+	if editedTrip.Destination != "Updated Work" {
+		t.Errorf("Expected destination to be 'Updated Work', got '%s'", editedTrip.Destination)
+	}
+	if editedTrip.Type != "round" {
+		t.Errorf("Expected type to be 'round', got '%s'", editedTrip.Type)
+	}
+
+	// Verify edit mode was cleared
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to reset to 'date', got '%s'", uiModel.Mode)
+	}
+	if uiModel.EditIndex != -1 {
+		t.Errorf("Expected EditIndex to reset to -1, got %d", uiModel.EditIndex)
+	}
+}
+
+func TestEditTripRouteRecalculatesMiles(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	mock := maps.NewMockClient()
+	mock.MockDistance = 7.25
+	uiModel.MapsClient = mock
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10.5, Type: "single"})
+	uiModel.SelectedTrip = 0
+	uiModel.ActiveTab = TabTrips
+
+	edit := func(values ...string) {
+		t.Helper()
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+		uiModel = updatedModel.(*Model)
+		for _, value := range values {
+			uiModel.TextInput.SetValue(value)
+			updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+			uiModel = updatedModel.(*Model)
+		}
+		if uiModel.Err != nil {
+			t.Fatalf("Unexpected error: %v", uiModel.Err)
+		}
+		if uiModel.Mode != "date" {
+			t.Fatalf("Expected the edit to finish, got mode %s", uiModel.Mode)
+		}
+	}
+
+	// Changing only the date and type keeps the stored miles
+	edit("2024-03-21", "Home", "Work", "round")
+	if trip := uiModel.Trips[0]; trip.Miles != 10.5 || mock.Calls() != 0 {
+		t.Errorf("Expected miles to be kept without a lookup, got %.2f after %d lookups", trip.Miles, mock.Calls())
+	}
+
+	// Correcting the destination looks the distance up again
+	uiModel.SelectedTrip = 0
+	edit("2024-03-21", "Home", "Office", "round")
+	if trip := uiModel.Trips[0]; trip.Destination != "Office" || trip.Miles != 7.25 || mock.Calls() != 1 {
+		t.Errorf("Expected the new route's miles of 7.25, got %+v after %d lookups", trip, mock.Calls())
+	}
+	if saved, err := uiModel.Storage.LoadData(); err != nil || saved.Trips[0].Miles != 7.25 {
+		t.Errorf("Expected the recalculated miles to be saved, got %+v (err %v)", saved, err)
+	}
+}
+
+func TestDeleteTrip(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Add a trip first; only archived trips can be deleted outright
+	trip := model.Trip{
+		Date:        "2024-03-20",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       10.5,
+		Archived:    true,
+	}
+	uiModel.AddTrip(trip)
+
+	// Select the trip
+	uiModel.SelectedTrip = 0
+
+	// Set active tab to Trips (required for delete to work)
+	uiModel.ActiveTab = TabTrips
+	uiModel.ShowArchived = true
+
+	// Enter delete confirmation mode
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+
+	// Verify we're in delete confirmation mode
+	if uiModel.Mode != "delete_confirm" {
+		t.Errorf("Expected mode to be 'delete_confirm', got '%s'", uiModel.Mode)
+	}
+
+	// Test cancellation by entering something other than 'yes'
+	uiModel.TextInput.SetValue("no")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Verify trip wasn't deleted and mode was reset
+	if len(uiModel.Trips) != 1 {
+		t.Errorf("Expected 1 trip after cancellation, got %d", len(uiModel.Trips))
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to be 'date' after cancellation, got '%s'", uiModel.Mode)
+	}
+
+	// Enter delete confirmation mode again
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+
+	// Confirm deletion by entering 'yes'
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	// Verify trip was deleted and mode was reset
+	if len(uiModel.Trips) != 0 {
+		t.Errorf("Expected 0 trips after deletion, got %d", len(uiModel.Trips))
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to be 'date' after deletion, got '%s'", uiModel.Mode)
+	}
+}
+
+func TestArchiveTrip(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10.5, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-19", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"})
+	uiModel.ActiveTab = TabTrips
+	uiModel.View()
+
+	// Ctrl+D archives the selected trip instead of deleting it
+	uiModel.SelectedTrip = 0
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode == "delete_confirm" {
+		t.Fatal("Expected Ctrl+D to archive without asking for confirmation")
+	}
+	if len(uiModel.Trips) != 2 {
+		t.Fatalf("Expected archiving to keep the trip, got %d trips", len(uiModel.Trips))
+	}
+	if !strings.Contains(uiModel.StatusMessage, "Archived") {
+		t.Errorf("Expected an archive confirmation, got %q", uiModel.StatusMessage)
+	}
+	if len(uiModel.Data.WeeklySummaries) != 1 || uiModel.Data.WeeklySummaries[0].TotalMiles != 4.0 {
+		t.Errorf("Expected the summary to leave out the archived trip, got %+v", uiModel.Data.WeeklySummaries)
+	}
+	view := uiModel.View()
+	if strings.Contains(view, "Home → Work (10.50 miles)") {
+		t.Error("Expected the archived trip hidden from the list")
+	}
+
+	// A shows archived trips, listed after the others
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+	uiModel = updatedModel.(*Model)
+	if !uiModel.ShowArchived {
+		t.Fatal("Expected A to show archived trips")
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to reach the input, got %q", uiModel.TextInput.Value())
+	}
+	view = uiModel.View()
+	if !strings.Contains(view, "[archived]") || !strings.Contains(view, "(including archived)") {
+		t.Error("Expected the archived trip listed and labelled")
+	}
+	if !uiModel.Trips[1].Archived {
+		t.Fatalf("Expected the archived trip sorted last, got %+v", uiModel.Trips)
+	}
+
+	// R restores the selected archived trip
+	uiModel.SelectedTrip = 1
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	uiModel = updatedModel.(*Model)
+	if len(model.UnarchivedTrips(uiModel.Trips)) != 2 {
+		t.Errorf("Expected the trip restored, got %+v", uiModel.Trips)
+	}
+	if len(uiModel.Data.WeeklySummaries) != 1 || uiModel.Data.WeeklySummaries[0].TotalMiles != 14.5 {
+		t.Errorf("Expected the restored trip back in the summary, got %+v", uiModel.Data.WeeklySummaries)
+	}
+}
+
+func TestBulkDeleteTrips(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	for _, date := range []string{"2024-02-28", "2024-03-06", "2024-03-13", "2024-04-03"} {
+		uiModel.AddTrip(model.Trip{Date: date, Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	}
+
+	uiModel.ActiveTab = TabTrips
+
+	// Enter bulk delete mode
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlB})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "bulk_delete_from" {
+		t.Fatalf("Expected mode to be 'bulk_delete_from', got '%s'", uiModel.Mode)
+	}
+
+	// Invalid date is rejected
+	uiModel.TextInput.SetValue("March 1")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err == nil {
+		t.Error("Expected error for invalid date")
+	}
+	uiModel.Err = nil
+
+	uiModel.TextInput.SetValue("2024-03-01")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	uiModel.TextInput.SetValue("2024-03-31")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "bulk_delete_confirm" {
+		t.Fatalf("Expected mode to be 'bulk_delete_confirm', got '%s'", uiModel.Mode)
+	}
+	if !strings.Contains(uiModel.TextInput.Placeholder, "delete 2 trip(s)") {
+		t.Errorf("Expected confirmation to mention 2 trips, got '%s'", uiModel.TextInput.Placeholder)
+	}
+
+	// Confirm deletion
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if len(uiModel.Trips) != 2 {
+		t.Errorf("Expected 2 trips after bulk deletion, got %d", len(uiModel.Trips))
+	}
+	for _, trip := range uiModel.Trips {
+		if trip.Date >= "2024-03-01" && trip.Date <= "2024-03-31" {
+			t.Errorf("Expected trip on %s to be deleted", trip.Date)
+		}
+	}
+	if len(uiModel.Data.WeeklySummaries) != 2 {
+		t.Errorf("Expected 2 weekly summaries after bulk deletion, got %d", len(uiModel.Data.WeeklySummaries))
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to be 'date' after deletion, got '%s'", uiModel.Mode)
+	}
+}
+
+func TestExtendRecurringTrips(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-01"
+	uiModel.Data.RecurringTrips = []model.RecurringTrip{
+		{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3},
+	}
+	if err := uiModel.Data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	uiModel.Trips = uiModel.Data.Trips
+	uiModel.RecurringTrips = uiModel.Data.RecurringTrips
+	uiModel.ActiveTab = TabTrips
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "recurring_extend" {
+		t.Fatalf("Expected mode to be 'recurring_extend', got '%s'", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to be typed, got %q", uiModel.TextInput.Value())
+	}
+
+	uiModel.TextInput.SetValue("2024-04-30")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if len(uiModel.Trips) != 8 {
+		t.Errorf("Expected 8 trips after extending through April, got %d", len(uiModel.Trips))
+	}
+	if uiModel.StatusMessage != "Extended recurring trips to 2024-04-30: 4 new trip(s)" {
+		t.Errorf("Unexpected status message %q", uiModel.StatusMessage)
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to return to 'date', got '%s'", uiModel.Mode)
+	}
+
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.Trips) != 8 || saved.RecurringTrips[0].EndDate != "2024-04-30" {
+		t.Errorf("Expected the extension to be saved, got %d trips ending %s", len(saved.Trips), saved.RecurringTrips[0].EndDate)
+	}
+}
+
+func TestDateFormatEntryAndDisplay(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.SetDateFormat(model.DateFormatUS)
+	if !strings.Contains(uiModel.TextInput.Placeholder, "MM/DD/YYYY") {
+		t.Errorf("Expected the prompt to ask for MM/DD/YYYY, got %q", uiModel.TextInput.Placeholder)
+	}
+
+	// A mistyped date names the configured format
+	uiModel.TextInput.SetValue("20/03/2024")
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "MM/DD/YYYY") {
+		t.Fatalf("Expected a format error naming MM/DD/YYYY, got %v", uiModel.Err)
+	}
+	uiModel.Err = nil
+
+	uiModel.TextInput.SetValue("3/20/2024")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.CurrentTrip.Date != "2024-03-20" {
+		t.Errorf("Expected the date to be stored as 2024-03-20, got %q", uiModel.CurrentTrip.Date)
+	}
+	if uiModel.Mode != "origin" {
+		t.Errorf("Expected mode to be 'origin', got '%s'", uiModel.Mode)
+	}
+
+	// Stored dates are shown in the configured format
+	uiModel.Data.Trips = []model.Trip{{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}}
+	uiModel.Trips = uiModel.Data.Trips
+	uiModel.ActiveTab = TabTrips
+	view := uiModel.View()
+	if !strings.Contains(view, "03/20/2024: Home → Work") {
+		t.Errorf("Expected the trip date in MM/DD/YYYY, got:\n%s", view)
+	}
+}
+
+func TestMergeDuplicateTrips(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.Trips = []model.Trip{
+		{Date: "2024-03-06", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+		{Date: "2024-03-06", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+		{Date: "2024-03-06", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "round"},
+	}
+	uiModel.Trips = uiModel.Data.Trips
+	uiModel.ActiveTab = TabTrips
+
+	if status := uiModel.renderStatusBar(); !strings.Contains(status, "1 duplicate trips") {
+		t.Errorf("Expected status bar to warn about 1 duplicate, got: %s", status)
+	}
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "dedupe_confirm" {
+		t.Fatalf("Expected mode to be 'dedupe_confirm', got '%s'", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to be typed, got %q", uiModel.TextInput.Value())
+	}
+
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if len(uiModel.Trips) != 2 {
+		t.Errorf("Expected 2 trips after merging, got %d", len(uiModel.Trips))
+	}
+	if uiModel.StatusMessage != "Merged 1 duplicate trip(s)" {
+		t.Errorf("Unexpected status message %q", uiModel.StatusMessage)
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to return to 'date', got '%s'", uiModel.Mode)
+	}
+	if strings.Contains(uiModel.renderStatusBar(), "duplicate") {
+		t.Error("Expected no duplicate warning after merging")
+	}
+
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.Trips) != 2 {
+		t.Errorf("Expected the merge to be saved, got %d trips", len(saved.Trips))
+	}
+}
+
+func TestMergeDuplicateExpenses(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.Expenses = []model.Expense{
+		{Date: "2024-03-06", Amount: 15.00, Description: "Zoo tickets"},
+		{Date: "2024-03-06", Amount: 15.00, Description: "Zoo tickets"},
+		// Same amount, different description: not a duplicate
+		{Date: "2024-03-06", Amount: 15.00, Description: "Zoo parking"},
+	}
+	uiModel.updateWeeklySummaries()
+	uiModel.ActiveTab = TabExpenses
+
+	if status := uiModel.renderStatusBar(); !strings.Contains(status, "1 duplicate expenses") {
+		t.Errorf("Expected status bar to warn about 1 duplicate, got: %s", status)
+	}
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "expense_dedupe_confirm" {
+		t.Fatalf("Expected mode to be 'expense_dedupe_confirm', got '%s'", uiModel.Mode)
+	}
+
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if len(uiModel.Data.Expenses) != 2 {
+		t.Errorf("Expected 2 expenses after merging, got %d", len(uiModel.Data.Expenses))
+	}
+	if uiModel.StatusMessage != "Merged 1 duplicate expense(s)" {
+		t.Errorf("Unexpected status message %q", uiModel.StatusMessage)
+	}
+	if len(uiModel.Data.WeeklySummaries) != 1 || uiModel.Data.WeeklySummaries[0].TotalExpenses != 30.00 {
+		t.Errorf("Expected the summary recalculated to $30.00 of expenses, got %+v", uiModel.Data.WeeklySummaries)
+	}
+	if strings.Contains(uiModel.renderStatusBar(), "duplicate") {
+		t.Error("Expected no duplicate warning after merging")
+	}
+
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(saved.Expenses) != 2 {
+		t.Errorf("Expected the merge to be saved, got %d expenses", len(saved.Expenses))
+	}
+}
+
+func TestTripFutureDateLimit(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-20"
+	uiModel.MaxFutureDays = 365
+
+	var updatedModel tea.Model
+	for _, value := range []string{"2204-03-20", "Home", "Work", "single", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	if uiModel.Err == nil {
+		t.Error("Expected error for trip dated far in the future")
+	}
+	if len(uiModel.Trips) != 0 {
+		t.Errorf("Expected no trips to be saved, got %d", len(uiModel.Trips))
+	}
+}
+
+func TestTripMaxMilesLimit(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// The mock client reports 10 miles for every trip
+	uiModel.MaxTripMiles = 5
+
+	var updatedModel tea.Model
+	for _, value := range []string{"2024-03-20", "Home", "Work", "single"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	if uiModel.Err == nil {
+		t.Error("Expected error for trip longer than the mile limit")
+	}
+	if uiModel.Mode != "type" {
+		t.Errorf("Expected to stay in type mode, got %s", uiModel.Mode)
+	}
+
+	// Raising the limit lets the trip through
+	uiModel.MaxTripMiles = 50
+	for _, value := range []string{"single", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if len(uiModel.Trips) != 1 {
+		t.Errorf("Expected 1 trip to be saved, got %d", len(uiModel.Trips))
+	}
+}
+
+func TestTripCreationConfirmation(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	var updatedModel tea.Model
+	for _, value := range []string{"2024-03-20", "Home", "Work", "round", "", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	want := "Added: Home → Work, 10.00 mi (round = 20.00 mi)"
+	if uiModel.StatusMessage != want {
+		t.Errorf("Expected status message %q, got %q", want, uiModel.StatusMessage)
+	}
+	if view := uiModel.View(); !strings.Contains(view, want) {
+		t.Errorf("Expected view to show the confirmation, got: %s", view)
+	}
+
+	// The next keypress clears it
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	uiModel = updatedModel.(*Model)
+	if uiModel.StatusMessage != "" {
+		t.Errorf("Expected the confirmation to clear, got %q", uiModel.StatusMessage)
+	}
+	if view := uiModel.View(); strings.Contains(view, "Added:") {
+		t.Errorf("Expected view to drop the confirmation, got: %s", view)
+	}
+}
+
+func TestSwitchFamily(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Families = []string{"Smith", "Jones"}
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Smiths", Miles: 5.0, Type: "single", Family: "Smith"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-21", Origin: "Home", Destination: "Joneses", Miles: 7.0, Type: "single", Family: "Jones"})
+	uiModel.ActiveTab = TabTrips
+
+	// Switch to the Smith family
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.ActiveFamily != "Smith" {
+		t.Fatalf("Expected active family Smith, got %q", uiModel.ActiveFamily)
+	}
+	view := uiModel.View()
+	if !strings.Contains(view, "Smiths") || strings.Contains(view, "Joneses") {
+		t.Errorf("Expected only Smith trips in view, got: %s", view)
+	}
+	if !strings.Contains(uiModel.renderStatusBar(), "Family: Smith") {
+		t.Errorf("Expected status bar to show the active family, got: %s", uiModel.renderStatusBar())
+	}
+	if total := model.CalculateGrandTotal(uiModel.Data.WeeklySummaries); total.TotalMiles != 5.0 {
+		t.Errorf("Expected Smith summaries to total 5 miles, got %.2f", total.TotalMiles)
+	}
+
+	// New trips are assigned to the active family
+	for _, value := range []string{"2024-03-22", "Home", "Park", "single", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if last := uiModel.Trips[len(uiModel.Trips)-1]; last.Family != "Smith" {
+		t.Errorf("Expected new trip in the Smith family, got %q", last.Family)
+	}
+
+	// Cycling past the last family shows every family again
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	uiModel = updatedModel.(*Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	uiModel = updatedModel.(*Model)
+	if uiModel.ActiveFamily != "" {
+		t.Errorf("Expected all families after cycling, got %q", uiModel.ActiveFamily)
+	}
+	if total := model.CalculateGrandTotal(uiModel.Data.WeeklySummaries); total.TotalMiles != 22.0 {
+		t.Errorf("Expected summaries across all families to total 22 miles, got %.2f", total.TotalMiles)
+	}
+}
+
+func TestManualMilesEntry(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.MapsClient = maps.NewManualClient()
+	uiModel.MaxTripMiles = 100
+
+	var updatedModel tea.Model
+	for _, value := range []string{"2024-03-20", "Home", "School", "round", "Store", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.Mode != "miles" {
+		t.Fatalf("Expected to be asked for the miles, got mode %s", uiModel.Mode)
+	}
+	if !strings.Contains(uiModel.TextInput.Placeholder, "Home to School") {
+		t.Errorf("Expected the prompt to name the route, got %q", uiModel.TextInput.Placeholder)
+	}
+
+	// Anything but a positive number is rejected
+	for _, value := range []string{"far", "0"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err == nil || uiModel.Mode != "miles" {
+			t.Errorf("Expected %q to be rejected, got mode %s (err %v)", value, uiModel.Mode, uiModel.Err)
+		}
+		uiModel.Err = nil
+	}
+
+	uiModel.TextInput.SetValue("4.5")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "return_miles" {
+		t.Fatalf("Expected to be asked for the return leg, got mode %s", uiModel.Mode)
+	}
+
+	uiModel.TextInput.SetValue("2")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if len(uiModel.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+	if trip := uiModel.Trips[0]; trip.Miles != 4.5 || trip.ReturnMiles != 2 {
+		t.Errorf("Expected the entered miles to be saved, got %+v", trip)
+	}
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to return to date, got %s", uiModel.Mode)
+	}
+
+	// Entered miles are still checked against the maximum
+	for _, value := range []string{"2024-03-21", "Home", "Airport", "single", "", "", "250"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Err == nil || len(uiModel.Trips) != 1 {
+		t.Errorf("Expected a trip over the maximum to be rejected, got %d trips (err %v)", len(uiModel.Trips), uiModel.Err)
+	}
+}
+
+func TestFrequentRouteQuickPicks(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.Data.Trips = []model.Trip{
+		{Date: "2024-03-18", Origin: "Home", Destination: "Park", Miles: 2, Type: "single"},
+		{Date: "2024-03-18", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+		{Date: "2024-03-19", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+	}
+	uiModel.Trips = uiModel.Data.Trips
+
+	enter := func(value string) {
+		t.Helper()
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err != nil {
+			t.Fatalf("Unexpected error after %q: %v", value, uiModel.Err)
+		}
+	}
+
+	enter("2024-03-20")
+	if view := uiModel.View(); !strings.Contains(view, "1. Home → School") || !strings.Contains(view, "2. Home → Park") {
+		t.Errorf("Expected the frequent routes to be offered in order, got:\n%s", view)
+	}
+
+	// Picking a route fills in both ends
+	enter("1")
+	if uiModel.Mode != "destination" || uiModel.CurrentTrip.Origin != "Home" || uiModel.TextInput.Value() != "School" {
+		t.Fatalf("Expected the picked route's destination to be pre-filled, got mode %s, trip %+v, input %q",
+			uiModel.Mode, uiModel.CurrentTrip, uiModel.TextInput.Value())
+	}
+
+	// Destinations can be picked on their own
+	enter("2")
+	if uiModel.Mode != "type" || uiModel.CurrentTrip.Destination != "Park" {
+		t.Errorf("Expected the second destination from Home to be picked, got mode %s, trip %+v", uiModel.Mode, uiModel.CurrentTrip)
+	}
+
+	// Numbers that are not on the list are taken as typed
+	uiModel.Mode = "destination"
+	enter("7")
+	if uiModel.CurrentTrip.Destination != "7" {
+		t.Errorf("Expected an unlisted number to be kept as the destination, got %q", uiModel.CurrentTrip.Destination)
+	}
+}
+
+func TestCustomTripMilesEntry(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.MaxTripMiles = 10
+
+	// Custom trips ask for the total even when the maps client could calculate it
+	var updatedModel tea.Model
+	for _, value := range []string{"2024-03-20", "Home", "Park", "custom", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.Mode != "miles" {
+		t.Fatalf("Expected to be asked for the miles, got mode %s", uiModel.Mode)
+	}
+
+	// The maximum trip length does not apply to entered totals
+	uiModel.TextInput.SetValue("12.5")
 	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if len(uiModel.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+	if trip := uiModel.Trips[0]; trip.Type != "custom" || trip.Miles != 12.5 || trip.EffectiveMiles() != 12.5 {
+		t.Errorf("Expected a custom trip of 12.5 miles, got %+v", trip)
+	}
+}
+
+func TestRoundTripReturnDestination(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	var updatedModel tea.Model
+	for _, value := range []string{"2024-03-20", "Home", "School", "round", "Store", "", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
 
-	// Edit origin
-	uiModel.TextInput.SetValue("Updated Home")
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = updatedModel.(*Model)
+	if len(uiModel.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+	trip := uiModel.Trips[0]
+	if trip.ReturnDestination != "Store" || trip.ReturnMiles != 10 {
+		t.Errorf("Expected the return leg to Store to be calculated, got %+v", trip)
+	}
+	if trip.EffectiveMiles() != 20 {
+		t.Errorf("Expected the outbound plus return leg miles, got %.2f", trip.EffectiveMiles())
+	}
+	uiModel.ActiveTab = TabTrips
+	if view := uiModel.View(); !strings.Contains(view, "School → Store (20.00 miles)") {
+		t.Errorf("Expected the return destination in the trips list, got view:\n%s", view)
+	}
 
-	// Edit destination
-	uiModel.TextInput.SetValue("Updated Work")
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = updatedModel.(*Model)
+	// Single trips skip the return destination prompt
+	for _, value := range []string{"2024-03-21", "Home", "Park", "single"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Mode != "notes" {
+		t.Errorf("Expected single trips to go straight to notes, got mode %s", uiModel.Mode)
+	}
+}
 
-	// Edit trip type
-	uiModel.TextInput.SetValue("round")
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+func TestDuplicateTrip(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Miles come from the original trip, not the maps client
+	uiModel.MapsClient.(*maps.MockClient).MockDistance = 99.0
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 12.5, Type: "round", Notes: "Morning drop-off"})
+
+	uiModel.ActiveTab = TabTrips
+	uiModel.SelectedTrip = 0
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
 	uiModel = updatedModel.(*Model)
 
-	// Verify final state
-	if len(uiModel.Trips) != 1 {
-		t.Errorf("Expected 1 trip, got %d", len(uiModel.Trips))
+	if uiModel.Mode != "date" {
+		t.Fatalf("Expected mode to be date, got %s", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != "2024-03-20" {
+		t.Errorf("Expected date prefilled with original date, got %q", uiModel.TextInput.Value())
+	}
+	if uiModel.CurrentTrip.Origin != "Home" || uiModel.CurrentTrip.Destination != "School" || uiModel.CurrentTrip.Type != "round" {
+		t.Errorf("Expected route to be copied, got %+v", uiModel.CurrentTrip)
 	}
 
-	editedTrip := uiModel.Trips[0]
-	if editedTrip.Date != newDate {
-		t.Errorf("Expected final date to be '%s', got '%s'", newDate, editedTrip.Date)
+	// Accept every prefilled value
+	for i := 0; i < 7; i++ {
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
 	}
-	if editedTrip.Origin != "Updated Home" {
-		t.Errorf("Expected origin to be 'Updated Home', got '%s'", editedTrip.Origin)
+
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
 	}
-	if editedTrip.Destination != "Updated Work" {
-		t.Errorf("Expected destination to be 'Updated Work', got '%s'", editedTrip.Destination)
+	if len(uiModel.Trips) != 2 {
+		t.Fatalf("Expected 2 trips after duplicating, got %d", len(uiModel.Trips))
 	}
-	if editedTrip.Type != "round" {
-		t.Errorf("Expected type to be 'round', got '%s'", editedTrip.Type)
+	for _, trip := range uiModel.Trips {
+		if trip.Miles != 12.5 {
+			t.Errorf("Expected duplicated miles 12.5, got %.2f", trip.Miles)
+		}
+		if trip.Notes != "Morning drop-off" {
+			t.Errorf("Expected notes to be copied, got %q", trip.Notes)
+		}
 	}
 
-	// Verify edit mode was cleared
-	if uiModel.Mode != "date" {
-		t.Errorf("Expected mode to reset to 'date', got '%s'", uiModel.Mode)
-	}
-	if uiModel.EditIndex != -1 {
-		t.Errorf("Expected EditIndex to reset to -1, got %d", uiModel.EditIndex)
+	// The duplicate is independent of the original
+	uiModel.Trips[1].Destination = "Park"
+	if uiModel.Trips[0].Destination != "School" {
+		t.Errorf("Expected original trip to be unchanged, got %s", uiModel.Trips[0].Destination)
 	}
 }
 
-func TestDeleteTrip(t *testing.T) {
+func TestUndoTripArchive(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
 
-	// Add a trip first
-	trip := model.Trip{
-		Date:        "2024-03-20",
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       10.5,
+	uiModel.AddTrip(model.Trip{Date: "2024-03-06", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-13", Origin: "Home", Destination: "School", Miles: 8.0, Type: "single"})
+
+	// Undo with nothing to restore is a no-op
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	uiModel = updatedModel.(*Model)
+	if len(uiModel.Trips) != 2 {
+		t.Fatalf("Expected 2 trips, got %d", len(uiModel.Trips))
 	}
-	uiModel.AddTrip(trip)
 
-	// Select the trip
+	// Ctrl+D archives the first trip, taking it out of the summaries
+	uiModel.ActiveTab = TabTrips
 	uiModel.SelectedTrip = 0
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
 
-	// Set active tab to Trips (required for delete to work)
+	if len(model.UnarchivedTrips(uiModel.Trips)) != 1 {
+		t.Fatalf("Expected 1 trip left after archiving, got %d", len(model.UnarchivedTrips(uiModel.Trips)))
+	}
+	if len(uiModel.Data.WeeklySummaries) != 1 {
+		t.Fatalf("Expected 1 weekly summary after archiving, got %d", len(uiModel.Data.WeeklySummaries))
+	}
+
+	// Undo restores the trip and its weekly summary
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	uiModel = updatedModel.(*Model)
+
+	if len(model.UnarchivedTrips(uiModel.Trips)) != 2 {
+		t.Fatalf("Expected 2 trips after undo, got %d", len(model.UnarchivedTrips(uiModel.Trips)))
+	}
+	destinations := map[string]bool{}
+	for _, trip := range uiModel.Trips {
+		destinations[trip.Destination] = true
+	}
+	if !destinations["Work"] || !destinations["School"] {
+		t.Errorf("Expected original trips after undo, got %+v", uiModel.Trips)
+	}
+	if len(uiModel.Data.WeeklySummaries) != 2 {
+		t.Errorf("Expected 2 weekly summaries after undo, got %d", len(uiModel.Data.WeeklySummaries))
+	}
+	if len(uiModel.UndoStack) != 0 {
+		t.Errorf("Expected empty undo stack, got %d entries", len(uiModel.UndoStack))
+	}
+
+	// The restored data is saved
+	data, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Trips) != 2 {
+		t.Errorf("Expected 2 saved trips after undo, got %d", len(data.Trips))
+	}
+}
+
+func TestUndoTripEdit(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-06", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
 	uiModel.ActiveTab = TabTrips
+	uiModel.SelectedTrip = 0
 
-	// Enter delete confirmation mode
+	// Edit the trip's destination through the Ctrl+E flow
 	var updatedModel tea.Model
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
 	uiModel = updatedModel.(*Model)
-
-	// Verify we're in delete confirmation mode
-	if uiModel.Mode != "delete_confirm" {
-		t.Errorf("Expected mode to be 'delete_confirm', got '%s'", uiModel.Mode)
+	for _, value := range []string{"2024-03-06", "Home", "Park", "single"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Trips[0].Destination != "Park" {
+		t.Fatalf("Expected edited destination Park, got %s", uiModel.Trips[0].Destination)
 	}
 
-	// Test cancellation by entering something other than 'yes'
-	uiModel.TextInput.SetValue("no")
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
 	uiModel = updatedModel.(*Model)
+	if uiModel.Trips[0].Destination != "Work" {
+		t.Errorf("Expected undo to restore destination Work, got %s", uiModel.Trips[0].Destination)
+	}
+}
 
-	// Verify trip wasn't deleted and mode was reset
-	if len(uiModel.Trips) != 1 {
-		t.Errorf("Expected 1 trip after cancellation, got %d", len(uiModel.Trips))
+func TestUndoStackDepth(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	for i := 0; i < maxUndoDepth+5; i++ {
+		uiModel.pushUndo()
 	}
-	if uiModel.Mode != "date" {
-		t.Errorf("Expected mode to be 'date' after cancellation, got '%s'", uiModel.Mode)
+	if len(uiModel.UndoStack) != maxUndoDepth {
+		t.Errorf("Expected undo stack depth %d, got %d", maxUndoDepth, len(uiModel.UndoStack))
 	}
 
-	// Enter delete confirmation mode again
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	// Quitting clears the undo stack
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
 	uiModel = updatedModel.(*Model)
+	if len(uiModel.UndoStack) != 0 {
+		t.Errorf("Expected undo stack to be cleared on quit, got %d entries", len(uiModel.UndoStack))
+	}
+}
 
-	// Confirm deletion by entering 'yes'
-	uiModel.TextInput.SetValue("yes")
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
-	uiModel = updatedModel.(*Model)
+func TestTripPassengersDisplay(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
 
-	// Verify trip was deleted and mode was reset
-	if len(uiModel.Trips) != 0 {
-		t.Errorf("Expected 0 trips after deletion, got %d", len(uiModel.Trips))
+	uiModel.AddTrip(model.Trip{
+		Date:        "2024-03-20",
+		Origin:      "Home",
+		Destination: "Zoo",
+		Miles:       10.0,
+		Type:        "round",
+		Passengers:  2,
+	})
+
+	// Weekly summary shows the cost split between the passengers
+	view := uiModel.View()
+	if !strings.Contains(view, "Per Passenger:        $6.55") {
+		t.Errorf("Expected per-passenger amount in weekly summary, got:\n%s", view)
 	}
-	if uiModel.Mode != "date" {
-		t.Errorf("Expected mode to be 'date' after deletion, got '%s'", uiModel.Mode)
+
+	uiModel.ActiveTab = TabTrips
+	view = uiModel.View()
+	if !strings.Contains(view, "[round] (2 passengers)") {
+		t.Errorf("Expected passenger count in trip list, got:\n%s", view)
 	}
 }
 
@@ -870,6 +2392,26 @@ func TestTimeGroupNavigation(t *testing.T) {
 	}
 }
 
+func TestSearchMatchesTags(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 10.0, Type: "single", Tags: []string{"field-trip"}})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-21", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+
+	uiModel.SearchMode = true
+	uiModel.SearchQuery = "field"
+	results := uiModel.filterBySearch()
+	if len(results) != 1 || results[0].Destination != "Zoo" {
+		t.Errorf("Expected the tagged trip to match a tag substring, got %+v", results)
+	}
+
+	uiModel.ActiveTab = TabTrips
+	if view := uiModel.View(); !strings.Contains(view, "#field-trip") {
+		t.Errorf("Expected tags to be shown in the trips list, got view:\n%s", view)
+	}
+}
+
 func TestSearchFunctionality(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
@@ -1134,44 +2676,198 @@ func TestConvertTripToRecurring(t *testing.T) {
 		t.Errorf("Expected mode to reset to 'date', got '%s'", uiModel.Mode)
 	}
 
-	// Verify trips were generated (should be 2 Wednesdays: March 20 and March 27)
-	if len(uiModel.Trips) != 2 {
-		t.Errorf("Expected 2 trips to be generated, got %d", len(uiModel.Trips))
-	}
+	// Verify trips were generated (should be 2 Wednesdays: March 20 and March 27)
+	if len(uiModel.Trips) != 2 {
+		t.Errorf("Expected 2 trips to be generated, got %d", len(uiModel.Trips))
+	}
+
+	// Verify the generated trips are on the correct dates
+	dates := make(map[string]bool)
+	for _, trip := range uiModel.Trips {
+		dates[trip.Date] = true
+	}
+	if !dates["2024-03-20"] {
+		t.Error("Expected trip on March 20, 2024")
+	}
+	if !dates["2024-03-27"] {
+		t.Error("Expected trip on March 27, 2024")
+	}
+}
+
+func TestConvertTripToRecurringWithNoSelection(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Set active tab to Trips (required for convert to recurring to work)
+	uiModel.ActiveTab = TabTrips
+
+	// Try to convert without selecting a trip
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	uiModel = updatedModel.(*Model)
+
+	// Verify we're in recurring trip creation mode
+	if uiModel.Mode != "recurring_date" {
+		t.Errorf("Expected mode to be 'recurring_date', got '%s'", uiModel.Mode)
+	}
+
+	// Verify no recurring trip was created
+	if len(uiModel.RecurringTrips) != 0 {
+		t.Errorf("Expected 0 recurring trips, got %d", len(uiModel.RecurringTrips))
+	}
+}
+
+func TestRecurringTripFrequencyPrompt(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.ActiveTab = TabTrips
+
+	// Enter recurring trip creation mode
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	uiModel = updatedModel.(*Model)
+
+	uiModel.TextInput.SetValue("2024-03-01")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "recurring_frequency" {
+		t.Fatalf("Expected mode to be 'recurring_frequency', got '%s'", uiModel.Mode)
+	}
+
+	// Invalid frequency keeps us on the same step
+	uiModel.TextInput.SetValue("daily")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "invalid frequency") {
+		t.Errorf("Expected error about invalid frequency, got: %v", uiModel.Err)
+	}
+	if uiModel.Mode != "recurring_frequency" {
+		t.Errorf("Expected mode to remain 'recurring_frequency', got '%s'", uiModel.Mode)
+	}
+	uiModel.Err = nil
+
+	// Monthly prompts for the day of month instead of a weekday
+	uiModel.TextInput.SetValue("monthly")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "recurring_day_of_month" {
+		t.Fatalf("Expected mode to be 'recurring_day_of_month', got '%s'", uiModel.Mode)
+	}
+
+	uiModel.TextInput.SetValue("31")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Err != nil {
+		t.Errorf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.CurrentRecurring.Frequency != "monthly" {
+		t.Errorf("Expected frequency to be 'monthly', got '%s'", uiModel.CurrentRecurring.Frequency)
+	}
+	if uiModel.CurrentRecurring.DayOfMonth != 31 {
+		t.Errorf("Expected day of month to be 31, got %d", uiModel.CurrentRecurring.DayOfMonth)
+	}
+	if uiModel.Mode != "recurring_excluded_dates" {
+		t.Fatalf("Expected mode to be 'recurring_excluded_dates', got '%s'", uiModel.Mode)
+	}
+
+	// Excluded dates are optional
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "recurring_end_date" {
+		t.Errorf("Expected mode to be 'recurring_end_date', got '%s'", uiModel.Mode)
+	}
+}
+
+func TestRecurringTripExcludedDatesPrompt(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.ActiveTab = TabTrips
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
+	uiModel = updatedModel.(*Model)
+	for _, value := range []string{"2024-03-01", "weekly", "3"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Mode != "recurring_excluded_dates" {
+		t.Fatalf("Expected mode to be 'recurring_excluded_dates', got '%s'", uiModel.Mode)
+	}
+
+	// An invalid date keeps us on the same step
+	uiModel.TextInput.SetValue("2024-03-13, next week")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Err == nil || !strings.Contains(uiModel.Err.Error(), "excluded date") {
+		t.Errorf("Expected error about the excluded date, got: %v", uiModel.Err)
+	}
+	if uiModel.Mode != "recurring_excluded_dates" {
+		t.Errorf("Expected mode to remain 'recurring_excluded_dates', got '%s'", uiModel.Mode)
+	}
+	uiModel.Err = nil
+
+	uiModel.TextInput.SetValue("2024-03-13, 2024-03-27,")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
 
-	// Verify the generated trips are on the correct dates
-	dates := make(map[string]bool)
-	for _, trip := range uiModel.Trips {
-		dates[trip.Date] = true
+	if uiModel.Err != nil {
+		t.Errorf("Unexpected error: %v", uiModel.Err)
 	}
-	if !dates["2024-03-20"] {
-		t.Error("Expected trip on March 20, 2024")
+	if got := strings.Join(uiModel.CurrentRecurring.ExcludedDates, ","); got != "2024-03-13,2024-03-27" {
+		t.Errorf("Expected excluded dates 2024-03-13 and 2024-03-27, got %v", uiModel.CurrentRecurring.ExcludedDates)
 	}
-	if !dates["2024-03-27"] {
-		t.Error("Expected trip on March 27, 2024")
+	if uiModel.Mode != "recurring_end_date" {
+		t.Errorf("Expected mode to be 'recurring_end_date', got '%s'", uiModel.Mode)
 	}
 }
 
-func TestConvertTripToRecurringWithNoSelection(t *testing.T) {
+func TestRecurringTripOccurrencesPrompt(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
-
-	// Set active tab to Trips (required for convert to recurring to work)
 	uiModel.ActiveTab = TabTrips
 
-	// Try to convert without selecting a trip
 	var updatedModel tea.Model
 	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlR})
 	uiModel = updatedModel.(*Model)
+	for _, value := range []string{"2024-03-01", "weekly", "3", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.Mode != "recurring_end_date" {
+		t.Fatalf("Expected mode to be 'recurring_end_date', got '%s'", uiModel.Mode)
+	}
 
-	// Verify we're in recurring trip creation mode
-	if uiModel.Mode != "recurring_date" {
-		t.Errorf("Expected mode to be 'recurring_date', got '%s'", uiModel.Mode)
+	// Recurring trips need an end date or a trip count
+	for _, value := range []string{"", "0", "2024-02-01"} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err == nil || uiModel.Mode != "recurring_end_date" {
+			t.Errorf("Expected %q to be rejected, got mode %s and error %v", value, uiModel.Mode, uiModel.Err)
+		}
+		uiModel.Err = nil
 	}
 
-	// Verify no recurring trip was created
-	if len(uiModel.RecurringTrips) != 0 {
-		t.Errorf("Expected 0 recurring trips, got %d", len(uiModel.RecurringTrips))
+	uiModel.TextInput.SetValue("8")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err != nil {
+		t.Fatalf("Unexpected error: %v", uiModel.Err)
+	}
+	if uiModel.CurrentRecurring.Occurrences != 8 || uiModel.CurrentRecurring.EndDate != "" {
+		t.Errorf("Expected a limit of 8 trips and no end date, got %d and %q", uiModel.CurrentRecurring.Occurrences, uiModel.CurrentRecurring.EndDate)
+	}
+	if uiModel.Mode != "origin" {
+		t.Errorf("Expected mode to be 'origin', got '%s'", uiModel.Mode)
 	}
 }
 
@@ -1419,97 +3115,418 @@ func TestTripSelection(t *testing.T) {
 	}
 }
 
-func TestExpenseDisplay(t *testing.T) {
+func TestWeekStartDaySelectsCurrentWeek(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	today := time.Now()
+	yesterday := today.AddDate(0, 0, -1)
+	uiModel.AddTrip(model.Trip{Date: yesterday.Format("2006-01-02"), Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: today.Format("2006-01-02"), Origin: "Home", Destination: "Work", Miles: 7.0, Type: "single"})
+
+	// Weeks that begin today split the two trips apart
+	uiModel.SetWeekStartDay(today.Weekday())
+	if len(uiModel.Data.WeeklySummaries) != 2 {
+		t.Fatalf("Expected 2 weeks, got %d", len(uiModel.Data.WeeklySummaries))
+	}
+	if week := uiModel.Data.WeeklySummaries[uiModel.SelectedWeek]; week.WeekStart != today.Format("2006-01-02") {
+		t.Errorf("Expected the current week to start today, got %s", week.WeekStart)
+	}
+
+	// Weeks that begin yesterday hold both
+	uiModel.SetWeekStartDay(yesterday.Weekday())
+	if len(uiModel.Data.WeeklySummaries) != 1 || uiModel.SelectedWeek != 0 {
+		t.Errorf("Expected a single current week, got %d weeks with week %d selected", len(uiModel.Data.WeeklySummaries), uiModel.SelectedWeek)
+	}
+	if week := uiModel.Data.WeeklySummaries[0]; week.WeekStart != yesterday.Format("2006-01-02") {
+		t.Errorf("Expected the week to start yesterday, got %s", week.WeekStart)
+	}
+}
+
+func TestJumpToFirstAndLastItem(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	for i := 0; i < 25; i++ {
+		uiModel.AddTrip(model.Trip{
+			Date:        fmt.Sprintf("2024-03-%02d", i+1),
+			Origin:      "Home",
+			Destination: "Work",
+			Miles:       5.0,
+			Type:        "single",
+		})
+		if err := uiModel.Data.AddExpense(model.Expense{Date: fmt.Sprintf("2024-03-%02d", i+1), Amount: 5.0, Description: "Snack"}); err != nil {
+			t.Fatalf("Failed to add expense: %v", err)
+		}
+	}
+	uiModel.TripTemplates = []model.TripTemplate{
+		{Name: "School", Origin: "Home", Destination: "School", TripType: "single"},
+		{Name: "art class", Origin: "Home", Destination: "Studio", TripType: "round"},
+		{Name: "Park", Origin: "Home", Destination: "Park", TripType: "single"},
+	}
+
+	tests := []struct {
+		name     string
+		tab      int
+		selected func(*Model) int
+		first    int
+		last     int
+		lastPage int
+	}{
+		{"trips", TabTrips, func(m *Model) int { return m.SelectedTrip }, 0, 24, 2},
+		{"expenses", TabExpenses, func(m *Model) int { return m.SelectedExpense }, 0, 24, 2},
+		// Templates are shown sorted by name: art class, Park, School
+		{"templates", TabTemplates, func(m *Model) int { return m.SelectedTemplate }, 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uiModel.ActiveTab = tt.tab
+			uiModel.CurrentPage = 1
+
+			updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnd})
+			uiModel = updatedModel.(*Model)
+			if got := tt.selected(uiModel); got != tt.last {
+				t.Errorf("Expected End to select %d, got %d", tt.last, got)
+			}
+			if uiModel.CurrentPage != tt.lastPage {
+				t.Errorf("Expected End to show page %d, got %d", tt.lastPage, uiModel.CurrentPage)
+			}
+
+			updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyHome})
+			uiModel = updatedModel.(*Model)
+			if got := tt.selected(uiModel); got != tt.first {
+				t.Errorf("Expected Home to select %d, got %d", tt.first, got)
+			}
+			if uiModel.CurrentPage != 0 {
+				t.Errorf("Expected Home to show page 0, got %d", uiModel.CurrentPage)
+			}
+		})
+	}
+}
+
+func TestExpenseDisplay(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Add test expenses in random order
+	expenses := []model.Expense{
+		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+		{Date: "2024-03-22", Amount: 30.00, Description: "Activities"},
+		{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
+	}
+
+	for _, expense := range expenses {
+		if err := uiModel.Data.AddExpense(expense); err != nil {
+			t.Fatalf("Failed to add expense: %v", err)
+		}
+	}
+
+	// Set active tab to Expenses
+	uiModel.ActiveTab = TabExpenses
+
+	// Get the view
+	view := uiModel.View()
+
+	// Check if expenses are displayed in descending order
+	expectedExpenses := []string{
+		"2024-03-22: $30.00 - Activities",
+		"2024-03-21: $15.75 - Snacks",
+		"2024-03-20: $25.50 - Lunch",
+	}
+
+	for i, expected := range expectedExpenses {
+		if !strings.Contains(view, expected) {
+			t.Errorf("View does not contain expected expense: %s", expected)
+		}
+		// Verify order
+		if i > 0 {
+			prevExpense := expectedExpenses[i-1]
+			prevIndex := strings.Index(view, prevExpense)
+			currentIndex := strings.Index(view, expected)
+			if prevIndex > currentIndex {
+				t.Errorf("Expenses not in descending order: %s appears before %s", expected, prevExpense)
+			}
+		}
+	}
+}
+
+func TestExpensePagination(t *testing.T) {
+	m := &Model{
+		Data: &model.StorageData{
+			Expenses: []model.Expense{
+				{Date: "2024-03-27", Amount: 10.00, Description: "Expense 1"},
+				{Date: "2024-03-26", Amount: 20.00, Description: "Expense 2"},
+				{Date: "2024-03-25", Amount: 30.00, Description: "Expense 3"},
+				{Date: "2024-03-24", Amount: 40.00, Description: "Expense 4"},
+				{Date: "2024-03-23", Amount: 50.00, Description: "Expense 5"},
+				{Date: "2024-03-22", Amount: 60.00, Description: "Expense 6"},
+				{Date: "2024-03-21", Amount: 70.00, Description: "Expense 7"},
+				{Date: "2024-03-20", Amount: 80.00, Description: "Expense 8"},
+				{Date: "2024-03-19", Amount: 90.00, Description: "Expense 9"},
+				{Date: "2024-03-18", Amount: 100.00, Description: "Expense 10"},
+				{Date: "2024-03-17", Amount: 110.00, Description: "Expense 11"},
+			},
+		},
+		PageSize:    5,
+		CurrentPage: 0,
+		ActiveTab:   TabExpenses,
+	}
+
+	// Test first page
+	view := m.View()
+	if !strings.Contains(view, "Page 1 of 3 (Showing 1-5 of 11 expenses)") {
+		t.Errorf("View does not contain pagination information")
+	}
+
+	// Test second page
+	m.CurrentPage = 1
+	view = m.View()
+	if !strings.Contains(view, "Page 2 of 3 (Showing 6-10 of 11 expenses)") {
+		t.Errorf("View does not contain second page information")
+	}
+
+	// Test third page
+	m.CurrentPage = 2
+	view = m.View()
+	if !strings.Contains(view, "Page 3 of 3 (Showing 11-11 of 11 expenses)") {
+		t.Errorf("View does not contain third page information")
+	}
+
+	// Test expense sorting
+	if !strings.Contains(view, "2024-03-17: $110.00 - Expense 11") {
+		t.Errorf("Expenses are not sorted by date in descending order")
+	}
+}
+
+func TestGrandTotalDisplay(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	expenses := []model.Expense{
+		{Date: "2024-03-17", Amount: 25.50, Description: "Lunch"},      // Week 1
+		{Date: "2024-03-24", Amount: 30.00, Description: "Activities"}, // Week 2
+	}
+	for _, expense := range expenses {
+		if err := uiModel.Data.AddExpense(expense); err != nil {
+			t.Fatalf("Failed to add expense: %v", err)
+		}
+	}
+	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile, uiModel.RoundingMode, time.Sunday)
+
+	view := uiModel.View()
+	if !strings.Contains(view, "All Weeks (2):") {
+		t.Errorf("Expected grand total header in view, got: %s", view)
+	}
+	if !strings.Contains(view, "Total Expenses:       $55.50") {
+		t.Errorf("Expected grand total expenses of $55.50 in view, got: %s", view)
+	}
+
+	// The grand total is shown above the selected week
+	if strings.Index(view, "All Weeks") > strings.Index(view, "Week of") {
+		t.Error("Expected grand total to be rendered above the weekly summary")
+	}
+}
+
+func TestWeeklyTripCountsDisplay(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-18", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-19", Origin: "Home", Destination: "School", Miles: 5.0, Type: "round"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Park", Miles: 3.0, Type: "round"})
+	uiModel.ActiveTab = TabWeeklySummaries
+
+	view := uiModel.View()
+	if !strings.Contains(view, "1 single, 2 round") {
+		t.Errorf("Expected trip counts in weekly view, got: %s", view)
+	}
+}
+
+func TestJumpToCurrentWeek(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	now := time.Now()
+	for _, offset := range []int{-21, -14, -7, 0, 7} {
+		date := now.AddDate(0, 0, offset).Format("2006-01-02")
+		uiModel.AddTrip(model.Trip{Date: date, Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	}
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = len(uiModel.Data.WeeklySummaries) - 1
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}})
+	uiModel = updatedModel.(*Model)
+
+	today := now.Format("2006-01-02")
+	summary := uiModel.Data.WeeklySummaries[uiModel.SelectedWeek]
+	if today < summary.WeekStart || today > summary.WeekEnd {
+		t.Errorf("Expected selected week to contain %s, got %s to %s", today, summary.WeekStart, summary.WeekEnd)
+	}
+	if uiModel.SelectedWeek != 1 {
+		t.Errorf("Expected week index 1 (one future week before it), got %d", uiModel.SelectedWeek)
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to be typed into the input, got %q", uiModel.TextInput.Value())
+	}
+}
+
+func TestJumpToWeekOfDate(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Weeks starting Sunday 2024-03-03, 03-10, 03-17 and 03-31; 03-24 has no trips
+	for _, date := range []string{"2024-03-04", "2024-03-12", "2024-03-20", "2024-04-02"} {
+		uiModel.AddTrip(model.Trip{Date: date, Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	}
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
+
+	jump := func(date string) {
+		t.Helper()
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Mode != "week_jump" {
+			t.Fatalf("Expected week_jump mode, got %s", uiModel.Mode)
+		}
+		if uiModel.TextInput.Value() != "" {
+			t.Fatalf("Expected the shortcut key not to be typed into the input, got %q", uiModel.TextInput.Value())
+		}
+		uiModel.TextInput.SetValue(date)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	// Summaries are most recent first, so the week of 03-10 is index 2
+	jump("2024-03-14")
+	if uiModel.Err != nil || uiModel.SelectedWeek != 2 || uiModel.Mode != "date" {
+		t.Errorf("Expected week index 2 to be selected, got %d in mode %s (err %v)", uiModel.SelectedWeek, uiModel.Mode, uiModel.Err)
+	}
+
+	// A date in a week without a summary is reported and the selection kept
+	jump("2024-03-26")
+	if uiModel.Err == nil || uiModel.SelectedWeek != 2 || uiModel.Mode != "week_jump" {
+		t.Errorf("Expected an error for an uncovered week, got week %d in mode %s (err %v)", uiModel.SelectedWeek, uiModel.Mode, uiModel.Err)
+	}
+	uiModel.Err = nil
+
+	uiModel.TextInput.SetValue("March 14")
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err == nil {
+		t.Error("Expected an invalid date to be rejected")
+	}
+}
+
+func TestToggleTripSortOrder(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	dates := []string{"2024-03-05", "2024-03-01", "2024-03-10"}
+	for _, date := range dates {
+		uiModel.AddTrip(model.Trip{Date: date, Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
+	}
+	uiModel.ActiveTab = TabTrips
+	uiModel.PageSize = 2
+
+	// firstDisplayed returns the date of the first trip listed on the current page
+	firstDisplayed := func() string {
+		view := uiModel.View()
+		start := strings.Index(view, "Regular Trips")
+		if start < 0 {
+			t.Fatalf("Expected trips list in view")
+		}
+		first, firstPos := "", len(view)
+		for _, date := range dates {
+			if pos := strings.Index(view[start:], date); pos >= 0 && pos < firstPos {
+				first, firstPos = date, pos
+			}
+		}
+		return first
+	}
+	pressO := func() {
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+		uiModel = updatedModel.(*Model)
+	}
+
+	if got := firstDisplayed(); got != "2024-03-10" {
+		t.Fatalf("Expected newest trip first by default, got %s", got)
+	}
+
+	pressO()
+	if !uiModel.SortAscending {
+		t.Fatal("Expected sort order to be ascending after pressing o")
+	}
+	if got := firstDisplayed(); got != "2024-03-01" {
+		t.Errorf("Expected oldest trip first after toggling, got %s", got)
+	}
+	if uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected the shortcut key not to be typed into the input, got %q", uiModel.TextInput.Value())
+	}
+
+	// The selected trip stays selected and its page is shown after toggling back
+	uiModel.SelectedTrip = 0
+	pressO()
+	if uiModel.SortAscending {
+		t.Fatal("Expected sort order to be descending after pressing o again")
+	}
+	if uiModel.SelectedTrip != 2 || uiModel.Trips[uiModel.SelectedTrip].Date != "2024-03-01" {
+		t.Errorf("Expected selection to follow the 2024-03-01 trip to index 2, got %d", uiModel.SelectedTrip)
+	}
+	if uiModel.CurrentPage != 1 {
+		t.Errorf("Expected page to follow the selected trip to page 1, got %d", uiModel.CurrentPage)
+	}
+	if got := firstDisplayed(); got != "2024-03-01" {
+		t.Errorf("Expected page 2 to list the oldest trip, got %s", got)
+	}
+}
+
+func TestTripsFooterTotals(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
 
-	// Add test expenses in random order
-	expenses := []model.Expense{
-		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-		{Date: "2024-03-22", Amount: 30.00, Description: "Activities"},
-		{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
-	}
-
-	for _, expense := range expenses {
-		if err := uiModel.Data.AddExpense(expense); err != nil {
-			t.Fatalf("Failed to add expense: %v", err)
-		}
-	}
-
-	// Set active tab to Expenses
-	uiModel.ActiveTab = TabExpenses
+	uiModel.AddTrip(model.Trip{Date: "2024-03-01", Origin: "Home", Destination: "Work", Miles: 10.0, Type: "round"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-02", Origin: "Home", Destination: "Park", Miles: 20.0, Type: "single"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-03", Origin: "School", Destination: "Work", Miles: 20.0, Type: "single"})
+	uiModel.ActiveTab = TabTrips
+	uiModel.PageSize = 1
 
-	// Get the view
+	// All trips count, including those on other pages: 20 + 20 + 20 miles at 0.655
 	view := uiModel.View()
-
-	// Check if expenses are displayed in descending order
-	expectedExpenses := []string{
-		"2024-03-22: $30.00 - Activities",
-		"2024-03-21: $15.75 - Snacks",
-		"2024-03-20: $25.50 - Lunch",
+	if !strings.Contains(view, "Total: 60.00 miles, $39.30") {
+		t.Errorf("Expected footer totals for all trips, got view:\n%s", view)
 	}
 
-	for i, expected := range expectedExpenses {
-		if !strings.Contains(view, expected) {
-			t.Errorf("View does not contain expected expense: %s", expected)
-		}
-		// Verify order
-		if i > 0 {
-			prevExpense := expectedExpenses[i-1]
-			prevIndex := strings.Index(view, prevExpense)
-			currentIndex := strings.Index(view, expected)
-			if prevIndex > currentIndex {
-				t.Errorf("Expenses not in descending order: %s appears before %s", expected, prevExpense)
-			}
-		}
+	// A search only totals the matching trips: 20 + 20 miles at 0.655
+	uiModel.SearchMode = true
+	uiModel.SearchQuery = "Work"
+	view = uiModel.View()
+	if !strings.Contains(view, "Total: 40.00 miles, $26.20") {
+		t.Errorf("Expected footer totals for matching trips, got view:\n%s", view)
 	}
 }
 
-func TestExpensePagination(t *testing.T) {
-	m := &Model{
-		Data: &model.StorageData{
-			Expenses: []model.Expense{
-				{Date: "2024-03-27", Amount: 10.00, Description: "Expense 1"},
-				{Date: "2024-03-26", Amount: 20.00, Description: "Expense 2"},
-				{Date: "2024-03-25", Amount: 30.00, Description: "Expense 3"},
-				{Date: "2024-03-24", Amount: 40.00, Description: "Expense 4"},
-				{Date: "2024-03-23", Amount: 50.00, Description: "Expense 5"},
-				{Date: "2024-03-22", Amount: 60.00, Description: "Expense 6"},
-				{Date: "2024-03-21", Amount: 70.00, Description: "Expense 7"},
-				{Date: "2024-03-20", Amount: 80.00, Description: "Expense 8"},
-				{Date: "2024-03-19", Amount: 90.00, Description: "Expense 9"},
-				{Date: "2024-03-18", Amount: 100.00, Description: "Expense 10"},
-				{Date: "2024-03-17", Amount: 110.00, Description: "Expense 11"},
-			},
-		},
-		PageSize:    5,
-		CurrentPage: 0,
-		ActiveTab:   TabExpenses,
-	}
-
-	// Test first page
-	view := m.View()
-	if !strings.Contains(view, "Page 1 of 3 (Showing 1-5 of 11 expenses)") {
-		t.Errorf("View does not contain pagination information")
-	}
+func TestJumpToCurrentMonth(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
 
-	// Test second page
-	m.CurrentPage = 1
-	view = m.View()
-	if !strings.Contains(view, "Page 2 of 3 (Showing 6-10 of 11 expenses)") {
-		t.Errorf("View does not contain second page information")
+	now := time.Now()
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	for _, date := range []time.Time{firstOfMonth.AddDate(0, -1, 0), firstOfMonth, firstOfMonth.AddDate(0, 0, 14), now} {
+		uiModel.AddTrip(model.Trip{Date: date.Format("2006-01-02"), Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"})
 	}
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.SelectedWeek = 0
 
-	// Test third page
-	m.CurrentPage = 2
-	view = m.View()
-	if !strings.Contains(view, "Page 3 of 3 (Showing 11-11 of 11 expenses)") {
-		t.Errorf("View does not contain third page information")
-	}
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'M'}})
+	uiModel = updatedModel.(*Model)
 
-	// Test expense sorting
-	if !strings.Contains(view, "2024-03-17: $110.00 - Expense 11") {
-		t.Errorf("Expenses are not sorted by date in descending order")
+	first := firstOfMonth.Format("2006-01-02")
+	summary := uiModel.Data.WeeklySummaries[uiModel.SelectedWeek]
+	if first < summary.WeekStart || first > summary.WeekEnd {
+		t.Errorf("Expected selected week to contain %s, got %s to %s", first, summary.WeekStart, summary.WeekEnd)
 	}
 }
 
@@ -1543,7 +3560,7 @@ func TestWeeklySummarySorting(t *testing.T) {
 		}
 	}
 
-	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile)
+	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile, uiModel.RoundingMode, time.Sunday)
 
 	// Set active tab to Weekly Summaries
 	uiModel.ActiveTab = TabWeeklySummaries
@@ -2054,6 +4071,17 @@ func TestTemplateToTripConversion(t *testing.T) {
 	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = model.(*Model)
 
+	// Enter notes (accept pre-filled value from template)
+	if uiModel.TextInput.Value() != template.Notes {
+		t.Errorf("Expected notes to be pre-filled with '%s', got '%s'", template.Notes, uiModel.TextInput.Value())
+	}
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
+	// Skip tags
+	model, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = model.(*Model)
+
 	// Verify the trip was created with calculated miles
 	if len(uiModel.Trips) != 1 {
 		t.Errorf("Expected 1 trip, got %d", len(uiModel.Trips))
@@ -2075,6 +4103,9 @@ func TestTemplateToTripConversion(t *testing.T) {
 	if trip.Miles != 10.0 { // Mock client returns 10.0 miles
 		t.Errorf("Expected miles to be 10.0, got %.2f", trip.Miles)
 	}
+	if trip.Notes != template.Notes {
+		t.Errorf("Expected notes to be '%s', got '%s'", template.Notes, trip.Notes)
+	}
 }
 
 func TestTemplateUsageWithUKey(t *testing.T) {
@@ -2152,6 +4183,80 @@ func TestTemplateUsageWithUKey(t *testing.T) {
 	}
 }
 
+func TestTemplateUsageCountAndSort(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.TripTemplates = []model.TripTemplate{
+		{Name: "Alpha", Origin: "Home", Destination: "Gym", TripType: "single"},
+		{Name: "Beta", Origin: "Home", Destination: "School", TripType: "single"},
+		{Name: "Gamma", Origin: "Home", Destination: "Park", TripType: "round", UsageCount: 1},
+	}
+	uiModel.Data.TripTemplates = uiModel.TripTemplates
+
+	// Use Beta twice, once with each key
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyCtrlU}, {Type: tea.KeyRunes, Runes: []rune{'u'}}} {
+		uiModel.ActiveTab = TabTemplates
+		uiModel.Mode = "date"
+		uiModel.SelectedTemplate = 1
+		updatedModel, _ := uiModel.Update(key)
+		uiModel = updatedModel.(*Model)
+		if uiModel.CurrentTrip.Destination != "School" {
+			t.Fatalf("Expected the Beta template used, got %+v", uiModel.CurrentTrip)
+		}
+	}
+	if uiModel.TripTemplates[1].UsageCount != 2 {
+		t.Errorf("Expected Beta used twice, got %d", uiModel.TripTemplates[1].UsageCount)
+	}
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if saved.TripTemplates[1].UsageCount != 2 {
+		t.Errorf("Expected the usage count saved, got %d", saved.TripTemplates[1].UsageCount)
+	}
+
+	names := func() []string {
+		templates, _ := uiModel.displayTemplates()
+		var names []string
+		for _, template := range templates {
+			names = append(names, template.Name)
+		}
+		return names
+	}
+	if got := strings.Join(names(), ","); got != "Alpha,Beta,Gamma" {
+		t.Errorf("Expected templates sorted by name, got %s", got)
+	}
+
+	// O sorts by usage, keeping the selected template selected
+	uiModel.ActiveTab = TabTemplates
+	uiModel.Mode = "date"
+	uiModel.TextInput.Reset()
+	uiModel.SelectedTemplate = 0
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	uiModel = updatedModel.(*Model)
+	if !uiModel.TemplatesByUsage {
+		t.Fatal("Expected O to sort templates by usage")
+	}
+	if got := strings.Join(names(), ","); got != "Beta,Gamma,Alpha" {
+		t.Errorf("Expected templates sorted by usage, got %s", got)
+	}
+	if uiModel.SelectedTemplate != 0 || uiModel.TextInput.Value() != "" {
+		t.Errorf("Expected Alpha still selected and no input typed, got %d and %q", uiModel.SelectedTemplate, uiModel.TextInput.Value())
+	}
+	if view := uiModel.View(); !strings.Contains(view, "most used first") || !strings.Contains(view, "(used: 2)") {
+		t.Error("Expected the view to show the usage order and counts")
+	}
+
+	// Down from the top of the usage order selects the next template in that order
+	uiModel.SelectedTemplate = 1
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	if uiModel.SelectedTemplate != 2 {
+		t.Errorf("Expected Gamma selected after Beta, got %d", uiModel.SelectedTemplate)
+	}
+}
+
 func TestTemplateSorting(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
@@ -2287,6 +4392,64 @@ func TestWeeklySummaryUpdateAfterTripEdit(t *testing.T) {
 	}
 }
 
+func TestTemplateSearch(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.TripTemplates = []model.TripTemplate{
+		{Name: "Zoo Visit", Origin: "Home", Destination: "City Zoo", TripType: "round"},
+		{Name: "School Run", Origin: "Home", Destination: "Elementary", TripType: "single"},
+		{Name: "Art Class", Origin: "Home", Destination: "Studio", TripType: "round"},
+		{Name: "Pickup", Origin: "Elementary", Destination: "Home", TripType: "single"},
+	}
+	uiModel.ActiveTab = TabTemplates
+	uiModel.SearchMode = true
+	uiModel.SearchQuery = "elementary"
+
+	// Matches on name, origin or destination, still sorted by name
+	templates, indexes := uiModel.displayTemplates()
+	if len(templates) != 2 || templates[0].Name != "Pickup" || templates[1].Name != "School Run" {
+		t.Fatalf("Expected Pickup and School Run, got %+v", templates)
+	}
+	if indexes[0] != 3 || indexes[1] != 1 {
+		t.Errorf("Expected original indexes [3 1], got %v", indexes)
+	}
+
+	view := uiModel.View()
+	if strings.Contains(view, "Zoo Visit") || strings.Contains(view, "Art Class") {
+		t.Errorf("Expected non-matching templates to be hidden, got view:\n%s", view)
+	}
+	if !strings.Contains(view, `Search: "elementary"`) {
+		t.Errorf("Expected the status bar to show the search query, got view:\n%s", view)
+	}
+
+	// Navigation walks the filtered list and selects by original index
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	if uiModel.SelectedTemplate != 3 {
+		t.Errorf("Expected Pickup (index 3) to be selected, got %d", uiModel.SelectedTemplate)
+	}
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	if uiModel.SelectedTemplate != 1 {
+		t.Errorf("Expected School Run (index 1) to be selected, got %d", uiModel.SelectedTemplate)
+	}
+	if view := uiModel.View(); !strings.Contains(view, "* School Run: Home → Elementary") {
+		t.Errorf("Expected School Run to be highlighted, got view:\n%s", view)
+	}
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	if uiModel.SelectedTemplate != 3 {
+		t.Errorf("Expected selection to wrap to Pickup (index 3), got %d", uiModel.SelectedTemplate)
+	}
+
+	// Leaving search mode shows every template again
+	uiModel.SearchMode = false
+	if templates, _ := uiModel.displayTemplates(); len(templates) != 4 {
+		t.Errorf("Expected all 4 templates without search, got %d", len(templates))
+	}
+}
+
 func TestTemplateNavigationWithSorting(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
@@ -2377,64 +4540,237 @@ func TestTemplateNavigationWithSorting(t *testing.T) {
 		t.Errorf("Expected wrap-around to Apple Trip, got %s", selectedTemplate.Name)
 	}
 
-	// Test up arrow (should go back to Zebra Trip)
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
-	uiModel = updatedModel.(*Model)
+	// Test up arrow (should go back to Zebra Trip)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	uiModel = updatedModel.(*Model)
+
+	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
+	if selectedTemplate.Name != "Zebra Trip" {
+		t.Errorf("Expected up arrow to go to Zebra Trip, got %s", selectedTemplate.Name)
+	}
+
+	// Test up arrow again (should go to Banana Trip)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	uiModel = updatedModel.(*Model)
+
+	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
+	if selectedTemplate.Name != "Banana Trip" {
+		t.Errorf("Expected up arrow to go to Banana Trip, got %s", selectedTemplate.Name)
+	}
+
+	// Test up arrow again (should go to Apple Trip)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	uiModel = updatedModel.(*Model)
+
+	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
+	if selectedTemplate.Name != "Apple Trip" {
+		t.Errorf("Expected up arrow to go to Apple Trip, got %s", selectedTemplate.Name)
+	}
+
+	// Test up arrow again (should wrap around to Zebra Trip)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	uiModel = updatedModel.(*Model)
+
+	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
+	if selectedTemplate.Name != "Zebra Trip" {
+		t.Errorf("Expected wrap-around to Zebra Trip, got %s", selectedTemplate.Name)
+	}
+}
+
+func TestRenderStatusBar(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	// Test Weekly Summary tab
+	uiModel.ActiveTab = TabWeeklySummaries
+	uiModel.Mode = "date"
+	status := uiModel.renderStatusBar()
+	if !strings.Contains(status, "📊 Weekly Summary") {
+		t.Errorf("Expected status to contain '📊 Weekly Summary', got: %s", status)
+	}
+
+	// Test Trips tab with search
+	uiModel.ActiveTab = TabTrips
+	uiModel.SearchMode = true
+	uiModel.SearchQuery = "grocery"
+	status = uiModel.renderStatusBar()
+	if !strings.Contains(status, "🚗 Trips") {
+		t.Errorf("Expected status to contain '🚗 Trips', got: %s", status)
+	}
+	if !strings.Contains(status, "Search: \"grocery\"") {
+		t.Errorf("Expected status to contain search query, got: %s", status)
+	}
+}
+
+func TestEditExpenseAmount(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	for _, expense := range []model.Expense{
+		{Date: "2024-03-18", Amount: 25.50, Description: "Lunch", Category: "food"},
+		{Date: "2024-03-19", Amount: 10.00, Description: "Crayons", Category: "supplies"},
+	} {
+		if err := uiModel.Data.AddExpense(expense); err != nil {
+			t.Fatalf("Failed to add expense: %v", err)
+		}
+	}
+	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile, uiModel.RoundingMode, time.Sunday)
+	uiModel.ActiveTab = TabExpenses
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	selected := uiModel.Data.Expenses[uiModel.SelectedExpense]
+
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "expense_edit_date" {
+		t.Fatalf("Expected mode to be 'expense_edit_date', got '%s'", uiModel.Mode)
+	}
+	if uiModel.TextInput.Value() != selected.Date {
+		t.Errorf("Expected date prefilled with %s, got %s", selected.Date, uiModel.TextInput.Value())
+	}
+
+	// Keep the date, change the amount, keep the description
+	for _, value := range []string{"", "40", ""} {
+		uiModel.TextInput.SetValue(value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err != nil {
+			t.Fatalf("Unexpected error: %v", uiModel.Err)
+		}
+	}
+
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to return to 'date', got '%s'", uiModel.Mode)
+	}
+	var edited model.Expense
+	for _, expense := range uiModel.Data.Expenses {
+		if expense.Description == selected.Description {
+			edited = expense
+		}
+	}
+	if edited.Amount != 40 || edited.Date != selected.Date || edited.Category != selected.Category {
+		t.Errorf("Expected only the amount to change to 40, got %+v", edited)
+	}
+
+	wantTotal := 35.50 - selected.Amount + 40
+	if len(uiModel.Data.WeeklySummaries) != 1 || uiModel.Data.WeeklySummaries[0].TotalExpenses != wantTotal {
+		t.Errorf("Expected weekly expenses of %.2f, got %+v", wantTotal, uiModel.Data.WeeklySummaries)
+	}
+
+	// An invalid amount keeps the edit open
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	uiModel = updatedModel.(*Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	uiModel.TextInput.SetValue("-5")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Err == nil || uiModel.Mode != "expense_edit_amount" {
+		t.Errorf("Expected an error and to stay on the amount, got err %v in mode %s", uiModel.Err, uiModel.Mode)
+	}
+}
+
+func TestDeleteExpense(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
 
-	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
-	if selectedTemplate.Name != "Zebra Trip" {
-		t.Errorf("Expected up arrow to go to Zebra Trip, got %s", selectedTemplate.Name)
+	for _, expense := range []model.Expense{
+		{Date: "2024-03-18", Amount: 25.50, Description: "Lunch"},
+		{Date: "2024-03-19", Amount: 10.00, Description: "Crayons"},
+	} {
+		if err := uiModel.Data.AddExpense(expense); err != nil {
+			t.Fatalf("Failed to add expense: %v", err)
+		}
 	}
+	model.CalculateAndUpdateWeeklySummaries(uiModel.Data, uiModel.RatePerMile, uiModel.RoundingMode, time.Sunday)
+	uiModel.ActiveTab = TabExpenses
 
-	// Test up arrow again (should go to Banana Trip)
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
 	uiModel = updatedModel.(*Model)
+	selected := uiModel.Data.Expenses[uiModel.SelectedExpense]
 
-	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
-	if selectedTemplate.Name != "Banana Trip" {
-		t.Errorf("Expected up arrow to go to Banana Trip, got %s", selectedTemplate.Name)
+	// Anything other than "yes" cancels
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "expense_delete_confirm" {
+		t.Fatalf("Expected mode to be 'expense_delete_confirm', got '%s'", uiModel.Mode)
 	}
-
-	// Test up arrow again (should go to Apple Trip)
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	uiModel.TextInput.SetValue("no")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*Model)
-
-	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
-	if selectedTemplate.Name != "Apple Trip" {
-		t.Errorf("Expected up arrow to go to Apple Trip, got %s", selectedTemplate.Name)
+	if len(uiModel.Data.Expenses) != 2 || uiModel.Mode != "date" {
+		t.Fatalf("Expected cancel to keep both expenses and reset mode, got %d in mode %s", len(uiModel.Data.Expenses), uiModel.Mode)
 	}
 
-	// Test up arrow again (should wrap around to Zebra Trip)
-	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyUp})
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
 	uiModel = updatedModel.(*Model)
 
-	selectedTemplate = uiModel.TripTemplates[uiModel.SelectedTemplate]
-	if selectedTemplate.Name != "Zebra Trip" {
-		t.Errorf("Expected wrap-around to Zebra Trip, got %s", selectedTemplate.Name)
+	if len(uiModel.Data.Expenses) != 1 || uiModel.Data.Expenses[0].Description == selected.Description {
+		t.Errorf("Expected %s to be deleted, got %+v", selected.Description, uiModel.Data.Expenses)
+	}
+	if uiModel.Mode != "date" || uiModel.SelectedExpense != -1 {
+		t.Errorf("Expected mode and selection to reset, got mode %s and selection %d", uiModel.Mode, uiModel.SelectedExpense)
+	}
+	if uiModel.Data.WeeklySummaries[0].TotalExpenses != 35.50-selected.Amount {
+		t.Errorf("Expected weekly expenses of %.2f, got %.2f", 35.50-selected.Amount, uiModel.Data.WeeklySummaries[0].TotalExpenses)
+	}
+	if len(uiModel.Data.WeeklySummaries[0].Expenses) != 1 {
+		t.Errorf("Expected 1 expense in the weekly summary, got %d", len(uiModel.Data.WeeklySummaries[0].Expenses))
 	}
 }
 
-func TestRenderStatusBar(t *testing.T) {
+func TestExpenseSearch(t *testing.T) {
 	uiModel, cleanup := setupTestUI(t)
 	defer cleanup()
 
-	// Test Weekly Summary tab
-	uiModel.ActiveTab = TabWeeklySummaries
-	uiModel.Mode = "date"
-	status := uiModel.renderStatusBar()
-	if !strings.Contains(status, "📊 Weekly Summary") {
-		t.Errorf("Expected status to contain '📊 Weekly Summary', got: %s", status)
+	uiModel.Data.Expenses = []model.Expense{
+		{Date: "2024-03-20", Amount: 25.50, Description: "Grocery run"},
+		{Date: "2024-03-21", Amount: 12.00, Description: "Museum tickets"},
+		{Date: "2024-04-02", Amount: 8.75, Description: "grocery snacks"},
 	}
-
-	// Test Trips tab with search
-	uiModel.ActiveTab = TabTrips
+	uiModel.ActiveTab = TabExpenses
 	uiModel.SearchMode = true
-	uiModel.SearchQuery = "grocery"
-	status = uiModel.renderStatusBar()
-	if !strings.Contains(status, "🚗 Trips") {
-		t.Errorf("Expected status to contain '🚗 Trips', got: %s", status)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"GROCERY", 2},
+		{"museum", 1},
+		{"2024-03", 2},
+		{"parking", 0},
+		{"", 3},
+	}
+
+	for _, tt := range tests {
+		uiModel.SearchQuery = tt.query
+		filtered := uiModel.filterExpensesBySearch()
+		if len(filtered) != tt.want {
+			t.Errorf("Query %q: expected %d expenses, got %d", tt.query, tt.want, len(filtered))
+		}
 	}
-	if !strings.Contains(status, "Search: \"grocery\"") {
+
+	// The expenses view only lists matching expenses
+	uiModel.SearchQuery = "Grocery"
+	view := uiModel.View()
+	if !strings.Contains(view, "Grocery run") || !strings.Contains(view, "grocery snacks") {
+		t.Errorf("Expected matching expenses in view, got: %s", view)
+	}
+	if strings.Contains(view, "Museum tickets") {
+		t.Errorf("Expected non-matching expense to be hidden, got: %s", view)
+	}
+
+	// The status bar shows the active expense search query
+	status := uiModel.renderStatusBar()
+	if !strings.Contains(status, "Search: \"Grocery\"") {
 		t.Errorf("Expected status to contain search query, got: %s", status)
 	}
 }
@@ -2839,3 +5175,400 @@ func TestTemplateCreationAfterEdit(t *testing.T) {
 		t.Errorf("Expected destination '296 Carmita Avenue, Rutherford, NJ', got '%s'", secondTemplate.Destination)
 	}
 }
+
+func TestRecurringTripNextOccurrenceShown(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-15"
+	uiModel.RecurringTrips = []model.RecurringTrip{
+		{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", Type: "single", Weekday: 3},
+		{Origin: "Home", Destination: "Camp", Miles: 8.0, StartDate: "2024-01-01", EndDate: "2024-02-29", Type: "single", Weekday: 1},
+	}
+	uiModel.ActiveTab = TabTrips
+
+	view := uiModel.View()
+	if !strings.Contains(view, "next: 2024-03-20") {
+		t.Errorf("Expected view to show the next occurrence, got: %s", view)
+	}
+	if !strings.Contains(view, "(ended)") {
+		t.Errorf("Expected view to mark the finished recurring trip as ended, got: %s", view)
+	}
+}
+
+func TestEditRecurringTripWeekday(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-15"
+	recurring := model.RecurringTrip{
+		Origin:      "Home",
+		Destination: "School",
+		Miles:       4.0,
+		StartDate:   "2024-03-01",
+		EndDate:     "2024-03-31",
+		Type:        "single",
+		Weekday:     3, // Wednesday
+	}
+	if err := uiModel.Data.AddRecurringTrip(recurring); err != nil {
+		t.Fatalf("Failed to add recurring trip: %v", err)
+	}
+	// A one-off trip on a Wednesday is not part of the pattern
+	uiModel.AddTrip(model.Trip{Date: "2024-03-13", Origin: "Home", Destination: "Park", Miles: 2.0, Type: "single"})
+	if err := uiModel.Data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	uiModel.Trips = uiModel.Data.Trips
+	uiModel.RecurringTrips = uiModel.Data.RecurringTrips
+	uiModel.ActiveTab = TabTrips
+
+	// Select the recurring trip and start editing it
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	uiModel = updatedModel.(*Model)
+	if uiModel.SelectedRecurring != 0 || uiModel.SelectedTrip != -1 {
+		t.Fatalf("Expected recurring trip 0 selected, got recurring %d, trip %d", uiModel.SelectedRecurring, uiModel.SelectedTrip)
+	}
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+	if uiModel.Mode != "recurring_edit_date" {
+		t.Fatalf("Expected mode to be 'recurring_edit_date', got '%s'", uiModel.Mode)
+	}
+
+	// Keep everything except the weekday, which moves to Friday
+	steps := []struct {
+		mode  string
+		value string
+	}{
+		{"recurring_edit_date", "2024-03-01"},
+		{"recurring_edit_weekday", "5"},
+		{"recurring_edit_origin", "Home"},
+		{"recurring_edit_destination", "School"},
+		{"recurring_edit_type", "single"},
+		{"recurring_edit_end_date", "2024-03-31"},
+	}
+	for _, step := range steps {
+		if uiModel.Mode != step.mode {
+			t.Fatalf("Expected mode to be '%s', got '%s'", step.mode, uiModel.Mode)
+		}
+		if uiModel.TextInput.Value() != step.value && step.mode != "recurring_edit_weekday" {
+			t.Errorf("Expected %s to be prefilled with %q, got %q", step.mode, step.value, uiModel.TextInput.Value())
+		}
+		uiModel.TextInput.SetValue(step.value)
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err != nil {
+			t.Fatalf("Unexpected error at %s: %v", step.mode, uiModel.Err)
+		}
+	}
+
+	if uiModel.Mode != "date" {
+		t.Errorf("Expected mode to return to 'date', got '%s'", uiModel.Mode)
+	}
+	if uiModel.Data.RecurringTrips[0].Weekday != 5 {
+		t.Errorf("Expected weekday 5, got %d", uiModel.Data.RecurringTrips[0].Weekday)
+	}
+
+	// The Wednesday instances are gone, replaced by Fridays; the one-off trip stays
+	dates := make(map[string]string)
+	for _, trip := range uiModel.Data.Trips {
+		dates[trip.Date] = trip.Destination
+	}
+	for _, date := range []string{"2024-03-06", "2024-03-20", "2024-03-27"} {
+		if _, ok := dates[date]; ok {
+			t.Errorf("Expected old Wednesday trip on %s to be removed", date)
+		}
+	}
+	for _, date := range []string{"2024-03-01", "2024-03-08", "2024-03-15", "2024-03-22", "2024-03-29"} {
+		if dates[date] != "School" {
+			t.Errorf("Expected generated Friday trip on %s", date)
+		}
+	}
+	if dates["2024-03-13"] != "Park" {
+		t.Error("Expected the one-off Wednesday trip to be kept")
+	}
+	if len(uiModel.Data.Trips) != 6 {
+		t.Errorf("Expected 6 trips after the edit, got %d", len(uiModel.Data.Trips))
+	}
+
+	// The edit is persisted
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load saved data: %v", err)
+	}
+	if saved.RecurringTrips[0].Weekday != 5 || len(saved.Trips) != 6 {
+		t.Errorf("Expected saved data to reflect the edit, got weekday %d and %d trips", saved.RecurringTrips[0].Weekday, len(saved.Trips))
+	}
+}
+
+func TestDeleteRecurringTrip(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.ReferenceDate = "2024-03-15"
+	recurring := model.RecurringTrip{
+		Origin:      "Home",
+		Destination: "School",
+		Miles:       4.0,
+		StartDate:   "2024-03-01",
+		EndDate:     "2024-03-31",
+		Type:        "single",
+		Weekday:     3, // Wednesday
+	}
+	if err := uiModel.Data.AddRecurringTrip(recurring); err != nil {
+		t.Fatalf("Failed to add recurring trip: %v", err)
+	}
+	uiModel.AddTrip(model.Trip{Date: "2024-03-14", Origin: "Home", Destination: "Park", Miles: 2.0, Type: "single"})
+	if err := uiModel.Data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	uiModel.Trips = uiModel.Data.Trips
+	uiModel.RecurringTrips = uiModel.Data.RecurringTrips
+	uiModel.ActiveTab = TabTrips
+
+	var updatedModel tea.Model
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyShiftDown})
+	uiModel = updatedModel.(*Model)
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+
+	if uiModel.Mode != "recurring_delete_confirm" {
+		t.Fatalf("Expected mode to be 'recurring_delete_confirm', got '%s'", uiModel.Mode)
+	}
+	if !strings.Contains(uiModel.TextInput.Placeholder, "4 trip(s) generated") {
+		t.Errorf("Expected prompt to mention the 4 generated trips, got %q", uiModel.TextInput.Placeholder)
+	}
+
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if len(uiModel.Data.RecurringTrips) != 0 || len(uiModel.RecurringTrips) != 0 {
+		t.Errorf("Expected the recurring trip to be deleted, got %d", len(uiModel.Data.RecurringTrips))
+	}
+	if len(uiModel.Data.Trips) != 1 || uiModel.Data.Trips[0].Destination != "Park" {
+		t.Errorf("Expected only the one-off trip to remain, got %+v", uiModel.Data.Trips)
+	}
+	if total := model.CalculateGrandTotal(uiModel.Data.WeeklySummaries); total.TotalMiles != 2.0 {
+		t.Errorf("Expected summaries to be recalculated to 2 miles, got %.2f", total.TotalMiles)
+	}
+
+	saved, err := uiModel.Storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load saved data: %v", err)
+	}
+	if len(saved.RecurringTrips) != 0 || len(saved.Trips) != 1 {
+		t.Errorf("Expected deletion to be saved, got %d recurring and %d trips", len(saved.RecurringTrips), len(saved.Trips))
+	}
+
+	// Undo brings back the pattern and its trips
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	uiModel = updatedModel.(*Model)
+	if len(uiModel.Data.RecurringTrips) != 1 || len(uiModel.Data.Trips) != 5 {
+		t.Errorf("Expected undo to restore 1 recurring and 5 trips, got %d and %d", len(uiModel.Data.RecurringTrips), len(uiModel.Data.Trips))
+	}
+}
+
+func TestEmptyStateHints(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	hints := map[int]string{
+		TabWeeklySummaries: "No weekly summaries yet",
+		TabTrips:           "No trips yet — press Enter after typing a date to add your first trip",
+		TabExpenses:        "No expenses yet — press [Ctrl+X]",
+		TabTemplates:       "No trip templates yet — press [Ctrl+T]",
+	}
+	for tab, hint := range hints {
+		uiModel.ActiveTab = tab
+		if view := uiModel.View(); !strings.Contains(view, hint) {
+			t.Errorf("Expected tab %d to show the hint %q without data, got: %s", tab, hint, view)
+		}
+	}
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-18", Origin: "Home", Destination: "School", Miles: 5, Type: "single"})
+	uiModel.Data.Expenses = []model.Expense{{Date: "2024-03-18", Amount: 4.5, Description: "Snacks"}}
+	uiModel.TripTemplates = []model.TripTemplate{{Name: "School run", Origin: "Home", Destination: "School", TripType: "single"}}
+	for tab, hint := range hints {
+		uiModel.ActiveTab = tab
+		if view := uiModel.View(); strings.Contains(view, hint) {
+			t.Errorf("Expected no hint on tab %d once it has data, got: %s", tab, view)
+		}
+	}
+
+	// A family with no trips of its own gets the plain message rather than the starter hint
+	uiModel.ActiveFamily = "Jones"
+	uiModel.ActiveTab = TabTrips
+	view := uiModel.View()
+	if !strings.Contains(view, "No trips available.") || strings.Contains(view, hints[TabTrips]) {
+		t.Errorf("Expected the plain empty message for a filtered list, got: %s", view)
+	}
+}
+
+func TestTripEntryStepsBack(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+	uiModel.ActiveTab = TabTrips
+
+	press := func(key tea.KeyType, value string) {
+		t.Helper()
+		if value != "" {
+			uiModel.TextInput.SetValue(value)
+		}
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: key})
+		uiModel = updatedModel.(*Model)
+		if uiModel.Err != nil {
+			t.Fatalf("Unexpected error: %v", uiModel.Err)
+		}
+	}
+
+	press(tea.KeyEnter, "2024-03-20")
+	press(tea.KeyEnter, "Hmoe")
+	uiModel.TextInput.SetValue("Sch")
+
+	// Going back from the destination restores the mistyped origin without switching tabs
+	press(tea.KeyShiftTab, "")
+	if uiModel.Mode != "origin" || uiModel.TextInput.Value() != "Hmoe" {
+		t.Fatalf("Expected to be back at the origin 'Hmoe', got mode %q with %q", uiModel.Mode, uiModel.TextInput.Value())
+	}
+	if uiModel.ActiveTab != TabTrips {
+		t.Errorf("Expected to stay on the Trips tab, got %d", uiModel.ActiveTab)
+	}
+	press(tea.KeyEnter, "Home")
+	press(tea.KeyEnter, "School")
+
+	// The type steps back to the destination, which is kept as entered
+	press(tea.KeyShiftTab, "")
+	if uiModel.Mode != "destination" || uiModel.TextInput.Value() != "School" {
+		t.Fatalf("Expected to be back at the destination 'School', got mode %q with %q", uiModel.Mode, uiModel.TextInput.Value())
+	}
+	press(tea.KeyEnter, "")
+	press(tea.KeyEnter, "single")
+	for i := 0; uiModel.Mode != "date" && i < 10; i++ {
+		press(tea.KeyEnter, "")
+	}
+
+	if len(uiModel.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(uiModel.Trips))
+	}
+	if trip := uiModel.Trips[0]; trip.Date != "2024-03-20" || trip.Origin != "Home" || trip.Destination != "School" {
+		t.Errorf("Expected the corrected trip Home → School on 2024-03-20, got %+v", trip)
+	}
+
+	// Editing steps back the same way, restoring the date
+	uiModel.SelectedTrip = 0
+	press(tea.KeyCtrlE, "")
+	press(tea.KeyEnter, "")
+	press(tea.KeyShiftTab, "")
+	if uiModel.Mode != "edit" || uiModel.EditIndex != 0 || uiModel.TextInput.Value() != "2024-03-20" {
+		t.Errorf("Expected to be back at the date while editing, got mode %q, index %d with %q", uiModel.Mode, uiModel.EditIndex, uiModel.TextInput.Value())
+	}
+	if !reflect.DeepEqual(uiModel.CurrentTrip, uiModel.Trips[0]) {
+		t.Errorf("Expected the trip being edited to be unchanged, got %+v", uiModel.CurrentTrip)
+	}
+}
+
+func TestTripActionsUseSelectedRowWithActiveFamily(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.AddTrip(model.Trip{Date: "2024-03-21", Origin: "Home", Destination: "Pool", Miles: 3, Type: "single", Family: "a"})
+	uiModel.AddTrip(model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Library", Miles: 4, Type: "single", Family: "b"})
+	uiModel.Families = []string{"a", "b"}
+	uiModel.ActiveFamily = "b"
+	uiModel.ActiveTab = TabTrips
+	uiModel.SelectedTrip = 0
+
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+	if uiModel.CurrentTrip.Destination != "Library" {
+		t.Errorf("Expected Ctrl+E to edit family b's trip to the Library, got %+v", uiModel.CurrentTrip)
+	}
+	uiModel.Mode = "date"
+	uiModel.CurrentTrip = model.Trip{}
+	uiModel.TextInput.Reset()
+
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	uiModel = updatedModel.(*Model)
+	if uiModel.CurrentTrip.Destination != "Library" {
+		t.Errorf("Expected Ctrl+Y to duplicate family b's trip to the Library, got %+v", uiModel.CurrentTrip)
+	}
+	uiModel.CurrentTrip = model.Trip{}
+	uiModel.TextInput.Reset()
+
+	uiModel.SelectedTrip = 0
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+	for _, trip := range uiModel.Data.Trips {
+		if trip.Archived != (trip.Destination == "Library") {
+			t.Errorf("Expected only the Library trip archived, got %+v", trip)
+		}
+	}
+}
+
+func TestDeleteExpenseUsesSearchedRow(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.Expenses = []model.Expense{
+		{Date: "2024-03-21", Amount: 12, Description: "Lunch"},
+		{Date: "2024-03-20", Amount: 40, Description: "Gas"},
+	}
+	uiModel.ActiveTab = TabExpenses
+	uiModel.SearchMode = true
+	uiModel.SearchQuery = "gas"
+	uiModel.SelectedExpense = 0
+
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	uiModel = updatedModel.(*Model)
+	uiModel.TextInput.SetValue("yes")
+	updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	uiModel = updatedModel.(*Model)
+
+	if len(uiModel.Data.Expenses) != 1 || uiModel.Data.Expenses[0].Description != "Lunch" {
+		t.Errorf("Expected only the Gas expense deleted, got %+v", uiModel.Data.Expenses)
+	}
+}
+
+func TestEditExpenseUsesSelectedRowWithActiveFamily(t *testing.T) {
+	uiModel, cleanup := setupTestUI(t)
+	defer cleanup()
+
+	uiModel.Data.Expenses = []model.Expense{
+		{Date: "2024-03-21", Amount: 12, Description: "Lunch", Family: "a"},
+		{Date: "2024-03-20", Amount: 40, Description: "Gas", Family: "b"},
+	}
+	uiModel.Families = []string{"a", "b"}
+	uiModel.ActiveFamily = "b"
+	uiModel.ActiveTab = TabExpenses
+
+	// Only one expense is shown, so moving down twice wraps back onto it
+	for i := 0; i < 2; i++ {
+		updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyDown})
+		uiModel = updatedModel.(*Model)
+	}
+	if uiModel.SelectedExpense != 0 {
+		t.Fatalf("Expected the selection to stay on the only displayed expense, got %d", uiModel.SelectedExpense)
+	}
+
+	updatedModel, _ := uiModel.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	uiModel = updatedModel.(*Model)
+	if uiModel.CurrentExpense.Description != "Gas" {
+		t.Fatalf("Expected Ctrl+E to edit the Gas expense, got %+v", uiModel.CurrentExpense)
+	}
+	for _, value := range []string{"", "", "Gas refill"} {
+		if value != "" {
+			uiModel.TextInput.SetValue(value)
+		}
+		updatedModel, _ = uiModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		uiModel = updatedModel.(*Model)
+	}
+
+	descriptions := map[string]bool{}
+	for _, expense := range uiModel.Data.Expenses {
+		descriptions[expense.Description] = true
+	}
+	if !descriptions["Lunch"] || !descriptions["Gas refill"] {
+		t.Errorf("Expected Lunch untouched and Gas renamed, got %+v", uiModel.Data.Expenses)
+	}
+}
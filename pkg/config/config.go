@@ -1,20 +1,79 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
 )
 
 const (
 	//
-	DefaultRatePerMile = 0.70
-	DefaultDataFile    = "trips.json"
+	DefaultRatePerMile       = 0.70
+	DefaultDataFile          = "trips.json"
+	DefaultDistanceCacheFile = "distance_cache.json"
+	DefaultPageSize          = 10
+	DefaultMaxFutureDays     = 365
+	DefaultReadTimeout       = 10 * time.Second
+	DefaultWriteTimeout      = 10 * time.Second
+	DefaultIdleTimeout       = 60 * time.Second
+	// DefaultAllowedOrigin is the web frontend's development server
+	DefaultAllowedOrigin = "http://localhost:3000"
 )
 
 type Config struct {
-	RatePerMile float64
-	DataFile    string
-	DataDir     string
+	RatePerMile  float64
+	DataFile     string
+	DataDir      string
+	PageSize     int    // Number of items per page in the TUI
+	HomeAddress  string // Default trip origin; empty means no default
+	RoundingMode string // How mileage reimbursement totals are rounded: none, cent or nearest_dollar
+	// MaxFutureDays limits how far ahead a trip may be dated; zero disables the check
+	MaxFutureDays int
+	// MaxTripMiles rejects trips longer than this one-way distance, catching bad geocodes; zero disables the check
+	MaxTripMiles float64
+	Families     []string // Known families that trips and expenses can be billed to
+	// ManualMiles turns off Google Maps so trip miles are always entered by hand
+	ManualMiles bool
+	// WeekStartDay is the day weekly summaries begin on; Sunday unless configured
+	WeekStartDay time.Weekday
+	// WeeklyMileageTarget is the miles aimed for each week, shown as progress; zero hides it
+	WeeklyMileageTarget float64
+	// StrictData refuses to start from a corrupt data file instead of backing it up and starting fresh
+	StrictData bool
+	// RememberPageSize keeps a page size chosen in the TUI for the next run (see Preferences)
+	RememberPageSize bool
+	// DateFormat is how dates are typed and shown (see model.DateFormatISO etc.); they are
+	// always stored as YYYY-MM-DD
+	DateFormat string
+	// AuditLogPath is the JSON Lines file the web server appends each change to; empty disables it
+	AuditLogPath string
+	// SaveInterval is how long the web server may hold changes in memory before writing
+	// them to disk; zero writes every change immediately
+	SaveInterval time.Duration
+	// RateSchedule lists mileage rate changes by date, oldest first; dates before the
+	// first change use RatePerMile
+	RateSchedule []model.RateChange
+	// ReadTimeout, WriteTimeout and IdleTimeout bound how long the web server waits on a
+	// request, its response and an idle keep-alive connection
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// AllowedOrigins lists the browser origins allowed to call the web API from another
+	// site; "*" allows any origin
+	AllowedOrigins []string
+	// APIKey, when set, must accompany every web API request; empty leaves the API open
+	APIKey string
+	// LogLevel is the least severe level the web server logs; info unless configured
+	LogLevel slog.Level
 }
 
 func New() (*Config, error) {
@@ -23,31 +82,427 @@ func New() (*Config, error) {
 	dataFile := os.Getenv("NANNYTRACKER_DATA_FILE")
 	ratePerMile := DefaultRatePerMile
 
+	// A full data path overrides both the directory and the file name
+	if dataPath := os.Getenv("NANNYTRACKER_DATA_PATH"); dataPath != "" {
+		var ok bool
+		if dataDir, dataFile, ok = splitDataPath(dataPath); !ok {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_DATA_PATH %q: must name a file", dataPath)
+		}
+	}
+
 	// If no environment variables are set, use defaults
 	if dataDir == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			return nil, err
 		}
-		dataDir = filepath.Join(homeDir, ".nannytracker")
+		dataDir = defaultDataDir(runtime.GOOS, homeDir, os.Getenv("XDG_DATA_HOME"))
 	}
 
 	if dataFile == "" {
 		dataFile = DefaultDataFile
 	}
 
+	pageSize := DefaultPageSize
+	if value := os.Getenv("NANNYTRACKER_PAGE_SIZE"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_PAGE_SIZE %q: must be a positive integer", value)
+		}
+		pageSize = parsed
+	}
+
+	roundingMode := os.Getenv("NANNYTRACKER_ROUNDING_MODE")
+	if roundingMode == "" {
+		roundingMode = model.RoundingNone
+	}
+	if !model.IsValidRoundingMode(roundingMode) {
+		return nil, fmt.Errorf("invalid NANNYTRACKER_ROUNDING_MODE %q: must be none, cent or nearest_dollar", roundingMode)
+	}
+
+	dateFormat := model.DateFormatISO
+	if value := os.Getenv("NANNYTRACKER_DATE_FORMAT"); value != "" {
+		dateFormat = strings.ToLower(value)
+		if !model.IsValidDateFormat(dateFormat) {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_DATE_FORMAT %q: must be iso, us or eu", value)
+		}
+	}
+
+	maxFutureDays := DefaultMaxFutureDays
+	if value := os.Getenv("NANNYTRACKER_MAX_FUTURE_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_MAX_FUTURE_DAYS %q: must be a non-negative integer", value)
+		}
+		maxFutureDays = parsed
+	}
+
+	var maxTripMiles float64
+	if value := os.Getenv("NANNYTRACKER_MAX_TRIP_MILES"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_MAX_TRIP_MILES %q: must be a non-negative number", value)
+		}
+		maxTripMiles = parsed
+	}
+
+	var weeklyMileageTarget float64
+	if value := os.Getenv("NANNYTRACKER_WEEKLY_MILEAGE_TARGET"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_WEEKLY_MILEAGE_TARGET %q: must be a non-negative number", value)
+		}
+		weeklyMileageTarget = parsed
+	}
+
+	var manualMiles bool
+	if value := os.Getenv("NANNYTRACKER_MANUAL_MILES"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_MANUAL_MILES %q: must be true or false", value)
+		}
+		manualMiles = parsed
+	}
+
+	var strictData bool
+	if value := os.Getenv("NANNYTRACKER_STRICT_DATA"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_STRICT_DATA %q: must be true or false", value)
+		}
+		strictData = parsed
+	}
+
+	var rememberPageSize bool
+	if value := os.Getenv("NANNYTRACKER_REMEMBER_PAGE_SIZE"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_REMEMBER_PAGE_SIZE %q: must be true or false", value)
+		}
+		rememberPageSize = parsed
+	}
+
+	var saveInterval time.Duration
+	if value := os.Getenv("NANNYTRACKER_SAVE_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_SAVE_INTERVAL %q: must be a non-negative duration such as 5s", value)
+		}
+		saveInterval = parsed
+	}
+
+	readTimeout, err := parseTimeout("NANNYTRACKER_READ_TIMEOUT", DefaultReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+	writeTimeout, err := parseTimeout("NANNYTRACKER_WRITE_TIMEOUT", DefaultWriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout, err := parseTimeout("NANNYTRACKER_IDLE_TIMEOUT", DefaultIdleTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	weekStartDay := time.Sunday
+	if value := os.Getenv("NANNYTRACKER_WEEK_START"); value != "" {
+		parsed, ok := parseWeekday(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_WEEK_START %q: must be a day of the week such as sunday or monday", value)
+		}
+		weekStartDay = parsed
+	}
+
+	rateSchedule, err := parseRateSchedule(os.Getenv("NANNYTRACKER_RATE_SCHEDULE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NANNYTRACKER_RATE_SCHEDULE: %w", err)
+	}
+
+	allowedOrigins := []string{DefaultAllowedOrigin}
+	if value := os.Getenv("NANNYTRACKER_ALLOWED_ORIGINS"); value != "" {
+		allowedOrigins, err = parseAllowedOrigins(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_ALLOWED_ORIGINS: %w", err)
+		}
+	}
+
+	logLevel := slog.LevelInfo
+	if value := os.Getenv("NANNYTRACKER_LOG_LEVEL"); value != "" {
+		if err := logLevel.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid NANNYTRACKER_LOG_LEVEL %q: must be debug, info, warn or error", value)
+		}
+	}
+
+	families := parseFamilies(os.Getenv("NANNYTRACKER_FAMILIES"))
+	if len(families) == 0 {
+		families = []string{model.DefaultFamily}
+	}
+
 	// Create the data directory if it doesn't exist
 	if err := os.MkdirAll(dataDir, 0750); err != nil {
 		return nil, err
 	}
 
 	return &Config{
-		RatePerMile: ratePerMile,
-		DataFile:    dataFile,
-		DataDir:     dataDir,
+		RatePerMile:         ratePerMile,
+		DataFile:            dataFile,
+		DataDir:             dataDir,
+		PageSize:            pageSize,
+		HomeAddress:         os.Getenv("NANNYTRACKER_HOME_ADDRESS"),
+		RoundingMode:        roundingMode,
+		MaxFutureDays:       maxFutureDays,
+		MaxTripMiles:        maxTripMiles,
+		Families:            families,
+		ManualMiles:         manualMiles,
+		WeekStartDay:        weekStartDay,
+		WeeklyMileageTarget: weeklyMileageTarget,
+		StrictData:          strictData,
+		RememberPageSize:    rememberPageSize,
+		DateFormat:          dateFormat,
+		AuditLogPath:        os.Getenv("NANNYTRACKER_AUDIT_LOG"),
+		SaveInterval:        saveInterval,
+		RateSchedule:        rateSchedule,
+		ReadTimeout:         readTimeout,
+		WriteTimeout:        writeTimeout,
+		IdleTimeout:         idleTimeout,
+		AllowedOrigins:      allowedOrigins,
+		APIKey:              os.Getenv("NANNYTRACKER_API_KEY"),
+		LogLevel:            logLevel,
 	}, nil
 }
 
+// parseTimeout reads a server timeout from the named environment variable, returning
+// fallback when it is unset
+func parseTimeout(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 30s", name, value)
+	}
+	return parsed, nil
+}
+
+// defaultDataDir returns the data directory used when none is configured. Linux follows
+// the XDG base directory spec ($XDG_DATA_HOME/nannytracker, falling back to
+// ~/.local/share/nannytracker); other systems use ~/.nannytracker. An existing
+// ~/.nannytracker directory is kept on Linux too, so older installs still find their data.
+func defaultDataDir(goos, homeDir, xdgDataHome string) string {
+	legacyDir := filepath.Join(homeDir, ".nannytracker")
+	if goos != "linux" {
+		return legacyDir
+	}
+	if info, err := os.Stat(legacyDir); err == nil && info.IsDir() {
+		return legacyDir
+	}
+	// The spec says relative paths in XDG_DATA_HOME are invalid and should be ignored
+	if xdgDataHome == "" || !filepath.IsAbs(xdgDataHome) {
+		xdgDataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(xdgDataHome, "nannytracker")
+}
+
+// parseFamilies splits a comma-separated list of family names, dropping blanks
+func parseFamilies(value string) []string {
+	var families []string
+	for _, family := range strings.Split(value, ",") {
+		if family = strings.TrimSpace(family); family != "" {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// parseAllowedOrigins splits a comma-separated list of origins such as
+// "https://example.com,http://localhost:3000". Each must be a scheme and host with no
+// path, or "*" to allow any origin.
+func parseAllowedOrigins(value string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin == "" {
+			continue
+		}
+		if origin != "*" {
+			parsed, err := url.Parse(origin)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+				strings.TrimSuffix(parsed.Path, "/") != "" || parsed.RawQuery != "" {
+				return nil, fmt.Errorf("%q must be an origin such as https://example.com, or *", origin)
+			}
+			origin = parsed.Scheme + "://" + parsed.Host
+		}
+		origins = append(origins, origin)
+	}
+	return origins, nil
+}
+
+// parseRateSchedule parses a comma-separated list of date=rate pairs such as
+// "2024-01-01=0.67,2025-01-01=0.70", returning the changes sorted by date
+func parseRateSchedule(value string) ([]model.RateChange, error) {
+	var schedule []model.RateChange
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		date, rateValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q must be a date and rate such as 2024-01-01=0.67", entry)
+		}
+		date = strings.TrimSpace(date)
+		if err := model.ValidateDate(date); err != nil {
+			return nil, fmt.Errorf("%q: %w", entry, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateValue), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("%q: rate must be a positive number", entry)
+		}
+		for _, change := range schedule {
+			if change.EffectiveFrom == date {
+				return nil, fmt.Errorf("%q: more than one rate for %s", entry, date)
+			}
+		}
+		schedule = append(schedule, model.RateChange{EffectiveFrom: date, RatePerMile: rate})
+	}
+	sort.Slice(schedule, func(i, j int) bool {
+		return schedule[i].EffectiveFrom < schedule[j].EffectiveFrom
+	})
+	return schedule, nil
+}
+
+// parseWeekday parses a day name such as "monday", ignoring case
+func parseWeekday(value string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(strings.TrimSpace(value), day.String()) {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// RateOn returns the mileage rate in effect on date (YYYY-MM-DD) and the date that rate
+// took effect, which is empty when the date falls before every scheduled change
+func (c *Config) RateOn(date string) (float64, string) {
+	return model.RateOn(c.RateSchedule, date, c.RatePerMile)
+}
+
+// IsKnownFamily reports whether family is one of the configured families.
+// Any family is accepted when none are configured.
+func (c *Config) IsKnownFamily(family string) bool {
+	if len(c.Families) == 0 {
+		return true
+	}
+	for _, known := range c.Families {
+		if known == family {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) DataPath() string {
 	return filepath.Join(c.DataDir, c.DataFile)
 }
+
+// SetDataPath points the config at a different data file, creating its directory
+// if needed. It is used when the path comes from a command line flag.
+func (c *Config) SetDataPath(path string) error {
+	dataDir, dataFile, ok := splitDataPath(path)
+	if !ok {
+		return fmt.Errorf("invalid data path %q: must name a file", path)
+	}
+	if err := os.MkdirAll(dataDir, 0750); err != nil {
+		return err
+	}
+	c.DataDir, c.DataFile = dataDir, dataFile
+	return nil
+}
+
+// splitDataPath splits a data file path into its directory and file name.
+// It reports false if the path does not name a file.
+func splitDataPath(path string) (dataDir, dataFile string, ok bool) {
+	dataDir, dataFile = filepath.Split(path)
+	if dataFile == "" {
+		return "", "", false
+	}
+	if dataDir == "" {
+		dataDir = "."
+	}
+	return dataDir, dataFile, true
+}
+
+// DistanceCachePath returns the path of the file used to cache Google Maps distances
+func (c *Config) DistanceCachePath() string {
+	return filepath.Join(c.DataDir, DefaultDistanceCacheFile)
+}
+
+// Redacted stands in for secret values when settings are printed
+const Redacted = "[redacted]"
+
+// Settings is the configuration in effect, in a form that is safe to print
+type Settings struct {
+	RatePerMile         float64  `json:"rate_per_mile"`
+	DataPath            string   `json:"data_path"`
+	DistanceCachePath   string   `json:"distance_cache_path"`
+	PageSize            int      `json:"page_size"`
+	HomeAddress         string   `json:"home_address"`
+	RoundingMode        string   `json:"rounding_mode"`
+	MaxFutureDays       int      `json:"max_future_days"`
+	MaxTripMiles        float64  `json:"max_trip_miles"`
+	Families            []string `json:"families"`
+	ManualMiles         bool     `json:"manual_miles"`
+	WeekStartDay        string   `json:"week_start_day"`
+	WeeklyMileageTarget float64  `json:"weekly_mileage_target"`
+	StrictData          bool     `json:"strict_data"`
+	RememberPageSize    bool     `json:"remember_page_size"`
+	DateFormat          string   `json:"date_format"`
+	AuditLogPath        string   `json:"audit_log_path"`
+	SaveInterval        string   `json:"save_interval"`
+	ReadTimeout         string   `json:"read_timeout"`
+	WriteTimeout        string   `json:"write_timeout"`
+	IdleTimeout         string   `json:"idle_timeout"`
+	AllowedOrigins      []string `json:"allowed_origins"`
+	LogLevel            string   `json:"log_level"`
+	// RateSchedule lists the dated rate changes, oldest first
+	RateSchedule []model.RateChange `json:"rate_schedule"`
+	// MapsAPIKey is Redacted when a Google Maps API key is set; the key itself is never included
+	MapsAPIKey string `json:"maps_api_key,omitempty"`
+	// APIKey is Redacted when the web API requires a key
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// Settings returns the resolved configuration with secrets redacted
+func (c *Config) Settings() Settings {
+	settings := Settings{
+		RatePerMile:         c.RatePerMile,
+		RateSchedule:        c.RateSchedule,
+		DataPath:            c.DataPath(),
+		DistanceCachePath:   c.DistanceCachePath(),
+		PageSize:            c.PageSize,
+		HomeAddress:         c.HomeAddress,
+		RoundingMode:        c.RoundingMode,
+		MaxFutureDays:       c.MaxFutureDays,
+		MaxTripMiles:        c.MaxTripMiles,
+		Families:            c.Families,
+		ManualMiles:         c.ManualMiles,
+		WeekStartDay:        strings.ToLower(c.WeekStartDay.String()),
+		WeeklyMileageTarget: c.WeeklyMileageTarget,
+		StrictData:          c.StrictData,
+		RememberPageSize:    c.RememberPageSize,
+		DateFormat:          c.DateFormat,
+		AuditLogPath:        c.AuditLogPath,
+		SaveInterval:        c.SaveInterval.String(),
+		ReadTimeout:         c.ReadTimeout.String(),
+		WriteTimeout:        c.WriteTimeout.String(),
+		IdleTimeout:         c.IdleTimeout.String(),
+		AllowedOrigins:      c.AllowedOrigins,
+		LogLevel:            strings.ToLower(c.LogLevel.String()),
+	}
+	if os.Getenv("GOOGLE_MAPS_API_KEY") != "" {
+		settings.MapsAPIKey = Redacted
+	}
+	if c.APIKey != "" {
+		settings.APIKey = Redacted
+	}
+	return settings
+}
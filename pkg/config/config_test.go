@@ -1,9 +1,15 @@
 package config
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
 )
 
 func setupTestEnv(t *testing.T) (string, func()) {
@@ -68,11 +74,163 @@ func TestDataPath(t *testing.T) {
 	}
 }
 
+func TestDistanceCachePath(t *testing.T) {
+	cfg := &Config{
+		DataDir:  filepath.Join("tmp", ".nannytracker"),
+		DataFile: "trips.json",
+	}
+
+	expectedPath := filepath.Join("tmp", ".nannytracker", "distance_cache.json")
+	if cfg.DistanceCachePath() != expectedPath {
+		t.Errorf("Expected DistanceCachePath to be %s, got %s", expectedPath, cfg.DistanceCachePath())
+	}
+}
+
+func TestDefaultDataDir(t *testing.T) {
+	homeDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// setupTestEnv creates ~/.nannytracker; use a second home without it
+	freshHome := filepath.Join(homeDir, "fresh")
+	legacyDir := filepath.Join(homeDir, ".nannytracker")
+
+	tests := []struct {
+		name        string
+		goos        string
+		homeDir     string
+		xdgDataHome string
+		want        string
+	}{
+		{
+			name:        "linux uses XDG_DATA_HOME",
+			goos:        "linux",
+			homeDir:     freshHome,
+			xdgDataHome: "/data/xdg",
+			want:        filepath.Join("/data/xdg", "nannytracker"),
+		},
+		{
+			name:    "linux falls back to ~/.local/share",
+			goos:    "linux",
+			homeDir: freshHome,
+			want:    filepath.Join(freshHome, ".local", "share", "nannytracker"),
+		},
+		{
+			name:        "linux ignores a relative XDG_DATA_HOME",
+			goos:        "linux",
+			homeDir:     freshHome,
+			xdgDataHome: "relative/data",
+			want:        filepath.Join(freshHome, ".local", "share", "nannytracker"),
+		},
+		{
+			name:        "linux keeps an existing ~/.nannytracker",
+			goos:        "linux",
+			homeDir:     homeDir,
+			xdgDataHome: "/data/xdg",
+			want:        legacyDir,
+		},
+		{
+			name:        "other systems use ~/.nannytracker",
+			goos:        "darwin",
+			homeDir:     freshHome,
+			xdgDataHome: "/data/xdg",
+			want:        filepath.Join(freshHome, ".nannytracker"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultDataDir(tt.goos, tt.homeDir, tt.xdgDataHome); got != tt.want {
+				t.Errorf("Expected data dir %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDataPathFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// The full path wins over the separate directory and file settings
+	dataDir := filepath.Join(tempDir, "nested", "data")
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+	t.Setenv("NANNYTRACKER_DATA_FILE", "ignored.json")
+	t.Setenv("NANNYTRACKER_DATA_PATH", filepath.Join(dataDir, "mytrips.json"))
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if cfg.DataPath() != filepath.Join(dataDir, "mytrips.json") {
+		t.Errorf("Expected DataPath to be %s, got %s", filepath.Join(dataDir, "mytrips.json"), cfg.DataPath())
+	}
+	if info, err := os.Stat(dataDir); err != nil || !info.IsDir() {
+		t.Errorf("Expected data directory %s to be created: %v", dataDir, err)
+	}
+
+	t.Setenv("NANNYTRACKER_DATA_PATH", dataDir+string(filepath.Separator))
+	if _, err := New(); err == nil {
+		t.Error("Expected error for a data path without a file name")
+	}
+}
+
+func TestSetDataPath(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	cfg := &Config{DataDir: filepath.Join(tempDir, ".nannytracker"), DataFile: DefaultDataFile}
+	dataDir := filepath.Join(tempDir, "family-b")
+	if err := cfg.SetDataPath(filepath.Join(dataDir, "trips.json")); err != nil {
+		t.Fatalf("Failed to set data path: %v", err)
+	}
+	if cfg.DataPath() != filepath.Join(dataDir, "trips.json") {
+		t.Errorf("Expected DataPath to be %s, got %s", filepath.Join(dataDir, "trips.json"), cfg.DataPath())
+	}
+	if info, err := os.Stat(dataDir); err != nil || !info.IsDir() {
+		t.Errorf("Expected data directory %s to be created: %v", dataDir, err)
+	}
+
+	if err := cfg.SetDataPath(dataDir + string(filepath.Separator)); err == nil {
+		t.Error("Expected error for a data path without a file name")
+	}
+	if cfg.DataPath() != filepath.Join(dataDir, "trips.json") {
+		t.Errorf("Expected DataPath to be unchanged after an error, got %s", cfg.DataPath())
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	// Clear environment variables to test defaults
 	os.Unsetenv("NANNYTRACKER_DATA_DIR")
 	os.Unsetenv("NANNYTRACKER_DATA_FILE")
 	os.Unsetenv("NANNYTRACKER_RATE_PER_MILE")
+	os.Unsetenv("NANNYTRACKER_PAGE_SIZE")
+	os.Unsetenv("NANNYTRACKER_HOME_ADDRESS")
+	os.Unsetenv("NANNYTRACKER_ROUNDING_MODE")
+	os.Unsetenv("NANNYTRACKER_MAX_FUTURE_DAYS")
+	os.Unsetenv("NANNYTRACKER_MAX_TRIP_MILES")
+	os.Unsetenv("NANNYTRACKER_FAMILIES")
+	os.Unsetenv("NANNYTRACKER_DATA_PATH")
+	os.Unsetenv("NANNYTRACKER_MANUAL_MILES")
+	os.Unsetenv("NANNYTRACKER_WEEK_START")
+	os.Unsetenv("NANNYTRACKER_WEEKLY_MILEAGE_TARGET")
+	os.Unsetenv("NANNYTRACKER_STRICT_DATA")
+	os.Unsetenv("NANNYTRACKER_REMEMBER_PAGE_SIZE")
+	os.Unsetenv("NANNYTRACKER_SAVE_INTERVAL")
+	os.Unsetenv("NANNYTRACKER_DATE_FORMAT")
+	os.Unsetenv("NANNYTRACKER_AUDIT_LOG")
+	os.Unsetenv("NANNYTRACKER_RATE_SCHEDULE")
+	os.Unsetenv("NANNYTRACKER_READ_TIMEOUT")
+	os.Unsetenv("NANNYTRACKER_WRITE_TIMEOUT")
+	os.Unsetenv("NANNYTRACKER_IDLE_TIMEOUT")
+	os.Unsetenv("NANNYTRACKER_ALLOWED_ORIGINS")
+	os.Unsetenv("NANNYTRACKER_API_KEY")
+	os.Unsetenv("NANNYTRACKER_LOG_LEVEL")
+
+	// Use an empty home directory so the default data directory is predictable
+	homeDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+	os.RemoveAll(filepath.Join(homeDir, ".nannytracker"))
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_DATA_HOME", "")
 
 	cfg, err := New()
 	if err != nil {
@@ -80,12 +238,10 @@ func TestDefaultConfig(t *testing.T) {
 	}
 
 	// Verify default values
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Fatalf("Failed to get user home directory: %v", err)
-	}
-
 	expectedDataDir := filepath.Join(homeDir, ".nannytracker")
+	if runtime.GOOS == "linux" {
+		expectedDataDir = filepath.Join(homeDir, ".local", "share", "nannytracker")
+	}
 	if cfg.DataDir != expectedDataDir {
 		t.Errorf("Expected default DataDir to be %s, got %s", expectedDataDir, cfg.DataDir)
 	}
@@ -97,4 +253,576 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.RatePerMile != 0.70 {
 		t.Errorf("Expected default RatePerMile to be 0.70, got %f", cfg.RatePerMile)
 	}
+
+	if cfg.PageSize != DefaultPageSize {
+		t.Errorf("Expected default PageSize to be %d, got %d", DefaultPageSize, cfg.PageSize)
+	}
+
+	if cfg.HomeAddress != "" {
+		t.Errorf("Expected no default HomeAddress, got %s", cfg.HomeAddress)
+	}
+
+	if cfg.RoundingMode != "none" {
+		t.Errorf("Expected default RoundingMode to be none, got %s", cfg.RoundingMode)
+	}
+
+	if cfg.MaxFutureDays != 365 {
+		t.Errorf("Expected default MaxFutureDays to be 365, got %d", cfg.MaxFutureDays)
+	}
+
+	if len(cfg.Families) != 1 || cfg.Families[0] != "default" {
+		t.Errorf("Expected default Families to be [default], got %v", cfg.Families)
+	}
+
+	if cfg.ManualMiles {
+		t.Error("Expected Google Maps to be used by default")
+	}
+
+	if cfg.WeekStartDay != time.Sunday {
+		t.Errorf("Expected weeks to start on Sunday by default, got %s", cfg.WeekStartDay)
+	}
+
+	if cfg.WeeklyMileageTarget != 0 {
+		t.Errorf("Expected no weekly mileage target by default, got %.1f", cfg.WeeklyMileageTarget)
+	}
+
+	if cfg.StrictData {
+		t.Error("Expected a corrupt data file to be recovered by default")
+	}
+
+	if cfg.RememberPageSize {
+		t.Error("Expected page size changes not to be remembered by default")
+	}
+
+	if cfg.DateFormat != "iso" {
+		t.Errorf("Expected dates to be ISO by default, got %s", cfg.DateFormat)
+	}
+
+	if cfg.AuditLogPath != "" {
+		t.Errorf("Expected no audit log by default, got %s", cfg.AuditLogPath)
+	}
+
+	if cfg.SaveInterval != 0 {
+		t.Errorf("Expected changes to be saved immediately by default, got %s", cfg.SaveInterval)
+	}
+
+	if len(cfg.RateSchedule) != 0 {
+		t.Errorf("Expected no rate schedule by default, got %v", cfg.RateSchedule)
+	}
+
+	if cfg.ReadTimeout != 10*time.Second || cfg.WriteTimeout != 10*time.Second || cfg.IdleTimeout != 60*time.Second {
+		t.Errorf("Expected 10s/10s/60s server timeouts by default, got %s/%s/%s", cfg.ReadTimeout, cfg.WriteTimeout, cfg.IdleTimeout)
+	}
+
+	if !reflect.DeepEqual(cfg.AllowedOrigins, []string{"http://localhost:3000"}) {
+		t.Errorf("Expected only the frontend dev server to be allowed by default, got %v", cfg.AllowedOrigins)
+	}
+
+	if cfg.APIKey != "" {
+		t.Error("Expected the API to be open by default")
+	}
+
+	if cfg.LogLevel != slog.LevelInfo {
+		t.Errorf("Expected info logging by default, got %s", cfg.LogLevel)
+	}
+}
+
+func TestManualMilesFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "1", want: true},
+		{value: "true", want: true},
+		{value: "0", want: false},
+		{value: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_MANUAL_MILES", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.ManualMiles != tt.want {
+				t.Errorf("Expected ManualMiles to be %v, got %v", tt.want, cfg.ManualMiles)
+			}
+		})
+	}
+}
+
+func TestStrictDataFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "", want: false},
+		{value: "true", want: true},
+		{value: "false", want: false},
+		{value: "very", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_STRICT_DATA", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.StrictData != tt.want {
+				t.Errorf("Expected StrictData to be %v, got %v", tt.want, cfg.StrictData)
+			}
+		})
+	}
+}
+
+func TestWeekStartFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    time.Weekday
+		wantErr bool
+	}{
+		{value: "", want: time.Sunday},
+		{value: "monday", want: time.Monday},
+		{value: "Saturday", want: time.Saturday},
+		{value: "mon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_WEEK_START", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.WeekStartDay != tt.want {
+				t.Errorf("Expected WeekStartDay to be %s, got %s", tt.want, cfg.WeekStartDay)
+			}
+		})
+	}
+}
+
+func TestFamiliesFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+	t.Setenv("NANNYTRACKER_FAMILIES", " Smith, Jones ,,")
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if len(cfg.Families) != 2 || cfg.Families[0] != "Smith" || cfg.Families[1] != "Jones" {
+		t.Errorf("Expected Families to be [Smith Jones], got %v", cfg.Families)
+	}
+	if !cfg.IsKnownFamily("Jones") {
+		t.Error("Expected Jones to be a known family")
+	}
+	if cfg.IsKnownFamily("Brown") {
+		t.Error("Expected Brown to be an unknown family")
+	}
+
+	// Any family is accepted when none are configured
+	if !(&Config{}).IsKnownFamily("Brown") {
+		t.Error("Expected any family to be known without configured families")
+	}
+}
+
+func TestMaxFutureDaysFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "30", want: 30},
+		{value: "0", want: 0},
+		{value: "-1", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_MAX_FUTURE_DAYS", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.MaxFutureDays != tt.want {
+				t.Errorf("Expected MaxFutureDays to be %d, got %d", tt.want, cfg.MaxFutureDays)
+			}
+		})
+	}
+}
+
+func TestMaxTripMilesFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "150", want: 150},
+		{value: "75.5", want: 75.5},
+		{value: "-1", wantErr: true},
+		{value: "far", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_MAX_TRIP_MILES", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.MaxTripMiles != tt.want {
+				t.Errorf("Expected MaxTripMiles to be %.1f, got %.1f", tt.want, cfg.MaxTripMiles)
+			}
+		})
+	}
+}
+
+func TestWeeklyMileageTargetFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "50", want: 50},
+		{value: "62.5", want: 62.5},
+		{value: "-5", wantErr: true},
+		{value: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_WEEKLY_MILEAGE_TARGET", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.WeeklyMileageTarget != tt.want {
+				t.Errorf("Expected WeeklyMileageTarget to be %.1f, got %.1f", tt.want, cfg.WeeklyMileageTarget)
+			}
+		})
+	}
+}
+
+func TestDateFormatFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "", want: "iso"},
+		{value: "us", want: "us"},
+		{value: "EU", want: "eu"},
+		{value: "MM/DD/YYYY", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_DATE_FORMAT", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.DateFormat != tt.want {
+				t.Errorf("Expected DateFormat to be %s, got %s", tt.want, cfg.DateFormat)
+			}
+		})
+	}
+}
+
+func TestRateScheduleFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    []model.RateChange
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "2025-01-01=0.72, 2024-01-01=0.67", want: []model.RateChange{
+			{EffectiveFrom: "2024-01-01", RatePerMile: 0.67},
+			{EffectiveFrom: "2025-01-01", RatePerMile: 0.72},
+		}},
+		{value: "2024-01-01", wantErr: true},
+		{value: "01/01/2024=0.67", wantErr: true},
+		{value: "2024-01-01=free", wantErr: true},
+		{value: "2024-01-01=0", wantErr: true},
+		{value: "2024-01-01=0.67,2024-01-01=0.70", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_RATE_SCHEDULE", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(cfg.RateSchedule, tt.want) {
+				t.Errorf("Expected RateSchedule to be %v, got %v", tt.want, cfg.RateSchedule)
+			}
+		})
+	}
+}
+
+func TestSaveIntervalFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "0", want: 0},
+		{value: "5s", want: 5 * time.Second},
+		{value: "1m30s", want: 90 * time.Second},
+		{value: "-1s", wantErr: true},
+		{value: "5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_SAVE_INTERVAL", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.SaveInterval != tt.want {
+				t.Errorf("Expected SaveInterval to be %s, got %s", tt.want, cfg.SaveInterval)
+			}
+		})
+	}
+}
+
+func TestServerTimeoutsFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		name    string
+		env     string
+		value   string
+		field   func(*Config) time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "read", env: "NANNYTRACKER_READ_TIMEOUT", value: "30s", field: func(c *Config) time.Duration { return c.ReadTimeout }, want: 30 * time.Second},
+		{name: "write", env: "NANNYTRACKER_WRITE_TIMEOUT", value: "2m", field: func(c *Config) time.Duration { return c.WriteTimeout }, want: 2 * time.Minute},
+		{name: "idle", env: "NANNYTRACKER_IDLE_TIMEOUT", value: "5s", field: func(c *Config) time.Duration { return c.IdleTimeout }, want: 5 * time.Second},
+		{name: "zero", env: "NANNYTRACKER_READ_TIMEOUT", value: "0", wantErr: true},
+		{name: "negative", env: "NANNYTRACKER_WRITE_TIMEOUT", value: "-1s", wantErr: true},
+		{name: "no unit", env: "NANNYTRACKER_IDLE_TIMEOUT", value: "30", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.field(cfg) != tt.want {
+				t.Errorf("Expected %s to be %s, got %s", tt.env, tt.want, tt.field(cfg))
+			}
+		})
+	}
+}
+
+func TestAllowedOriginsFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "*", want: []string{"*"}},
+		{value: "https://nanny.example.com/, http://localhost:5173", want: []string{"https://nanny.example.com", "http://localhost:5173"}},
+		{value: "nanny.example.com", wantErr: true},
+		{value: "https://nanny.example.com/api", wantErr: true},
+		{value: "ftp://nanny.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_ALLOWED_ORIGINS", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(cfg.AllowedOrigins, tt.want) {
+				t.Errorf("Expected AllowedOrigins %v, got %v", tt.want, cfg.AllowedOrigins)
+			}
+		})
+	}
+}
+
+func TestLogLevelFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{value: "debug", want: slog.LevelDebug},
+		{value: "WARN", want: slog.LevelWarn},
+		{value: "error", want: slog.LevelError},
+		{value: "verbose", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_LOG_LEVEL", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.LogLevel != tt.want {
+				t.Errorf("Expected LogLevel %s, got %s", tt.want, cfg.LogLevel)
+			}
+		})
+	}
+}
+
+func TestRoundingModeFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	for _, mode := range []string{"none", "cent", "nearest_dollar"} {
+		t.Setenv("NANNYTRACKER_ROUNDING_MODE", mode)
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Failed to create config for mode %s: %v", mode, err)
+		}
+		if cfg.RoundingMode != mode {
+			t.Errorf("Expected RoundingMode to be %s, got %s", mode, cfg.RoundingMode)
+		}
+	}
+
+	t.Setenv("NANNYTRACKER_ROUNDING_MODE", "dime")
+	if _, err := New(); err == nil {
+		t.Error("Expected error for invalid rounding mode")
+	}
+}
+
+func TestHomeAddressFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+	t.Setenv("NANNYTRACKER_HOME_ADDRESS", "123 Home St")
+
+	cfg, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	if cfg.HomeAddress != "123 Home St" {
+		t.Errorf("Expected HomeAddress to be 123 Home St, got %s", cfg.HomeAddress)
+	}
+}
+
+func TestPageSizeFromEnv(t *testing.T) {
+	tempDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	t.Setenv("NANNYTRACKER_DATA_DIR", filepath.Join(tempDir, ".nannytracker"))
+
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "25", want: 25},
+		{value: "1", want: 1},
+		{value: "0", wantErr: true},
+		{value: "-5", wantErr: true},
+		{value: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("NANNYTRACKER_PAGE_SIZE", tt.value)
+
+			cfg, err := New()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.PageSize != tt.want {
+				t.Errorf("Expected PageSize to be %d, got %d", tt.want, cfg.PageSize)
+			}
+		})
+	}
 }
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPreferencesFile holds settings chosen in the TUI that are remembered between runs
+const DefaultPreferencesFile = "preferences.json"
+
+// Preferences are settings chosen while the TUI runs rather than through the environment
+type Preferences struct {
+	PageSize int `json:"page_size,omitempty"`
+}
+
+// PreferencesPath returns the path of the file preferences are remembered in
+func (c *Config) PreferencesPath() string {
+	return filepath.Join(c.DataDir, DefaultPreferencesFile)
+}
+
+// LoadPreferences reads the remembered preferences; a missing file means there are none
+func (c *Config) LoadPreferences() (Preferences, error) {
+	var prefs Preferences
+	data, err := os.ReadFile(c.PreferencesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return prefs, nil
+		}
+		return prefs, err
+	}
+	err = json.Unmarshal(data, &prefs)
+	return prefs, err
+}
+
+// SavePreferences remembers prefs for the next run
+func (c *Config) SavePreferences(prefs Preferences) error {
+	data, err := json.MarshalIndent(prefs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.PreferencesPath(), data, 0600)
+}
+
+// ApplyPreferences replaces the page size with the remembered one when
+// RememberPageSize is set. Call it after the data directory is final.
+func (c *Config) ApplyPreferences() error {
+	if !c.RememberPageSize {
+		return nil
+	}
+	prefs, err := c.LoadPreferences()
+	if err != nil {
+		return err
+	}
+	if prefs.PageSize > 0 {
+		c.PageSize = prefs.PageSize
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyPreferences(t *testing.T) {
+	cfg := &Config{DataDir: t.TempDir(), PageSize: DefaultPageSize}
+
+	// Nothing remembered yet
+	cfg.RememberPageSize = true
+	if err := cfg.ApplyPreferences(); err != nil {
+		t.Fatalf("ApplyPreferences() error = %v", err)
+	}
+	if cfg.PageSize != DefaultPageSize {
+		t.Errorf("Expected PageSize to stay %d, got %d", DefaultPageSize, cfg.PageSize)
+	}
+
+	if err := cfg.SavePreferences(Preferences{PageSize: 25}); err != nil {
+		t.Fatalf("SavePreferences() error = %v", err)
+	}
+	if err := cfg.ApplyPreferences(); err != nil {
+		t.Fatalf("ApplyPreferences() error = %v", err)
+	}
+	if cfg.PageSize != 25 {
+		t.Errorf("Expected the remembered PageSize 25, got %d", cfg.PageSize)
+	}
+
+	// Remembered preferences are ignored unless enabled
+	other := &Config{DataDir: cfg.DataDir, PageSize: DefaultPageSize}
+	if err := other.ApplyPreferences(); err != nil {
+		t.Fatalf("ApplyPreferences() error = %v", err)
+	}
+	if other.PageSize != DefaultPageSize {
+		t.Errorf("Expected PageSize %d without RememberPageSize, got %d", DefaultPageSize, other.PageSize)
+	}
+
+	if err := os.WriteFile(filepath.Join(cfg.DataDir, DefaultPreferencesFile), []byte("{"), 0600); err != nil {
+		t.Fatalf("Failed to write preferences: %v", err)
+	}
+	if err := cfg.ApplyPreferences(); err == nil {
+		t.Error("Expected an error for unreadable preferences")
+	}
+}
@@ -0,0 +1,104 @@
+// Package audit keeps an append-only record of changes to the tracker data, one JSON
+// object per line, so what changed and when can be reconstructed later
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionCreate = "create"
+	ActionEdit   = "edit"
+	ActionDelete = "delete"
+)
+
+// Entity types recorded in the audit log
+const (
+	EntityTrip          = "trip"
+	EntityExpense       = "expense"
+	EntityRecurringTrip = "recurring_trip"
+	EntityAdjustment    = "adjustment"
+	EntityData          = "data" // The whole data file, as when a backup is restored
+)
+
+// Entry is one line of the audit log
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Entity  string    `json:"entity"`
+	Summary string    `json:"summary"`
+}
+
+// Logger appends entries to an audit log file. It is safe for concurrent use, and a nil
+// Logger records nothing, so callers need not check whether auditing is enabled.
+type Logger struct {
+	path string
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// New creates a Logger appending to the file at path, which is created on the first entry
+func New(path string) *Logger {
+	return &Logger{path: path, now: time.Now}
+}
+
+// Record appends an entry for a change that has already been saved. Auditing must never
+// undo or block a save, so a failed write is only logged as a warning.
+func (l *Logger) Record(action, entity, summary string) {
+	if l == nil {
+		return
+	}
+	if err := l.append(Entry{Time: l.now().UTC(), Action: action, Entity: entity, Summary: summary}); err != nil {
+		slog.Warn("Failed to write audit log", "path", l.path, "error", err)
+	}
+}
+
+// append writes entry as a single line at the end of the log file
+func (l *Logger) append(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// TripSummary describes a trip for an audit entry
+func TripSummary(trip model.Trip) string {
+	return fmt.Sprintf("%s %s → %s (%.2f miles, %s)", trip.Date, trip.Origin, trip.Destination, trip.Miles, trip.Type)
+}
+
+// ExpenseSummary describes an expense for an audit entry
+func ExpenseSummary(expense model.Expense) string {
+	return fmt.Sprintf("%s $%.2f %s", expense.Date, expense.Amount, expense.Description)
+}
+
+// RecurringTripSummary describes a recurring trip for an audit entry
+func RecurringTripSummary(rt model.RecurringTrip) string {
+	return fmt.Sprintf("%s → %s (%.2f miles, %s) from %s", rt.Origin, rt.Destination, rt.Miles, rt.Type, rt.StartDate)
+}
+
+// AdjustmentSummary describes a mileage adjustment for an audit entry
+func AdjustmentSummary(adjustment model.Adjustment) string {
+	return fmt.Sprintf("%s %+.2f miles (%s)", adjustment.Date, adjustment.Miles, adjustment.Reason)
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// readEntries returns the entries in the audit log at path
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Expected one JSON object per line, got %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLoggerAppendsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger := New(path)
+	logger.now = func() time.Time { return time.Date(2024, 3, 20, 9, 30, 0, 0, time.UTC) }
+
+	trip := model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 4.5, Type: "round"}
+	logger.Record(ActionCreate, EntityTrip, TripSummary(trip))
+	logger.Record(ActionDelete, EntityExpense, ExpenseSummary(model.Expense{Date: "2024-03-20", Amount: 8, Description: "Snacks"}))
+
+	entries := readEntries(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	want := Entry{
+		Time:    time.Date(2024, 3, 20, 9, 30, 0, 0, time.UTC),
+		Action:  ActionCreate,
+		Entity:  EntityTrip,
+		Summary: "2024-03-20 Home → School (4.50 miles, round)",
+	}
+	if entries[0] != want {
+		t.Errorf("Expected first entry %+v, got %+v", want, entries[0])
+	}
+	if entries[1].Action != ActionDelete || entries[1].Summary != "2024-03-20 $8.00 Snacks" {
+		t.Errorf("Unexpected second entry %+v", entries[1])
+	}
+
+	// Later loggers keep appending to the same file
+	New(path).Record(ActionEdit, EntityTrip, "again")
+	if entries := readEntries(t, path); len(entries) != 3 {
+		t.Errorf("Expected 3 entries after reopening, got %d", len(entries))
+	}
+}
+
+func TestLoggerIgnoresWriteFailures(t *testing.T) {
+	// The directory does not exist, so every write fails
+	logger := New(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+	logger.Record(ActionCreate, EntityTrip, "not written")
+
+	var disabled *Logger
+	disabled.Record(ActionCreate, EntityTrip, "not written")
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// weeklyCSVHeader lists the columns written by ExportWeeklySummariesCSV
+var weeklyCSVHeader = []string{"week_start", "week_end", "total_miles", "total_mileage_amount", "total_expenses"}
+
+// ExportWeeklySummariesCSV writes one row per week, most recent week first,
+// with the week's mileage, reimbursement and expense totals
+func ExportWeeklySummariesCSV(w io.Writer, summaries []model.WeeklySummary) error {
+	sorted := append([]model.WeeklySummary(nil), summaries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].WeekStart > sorted[j].WeekStart
+	})
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(weeklyCSVHeader); err != nil {
+		return err
+	}
+	for _, summary := range sorted {
+		if err := cw.Write([]string{
+			summary.WeekStart,
+			summary.WeekEnd,
+			strconv.FormatFloat(summary.TotalMiles, 'f', 2, 64),
+			strconv.FormatFloat(summary.TotalAmount, 'f', 2, 64),
+			strconv.FormatFloat(summary.TotalExpenses, 'f', 2, 64),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// expenseCSVColumns is the column order assumed when an expense CSV has no header row
+var expenseCSVColumns = map[string]int{"date": 0, "amount": 1, "description": 2, "category": 3}
+
+// ImportExpensesCSV parses expenses from CSV with date, amount and description
+// columns and an optional category column. A header row is optional; when present
+// it decides the column order and extra columns are ignored. Every row must pass
+// Expense.Validate, and errors name the offending line.
+func ImportExpensesCSV(r io.Reader) ([]model.Expense, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	columns := expenseCSVColumns
+	var expenses []model.Expense
+	for first := true; ; first = false {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		if first && isExpenseHeader(record) {
+			if columns, err = expenseHeaderColumns(record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+
+		expense, err := parseExpenseRecord(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses, nil
+}
+
+// isExpenseHeader reports whether record looks like a header row rather than data
+func isExpenseHeader(record []string) bool {
+	for _, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "date") {
+			return true
+		}
+	}
+	return false
+}
+
+// expenseHeaderColumns maps column names in a header row to their positions
+func expenseHeaderColumns(record []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, field := range record {
+		name := strings.ToLower(strings.TrimSpace(field))
+		if _, ok := expenseCSVColumns[name]; ok {
+			columns[name] = i
+		}
+	}
+	for _, required := range []string{"date", "amount", "description"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("header is missing the %s column", required)
+		}
+	}
+	return columns, nil
+}
+
+// parseExpenseRecord builds and validates an expense from one CSV row
+func parseExpenseRecord(record []string, columns map[string]int) (model.Expense, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	// Bank exports often format amounts like "$1,234.50"
+	amountText := strings.NewReplacer("$", "", ",", "").Replace(field("amount"))
+	amount, err := strconv.ParseFloat(amountText, 64)
+	if err != nil {
+		return model.Expense{}, &model.ValidationError{Field: "amount", Message: fmt.Sprintf("amount %q is not a number", field("amount"))}
+	}
+
+	expense := model.Expense{
+		Date:        field("date"),
+		Amount:      amount,
+		Description: field("description"),
+		Category:    strings.ToLower(field("category")),
+	}
+	if err := expense.Validate(); err != nil {
+		return model.Expense{}, err
+	}
+	return expense, nil
+}
@@ -0,0 +1,139 @@
+// Package export converts tracker data to and from printable documents and spreadsheets
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+const (
+	lineHeight = 7.0
+	pageWidth  = 196.0 // Letter width less the default 10mm margins
+)
+
+// ExportSummaryPDF writes a printable statement for the month: every trip and
+// expense, the mileage total at the summary's rate and the grand total owed. Dates are
+// shown in dateFormat (see model.DateFormatISO etc.).
+func ExportSummaryPDF(w io.Writer, summary model.MonthlySummary, dateFormat string) error {
+	month, err := time.Parse("2006-01", summary.Month)
+	if err != nil {
+		return fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "Letter", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(pageWidth, 10, tr("NannyTracker Monthly Statement - "+month.Format("January 2006")), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(pageWidth, 6, fmt.Sprintf("Rate per mile: $%.2f", summary.RatePerMile), "", 1, "L", false, 0, "")
+	pdf.CellFormat(pageWidth, 6, "Generated on: "+model.FormatDate(time.Now().Format("2006-01-02"), dateFormat), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// Trips
+	tripWidths := []float64{25, 55, 55, 20, 20, 21}
+	writeSectionTitle(pdf, "Trips")
+	writeRow(pdf, tr, tripWidths, []string{"Date", "Origin", "Destination", "Type", "Miles", "Amount"}, 2, true)
+	for _, trip := range summary.Trips {
+		miles := trip.EffectiveMiles()
+		writeRow(pdf, tr, tripWidths, []string{
+			model.FormatDate(trip.Date, dateFormat),
+			trip.Origin,
+			trip.Destination,
+			trip.Type,
+			fmt.Sprintf("%.2f", miles),
+			fmt.Sprintf("$%.2f", miles*summary.RatePerMile),
+		}, 2, false)
+	}
+	if len(summary.Trips) == 0 {
+		writeEmpty(pdf, "No trips this month")
+	}
+	pdf.Ln(4)
+
+	// Expenses
+	expenseWidths := []float64{25, 35, 110, 26}
+	writeSectionTitle(pdf, "Expenses")
+	writeRow(pdf, tr, expenseWidths, []string{"Date", "Category", "Description", "Amount"}, 1, true)
+	billed := 0
+	for _, expense := range summary.Expenses {
+		// Personal expenses are not billed to the family
+		if !expense.IsReimbursable() {
+			continue
+		}
+		writeRow(pdf, tr, expenseWidths, []string{
+			model.FormatDate(expense.Date, dateFormat),
+			expense.CategoryOrDefault(),
+			expense.Description,
+			fmt.Sprintf("$%.2f", expense.Amount),
+		}, 1, false)
+		billed++
+	}
+	if billed == 0 {
+		writeEmpty(pdf, "No expenses this month")
+	}
+	pdf.Ln(4)
+
+	// Totals
+	writeSectionTitle(pdf, "Totals")
+	pdf.SetFont("Helvetica", "", 10)
+	writeTotal(pdf, "Total miles", fmt.Sprintf("%.2f", summary.TotalMiles))
+	writeTotal(pdf, fmt.Sprintf("Mileage reimbursement (%.2f mi @ $%.2f/mi)", summary.TotalMiles, summary.RatePerMile),
+		fmt.Sprintf("$%.2f", summary.TotalAmount))
+	writeTotal(pdf, "Expenses", fmt.Sprintf("$%.2f", summary.TotalExpenses))
+	pdf.SetFont("Helvetica", "B", 11)
+	writeTotal(pdf, "Grand total reimbursement", fmt.Sprintf("$%.2f", summary.TotalAmount+summary.TotalExpenses))
+
+	return pdf.Output(w)
+}
+
+func writeSectionTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(pageWidth, 8, title, "", 1, "L", false, 0, "")
+}
+
+func writeEmpty(pdf *fpdf.Fpdf, message string) {
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(pageWidth, lineHeight, message, "1", 1, "C", false, 0, "")
+}
+
+func writeTotal(pdf *fpdf.Fpdf, label, value string) {
+	pdf.CellFormat(pageWidth-40, lineHeight, label, "", 0, "L", false, 0, "")
+	pdf.CellFormat(40, lineHeight, value, "", 1, "R", false, 0, "")
+}
+
+// writeRow writes one table row, right-aligning the last numeric columns and
+// truncating cells that don't fit their column
+func writeRow(pdf *fpdf.Fpdf, tr func(string) string, widths []float64, cells []string, numeric int, header bool) {
+	if header {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+	} else {
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	for i, cell := range cells {
+		align := "L"
+		if !header && i >= len(cells)-numeric {
+			align = "R"
+		}
+		pdf.CellFormat(widths[i], lineHeight, fitText(pdf, tr(cell), widths[i]-2), "1", 0, align, header, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// fitText shortens text with an ellipsis until it fits within width
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
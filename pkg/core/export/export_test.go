@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+func TestExportSummaryPDF(t *testing.T) {
+	trips := []model.Trip{
+		{Date: "2024-02-10", Origin: "Home", Destination: "A very long destination address that will not fit in its column", Miles: 10, Type: "round"},
+	}
+	expenses := []model.Expense{
+		{Date: "2024-02-12", Amount: 15, Description: "Café lunch", Category: "food"},
+	}
+	summary, err := model.CalculateMonthlySummary(trips, expenses, 0.70, "2024-02")
+	if err != nil {
+		t.Fatalf("Failed to calculate summary: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportSummaryPDF(&buf, summary, model.DateFormatUS); err != nil {
+		t.Fatalf("Failed to export PDF: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "%PDF-") {
+		t.Error("Expected output to start with a PDF header")
+	}
+
+	// An empty month still renders a statement
+	buf.Reset()
+	if err := ExportSummaryPDF(&buf, model.MonthlySummary{Month: "2024-03", RatePerMile: 0.70}, model.DateFormatISO); err != nil {
+		t.Errorf("Failed to export empty month: %v", err)
+	}
+
+	if err := ExportSummaryPDF(&buf, model.MonthlySummary{Month: "March"}, model.DateFormatISO); err == nil {
+		t.Error("Expected error for invalid month")
+	}
+}
+
+func TestImportExpensesCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCount int
+		wantErr   string
+		wantField string
+	}{
+		{
+			name:      "with header",
+			input:     "date,amount,description\n2024-03-01,12.50,Lunch\n2024-03-02,\"$1,020.00\",Camp fee\n",
+			wantCount: 2,
+		},
+		{
+			name:      "header in different order with category",
+			input:     "Description,Date,Category,Amount\nCrayons,2024-03-03,Supplies,4.25\n",
+			wantCount: 1,
+		},
+		{
+			name:      "no header",
+			input:     "2024-03-01,12.50,Lunch\n",
+			wantCount: 1,
+		},
+		{
+			name:      "invalid amount",
+			input:     "date,amount,description\n2024-03-01,12.50,Lunch\n2024-03-02,abc,Snacks\n",
+			wantErr:   "line 3: amount \"abc\" is not a number",
+			wantField: "amount",
+		},
+		{
+			name:      "invalid date",
+			input:     "2024-03-01,12.50,Lunch\n03/02/2024,5,Snacks\n",
+			wantErr:   "line 2: date must be in YYYY-MM-DD format",
+			wantField: "date",
+		},
+		{
+			name:    "header missing column",
+			input:   "date,description\n2024-03-01,Lunch\n",
+			wantErr: "line 1: header is missing the amount column",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expenses, err := ImportExpensesCSV(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				var verr *model.ValidationError
+				if tt.wantField != "" && (!errors.As(err, &verr) || verr.Field != tt.wantField) {
+					t.Errorf("Expected validation error on %q, got %v", tt.wantField, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(expenses) != tt.wantCount {
+				t.Errorf("Expected %d expenses, got %d", tt.wantCount, len(expenses))
+			}
+		})
+	}
+
+	expenses, err := ImportExpensesCSV(strings.NewReader("Description,Date,Category,Amount\nCrayons,2024-03-03,Supplies,\"$1,004.25\"\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := model.Expense{Date: "2024-03-03", Amount: 1004.25, Description: "Crayons", Category: "supplies"}
+	if expenses[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, expenses[0])
+	}
+}
@@ -0,0 +1,20 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// ExportWeekJSON writes one week's full detail, its totals together with every trip
+// and expense, as indented JSON
+func ExportWeekJSON(w io.Writer, summary model.WeeklySummary) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode week: %w", err)
+	}
+	return nil
+}
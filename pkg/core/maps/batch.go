@@ -0,0 +1,43 @@
+package maps
+
+import (
+	"context"
+)
+
+// Route is an origin/destination pair to look up
+type Route struct {
+	Origin      string
+	Destination string
+}
+
+// BatchDistanceCalculator is implemented by calculators that can look up several
+// routes in a single round trip
+type BatchDistanceCalculator interface {
+	DistanceCalculator
+	CalculateDistanceBatch(ctx context.Context, routes []Route) ([]float64, error)
+}
+
+// CalculateDistanceBatch returns the distance of each route, in order. It uses the
+// calculator's own batch lookup when available, otherwise it calls CalculateDistance
+// once per distinct route and reuses the result for repeats.
+func CalculateDistanceBatch(ctx context.Context, calc DistanceCalculator, routes []Route) ([]float64, error) {
+	if batch, ok := calc.(BatchDistanceCalculator); ok {
+		return batch.CalculateDistanceBatch(ctx, routes)
+	}
+
+	distances := make([]float64, len(routes))
+	seen := make(map[Route]float64, len(routes))
+	for i, route := range routes {
+		miles, ok := seen[route]
+		if !ok {
+			var err error
+			miles, err = calc.CalculateDistance(ctx, route.Origin, route.Destination)
+			if err != nil {
+				return nil, err
+			}
+			seen[route] = miles
+		}
+		distances[i] = miles
+	}
+	return distances, nil
+}
@@ -0,0 +1,62 @@
+package maps
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCalculateDistanceBatch(t *testing.T) {
+	mock := NewMockClient()
+	routes := []Route{
+		{Origin: "Home", Destination: "School"},
+		{Origin: "Home", Destination: "School"},
+		{Origin: "School", Destination: "Park"},
+		{Origin: "Home", Destination: "School"},
+	}
+
+	distances, err := CalculateDistanceBatch(context.Background(), mock, routes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(distances) != len(routes) {
+		t.Fatalf("Expected %d distances, got %d", len(routes), len(distances))
+	}
+	for i, miles := range distances {
+		if miles != 10.0 {
+			t.Errorf("Expected 10.0 miles for route %d, got %.2f", i, miles)
+		}
+	}
+	if mock.Calls() != 2 {
+		t.Errorf("Expected one lookup per distinct route (2), got %d", mock.Calls())
+	}
+}
+
+func TestCalculateDistanceBatchVariesByRoute(t *testing.T) {
+	mock := NewMockClientFunc(func(origin, destination string) (float64, error) {
+		if destination == "School" {
+			return 3.5, nil
+		}
+		return 12.0, nil
+	})
+	routes := []Route{
+		{Origin: "Home", Destination: "School"},
+		{Origin: "School", Destination: "Park"},
+		{Origin: "Home", Destination: "School"},
+	}
+
+	distances, err := CalculateDistanceBatch(context.Background(), mock, routes)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []float64{3.5, 12.0, 3.5}
+	for i, miles := range distances {
+		if miles != want[i] {
+			t.Errorf("Expected %.2f miles for route %d, got %.2f", want[i], i, miles)
+		}
+	}
+
+	fixed := NewMockClientWithDistance(4.2)
+	if miles, err := fixed.CalculateDistance(context.Background(), "Home", "Park"); err != nil || miles != 4.2 {
+		t.Errorf("Expected 4.2 miles, got %.2f (%v)", miles, err)
+	}
+}
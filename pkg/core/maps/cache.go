@@ -0,0 +1,121 @@
+package maps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CachedClient wraps a DistanceCalculator and persists results to disk so
+// repeated routes don't trigger new API calls
+type CachedClient struct {
+	// TTL is how long a cached distance stays valid. Zero means entries never expire.
+	TTL time.Duration
+
+	inner   DistanceCalculator
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+// cacheEntry represents a single cached origin/destination distance
+type cacheEntry struct {
+	Origin      string    `json:"origin"`
+	Destination string    `json:"destination"`
+	Miles       float64   `json:"miles"`
+	CachedAt    time.Time `json:"cached_at"`
+}
+
+// NewCachedClient creates a caching decorator around inner, loading any
+// previously cached distances from path
+func NewCachedClient(inner DistanceCalculator, path string) (*CachedClient, error) {
+	c := &CachedClient{
+		inner:   inner,
+		path:    path,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to read distance cache: %w", err)
+	}
+
+	var entries []cacheEntry
+	if err := json.Unmarshal(fileData, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse distance cache: %w", err)
+	}
+	for _, entry := range entries {
+		c.entries[cacheKey(entry.Origin, entry.Destination)] = entry
+	}
+
+	return c, nil
+}
+
+// CalculateDistance returns the cached distance for an exact origin/destination
+// match, falling back to the wrapped client on a miss or expired entry
+func (c *CachedClient) CalculateDistance(ctx context.Context, origin, destination string) (float64, error) {
+	key := cacheKey(origin, destination)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && !c.expired(entry) {
+		return entry.Miles, nil
+	}
+
+	miles, err := c.inner.CalculateDistance(ctx, origin, destination)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = cacheEntry{
+		Origin:      origin,
+		Destination: destination,
+		Miles:       miles,
+		CachedAt:    c.now(),
+	}
+	// The distance is still good when the cache can't be written; it stays cached in memory
+	if err := c.save(); err != nil {
+		slog.Warn("Failed to save distance cache", "path", c.path, "error", err)
+	}
+
+	return miles, nil
+}
+
+// expired reports whether a cache entry is older than the configured TTL
+func (c *CachedClient) expired(entry cacheEntry) bool {
+	return c.TTL > 0 && c.now().Sub(entry.CachedAt) > c.TTL
+}
+
+// save writes the cache to disk. The caller must hold c.mu.
+func (c *CachedClient) save() error {
+	entries := make([]cacheEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+
+	jsonData, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode distance cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write distance cache: %w", err)
+	}
+	return nil
+}
+
+// cacheKey builds the lookup key for an origin/destination pair
+func cacheKey(origin, destination string) string {
+	return origin + "\x00" + destination
+}
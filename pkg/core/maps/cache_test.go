@@ -0,0 +1,148 @@
+package maps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedClient(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "nannytracker-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cachePath := filepath.Join(tempDir, "distance_cache.json")
+	mock := NewMockClient()
+
+	client, err := NewCachedClient(mock, cachePath)
+	if err != nil {
+		t.Fatalf("Failed to create cached client: %v", err)
+	}
+
+	// First call goes to the inner client
+	miles, err := client.CalculateDistance(context.Background(), "Home", "Work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 10.0 {
+		t.Errorf("Expected 10.0 miles, got %.2f", miles)
+	}
+
+	// Change the inner distance; a cached route should not see it
+	mock.MockDistance = 25.0
+	miles, err = client.CalculateDistance(context.Background(), "Home", "Work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 10.0 {
+		t.Errorf("Expected cached 10.0 miles, got %.2f", miles)
+	}
+
+	// A different route is a cache miss
+	miles, err = client.CalculateDistance(context.Background(), "Work", "Home")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 25.0 {
+		t.Errorf("Expected 25.0 miles for uncached route, got %.2f", miles)
+	}
+
+	// Cached entries are persisted and reloaded from disk
+	reloaded, err := NewCachedClient(NewMockClient(), cachePath)
+	if err != nil {
+		t.Fatalf("Failed to reload cached client: %v", err)
+	}
+	miles, err = reloaded.CalculateDistance(context.Background(), "Work", "Home")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 25.0 {
+		t.Errorf("Expected persisted 25.0 miles, got %.2f", miles)
+	}
+}
+
+func TestCachedClientTTL(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "nannytracker-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	mock := NewMockClient()
+	client, err := NewCachedClient(mock, filepath.Join(tempDir, "distance_cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to create cached client: %v", err)
+	}
+	client.TTL = time.Hour
+
+	now := time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+
+	if _, err := client.CalculateDistance(context.Background(), "Home", "Work"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Still within the TTL
+	mock.MockDistance = 12.0
+	now = now.Add(30 * time.Minute)
+	miles, err := client.CalculateDistance(context.Background(), "Home", "Work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 10.0 {
+		t.Errorf("Expected cached 10.0 miles within TTL, got %.2f", miles)
+	}
+
+	// Past the TTL the entry is refreshed from the inner client
+	now = now.Add(time.Hour)
+	miles, err = client.CalculateDistance(context.Background(), "Home", "Work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 12.0 {
+		t.Errorf("Expected refreshed 12.0 miles after TTL, got %.2f", miles)
+	}
+}
+
+func TestNewCachedClientInvalidFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "nannytracker-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	cachePath := filepath.Join(tempDir, "distance_cache.json")
+	if err := os.WriteFile(cachePath, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write cache file: %v", err)
+	}
+
+	if _, err := NewCachedClient(NewMockClient(), cachePath); err == nil {
+		t.Error("Expected error for corrupt cache file")
+	}
+}
+
+func TestCachedClientUnwritableFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "nannytracker-cache-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	client, err := NewCachedClient(NewMockClient(), filepath.Join(tempDir, "missing", "distance_cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to create cached client: %v", err)
+	}
+
+	// A cache that cannot be written does not fail the lookup
+	miles, err := client.CalculateDistance(context.Background(), "Home", "Work")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if miles != 10.0 {
+		t.Errorf("Expected 10.0 miles, got %.2f", miles)
+	}
+}
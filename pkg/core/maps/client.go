@@ -3,10 +3,21 @@ package maps
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times NewClient retries a transient failure
+	DefaultMaxRetries = 3
+	// DefaultRetryDelay is the backoff before the first retry; it doubles on each attempt
+	DefaultRetryDelay = 500 * time.Millisecond
+	// DefaultRequestsPerSecond is the sustained request rate allowed by NewClient
+	DefaultRequestsPerSecond = 10
 )
 
 // DistanceCalculator is an interface for calculating distances between two points
@@ -16,11 +27,27 @@ type DistanceCalculator interface {
 
 // Client represents a Google Maps Distance Matrix API client
 type Client struct {
+	// MaxRetries is how many times a transient failure is retried; zero disables retries
+	MaxRetries int
+	// RetryDelay is the backoff before the first retry, doubling on each subsequent one
+	RetryDelay time.Duration
+	// Limiter throttles outgoing requests; nil means requests are not throttled
+	Limiter *RateLimiter
+
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+	sleep      func(ctx context.Context, d time.Duration) error
 }
 
+// transientError marks a failure that may succeed if the request is retried
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
 // DistanceMatrixResponse represents the response from the Distance Matrix API
 type DistanceMatrixResponse struct {
 	Rows []struct {
@@ -47,18 +74,50 @@ func NewClient() (*Client, error) {
 	}
 
 	return &Client{
+		MaxRetries: DefaultMaxRetries,
+		RetryDelay: DefaultRetryDelay,
+		Limiter:    NewRateLimiter(DefaultRequestsPerSecond, DefaultRequestsPerSecond),
 		apiKey:     apiKey,
 		httpClient: &http.Client{},
 		baseURL:    "https://maps.googleapis.com/maps/api/distancematrix/json",
 	}, nil
 }
 
-// CalculateDistance calculates the distance between two addresses
+// CalculateDistance calculates the distance between two addresses, retrying
+// transient failures with exponential backoff
 func (c *Client) CalculateDistance(ctx context.Context, origin, destination string) (float64, error) {
 	if origin == "" || destination == "" {
 		return 0, fmt.Errorf("origin and destination addresses cannot be empty")
 	}
 
+	sleep := c.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	delay := c.RetryDelay
+	for attempt := 0; ; attempt++ {
+		if c.Limiter != nil {
+			if err := c.Limiter.Wait(ctx); err != nil {
+				return 0, err
+			}
+		}
+
+		miles, err := c.requestDistance(ctx, origin, destination)
+		var transient *transientError
+		if err == nil || !errors.As(err, &transient) || attempt >= c.MaxRetries {
+			return miles, err
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return 0, err
+		}
+		delay *= 2
+	}
+}
+
+// requestDistance performs a single Distance Matrix request
+func (c *Client) requestDistance(ctx context.Context, origin, destination string) (float64, error) {
 	// Build the URL with query parameters
 	params := url.Values{}
 	params.Add("origins", origin)
@@ -77,7 +136,10 @@ func (c *Client) CalculateDistance(ctx context.Context, origin, destination stri
 	// Make the request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("failed to make request: %w", err)
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("failed to make request: %w", err)
+		}
+		return 0, &transientError{fmt.Errorf("failed to make request: %w", err)}
 	}
 	defer resp.Body.Close()
 
@@ -85,6 +147,9 @@ func (c *Client) CalculateDistance(ctx context.Context, origin, destination stri
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
 		return 0, fmt.Errorf("invalid or unauthorized API key. Please check your GOOGLE_MAPS_API_KEY in .env file")
 	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return 0, &transientError{fmt.Errorf("API request failed with status: %s", resp.Status)}
+	}
 	if resp.StatusCode != http.StatusOK {
 		return 0, fmt.Errorf("API request failed with status: %s", resp.Status)
 	}
@@ -99,6 +164,9 @@ func (c *Client) CalculateDistance(ctx context.Context, origin, destination stri
 	if result.Status == "REQUEST_DENIED" {
 		return 0, fmt.Errorf("API request denied. Please check your API key and billing status")
 	}
+	if result.Status == "OVER_QUERY_LIMIT" || result.Status == "UNKNOWN_ERROR" {
+		return 0, &transientError{fmt.Errorf("API returned error status: %s", result.Status)}
+	}
 	if result.Status != "OK" {
 		return 0, fmt.Errorf("API returned error status: %s", result.Status)
 	}
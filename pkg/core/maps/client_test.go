@@ -2,10 +2,14 @@ package maps
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -31,6 +35,20 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+func TestManualClientNeverLooksUpDistances(t *testing.T) {
+	// Manual miles work without an API key
+	t.Setenv("GOOGLE_MAPS_API_KEY", "")
+
+	var client DistanceCalculator = NewManualClient()
+	miles, err := client.CalculateDistance(context.Background(), "Home", "Work")
+	if !errors.Is(err, ErrManualMiles) {
+		t.Errorf("Expected ErrManualMiles, got %v", err)
+	}
+	if miles != 0 {
+		t.Errorf("Expected no distance, got %.2f", miles)
+	}
+}
+
 func TestCalculateDistance(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -94,6 +112,161 @@ func TestCalculateDistance(t *testing.T) {
 	}
 }
 
+// stubTransport replays canned responses in order, one per request
+type stubTransport struct {
+	statuses []int
+	calls    int
+}
+
+func (s *stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := s.statuses[len(s.statuses)-1]
+	if s.calls < len(s.statuses) {
+		status = s.statuses[s.calls]
+	}
+	s.calls++
+
+	body := `{"status": "UNKNOWN_ERROR"}`
+	if status == http.StatusOK {
+		body = `{"rows": [{"elements": [{"distance": {"value": 16093}, "status": "OK"}]}], "status": "OK"}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+func TestCalculateDistanceRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantErr    bool
+		wantCalls  int
+		wantDelays []time.Duration
+	}{
+		{"succeeds after transient failures", []int{503, 500, 200}, 3, false, 3, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}},
+		{"rate limited then succeeds", []int{429, 200}, 3, false, 2, []time.Duration{100 * time.Millisecond}},
+		{"gives up after max retries", []int{503}, 2, true, 3, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}},
+		{"does not retry client errors", []int{400, 200}, 3, true, 1, nil},
+		{"does not retry bad key", []int{403, 200}, 3, true, 1, nil},
+		{"retries disabled", []int{503, 200}, 0, true, 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &stubTransport{statuses: tt.statuses}
+			var delays []time.Duration
+			client := &Client{
+				MaxRetries: tt.maxRetries,
+				RetryDelay: 100 * time.Millisecond,
+				apiKey:     "test-key",
+				httpClient: &http.Client{Transport: transport},
+				baseURL:    "http://maps.test",
+				sleep: func(ctx context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}
+
+			distance, err := client.CalculateDistance(context.Background(), "123 Main St", "456 Oak Ave")
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if distance != metersToMiles(16093) {
+					t.Errorf("Expected distance %f, got %f", metersToMiles(16093), distance)
+				}
+			}
+			if transport.calls != tt.wantCalls {
+				t.Errorf("Expected %d requests, got %d", tt.wantCalls, transport.calls)
+			}
+			if len(delays) != len(tt.wantDelays) {
+				t.Fatalf("Expected delays %v, got %v", tt.wantDelays, delays)
+			}
+			for i := range delays {
+				if delays[i] != tt.wantDelays[i] {
+					t.Errorf("Expected delay %d to be %v, got %v", i, tt.wantDelays[i], delays[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateDistanceRetriesOverQueryLimit(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			_, _ = w.Write([]byte(`{"status": "OVER_QUERY_LIMIT"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"rows": [{"elements": [{"distance": {"value": 16093}, "status": "OK"}]}], "status": "OK"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		MaxRetries: 1,
+		apiKey:     "test-key",
+		httpClient: server.Client(),
+		baseURL:    server.URL,
+		sleep:      func(ctx context.Context, d time.Duration) error { return nil },
+	}
+
+	if _, err := client.CalculateDistance(context.Background(), "123 Main St", "456 Oak Ave"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 requests, got %d", calls)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+	limiter := NewRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return nil
+	}
+
+	// The burst is available immediately
+	for i := 0; i < 2; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if len(slept) != 0 {
+		t.Fatalf("Expected no waiting within the burst, got %v", slept)
+	}
+
+	// The next request waits for a token to refill at 2 per second
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 500*time.Millisecond {
+		t.Errorf("Expected a single 500ms wait, got %v", slept)
+	}
+
+	// A cancelled context stops the wait
+	limiter.sleep = sleepContext
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected an error for a cancelled context")
+	}
+}
+
 func TestMetersToMiles(t *testing.T) {
 	const conversionFactor = 0.000621371
 	tests := []struct {
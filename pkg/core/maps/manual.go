@@ -0,0 +1,23 @@
+package maps
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrManualMiles is returned by ManualClient to tell callers the miles must be entered by hand
+var ErrManualMiles = errors.New("distance lookups are disabled; enter the miles manually")
+
+// ManualClient is used when Google Maps is turned off. It never looks up a distance,
+// so callers ask for the miles instead of geocoding the route.
+type ManualClient struct{}
+
+// NewManualClient creates a client for entering miles manually
+func NewManualClient() *ManualClient {
+	return &ManualClient{}
+}
+
+// CalculateDistance always fails with ErrManualMiles
+func (c *ManualClient) CalculateDistance(ctx context.Context, origin, destination string) (float64, error) {
+	return 0, ErrManualMiles
+}
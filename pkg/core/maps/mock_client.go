@@ -2,12 +2,17 @@ package maps
 
 import (
 	"context"
+	"sync/atomic"
 )
 
 // MockClient is a mock implementation of the maps client for testing
 type MockClient struct {
 	// MockDistance is the distance that will be returned by CalculateDistance
 	MockDistance float64
+	// DistanceFunc, when set, is used instead of MockDistance so distances can vary by route
+	DistanceFunc func(origin, destination string) (float64, error)
+
+	calls atomic.Int64
 }
 
 // NewMockClient creates a new mock client for testing
@@ -17,7 +22,26 @@ func NewMockClient() *MockClient {
 	}
 }
 
+// NewMockClientWithDistance creates a mock client that returns miles for every route
+func NewMockClientWithDistance(miles float64) *MockClient {
+	return &MockClient{MockDistance: miles}
+}
+
+// NewMockClientFunc creates a mock client that asks distance for the miles of each route
+func NewMockClientFunc(distance func(origin, destination string) (float64, error)) *MockClient {
+	return &MockClient{DistanceFunc: distance}
+}
+
 // CalculateDistance returns the mock distance
 func (m *MockClient) CalculateDistance(ctx context.Context, origin, destination string) (float64, error) {
+	m.calls.Add(1)
+	if m.DistanceFunc != nil {
+		return m.DistanceFunc(origin, destination)
+	}
 	return m.MockDistance, nil
 }
+
+// Calls returns how many times CalculateDistance has been called
+func (m *MockClient) Calls() int {
+	return int(m.calls.Load())
+}
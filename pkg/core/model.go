@@ -1,59 +1,342 @@
 package model
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
 	"sort"
+	"strings"
 	"time"
+
+	"github.com/laurendc/nannytracker/pkg/core/maps"
 )
 
 // Trip represents a single trip with origin, destination, and mileage
 type Trip struct {
-	Origin      string  `json:"origin"`
-	Destination string  `json:"destination"`
-	Miles       float64 `json:"miles"`
-	Date        string  `json:"date"` // Format: YYYY-MM-DD
-	Type        string  `json:"type"` // "single" or "round"
+	// ID identifies the trip to expenses linked to it; it is assigned when the first
+	// expense is linked and is kept when the trip is edited, sorted or archived
+	ID          string   `json:"id,omitempty"`
+	Origin      string   `json:"origin"`
+	Destination string   `json:"destination"`
+	Miles       float64  `json:"miles"`
+	Date        string   `json:"date"` // Format: YYYY-MM-DD
+	Type        string   `json:"type"` // "single", "round" or "custom"
+	Notes       string   `json:"notes,omitempty"`
+	Family      string   `json:"family,omitempty"`     // Family the trip is billed to; empty means DefaultFamily
+	Tags        []string `json:"tags,omitempty"`       // Free-form labels such as "field-trip"
+	Passengers  int      `json:"passengers,omitempty"` // Children driven, for splitting the cost; zero means 1
+	IsRecurring bool     `json:"recurring,omitempty"`  // Generated from a recurring trip
+	Archived    bool     `json:"archived,omitempty"`   // Hidden from lists and left out of summaries
+	Projected   bool     `json:"projected,omitempty"`  // Forecast from a recurring trip rather than recorded; never saved
+	// ReturnDestination is where a round trip heads after Destination instead of
+	// retracing its route; ReturnMiles is the distance of that leg
+	ReturnDestination string  `json:"return_destination,omitempty"`
+	ReturnMiles       float64 `json:"return_miles,omitempty"`
 }
 
-// RecurringTrip represents a trip that occurs weekly
+// RecurringTrip represents a trip that occurs on a weekly, biweekly, or monthly schedule
 type RecurringTrip struct {
 	Origin      string  `json:"origin"`
 	Destination string  `json:"destination"`
 	Miles       float64 `json:"miles"`
-	StartDate   string  `json:"start_date"` // Format: YYYY-MM-DD
-	EndDate     string  `json:"end_date"`   // Format: YYYY-MM-DD, optional
-	Type        string  `json:"type"`       // "single" or "round"
-	Weekday     int     `json:"weekday"`    // 0-6, where 0 is Sunday
+	StartDate   string  `json:"start_date"`             // Format: YYYY-MM-DD
+	EndDate     string  `json:"end_date"`               // Format: YYYY-MM-DD, optional
+	Type        string  `json:"type"`                   // "single" or "round"
+	Weekday     int     `json:"weekday"`                // 0-6, where 0 is Sunday
+	Frequency   string  `json:"frequency,omitempty"`    // "weekly", "biweekly", or "monthly"; empty means weekly
+	DayOfMonth  int     `json:"day_of_month,omitempty"` // 1-31, used when Frequency is "monthly"
+	Family      string  `json:"family,omitempty"`       // Family generated trips are billed to
+	// ExcludedDates lists dates (YYYY-MM-DD) on which no trip is generated, e.g. holidays
+	ExcludedDates []string `json:"excluded_dates,omitempty"`
+	// Occurrences caps how many trips are generated; generation stops at whichever of this
+	// and EndDate comes first. Zero means no limit.
+	Occurrences int `json:"occurrences,omitempty"`
+}
+
+// EffectiveMiles returns the miles the trip contributes to totals. Miles holds the
+// one-way distance, so round trips count double unless they return by way of a
+// different destination, in which case the return leg's miles are added instead.
+// Custom trips count their entered miles as-is.
+func (t Trip) EffectiveMiles() float64 {
+	if t.Type == "round" {
+		if t.ReturnDestination != "" {
+			return t.Miles + t.ReturnMiles
+		}
+		return t.Miles * 2
+	}
+	return t.Miles
+}
+
+// EffectiveMiles returns the miles each generated trip contributes to totals
+func (rt RecurringTrip) EffectiveMiles() float64 {
+	return Trip{Miles: rt.Miles, Type: rt.Type}.EffectiveMiles()
+}
+
+// IsValidTripType reports whether tripType is one a trip can have: "single", "round", or
+// "custom" for trips such as multi-stop outings whose total miles are entered by hand
+func IsValidTripType(tripType string) bool {
+	switch tripType {
+	case "single", "round", "custom":
+		return true
+	}
+	return false
+}
+
+// IsCustom reports whether the trip's miles were entered by hand rather than looked up
+func (t Trip) IsCustom() bool {
+	return t.Type == "custom"
+}
+
+// DefaultFamily is the family assigned to records that don't specify one
+const DefaultFamily = "default"
+
+// FamilyOrDefault returns the trip's family, falling back to DefaultFamily
+func (t Trip) FamilyOrDefault() string {
+	if t.Family == "" {
+		return DefaultFamily
+	}
+	return t.Family
+}
+
+// PassengersOrDefault returns the number of passengers, treating an unset count as one
+func (t Trip) PassengersOrDefault() int {
+	if t.Passengers <= 0 {
+		return 1
+	}
+	return t.Passengers
+}
+
+// FamilyOrDefault returns the expense's family, falling back to DefaultFamily
+func (e Expense) FamilyOrDefault() string {
+	if e.Family == "" {
+		return DefaultFamily
+	}
+	return e.Family
+}
+
+// FilterTripsByFamily returns the trips belonging to family. An empty family returns all trips.
+func FilterTripsByFamily(trips []Trip, family string) []Trip {
+	if family == "" {
+		return trips
+	}
+	filtered := make([]Trip, 0, len(trips))
+	for _, trip := range trips {
+		if trip.FamilyOrDefault() == family {
+			filtered = append(filtered, trip)
+		}
+	}
+	return filtered
+}
+
+// UnarchivedTrips returns the trips that have not been archived
+func UnarchivedTrips(trips []Trip) []Trip {
+	filtered := make([]Trip, 0, len(trips))
+	for _, trip := range trips {
+		if !trip.Archived {
+			filtered = append(filtered, trip)
+		}
+	}
+	return filtered
+}
+
+// FilterTripsByTag returns the trips carrying tag, compared case-insensitively
+func FilterTripsByTag(trips []Trip, tag string) []Trip {
+	filtered := make([]Trip, 0, len(trips))
+	for _, trip := range trips {
+		if trip.HasTag(tag) {
+			filtered = append(filtered, trip)
+		}
+	}
+	return filtered
+}
+
+// HasTag reports whether the trip carries tag, compared case-insensitively
+func (t Trip) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags splits comma-separated input into trimmed tags, dropping empty entries
+func ParseTags(input string) []string {
+	var tags []string
+	for _, tag := range strings.Split(input, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// FilterRecurringTripsByFamily returns the recurring trips belonging to family. An empty
+// family returns all recurring trips.
+func FilterRecurringTripsByFamily(trips []RecurringTrip, family string) []RecurringTrip {
+	if family == "" {
+		return trips
+	}
+	filtered := make([]RecurringTrip, 0, len(trips))
+	for _, rt := range trips {
+		if (Trip{Family: rt.Family}).FamilyOrDefault() == family {
+			filtered = append(filtered, rt)
+		}
+	}
+	return filtered
 }
 
-// Validate checks if a trip is valid
+// FilterExpensesByFamily returns the expenses belonging to family. An empty family returns all expenses.
+func FilterExpensesByFamily(expenses []Expense, family string) []Expense {
+	if family == "" {
+		return expenses
+	}
+	filtered := make([]Expense, 0, len(expenses))
+	for _, expense := range expenses {
+		if expense.FamilyOrDefault() == family {
+			filtered = append(filtered, expense)
+		}
+	}
+	return filtered
+}
+
+// ValidationError describes why a value failed validation. Field names the
+// offending input using its JSON name so callers can point at it directly.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the human-readable message
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid returns a ValidationError for field
+func invalid(field, message string) error {
+	return &ValidationError{Field: field, Message: message}
+}
+
+// invalidf returns a ValidationError for field with a formatted message
+func invalidf(field, format string, args ...interface{}) error {
+	return &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks if a trip is valid, returning the first problem found
 func (t Trip) Validate() error {
-	if t.Origin == "" {
-		return errors.New("origin cannot be empty")
+	if errs := t.ValidationErrors(); len(errs) > 0 {
+		return errs[0]
 	}
-	if t.Destination == "" {
-		return errors.New("destination cannot be empty")
+	return nil
+}
+
+// NormalizeAddress trims an address and collapses each run of whitespace inside it to a
+// single space, so "Home " and "Home" name the same place
+func NormalizeAddress(address string) string {
+	return strings.Join(strings.Fields(address), " ")
+}
+
+// Normalize tidies the whitespace in the trip's addresses (see NormalizeAddress)
+func (t *Trip) Normalize() {
+	t.Origin = NormalizeAddress(t.Origin)
+	t.Destination = NormalizeAddress(t.Destination)
+	t.ReturnDestination = NormalizeAddress(t.ReturnDestination)
+}
+
+// ValidationErrors checks every field of the trip and returns one error for each field
+// that fails, so all of them can be reported at once. It returns nil for a valid trip.
+func (t Trip) ValidationErrors() []*ValidationError {
+	var errs []*ValidationError
+	add := func(field, message string) {
+		errs = append(errs, &ValidationError{Field: field, Message: message})
+	}
+
+	// An address of nothing but spaces is as empty as no address
+	if NormalizeAddress(t.Origin) == "" {
+		add("origin", "origin cannot be empty")
+	}
+	if NormalizeAddress(t.Destination) == "" {
+		add("destination", "destination cannot be empty")
 	}
 	if t.Miles <= 0 {
-		return errors.New("miles must be greater than 0")
+		if t.IsCustom() {
+			add("miles", "miles are required for custom trips and must be greater than 0")
+		} else {
+			add("miles", "miles must be greater than 0")
+		}
 	}
 	if t.Date == "" {
-		return errors.New("date cannot be empty")
+		add("date", "date cannot be empty")
+	} else if date, err := time.Parse("2006-01-02", t.Date); err != nil {
+		add("date", "date must be in YYYY-MM-DD format")
+	} else if date.Year() < 1000 {
+		add("date", "year must be at least 1000")
 	}
 	if t.Type == "" {
-		return errors.New("trip type cannot be empty")
+		add("type", "trip type cannot be empty")
+	} else if !IsValidTripType(t.Type) {
+		add("type", "trip type must be 'single', 'round' or 'custom'")
 	}
-	if t.Type != "single" && t.Type != "round" {
-		return errors.New("trip type must be either 'single' or 'round'")
+	if t.Passengers < 0 {
+		add("passengers", "passengers must be at least 1")
+	}
+	if t.ReturnDestination != "" {
+		if t.Type != "round" {
+			add("return_destination", "return destination only applies to round trips")
+		}
+		if t.ReturnMiles <= 0 {
+			add("return_miles", "return miles must be greater than 0")
+		}
+	} else if t.ReturnMiles != 0 {
+		add("return_miles", "return miles require a return destination")
+	}
+	for _, tag := range t.Tags {
+		if strings.TrimSpace(tag) == "" {
+			add("tags", "tags cannot be empty")
+			break
+		}
+		if tag != strings.TrimSpace(tag) {
+			add("tags", fmt.Sprintf("tag %q must not start or end with spaces", tag))
+			break
+		}
+	}
+	return errs
+}
+
+// ValidateWithBounds validates the trip and also rejects dates more than maxFutureDays
+// after now. A maxFutureDays of zero or less disables the future date check.
+func (t Trip) ValidateWithBounds(now time.Time, maxFutureDays int) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	return t.ValidateFutureDate(now, maxFutureDays)
+}
+
+// ValidateFutureDate rejects trips dated more than maxFutureDays after now. A malformed
+// date is left to Validate, and a maxFutureDays of zero or less disables the check.
+func (t Trip) ValidateFutureDate(now time.Time, maxFutureDays int) error {
+	if maxFutureDays <= 0 {
+		return nil
 	}
-	// Validate date format (YYYY-MM-DD)
 	date, err := time.Parse("2006-01-02", t.Date)
 	if err != nil {
-		return errors.New("date must be in YYYY-MM-DD format")
+		return nil
 	}
-	// Check for invalid year (less than 1000)
-	if date.Year() < 1000 {
-		return errors.New("year must be at least 1000")
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if date.After(today.AddDate(0, 0, maxFutureDays)) {
+		return invalidf("date", "date %s is more than %d days in the future", t.Date, maxFutureDays)
+	}
+	return nil
+}
+
+// ValidateMaxMiles rejects trips whose one-way distance exceeds maxMiles, which usually
+// means an address was mistyped or geocoded badly. A maxMiles of zero or less disables the
+// check, and custom trips, whose miles are entered rather than looked up, are not checked.
+func (t Trip) ValidateMaxMiles(maxMiles float64) error {
+	if maxMiles > 0 && !t.IsCustom() && t.Miles > maxMiles {
+		return invalidf("miles", "%.1f miles is more than the %.1f mile limit; check the addresses", t.Miles, maxMiles)
 	}
 	return nil
 }
@@ -61,44 +344,66 @@ func (t Trip) Validate() error {
 // Validate checks if a recurring trip is valid
 func (rt RecurringTrip) Validate() error {
 	if rt.Origin == "" {
-		return errors.New("origin cannot be empty")
+		return invalid("origin", "origin cannot be empty")
 	}
 	if rt.Destination == "" {
-		return errors.New("destination cannot be empty")
+		return invalid("destination", "destination cannot be empty")
 	}
 	if rt.Miles <= 0 {
-		return errors.New("miles must be greater than 0")
+		return invalid("miles", "miles must be greater than 0")
 	}
 	if rt.StartDate == "" {
-		return errors.New("start date cannot be empty")
+		return invalid("start_date", "start date cannot be empty")
 	}
 	if rt.Type == "" {
-		return errors.New("trip type cannot be empty")
+		return invalid("type", "trip type cannot be empty")
 	}
 	if rt.Type != "single" && rt.Type != "round" {
-		return errors.New("trip type must be either 'single' or 'round'")
+		return invalid("type", "trip type must be either 'single' or 'round'")
 	}
 	if rt.Weekday < 0 || rt.Weekday > 6 {
-		return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		return invalid("weekday", "weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	switch rt.Frequency {
+	case "", "weekly", "biweekly":
+	case "monthly":
+		if rt.DayOfMonth < 1 || rt.DayOfMonth > 31 {
+			return invalid("day_of_month", "day of month must be between 1 and 31")
+		}
+	default:
+		return invalid("frequency", "frequency must be 'weekly', 'biweekly', or 'monthly'")
 	}
 
 	// Validate start date format
 	startDate, err := time.Parse("2006-01-02", rt.StartDate)
 	if err != nil {
-		return errors.New("start date must be in YYYY-MM-DD format")
+		return invalid("start_date", "start date must be in YYYY-MM-DD format")
 	}
 	if startDate.Year() < 1000 {
-		return errors.New("start year must be at least 1000")
+		return invalid("start_date", "start year must be at least 1000")
+	}
+
+	if rt.Occurrences < 0 {
+		return invalid("occurrences", "occurrences cannot be negative")
+	}
+	if rt.EndDate == "" && rt.Occurrences == 0 {
+		return invalid("end_date", "either an end date or a number of occurrences is required")
 	}
 
 	// Validate end date if provided
 	if rt.EndDate != "" {
 		endDate, err := time.Parse("2006-01-02", rt.EndDate)
 		if err != nil {
-			return errors.New("end date must be in YYYY-MM-DD format")
+			return invalid("end_date", "end date must be in YYYY-MM-DD format")
 		}
 		if endDate.Before(startDate) {
-			return errors.New("end date must be after start date")
+			return invalid("end_date", "end date must be after start date")
+		}
+	}
+
+	for _, date := range rt.ExcludedDates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return invalidf("excluded_dates", "excluded date %q must be in YYYY-MM-DD format", date)
 		}
 	}
 
@@ -108,14 +413,43 @@ func (rt RecurringTrip) Validate() error {
 // CalculateTotalMiles returns the sum of miles for all trips
 func CalculateTotalMiles(trips []Trip) float64 {
 	var total float64
+	for _, t := range trips {
+		total += t.EffectiveMiles()
+	}
+	return total
+}
+
+// AverageMilesPerTrip returns the mean effective miles of the trips, or zero when there are none
+func AverageMilesPerTrip(trips []Trip) float64 {
+	if len(trips) == 0 {
+		return 0
+	}
+	return CalculateTotalMiles(trips) / float64(len(trips))
+}
+
+// AverageMilesPerActiveDay returns the effective miles driven per day that has at least
+// one trip, or zero when there are no trips
+func AverageMilesPerActiveDay(trips []Trip) float64 {
+	days := make(map[string]bool)
+	for _, t := range trips {
+		days[t.Date] = true
+	}
+	if len(days) == 0 {
+		return 0
+	}
+	return CalculateTotalMiles(trips) / float64(len(days))
+}
+
+// CountTripsByType returns the number of single and round trips. Custom trips count as single.
+func CountTripsByType(trips []Trip) (single, round int) {
 	for _, t := range trips {
 		if t.Type == "round" {
-			total += t.Miles * 2
+			round++
 		} else {
-			total += t.Miles
+			single++
 		}
 	}
-	return total
+	return single, round
 }
 
 // CalculateReimbursement calculates the total reimbursement amount
@@ -123,32 +457,205 @@ func CalculateReimbursement(trips []Trip, ratePerMile float64) float64 {
 	return CalculateTotalMiles(trips) * ratePerMile
 }
 
+// CalculateReimbursementPerPassenger returns the mileage reimbursement with each trip's
+// share divided among its passengers, for splitting the cost between families
+func CalculateReimbursementPerPassenger(trips []Trip, ratePerMile float64) float64 {
+	var total float64
+	for _, trip := range trips {
+		total += trip.EffectiveMiles() * ratePerMile / float64(trip.PassengersOrDefault())
+	}
+	return total
+}
+
+// Rounding modes applied to mileage reimbursement totals
+const (
+	RoundingNone          = "none"
+	RoundingCent          = "cent"
+	RoundingNearestDollar = "nearest_dollar"
+)
+
+// IsValidRoundingMode reports whether mode is a known rounding mode
+func IsValidRoundingMode(mode string) bool {
+	switch mode {
+	case RoundingNone, RoundingCent, RoundingNearestDollar:
+		return true
+	}
+	return false
+}
+
+// RoundAmount rounds a dollar amount half-up according to the rounding mode.
+// An empty or unknown mode leaves the amount unchanged.
+func RoundAmount(amount float64, mode string) float64 {
+	switch mode {
+	case RoundingCent:
+		return roundHalfUp(amount, 100)
+	case RoundingNearestDollar:
+		return roundHalfUp(amount, 1)
+	}
+	return amount
+}
+
+// roundHalfUp rounds amount to the nearest 1/scale, with halves rounding up.
+// The small epsilon absorbs float error so values like 26.195 round to 26.20.
+func roundHalfUp(amount, scale float64) float64 {
+	return math.Floor(amount*scale+0.5+1e-9) / scale
+}
+
+// RateChange is a mileage rate that applies to trips from EffectiveFrom onwards, until
+// the next change in a rate schedule
+type RateChange struct {
+	EffectiveFrom string  `json:"effective_from"` // Format: YYYY-MM-DD
+	RatePerMile   float64 `json:"rate_per_mile"`
+}
+
+// RateOn returns the rate in effect on date from a schedule sorted by EffectiveFrom, and
+// the date it took effect. Dates before the first change, or an empty schedule, get
+// defaultRate and an empty effective date.
+func RateOn(schedule []RateChange, date string, defaultRate float64) (float64, string) {
+	rate, effectiveFrom := defaultRate, ""
+	for _, change := range schedule {
+		if change.EffectiveFrom > date {
+			break
+		}
+		rate, effectiveFrom = change.RatePerMile, change.EffectiveFrom
+	}
+	return rate, effectiveFrom
+}
+
+// DefaultExpenseCategory is the category used for expenses that don't specify one
+const DefaultExpenseCategory = "other"
+
+// ExpenseCategories lists the valid expense categories
+var ExpenseCategories = []string{"food", "activities", "supplies", "other"}
+
 // Expense represents a reimbursable expense
 type Expense struct {
-	Date        string  `json:"date"`        // Format: YYYY-MM-DD
-	Amount      float64 `json:"amount"`      // Amount in dollars
-	Description string  `json:"description"` // Brief description of the expense
+	Date        string  `json:"date"`               // Format: YYYY-MM-DD
+	Amount      float64 `json:"amount"`             // Amount in dollars
+	Description string  `json:"description"`        // Brief description of the expense
+	Category    string  `json:"category,omitempty"` // One of ExpenseCategories; empty means "other"
+	Family      string  `json:"family,omitempty"`   // Family the expense is billed to; empty means DefaultFamily
+	// Reimbursable reports whether the family pays the expense back; nil means it does
+	Reimbursable *bool `json:"reimbursable,omitempty"`
+	// TripID optionally ties the expense to the trip with that ID, such as parking for
+	// one outing. An empty TripID means the expense stands alone.
+	TripID string `json:"trip_id,omitempty"`
+	// TripDate and TripIndex name the trip to link when the expense is created: the
+	// TripIndex'th trip (from 0, in stored order) of the expense's family dated TripDate.
+	// LinkByDate turns them into TripID; files from before TripID stored links this way.
+	TripDate  string `json:"trip_date,omitempty"`
+	TripIndex int    `json:"trip_index,omitempty"`
+}
+
+// IsReimbursable reports whether the expense is billed to the family rather than personal
+func (e Expense) IsReimbursable() bool {
+	return e.Reimbursable == nil || *e.Reimbursable
+}
+
+// IsTripLinked reports whether the expense belongs to a trip
+func (e Expense) IsTripLinked() bool {
+	return e.TripID != ""
+}
+
+// LinkToTrip ties the expense to trips[i], giving that trip an ID if it has none, and
+// bills the expense to the trip's family
+func (e *Expense) LinkToTrip(trips []Trip, i int) {
+	if trips[i].ID == "" {
+		trips[i].ID = newTripID()
+	}
+	e.Family = trips[i].FamilyOrDefault()
+	e.TripID = trips[i].ID
+	e.TripDate = ""
+	e.TripIndex = 0
+}
+
+// LinkByDate links the expense to the trip named by TripDate and TripIndex, reporting
+// false when trips has no such trip
+func (e *Expense) LinkByDate(trips []Trip) bool {
+	family := e.FamilyOrDefault()
+	position := 0
+	for i, trip := range trips {
+		if trip.Date != e.TripDate || trip.FamilyOrDefault() != family {
+			continue
+		}
+		if position == e.TripIndex {
+			e.LinkToTrip(trips, i)
+			return true
+		}
+		position++
+	}
+	return false
+}
+
+// LinkedTrip returns the index in trips of the trip the expense is linked to, reporting
+// false when it is not linked or that trip is not in trips
+func (e Expense) LinkedTrip(trips []Trip) (int, bool) {
+	if !e.IsTripLinked() {
+		return -1, false
+	}
+	for i, trip := range trips {
+		if trip.ID == e.TripID {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// newTripID returns a random ID for a trip that expenses are linked to
+func newTripID() string {
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
+
+// CategoryOrDefault returns the expense category, falling back to DefaultExpenseCategory
+func (e Expense) CategoryOrDefault() string {
+	if e.Category == "" {
+		return DefaultExpenseCategory
+	}
+	return e.Category
+}
+
+// IsValidExpenseCategory reports whether category is one of ExpenseCategories
+func IsValidExpenseCategory(category string) bool {
+	for _, c := range ExpenseCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
 }
 
 // Validate checks if an expense is valid
 func (e Expense) Validate() error {
 	if e.Date == "" {
-		return errors.New("date cannot be empty")
+		return invalid("date", "date cannot be empty")
 	}
 	if e.Amount <= 0 {
-		return errors.New("amount must be greater than 0")
+		return invalid("amount", "amount must be greater than 0")
 	}
 	if e.Description == "" {
-		return errors.New("description cannot be empty")
+		return invalid("description", "description cannot be empty")
+	}
+	if e.Category != "" && !IsValidExpenseCategory(e.Category) {
+		return invalid("category", "category must be one of: food, activities, supplies, other")
 	}
 	// Validate date format (YYYY-MM-DD)
 	date, err := time.Parse("2006-01-02", e.Date)
 	if err != nil {
-		return errors.New("date must be in YYYY-MM-DD format")
+		return invalid("date", "date must be in YYYY-MM-DD format")
 	}
 	// Check for invalid year (less than 1000)
 	if date.Year() < 1000 {
-		return errors.New("year must be at least 1000")
+		return invalid("date", "year must be at least 1000")
+	}
+	if e.TripDate != "" {
+		if _, err := time.Parse("2006-01-02", e.TripDate); err != nil {
+			return invalid("trip_date", "trip date must be in YYYY-MM-DD format")
+		}
+	} else if e.TripIndex != 0 {
+		return invalid("trip_date", "trip date is required to link a trip")
+	}
+	if e.TripIndex < 0 {
+		return invalid("trip_index", "trip index cannot be negative")
 	}
 	return nil
 }
@@ -162,30 +669,242 @@ func CalculateTotalExpenses(expenses []Expense) float64 {
 	return total
 }
 
+// CalculateReimbursableExpenses returns the sum of the expenses billed to the family
+func CalculateReimbursableExpenses(expenses []Expense) float64 {
+	var total float64
+	for _, e := range expenses {
+		if e.IsReimbursable() {
+			total += e.Amount
+		}
+	}
+	return total
+}
+
+// CalculatePersonalExpenses returns the sum of the expenses that are not reimbursed
+func CalculatePersonalExpenses(expenses []Expense) float64 {
+	return CalculateTotalExpenses(expenses) - CalculateReimbursableExpenses(expenses)
+}
+
+// CalculateExpensesByCategory returns the sum of reimbursable expenses grouped by category
+func CalculateExpensesByCategory(expenses []Expense) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, e := range expenses {
+		if e.IsReimbursable() {
+			totals[e.CategoryOrDefault()] += e.Amount
+		}
+	}
+	return totals
+}
+
+// Adjustment corrects a week's mileage without editing the trips recorded in it,
+// e.g. to take off miles that were logged twice
+type Adjustment struct {
+	Date   string  `json:"date"`  // Format: YYYY-MM-DD; the adjustment applies to the week containing it
+	Miles  float64 `json:"miles"` // Negative to remove miles, positive to add them
+	Reason string  `json:"reason"`
+	Family string  `json:"family,omitempty"` // Family whose week is adjusted; empty means DefaultFamily
+}
+
+// FamilyOrDefault returns the adjustment's family, falling back to DefaultFamily
+func (a Adjustment) FamilyOrDefault() string {
+	if a.Family == "" {
+		return DefaultFamily
+	}
+	return a.Family
+}
+
+// Validate checks if the adjustment is valid
+func (a Adjustment) Validate() error {
+	if err := ValidateDate(a.Date); err != nil {
+		return invalid("date", err.Error())
+	}
+	if a.Miles == 0 {
+		return invalid("miles", "miles cannot be zero")
+	}
+	if strings.TrimSpace(a.Reason) == "" {
+		return invalid("reason", "reason cannot be empty")
+	}
+	return nil
+}
+
+// FilterAdjustmentsByFamily returns the adjustments belonging to family. An empty family
+// returns all adjustments.
+func FilterAdjustmentsByFamily(adjustments []Adjustment, family string) []Adjustment {
+	if family == "" {
+		return adjustments
+	}
+	filtered := make([]Adjustment, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		if adjustment.FamilyOrDefault() == family {
+			filtered = append(filtered, adjustment)
+		}
+	}
+	return filtered
+}
+
+// CalculateAdjustmentMiles returns the sum of the adjustments' miles
+func CalculateAdjustmentMiles(adjustments []Adjustment) float64 {
+	var total float64
+	for _, a := range adjustments {
+		total += a.Miles
+	}
+	return total
+}
+
 // WeeklySummary represents the total miles and reimbursement for a week
 type WeeklySummary struct {
-	WeekStart     string // YYYY-MM-DD format
-	WeekEnd       string // YYYY-MM-DD format
+	WeekStart             string // YYYY-MM-DD format
+	WeekEnd               string // YYYY-MM-DD format
+	TotalMiles            float64
+	TotalAmount           float64
+	PerPassengerAmount    float64            // TotalAmount with each trip split among its passengers
+	TotalExpenses         float64            // Reimbursable expenses only
+	TotalPersonalExpenses float64            // Expenses the family does not pay back
+	SingleTripCount       int                // Number of one-way trips this week
+	RoundTripCount        int                // Number of round trips this week
+	ExpensesByCategory    map[string]float64 // Reimbursable expense subtotal for each category
+	Trips                 []Trip             // Itemized list of trips for this week
+	Expenses              []Expense          // Itemized list of expenses for this week
+	HasTrips              bool               // At least one trip was recorded this week (see FlagActivity)
+	HasExpenses           bool               // At least one expense, reimbursable or personal, was recorded this week
+	AdjustmentMiles       float64            // Net miles added by adjustments, already included in TotalMiles
+	ProjectedMiles        float64            // Miles of projected trips, already included in TotalMiles
+	Adjustments           []Adjustment       // Itemized list of adjustments for this week (see ApplyAdjustments)
+}
+
+// GrandTotal represents totals across every weekly summary
+type GrandTotal struct {
+	TotalMiles            float64
+	TotalAmount           float64
+	TotalExpenses         float64
+	TotalPersonalExpenses float64
+}
+
+// CalculateGrandTotal sums miles, mileage amounts and expenses across all weekly summaries
+func CalculateGrandTotal(summaries []WeeklySummary) GrandTotal {
+	var total GrandTotal
+	for _, summary := range summaries {
+		total.TotalMiles += summary.TotalMiles
+		total.TotalAmount += summary.TotalAmount
+		total.TotalExpenses += summary.TotalExpenses
+		total.TotalPersonalExpenses += summary.TotalPersonalExpenses
+	}
+	return total
+}
+
+// MonthlySummary represents the totals for one month. The trips, expenses and
+// rate are only filled in by CalculateMonthlySummary, for month statements.
+type MonthlySummary struct {
+	Month         string // YYYY-MM format
+	TotalMiles    float64
+	TotalAmount   float64
+	TotalExpenses float64   // Reimbursable expenses only
+	RatePerMile   float64   `json:",omitempty"`
+	Trips         []Trip    `json:",omitempty"`
+	Expenses      []Expense `json:",omitempty"`
+}
+
+// CalculateMonthlySummary totals the trips and expenses dated within month (YYYY-MM),
+// keeping the matching records sorted by date. Archived trips are left out.
+func CalculateMonthlySummary(trips []Trip, expenses []Expense, rate float64, month string) (MonthlySummary, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return MonthlySummary{}, fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+	}
+
+	summary := MonthlySummary{Month: month, RatePerMile: rate}
+	for _, trip := range trips {
+		if !trip.Archived && strings.HasPrefix(trip.Date, month+"-") {
+			summary.Trips = append(summary.Trips, trip)
+		}
+	}
+	for _, expense := range expenses {
+		if strings.HasPrefix(expense.Date, month+"-") {
+			summary.Expenses = append(summary.Expenses, expense)
+		}
+	}
+	sort.SliceStable(summary.Trips, func(i, j int) bool {
+		return summary.Trips[i].Date < summary.Trips[j].Date
+	})
+	sort.SliceStable(summary.Expenses, func(i, j int) bool {
+		return summary.Expenses[i].Date < summary.Expenses[j].Date
+	})
+
+	summary.TotalMiles = CalculateTotalMiles(summary.Trips)
+	summary.TotalAmount = CalculateReimbursement(summary.Trips, rate)
+	summary.TotalExpenses = CalculateReimbursableExpenses(summary.Expenses)
+	return summary, nil
+}
+
+// YearlySummary represents the totals for a calendar year with a month-by-month breakdown
+type YearlySummary struct {
+	Year          int
 	TotalMiles    float64
 	TotalAmount   float64
 	TotalExpenses float64
-	Trips         []Trip    // Itemized list of trips for this week
-	Expenses      []Expense // Itemized list of expenses for this week
+	Months        []MonthlySummary // One entry per month, January through December
 }
 
-// CalculateWeeklySummaries groups trips and expenses by week and calculates totals
-func CalculateWeeklySummaries(trips []Trip, expenses []Expense, ratePerMile float64) []WeeklySummary {
+// CalculateYearlySummary totals the trips and expenses dated within the given year,
+// leaving out archived trips
+func CalculateYearlySummary(trips []Trip, expenses []Expense, rate float64, year int) YearlySummary {
+	monthlyTrips := make([][]Trip, 12)
+	monthlyExpenses := make([][]Expense, 12)
+
+	for _, trip := range trips {
+		t, err := time.Parse("2006-01-02", trip.Date)
+		if err != nil || t.Year() != year || trip.Archived {
+			continue
+		}
+		monthlyTrips[t.Month()-1] = append(monthlyTrips[t.Month()-1], trip)
+	}
+	for _, expense := range expenses {
+		t, err := time.Parse("2006-01-02", expense.Date)
+		if err != nil || t.Year() != year {
+			continue
+		}
+		monthlyExpenses[t.Month()-1] = append(monthlyExpenses[t.Month()-1], expense)
+	}
+
+	summary := YearlySummary{
+		Year:   year,
+		Months: make([]MonthlySummary, 12),
+	}
+	for i := range summary.Months {
+		month := MonthlySummary{
+			Month:         fmt.Sprintf("%04d-%02d", year, i+1),
+			TotalMiles:    CalculateTotalMiles(monthlyTrips[i]),
+			TotalAmount:   CalculateReimbursement(monthlyTrips[i], rate),
+			TotalExpenses: CalculateReimbursableExpenses(monthlyExpenses[i]),
+		}
+		summary.Months[i] = month
+		summary.TotalMiles += month.TotalMiles
+		summary.TotalAmount += month.TotalAmount
+		summary.TotalExpenses += month.TotalExpenses
+	}
+
+	return summary
+}
+
+// WeekStartOf returns the first day of the week containing date, for weeks beginning on weekStartDay
+func WeekStartOf(date time.Time, weekStartDay time.Weekday) time.Time {
+	offset := (int(date.Weekday()) - int(weekStartDay) + 7) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+// CalculateWeeklySummaries groups trips and expenses into weeks beginning on weekStartDay
+// and calculates totals, rounding each week's mileage amount according to roundingMode
+func CalculateWeeklySummaries(trips []Trip, expenses []Expense, ratePerMile float64, roundingMode string, weekStartDay time.Weekday) []WeeklySummary {
 	if len(trips) == 0 && len(expenses) == 0 {
 		return nil
 	}
 
-	// Sort trips by date in descending order
-	sort.Slice(trips, func(i, j int) bool {
+	// Sort trips by date in descending order, keeping same-day trips in stored order
+	sort.SliceStable(trips, func(i, j int) bool {
 		return trips[i].Date > trips[j].Date
 	})
 
 	// Sort expenses by date in descending order
-	sort.Slice(expenses, func(i, j int) bool {
+	sort.SliceStable(expenses, func(i, j int) bool {
 		return expenses[i].Date > expenses[j].Date
 	})
 
@@ -193,14 +912,13 @@ func CalculateWeeklySummaries(trips []Trip, expenses []Expense, ratePerMile floa
 	weeklyTrips := make(map[string][]Trip)
 	weeklyExpenses := make(map[string][]Expense)
 
-	// Group trips
+	// Group trips, leaving out archived ones
 	for _, trip := range trips {
 		t, err := time.Parse("2006-01-02", trip.Date)
-		if err != nil {
+		if err != nil || trip.Archived {
 			continue
 		}
-		// Adjust to start of week (Sunday)
-		weekStart := t.AddDate(0, 0, -int(t.Weekday()))
+		weekStart := WeekStartOf(t, weekStartDay)
 		weekKey := weekStart.Format("2006-01-02")
 		weeklyTrips[weekKey] = append(weeklyTrips[weekKey], trip)
 	}
@@ -211,8 +929,7 @@ func CalculateWeeklySummaries(trips []Trip, expenses []Expense, ratePerMile floa
 		if err != nil {
 			continue
 		}
-		// Adjust to start of week (Sunday)
-		weekStart := t.AddDate(0, 0, -int(t.Weekday()))
+		weekStart := WeekStartOf(t, weekStartDay)
 		weekKey := weekStart.Format("2006-01-02")
 		weeklyExpenses[weekKey] = append(weeklyExpenses[weekKey], expense)
 	}
@@ -261,60 +978,551 @@ func CalculateWeeklySummaries(trips []Trip, expenses []Expense, ratePerMile floa
 		})
 
 		totalMiles := CalculateTotalMiles(weekTrips)
-		totalAmount := CalculateReimbursement(weekTrips, ratePerMile)
-		totalExpenses := CalculateTotalExpenses(weekExpenses)
+		totalAmount := RoundAmount(CalculateReimbursement(weekTrips, ratePerMile), roundingMode)
+		totalExpenses := CalculateReimbursableExpenses(weekExpenses)
+
+		singleCount, roundCount := CountTripsByType(weekTrips)
+
+		var projectedMiles float64
+		for _, trip := range weekTrips {
+			if trip.Projected {
+				projectedMiles += trip.EffectiveMiles()
+			}
+		}
 
 		// Calculate week end date
 		weekEnd := weekTime.AddDate(0, 0, 6).Format("2006-01-02")
 
 		summaries = append(summaries, WeeklySummary{
-			WeekStart:     weekKey,
-			WeekEnd:       weekEnd,
-			TotalMiles:    totalMiles,
-			TotalAmount:   totalAmount,
-			TotalExpenses: totalExpenses,
-			Trips:         weekTrips,
-			Expenses:      weekExpenses,
+			WeekStart:             weekKey,
+			WeekEnd:               weekEnd,
+			TotalMiles:            totalMiles,
+			TotalAmount:           totalAmount,
+			PerPassengerAmount:    RoundAmount(CalculateReimbursementPerPassenger(weekTrips, ratePerMile), roundingMode),
+			TotalExpenses:         totalExpenses,
+			TotalPersonalExpenses: CalculatePersonalExpenses(weekExpenses),
+			SingleTripCount:       singleCount,
+			RoundTripCount:        roundCount,
+			ExpensesByCategory:    CalculateExpensesByCategory(weekExpenses),
+			Trips:                 weekTrips,
+			Expenses:              weekExpenses,
+			ProjectedMiles:        projectedMiles,
 		})
 	}
+	FlagActivity(summaries)
+
+	return summaries
+}
+
+// FlagActivity sets HasTrips and HasExpenses on each summary from its itemized trips
+// and expenses
+func FlagActivity(summaries []WeeklySummary) {
+	for i := range summaries {
+		summaries[i].HasTrips = len(summaries[i].Trips) > 0
+		summaries[i].HasExpenses = len(summaries[i].Expenses) > 0
+	}
+}
+
+// ApplyAdjustments folds adjustments into the weekly summaries they fall in and returns
+// the result. Each adjusted week's miles and mileage amounts are recalculated from its
+// trips plus the adjustment, never dropping below zero. Weeks that have adjustments but
+// no trips or expenses get a summary of their own, keeping the most recent week first.
+func ApplyAdjustments(summaries []WeeklySummary, adjustments []Adjustment, ratePerMile float64, roundingMode string, weekStartDay time.Weekday) []WeeklySummary {
+	weekly := make(map[string][]Adjustment)
+	for _, adjustment := range adjustments {
+		t, err := time.Parse("2006-01-02", adjustment.Date)
+		if err != nil {
+			continue
+		}
+		weekKey := WeekStartOf(t, weekStartDay).Format("2006-01-02")
+		weekly[weekKey] = append(weekly[weekKey], adjustment)
+	}
+	if len(weekly) == 0 {
+		return summaries
+	}
+
+	for i := range summaries {
+		if weekAdjustments, ok := weekly[summaries[i].WeekStart]; ok {
+			summaries[i] = applyWeekAdjustments(summaries[i], weekAdjustments, ratePerMile, roundingMode)
+			delete(weekly, summaries[i].WeekStart)
+		}
+	}
+	for weekKey, weekAdjustments := range weekly {
+		weekStart, _ := time.Parse("2006-01-02", weekKey)
+		summary := WeeklySummary{
+			WeekStart:          weekKey,
+			WeekEnd:            weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+			ExpensesByCategory: map[string]float64{},
+		}
+		summaries = append(summaries, applyWeekAdjustments(summary, weekAdjustments, ratePerMile, roundingMode))
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].WeekStart > summaries[j].WeekStart
+	})
+	return summaries
+}
+
+// applyWeekAdjustments recalculates one week's totals with its adjustments included
+func applyWeekAdjustments(summary WeeklySummary, adjustments []Adjustment, ratePerMile float64, roundingMode string) WeeklySummary {
+	sort.SliceStable(adjustments, func(i, j int) bool {
+		return adjustments[i].Date > adjustments[j].Date
+	})
+	adjustmentMiles := CalculateAdjustmentMiles(adjustments)
+	tripMiles := CalculateTotalMiles(summary.Trips)
+	if tripMiles+adjustmentMiles < 0 {
+		adjustmentMiles = 0 - tripMiles // not -tripMiles, which is -0 for a week without trips
+	}
+	summary.AdjustmentMiles = adjustmentMiles
+	summary.Adjustments = adjustments
+	summary.TotalMiles = tripMiles + adjustmentMiles
+	adjustmentAmount := adjustmentMiles * ratePerMile
+	summary.TotalAmount = RoundAmount(CalculateReimbursement(summary.Trips, ratePerMile)+adjustmentAmount, roundingMode)
+	summary.PerPassengerAmount = RoundAmount(CalculateReimbursementPerPassenger(summary.Trips, ratePerMile)+adjustmentAmount, roundingMode)
+	if summary.PerPassengerAmount < 0 {
+		summary.PerPassengerAmount = 0
+	}
+	return summary
+}
+
+// WeekIndexContaining returns the index of the summary whose week contains date, or -1
+// when no summary covers that week
+func WeekIndexContaining(summaries []WeeklySummary, date time.Time) int {
+	day := date.Format("2006-01-02")
+	for i, summary := range summaries {
+		if summary.WeekStart <= day && day <= summary.WeekEnd {
+			return i
+		}
+	}
+	return -1
+}
+
+// SummaryByWeekStart returns the summary of the week starting on weekStart (YYYY-MM-DD),
+// reporting false when nothing was recorded that week
+func SummaryByWeekStart(summaries []WeeklySummary, weekStart string) (WeeklySummary, bool) {
+	for _, summary := range summaries {
+		if summary.WeekStart == weekStart {
+			return summary, true
+		}
+	}
+	return WeeklySummary{}, false
+}
+
+// SummaryForWeek returns the summary of the week containing date. Weeks with no trips or
+// expenses get a zeroed summary spanning that week, starting on weekStartDay.
+func SummaryForWeek(summaries []WeeklySummary, date time.Time, weekStartDay time.Weekday) WeeklySummary {
+	if i := WeekIndexContaining(summaries, date); i >= 0 {
+		return summaries[i]
+	}
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	weekStart := WeekStartOf(day, weekStartDay)
+	return WeeklySummary{
+		WeekStart: weekStart.Format("2006-01-02"),
+		WeekEnd:   weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+	}
+}
+
+// SummaryDelta is the change in a week's totals compared with an earlier week
+type SummaryDelta struct {
+	Miles    float64
+	Amount   float64
+	Expenses float64 // Reimbursable expenses only
+}
+
+// CompareTo returns how the summary's totals changed since prev; positive values
+// mean this week was higher
+func (s WeeklySummary) CompareTo(prev WeeklySummary) SummaryDelta {
+	return SummaryDelta{
+		Miles:    s.TotalMiles - prev.TotalMiles,
+		Amount:   s.TotalAmount - prev.TotalAmount,
+		Expenses: s.TotalExpenses - prev.TotalExpenses,
+	}
+}
+
+// ExpensesByTrip splits the week's expenses into those linked to one of its trips,
+// keyed by the trip's index in Trips, and the rest
+func (s WeeklySummary) ExpensesByTrip() (map[int][]Expense, []Expense) {
+	byTrip := make(map[int][]Expense)
+	var unlinked []Expense
+	for _, expense := range s.Expenses {
+		if i, ok := expense.LinkedTrip(s.Trips); ok {
+			byTrip[i] = append(byTrip[i], expense)
+			continue
+		}
+		unlinked = append(unlinked, expense)
+	}
+	return byTrip, unlinked
+}
+
+// ExpensesWithoutMiles reports whether expenses were logged in a week with no miles
+// driven, which usually means its trips were never entered
+func (s WeeklySummary) ExpensesWithoutMiles() bool {
+	return s.HasExpenses && s.TotalMiles == 0
+}
+
+// TargetPercent returns the week's miles as a percentage of a weekly mileage target,
+// which may exceed 100; it is zero when no target is set
+func (s WeeklySummary) TargetPercent(target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return s.TotalMiles / target * 100
+}
+
+// PreviousWeek returns the summary of the calendar week before summaries[i], reporting
+// false when nothing was recorded that week
+func PreviousWeek(summaries []WeeklySummary, i int) (WeeklySummary, bool) {
+	if i < 0 || i >= len(summaries) {
+		return WeeklySummary{}, false
+	}
+	weekStart, err := time.Parse("2006-01-02", summaries[i].WeekStart)
+	if err != nil {
+		return WeeklySummary{}, false
+	}
+	prev := WeekIndexContaining(summaries, weekStart.AddDate(0, 0, -7))
+	if prev < 0 {
+		return WeeklySummary{}, false
+	}
+	return summaries[prev], true
+}
+
+// StorageData represents the complete data structure stored in the JSON file
+type StorageData struct {
+	Trips           []Trip          `json:"trips"`
+	RecurringTrips  []RecurringTrip `json:"recurring_trips"`
+	Expenses        []Expense       `json:"expenses"`
+	WeeklySummaries []WeeklySummary `json:"weekly_summaries"`
+	TripTemplates   []TripTemplate  `json:"trip_templates"`
+	Adjustments     []Adjustment    `json:"adjustments"`
+	ReferenceDate   string          `json:"reference_date,omitempty"` // For testing purposes
+	UpdatedAt       time.Time       `json:"updated_at"`               // Set by storage on every save
+	SchemaVersion   int             `json:"schema_version"`           // Layout version, upgraded by storage.Migrate
+}
+
+// Clone returns a copy of the storage data that shares no slices with the original
+func (d *StorageData) Clone() *StorageData {
+	clone := *d
+	clone.Trips = append([]Trip(nil), d.Trips...)
+	for i := range clone.Trips {
+		clone.Trips[i].Tags = append([]string(nil), d.Trips[i].Tags...)
+	}
+	clone.RecurringTrips = append([]RecurringTrip(nil), d.RecurringTrips...)
+	for i := range clone.RecurringTrips {
+		clone.RecurringTrips[i].ExcludedDates = append([]string(nil), d.RecurringTrips[i].ExcludedDates...)
+	}
+	clone.Expenses = append([]Expense(nil), d.Expenses...)
+	clone.WeeklySummaries = append([]WeeklySummary(nil), d.WeeklySummaries...)
+	clone.TripTemplates = append([]TripTemplate(nil), d.TripTemplates...)
+	clone.Adjustments = append([]Adjustment(nil), d.Adjustments...)
+	return &clone
+}
+
+// Validate checks every trip, recurring trip, expense, and template in the storage data
+func (d *StorageData) Validate() error {
+	for i, trip := range d.Trips {
+		if err := trip.Validate(); err != nil {
+			return fmt.Errorf("trip %d: %w", i, err)
+		}
+	}
+	for i, trip := range d.RecurringTrips {
+		if err := trip.Validate(); err != nil {
+			return fmt.Errorf("recurring trip %d: %w", i, err)
+		}
+	}
+	for i, expense := range d.Expenses {
+		if err := expense.Validate(); err != nil {
+			return fmt.Errorf("expense %d: %w", i, err)
+		}
+	}
+	for i, template := range d.TripTemplates {
+		if err := template.Validate(); err != nil {
+			return fmt.Errorf("template %d: %w", i, err)
+		}
+	}
+	for i, adjustment := range d.Adjustments {
+		if err := adjustment.Validate(); err != nil {
+			return fmt.Errorf("adjustment %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// CalculateAndUpdateWeeklySummaries calculates weekly summaries and updates the storage
+// data. Trips and expenses keep their stored order, which API and CLI indexes refer to.
+func CalculateAndUpdateWeeklySummaries(data *StorageData, ratePerMile float64, roundingMode string, weekStartDay time.Weekday) {
+	data.WeeklySummaries = CalculateWeeklySummaries(
+		append([]Trip(nil), data.Trips...),
+		append([]Expense(nil), data.Expenses...),
+		ratePerMile, roundingMode, weekStartDay)
+	data.WeeklySummaries = ApplyAdjustments(data.WeeklySummaries, data.Adjustments, ratePerMile, roundingMode, weekStartDay)
+}
+
+// EditTrip updates a trip at the specified index
+func (d *StorageData) EditTrip(index int, newTrip Trip) error {
+	if index < 0 || index >= len(d.Trips) {
+		return errors.New("invalid trip index")
+	}
+	newTrip.Normalize()
+	if err := newTrip.Validate(); err != nil {
+		return err
+	}
+	// An edited generated trip still came from its recurring pattern, and keeps the ID
+	// its expenses are linked by
+	if d.Trips[index].IsRecurring {
+		newTrip.IsRecurring = true
+	}
+	newTrip.ID = d.Trips[index].ID
+	d.Trips[index] = newTrip
+	return nil
+}
+
+// TripEditErrors reports every trip a batch edit rejected, keyed by trip index
+type TripEditErrors map[int]error
+
+// Error lists the rejected trips in index order
+func (e TripEditErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for index := range e {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	messages := make([]string, len(indexes))
+	for i, index := range indexes {
+		messages[i] = fmt.Sprintf("trip %d: %v", index, e[index])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// EditTrips replaces several trips at once, keyed by index. Every update is checked
+// first; if any is rejected nothing changes and TripEditErrors lists each problem.
+func (d *StorageData) EditTrips(updates map[int]Trip) error {
+	errs := make(TripEditErrors)
+	for index, trip := range updates {
+		if index < 0 || index >= len(d.Trips) {
+			errs[index] = errors.New("invalid trip index")
+		} else if err := trip.Validate(); err != nil {
+			errs[index] = err
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	for index, trip := range updates {
+		if err := d.EditTrip(index, trip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTrip removes a trip at the specified index
+func (d *StorageData) DeleteTrip(index int) error {
+	if index < 0 || index >= len(d.Trips) {
+		return errors.New("invalid trip index")
+	}
+	d.unlinkExpenses(d.Trips[index])
+	d.Trips = append(d.Trips[:index], d.Trips[index+1:]...)
+	return nil
+}
+
+// unlinkExpenses makes the expenses linked to trip stand alone, for when it is deleted
+func (d *StorageData) unlinkExpenses(trip Trip) {
+	d.relinkExpenses(trip, "")
+}
+
+// relinkExpenses moves the expenses linked to trip to the trip with ID to, or unlinks
+// them when to is empty
+func (d *StorageData) relinkExpenses(trip Trip, to string) {
+	if trip.ID == "" {
+		return
+	}
+	for i := range d.Expenses {
+		if d.Expenses[i].TripID == trip.ID {
+			d.Expenses[i].TripID = to
+		}
+	}
+}
+
+// ArchiveTrip hides the trip at index from lists and summaries without deleting it
+func (d *StorageData) ArchiveTrip(index int) error {
+	return d.setTripArchived(index, true)
+}
+
+// UnarchiveTrip restores an archived trip to lists and summaries
+func (d *StorageData) UnarchiveTrip(index int) error {
+	return d.setTripArchived(index, false)
+}
+
+func (d *StorageData) setTripArchived(index int, archived bool) error {
+	if index < 0 || index >= len(d.Trips) {
+		return errors.New("invalid trip index")
+	}
+	d.Trips[index].Archived = archived
+	return nil
+}
+
+// FindDuplicateTrips groups the indexes of trips that share a date, origin, destination,
+// type and family, such as a converted trip and the recurring instance generated on its
+// date. Only groups of two or more are returned, ordered by their first trip.
+func (d *StorageData) FindDuplicateTrips() [][]int {
+	type tripKey struct {
+		date, origin, destination, tripType, family string
+	}
+	groups := make(map[tripKey][]int)
+	var order []tripKey
+	for i, trip := range d.Trips {
+		key := tripKey{trip.Date, NormalizeAddress(trip.Origin), NormalizeAddress(trip.Destination), trip.Type, trip.FamilyOrDefault()}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	var duplicates [][]int
+	for _, key := range order {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
+}
+
+// MergeDuplicates keeps the first trip of each group found by FindDuplicateTrips,
+// removes the others and returns how many were removed. Expenses linked to a removed
+// trip move to the kept one.
+func (d *StorageData) MergeDuplicates() int {
+	remove := make(map[int]bool)
+	for _, group := range d.FindDuplicateTrips() {
+		for _, index := range group[1:] {
+			remove[index] = true
+			if d.Trips[index].ID != "" {
+				if d.Trips[group[0]].ID == "" {
+					d.Trips[group[0]].ID = newTripID()
+				}
+				d.relinkExpenses(d.Trips[index], d.Trips[group[0]].ID)
+			}
+		}
+	}
+	if len(remove) == 0 {
+		return 0
+	}
+
+	kept := make([]Trip, 0, len(d.Trips)-len(remove))
+	for i, trip := range d.Trips {
+		if !remove[i] {
+			kept = append(kept, trip)
+		}
+	}
+	d.Trips = kept
+	return len(remove)
+}
+
+// FindDuplicateExpenses groups the indexes of expenses that share a date, amount,
+// description and family, such as a receipt entered twice. Only groups of two or more
+// are returned, ordered by their first expense.
+func (d *StorageData) FindDuplicateExpenses() [][]int {
+	type expenseKey struct {
+		date        string
+		cents       int64
+		description string
+		family      string
+	}
+	groups := make(map[expenseKey][]int)
+	var order []expenseKey
+	for i, expense := range d.Expenses {
+		// Compare whole cents so amounts that print the same always match
+		key := expenseKey{expense.Date, int64(math.Round(expense.Amount * 100)), expense.Description, expense.FamilyOrDefault()}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
 
-	return summaries
+	var duplicates [][]int
+	for _, key := range order {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
 }
 
-// StorageData represents the complete data structure stored in the JSON file
-type StorageData struct {
-	Trips           []Trip          `json:"trips"`
-	RecurringTrips  []RecurringTrip `json:"recurring_trips"`
-	Expenses        []Expense       `json:"expenses"`
-	WeeklySummaries []WeeklySummary `json:"weekly_summaries"`
-	TripTemplates   []TripTemplate  `json:"trip_templates"`
-	ReferenceDate   string          `json:"reference_date,omitempty"` // For testing purposes
-}
+// MergeDuplicateExpenses keeps the first expense of each group found by
+// FindDuplicateExpenses, removes the others and returns how many were removed
+func (d *StorageData) MergeDuplicateExpenses() int {
+	remove := make(map[int]bool)
+	for _, group := range d.FindDuplicateExpenses() {
+		for _, index := range group[1:] {
+			remove[index] = true
+		}
+	}
+	if len(remove) == 0 {
+		return 0
+	}
 
-// CalculateAndUpdateWeeklySummaries calculates weekly summaries and updates the storage data
-func CalculateAndUpdateWeeklySummaries(data *StorageData, ratePerMile float64) {
-	data.WeeklySummaries = CalculateWeeklySummaries(data.Trips, data.Expenses, ratePerMile)
+	kept := make([]Expense, 0, len(d.Expenses)-len(remove))
+	for i, expense := range d.Expenses {
+		if !remove[i] {
+			kept = append(kept, expense)
+		}
+	}
+	d.Expenses = kept
+	return len(remove)
 }
 
-// EditTrip updates a trip at the specified index
-func (d *StorageData) EditTrip(index int, newTrip Trip) error {
-	if index < 0 || index >= len(d.Trips) {
-		return errors.New("invalid trip index")
+// TopRoutes returns up to n distinct origin/destination pairs, most frequently
+// travelled first. Routes taken equally often are ordered by their latest trip, most
+// recent first. A non-positive n returns every route.
+func (d *StorageData) TopRoutes(n int) []maps.Route {
+	counts := make(map[maps.Route]int)
+	latest := make(map[maps.Route]string)
+	var routes []maps.Route
+	for _, trip := range d.Trips {
+		// Trips saved before addresses were normalized still count towards their route
+		route := maps.Route{Origin: NormalizeAddress(trip.Origin), Destination: NormalizeAddress(trip.Destination)}
+		if _, ok := counts[route]; !ok {
+			routes = append(routes, route)
+		}
+		counts[route]++
+		if trip.Date > latest[route] {
+			latest[route] = trip.Date
+		}
 	}
-	if err := newTrip.Validate(); err != nil {
-		return err
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if counts[routes[i]] != counts[routes[j]] {
+			return counts[routes[i]] > counts[routes[j]]
+		}
+		return latest[routes[i]] > latest[routes[j]]
+	})
+	if n > 0 && len(routes) > n {
+		routes = routes[:n]
 	}
-	d.Trips[index] = newTrip
-	return nil
+	return routes
 }
 
-// DeleteTrip removes a trip at the specified index
-func (d *StorageData) DeleteTrip(index int) error {
-	if index < 0 || index >= len(d.Trips) {
-		return errors.New("invalid trip index")
+// DeleteTripsInRange removes all trips dated within the inclusive range and returns how many were removed
+func (d *StorageData) DeleteTripsInRange(from, to string) (int, error) {
+	if err := ValidateDate(from); err != nil {
+		return 0, err
 	}
-	d.Trips = append(d.Trips[:index], d.Trips[index+1:]...)
-	return nil
+	if err := ValidateDate(to); err != nil {
+		return 0, err
+	}
+	if to < from {
+		return 0, errors.New("end of range must not be before start of range")
+	}
+
+	kept := make([]Trip, 0, len(d.Trips))
+	for _, trip := range d.Trips {
+		if trip.Date < from || trip.Date > to {
+			kept = append(kept, trip)
+		} else {
+			d.unlinkExpenses(trip)
+		}
+	}
+	removed := len(d.Trips) - len(kept)
+	d.Trips = kept
+	return removed, nil
 }
 
 // AddExpense adds a new expense to the storage data
@@ -347,54 +1555,237 @@ func (d *StorageData) DeleteExpense(index int) error {
 	return nil
 }
 
+// AddAdjustment adds a mileage adjustment to the storage data. The adjustment is rejected
+// if it would leave its family's miles for that week below zero.
+func (d *StorageData) AddAdjustment(adjustment Adjustment, weekStartDay time.Weekday) error {
+	if err := adjustment.Validate(); err != nil {
+		return err
+	}
+	adjustments := append(append([]Adjustment(nil), d.Adjustments...), adjustment)
+	if err := d.checkWeekMiles(adjustments, adjustment, weekStartDay); err != nil {
+		return err
+	}
+	d.Adjustments = adjustments
+	return nil
+}
+
+// DeleteAdjustment removes the adjustment at the specified index. Removing a positive
+// adjustment is rejected if the week's remaining corrections would take its miles below zero.
+func (d *StorageData) DeleteAdjustment(index int, weekStartDay time.Weekday) error {
+	if index < 0 || index >= len(d.Adjustments) {
+		return errors.New("invalid adjustment index")
+	}
+	removed := d.Adjustments[index]
+	adjustments := append(append([]Adjustment(nil), d.Adjustments[:index]...), d.Adjustments[index+1:]...)
+	if err := d.checkWeekMiles(adjustments, removed, weekStartDay); err != nil {
+		return err
+	}
+	d.Adjustments = adjustments
+	return nil
+}
+
+// checkWeekMiles returns a validation error if the trips and adjustments in the week and
+// family of changed would total fewer than zero miles
+func (d *StorageData) checkWeekMiles(adjustments []Adjustment, changed Adjustment, weekStartDay time.Weekday) error {
+	date, err := time.Parse("2006-01-02", changed.Date)
+	if err != nil {
+		return invalid("date", "date must be in YYYY-MM-DD format")
+	}
+	weekStart := WeekStartOf(date, weekStartDay)
+	inWeek := func(value string) bool {
+		t, err := time.Parse("2006-01-02", value)
+		return err == nil && WeekStartOf(t, weekStartDay).Equal(weekStart)
+	}
+	family := changed.FamilyOrDefault()
+
+	var miles float64
+	for _, trip := range d.Trips {
+		if !trip.Archived && trip.FamilyOrDefault() == family && inWeek(trip.Date) {
+			miles += trip.EffectiveMiles()
+		}
+	}
+	for _, adjustment := range adjustments {
+		if adjustment.FamilyOrDefault() == family && inWeek(adjustment.Date) {
+			miles += adjustment.Miles
+		}
+	}
+	if miles < 0 {
+		return invalidf("miles", "adjustment would leave the week of %s with %.2f miles; miles cannot go below zero", weekStart.Format("2006-01-02"), miles)
+	}
+	return nil
+}
+
 // GenerateTrips generates individual trips from a recurring trip for a given date range
 func (rt RecurringTrip) GenerateTrips(startDate, endDate time.Time) []Trip {
 	var trips []Trip
-	current := startDate
+	for _, date := range rt.occurrences(startDate, endDate) {
+		trips = append(trips, Trip{
+			Origin:      rt.Origin,
+			Destination: rt.Destination,
+			Miles:       rt.Miles,
+			Date:        date.Format("2006-01-02"),
+			Type:        rt.Type,
+			Family:      rt.Family,
+			IsRecurring: true,
+		})
+	}
+	return trips
+}
+
+// NextOccurrence returns the first date on or after the given day on which the recurring
+// trip generates a trip. It returns false once the trip has passed its end date.
+func (rt RecurringTrip) NextOccurrence(after time.Time) (string, bool) {
+	startDate, err := time.Parse("2006-01-02", rt.StartDate)
+	if err != nil {
+		return "", false
+	}
+	from := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Any schedule fires within two months once past the last excluded date
+	horizon := from
+	if startDate.After(horizon) {
+		horizon = startDate
+	}
+	for _, date := range rt.ExcludedDates {
+		if excluded, err := time.Parse("2006-01-02", date); err == nil && excluded.After(horizon) {
+			horizon = excluded
+		}
+	}
+	horizon = horizon.AddDate(0, 2, 0)
+	if rt.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", rt.EndDate)
+		if err != nil {
+			return "", false
+		}
+		if endDate.Before(horizon) {
+			horizon = endDate
+		}
+	}
+
+	// Walk from the start date so biweekly trips keep their alignment
+	for _, date := range rt.occurrences(startDate, horizon) {
+		if !date.Before(from) {
+			return date.Format("2006-01-02"), true
+		}
+	}
+	return "", false
+}
+
+// occurrences returns the dates between startDate and endDate (inclusive) on which the
+// recurring trip occurs, leaving out its excluded dates and any past its Occurrences limit
+func (rt RecurringTrip) occurrences(startDate, endDate time.Time) []time.Time {
+	if rt.Occurrences > 0 {
+		if last, ok := rt.lastCountedOccurrence(); ok && last.Before(endDate) {
+			endDate = last
+		}
+	}
+	return rt.allowedDates(startDate, endDate)
+}
+
+// lastCountedOccurrence returns the date of the final trip allowed by Occurrences,
+// counting from the start date
+func (rt RecurringTrip) lastCountedOccurrence() (time.Time, bool) {
+	startDate, err := time.Parse("2006-01-02", rt.StartDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// Every schedule fires at least monthly, and each excluded date delays it by at most a month
+	horizon := startDate.AddDate(0, rt.Occurrences+len(rt.ExcludedDates)+1, 0)
+	dates := rt.allowedDates(startDate, horizon)
+	if len(dates) < rt.Occurrences {
+		return time.Time{}, false
+	}
+	return dates[rt.Occurrences-1], true
+}
+
+// allowedDates returns the scheduled dates between startDate and endDate (inclusive),
+// leaving out the recurring trip's excluded dates
+func (rt RecurringTrip) allowedDates(startDate, endDate time.Time) []time.Time {
+	if len(rt.ExcludedDates) == 0 {
+		return rt.scheduledDates(startDate, endDate)
+	}
+
+	excluded := make(map[string]bool, len(rt.ExcludedDates))
+	for _, date := range rt.ExcludedDates {
+		excluded[date] = true
+	}
+	var dates []time.Time
+	for _, date := range rt.scheduledDates(startDate, endDate) {
+		if !excluded[date.Format("2006-01-02")] {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
+
+// scheduledDates returns every date between startDate and endDate (inclusive) that
+// matches the recurring trip's frequency
+func (rt RecurringTrip) scheduledDates(startDate, endDate time.Time) []time.Time {
+	var dates []time.Time
+
+	if rt.Frequency == "monthly" {
+		// Walk month by month, clamping the day to the last day of shorter months
+		year, month, _ := startDate.Date()
+		for {
+			lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, startDate.Location()).Day()
+			day := rt.DayOfMonth
+			if day > lastDay {
+				day = lastDay
+			}
+			current := time.Date(year, month, day, 0, 0, 0, 0, startDate.Location())
+			if current.After(endDate) {
+				break
+			}
+			if !current.Before(startDate) {
+				dates = append(dates, current)
+			}
+			month++
+			if month > time.December {
+				month = time.January
+				year++
+			}
+		}
+		return dates
+	}
+
+	interval := 7
+	if rt.Frequency == "biweekly" {
+		interval = 14
+	}
 
 	// If the start date is not the target weekday, find the next occurrence
+	current := startDate
 	if current.Weekday() != time.Weekday(rt.Weekday) {
 		daysUntilNext := (rt.Weekday - int(current.Weekday()) + 7) % 7
 		current = current.AddDate(0, 0, daysUntilNext)
 	}
 
-	// Generate trips for each occurrence until end date
+	// Generate an occurrence for each interval until end date
 	for !current.After(endDate) {
-		trip := Trip{
-			Origin:      rt.Origin,
-			Destination: rt.Destination,
-			Miles:       rt.Miles,
-			Date:        current.Format("2006-01-02"),
-			Type:        rt.Type,
-		}
-		trips = append(trips, trip)
-		current = current.AddDate(0, 0, 7) // Add one week
+		dates = append(dates, current)
+		current = current.AddDate(0, 0, interval)
 	}
 
-	return trips
+	return dates
+}
+
+// today returns ReferenceDate when it is set, and the current time otherwise
+func (d *StorageData) today() (time.Time, error) {
+	if d.ReferenceDate != "" {
+		return time.Parse("2006-01-02", d.ReferenceDate)
+	}
+	return time.Now(), nil
 }
 
 // GenerateTripsFromRecurring generates individual trips from all recurring trips
 func (d *StorageData) GenerateTripsFromRecurring() error {
 	// Get current date and end of current month
-	var now time.Time
-	var err error
-	if d.ReferenceDate != "" {
-		now, err = time.Parse("2006-01-02", d.ReferenceDate)
-		if err != nil {
-			return err
-		}
-	} else {
-		now = time.Now()
+	now, err := d.today()
+	if err != nil {
+		return err
 	}
 	endOfMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location())
 
-	// Create a map to track existing trip dates
-	existingDates := make(map[string]bool)
-	for _, trip := range d.Trips {
-		existingDates[trip.Date] = true
-	}
-
 	// Generate trips for each recurring trip
 	for _, rt := range d.RecurringTrips {
 		startDate, err := time.Parse("2006-01-02", rt.StartDate)
@@ -417,16 +1808,161 @@ func (d *StorageData) GenerateTripsFromRecurring() error {
 		// Generate trips and add them to the storage
 		trips := rt.GenerateTrips(startDate, endDate)
 		for _, trip := range trips {
-			// Only add the trip if it doesn't already exist for that date
-			if !existingDates[trip.Date] {
-				if err := d.AddTrip(trip); err != nil {
-					return err
-				}
-				existingDates[trip.Date] = true
+			// Skip dates that already have this trip, including ones added in this pass;
+			// other trips on the same day, such as another family's, don't count
+			if d.hasMatchingTrip(rt, trip.Date) {
+				continue
+			}
+			if err := d.AddTrip(trip); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ProjectRecurringTrips returns the trips the recurring trips are scheduled to make after
+// today, up to and including until, marked Projected. Dates that already have a matching
+// trip, such as one generated earlier, are skipped, as is anything past a recurring
+// trip's end date or Occurrences limit.
+func (d *StorageData) ProjectRecurringTrips(until string) ([]Trip, error) {
+	if err := ValidateDate(until); err != nil {
+		return nil, invalid("until", err.Error())
+	}
+	untilDate, _ := time.Parse("2006-01-02", until)
+	now, err := d.today()
+	if err != nil {
+		return nil, err
+	}
+	today := now.Format("2006-01-02")
+
+	var projected []Trip
+	for _, rt := range d.RecurringTrips {
+		startDate, err := time.Parse("2006-01-02", rt.StartDate)
+		if err != nil {
+			return nil, err
+		}
+		endDate := untilDate
+		if rt.EndDate != "" && rt.EndDate < until {
+			if endDate, err = time.Parse("2006-01-02", rt.EndDate); err != nil {
+				return nil, err
+			}
+		}
+
+		// Walk from the start date so biweekly trips keep their alignment
+		for _, trip := range rt.GenerateTrips(startDate, endDate) {
+			if trip.Date <= today || d.hasMatchingTrip(rt, trip.Date) {
+				continue
+			}
+			trip.Projected = true
+			projected = append(projected, trip)
+		}
+	}
+	return projected, nil
+}
+
+// CalculateWeeklySummariesWithProjections returns the weekly summaries of data's trips,
+// expenses and adjustments with the trips from ProjectRecurringTrips added, so upcoming
+// weeks forecast their reimbursement. Projected trips stay marked in each summary's
+// Trips and counted in its ProjectedMiles; data itself is left unchanged.
+func CalculateWeeklySummariesWithProjections(data *StorageData, rate float64, roundingMode string, weekStartDay time.Weekday, until string) ([]WeeklySummary, error) {
+	projected, err := data.ProjectRecurringTrips(until)
+	if err != nil {
+		return nil, err
+	}
+
+	// Summaries sort their inputs in place, so work on copies
+	trips := append(append([]Trip(nil), data.Trips...), projected...)
+	expenses := append([]Expense(nil), data.Expenses...)
+	summaries := CalculateWeeklySummaries(trips, expenses, rate, roundingMode, weekStartDay)
+	return ApplyAdjustments(summaries, data.Adjustments, rate, roundingMode, weekStartDay), nil
+}
+
+// ExtendRecurringTrips moves the end date of every active recurring trip out to
+// newEndDate and adds the trips scheduled after its old end date, returning how many
+// were created. A recurring trip is active when it has an end date before newEndDate
+// and hasn't already generated all of its Occurrences.
+func (d *StorageData) ExtendRecurringTrips(newEndDate string) (int, error) {
+	if err := ValidateDate(newEndDate); err != nil {
+		return 0, invalid("end_date", err.Error())
+	}
+	newEnd, _ := time.Parse("2006-01-02", newEndDate)
+
+	created := 0
+	for i := range d.RecurringTrips {
+		rt := &d.RecurringTrips[i]
+		if rt.EndDate == "" || rt.EndDate >= newEndDate {
+			continue
+		}
+		oldEndDate := rt.EndDate
+		if rt.Occurrences > 0 {
+			if last, ok := rt.lastCountedOccurrence(); ok && last.Format("2006-01-02") <= oldEndDate {
+				continue
+			}
+		}
+		startDate, err := time.Parse("2006-01-02", rt.StartDate)
+		if err != nil {
+			return created, err
+		}
+
+		rt.EndDate = newEndDate
+		// Walk from the start date so biweekly trips keep their alignment
+		for _, trip := range rt.GenerateTrips(startDate, newEnd) {
+			if trip.Date <= oldEndDate || d.hasMatchingTrip(*rt, trip.Date) {
+				continue
+			}
+			if err := d.AddTrip(trip); err != nil {
+				return created, err
 			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// hasMatchingTrip reports whether a trip generated from rt already exists on date
+func (d *StorageData) hasMatchingTrip(rt RecurringTrip, date string) bool {
+	for _, trip := range d.Trips {
+		if trip.Date == date && rt.Matches(trip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecalculateTripMiles looks up the distances of the trips at indexes again, including
+// return legs that head somewhere else, and replaces their stored miles. Each distinct
+// route is looked up once. Custom trips keep their entered miles.
+func (d *StorageData) RecalculateTripMiles(ctx context.Context, calc maps.DistanceCalculator, indexes []int) error {
+	var routes []maps.Route
+	var legs []*float64
+	for _, index := range indexes {
+		if index < 0 || index >= len(d.Trips) {
+			return errors.New("invalid trip index")
+		}
+		trip := &d.Trips[index]
+		if trip.IsCustom() {
+			continue
+		}
+		routes = append(routes, maps.Route{Origin: trip.Origin, Destination: trip.Destination})
+		legs = append(legs, &trip.Miles)
+		if trip.ReturnDestination != "" {
+			routes = append(routes, maps.Route{Origin: trip.Destination, Destination: trip.ReturnDestination})
+			legs = append(legs, &trip.ReturnMiles)
 		}
 	}
+	if len(routes) == 0 {
+		return nil
+	}
 
+	distances, err := maps.CalculateDistanceBatch(ctx, calc, routes)
+	if err != nil {
+		return fmt.Errorf("failed to calculate distance: %w", err)
+	}
+	for i, miles := range legs {
+		*miles = distances[i]
+	}
 	return nil
 }
 
@@ -451,6 +1987,62 @@ func (d *StorageData) EditRecurringTrip(index int, newTrip RecurringTrip) error
 	return nil
 }
 
+// Matches reports whether trip looks like one generated from the recurring trip:
+// the same route, type, miles and family on one of its scheduled dates
+func (rt RecurringTrip) Matches(trip Trip) bool {
+	if NormalizeAddress(trip.Origin) != NormalizeAddress(rt.Origin) || NormalizeAddress(trip.Destination) != NormalizeAddress(rt.Destination) ||
+		trip.Type != rt.Type || trip.Miles != rt.Miles || trip.Family != rt.Family {
+		return false
+	}
+	date, err := time.Parse("2006-01-02", trip.Date)
+	if err != nil {
+		return false
+	}
+	startDate, err := time.Parse("2006-01-02", rt.StartDate)
+	if err != nil || date.Before(startDate) {
+		return false
+	}
+	if rt.EndDate != "" && trip.Date > rt.EndDate {
+		return false
+	}
+	dates := rt.occurrences(startDate, date)
+	return len(dates) > 0 && dates[len(dates)-1].Equal(date)
+}
+
+// CountGeneratedTrips returns how many trips were generated from the recurring trip at the specified index
+func (d *StorageData) CountGeneratedTrips(index int) (int, error) {
+	if index < 0 || index >= len(d.RecurringTrips) {
+		return 0, errors.New("invalid recurring trip index")
+	}
+	count := 0
+	for _, trip := range d.Trips {
+		if d.RecurringTrips[index].Matches(trip) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// DeleteGeneratedTrips removes the trips generated from the recurring trip at the
+// specified index and returns how many were removed
+func (d *StorageData) DeleteGeneratedTrips(index int) (int, error) {
+	if index < 0 || index >= len(d.RecurringTrips) {
+		return 0, errors.New("invalid recurring trip index")
+	}
+	rt := d.RecurringTrips[index]
+	kept := make([]Trip, 0, len(d.Trips))
+	for _, trip := range d.Trips {
+		if !rt.Matches(trip) {
+			kept = append(kept, trip)
+		} else {
+			d.unlinkExpenses(trip)
+		}
+	}
+	removed := len(d.Trips) - len(kept)
+	d.Trips = kept
+	return removed, nil
+}
+
 // DeleteRecurringTrip removes a recurring trip at the specified index
 func (d *StorageData) DeleteRecurringTrip(index int) error {
 	if index < 0 || index >= len(d.RecurringTrips) {
@@ -460,8 +2052,9 @@ func (d *StorageData) DeleteRecurringTrip(index int) error {
 	return nil
 }
 
-// AddTrip adds a new trip to the storage data
+// AddTrip adds a new trip to the storage data, normalizing its addresses first
 func (d *StorageData) AddTrip(trip Trip) error {
+	trip.Normalize()
 	if err := trip.Validate(); err != nil {
 		return err
 	}
@@ -490,6 +2083,15 @@ func (d *StorageData) EditTripTemplate(index int, newTemplate TripTemplate) erro
 	return nil
 }
 
+// UseTripTemplate counts a use of the trip template at the specified index
+func (d *StorageData) UseTripTemplate(index int) error {
+	if index < 0 || index >= len(d.TripTemplates) {
+		return errors.New("invalid template index")
+	}
+	d.TripTemplates[index].UsageCount++
+	return nil
+}
+
 // DeleteTripTemplate removes a trip template at the specified index
 func (d *StorageData) DeleteTripTemplate(index int) error {
 	if index < 0 || index >= len(d.TripTemplates) {
@@ -515,3 +2117,71 @@ func ValidateDate(date string) error {
 	}
 	return nil
 }
+
+// Date formats for entering and displaying dates. Dates are always stored as YYYY-MM-DD.
+const (
+	DateFormatISO = "iso" // 2024-03-20
+	DateFormatUS  = "us"  // 03/20/2024
+	DateFormatEU  = "eu"  // 20/03/2024
+)
+
+// dateLayout holds how a date format is parsed, displayed and described to the user
+type dateLayout struct {
+	parse   string
+	display string
+	pattern string
+}
+
+var dateLayouts = map[string]dateLayout{
+	DateFormatISO: {parse: "2006-01-02", display: "2006-01-02", pattern: "YYYY-MM-DD"},
+	// Leading zeros are optional when typing, so 3/5/2024 works as well as 03/05/2024
+	DateFormatUS: {parse: "1/2/2006", display: "01/02/2006", pattern: "MM/DD/YYYY"},
+	DateFormatEU: {parse: "2/1/2006", display: "02/01/2006", pattern: "DD/MM/YYYY"},
+}
+
+// IsValidDateFormat reports whether format is a known date format
+func IsValidDateFormat(format string) bool {
+	_, ok := dateLayouts[format]
+	return ok
+}
+
+// layoutFor returns the layout of format, falling back to ISO for unknown formats
+func layoutFor(format string) dateLayout {
+	if layout, ok := dateLayouts[format]; ok {
+		return layout
+	}
+	return dateLayouts[DateFormatISO]
+}
+
+// DateFormatPattern describes format for prompts, such as MM/DD/YYYY
+func DateFormatPattern(format string) string {
+	return layoutFor(format).pattern
+}
+
+// ParseDate converts a date written in format to the canonical YYYY-MM-DD. The canonical
+// form itself is accepted in every format.
+func ParseDate(value, format string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", errors.New("date cannot be empty")
+	}
+	if parsed, err := time.Parse(layoutFor(format).parse, value); err == nil {
+		value = parsed.Format("2006-01-02")
+	} else if _, isoErr := time.Parse("2006-01-02", value); isoErr != nil {
+		return "", fmt.Errorf("date must be in %s format", DateFormatPattern(format))
+	}
+	if err := ValidateDate(value); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// FormatDate writes a canonical YYYY-MM-DD date in format. Anything that is not a
+// canonical date is returned unchanged.
+func FormatDate(date, format string) string {
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(layoutFor(format).display)
+}
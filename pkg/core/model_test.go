@@ -1,10 +1,17 @@
 package model
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/laurendc/nannytracker/pkg/core/maps"
 )
 
 func TestCalculateTotalMiles(t *testing.T) {
@@ -20,6 +27,39 @@ func TestCalculateTotalMiles(t *testing.T) {
 	}
 }
 
+func TestAverageMiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		trips       []Trip
+		wantPerTrip float64
+		wantPerDay  float64
+	}{
+		{name: "no trips"},
+		{
+			name: "several trips a day",
+			trips: []Trip{
+				{Origin: "A", Destination: "B", Miles: 10.0, Date: "2024-03-20", Type: "round"},
+				{Origin: "B", Destination: "C", Miles: 4.0, Date: "2024-03-20", Type: "single"},
+				{Origin: "C", Destination: "D", Miles: 6.0, Date: "2024-03-21", Type: "single"},
+			},
+			// Round trips count both ways: 30 miles over 3 trips and 2 days
+			wantPerTrip: 10.0,
+			wantPerDay:  15.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AverageMilesPerTrip(tt.trips); got != tt.wantPerTrip {
+				t.Errorf("AverageMilesPerTrip() = %.2f, want %.2f", got, tt.wantPerTrip)
+			}
+			if got := AverageMilesPerActiveDay(tt.trips); got != tt.wantPerDay {
+				t.Errorf("AverageMilesPerActiveDay() = %.2f, want %.2f", got, tt.wantPerDay)
+			}
+		})
+	}
+}
+
 func TestCalculateReimbursement(t *testing.T) {
 	trips := []Trip{
 		{Origin: "A", Destination: "B", Miles: 10.0, Date: "2024-03-20"},
@@ -130,6 +170,160 @@ func TestTripValidation(t *testing.T) {
 	}
 }
 
+func TestValidationErrorFields(t *testing.T) {
+	validTrip := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"}
+	badTripDate := validTrip
+	badTripDate.Date = "03/20/2024"
+	badTripType := validTrip
+	badTripType.Type = "oneway"
+
+	validRecurring := RecurringTrip{StartDate: "2024-03-01", Origin: "Home", Destination: "Work", Miles: 10, Type: "single", Weekday: 1}
+	badEndDate := validRecurring
+	badEndDate.EndDate = "2024-02-01"
+
+	tests := []struct {
+		name      string
+		err       error
+		wantField string
+		wantMsg   string
+	}{
+		{"trip date", badTripDate.Validate(), "date", "date must be in YYYY-MM-DD format"},
+		{"trip type", badTripType.Validate(), "type", "trip type must be 'single', 'round' or 'custom'"},
+		{"trip far future", validTrip.ValidateWithBounds(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 30), "date", "date 2024-03-20 is more than 30 days in the future"},
+		{"expense amount", Expense{Date: "2024-03-20", Description: "Lunch"}.Validate(), "amount", "amount must be greater than 0"},
+		{"recurring end date", badEndDate.Validate(), "end_date", "end date must be after start date"},
+		{"template name", (&TripTemplate{Origin: "Home", Destination: "Work", TripType: "single"}).Validate(), "name", "template name cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			if !errors.As(tt.err, &verr) {
+				t.Fatalf("Expected a ValidationError, got %v", tt.err)
+			}
+			if verr.Field != tt.wantField {
+				t.Errorf("Expected field %q, got %q", tt.wantField, verr.Field)
+			}
+			if tt.err.Error() != tt.wantMsg {
+				t.Errorf("Expected message %q, got %q", tt.wantMsg, tt.err.Error())
+			}
+		})
+	}
+
+	// Wrapped errors from StorageData still expose the field
+	data := &StorageData{Expenses: []Expense{{Date: "2024-03-20", Description: "Lunch"}}}
+	var verr *ValidationError
+	if err := data.Validate(); !errors.As(err, &verr) || verr.Field != "amount" {
+		t.Errorf("Expected wrapped ValidationError for amount, got %v", err)
+	}
+}
+
+func TestTripValidationErrorsListsEveryField(t *testing.T) {
+	trip := Trip{Date: "03/20/2024", Origin: "Home", Miles: 0, Type: "oneway", Passengers: -1, ReturnMiles: 2}
+	var fields []string
+	for _, verr := range trip.ValidationErrors() {
+		fields = append(fields, verr.Field)
+	}
+	want := []string{"destination", "miles", "date", "type", "passengers", "return_miles"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Expected errors for %v, got %v", want, fields)
+	}
+
+	// Validate reports the first of them
+	var verr *ValidationError
+	if err := trip.Validate(); !errors.As(err, &verr) || verr.Field != "destination" {
+		t.Errorf("Expected the destination error first, got %v", err)
+	}
+
+	valid := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"}
+	if errs := valid.ValidationErrors(); errs != nil {
+		t.Errorf("Expected no errors for a valid trip, got %v", errs)
+	}
+}
+
+func TestTripTags(t *testing.T) {
+	if got := ParseTags(" field-trip, ,rainy-day,"); !reflect.DeepEqual(got, []string{"field-trip", "rainy-day"}) {
+		t.Errorf("Expected [field-trip rainy-day], got %v", got)
+	}
+	if got := ParseTags("  "); got != nil {
+		t.Errorf("Expected no tags for blank input, got %v", got)
+	}
+
+	trip := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 10, Type: "single", Tags: []string{"field-trip"}}
+	if err := trip.Validate(); err != nil {
+		t.Errorf("Expected tagged trip to be valid, got %v", err)
+	}
+	for _, tags := range [][]string{{""}, {"  "}, {" field-trip"}, {"rainy-day "}} {
+		trip.Tags = tags
+		var verr *ValidationError
+		if err := trip.Validate(); !errors.As(err, &verr) || verr.Field != "tags" {
+			t.Errorf("Expected tags validation error for %q, got %v", tags, err)
+		}
+	}
+
+	trips := []Trip{
+		{Date: "2024-03-20", Tags: []string{"Field-Trip", "rainy-day"}},
+		{Date: "2024-03-21", Tags: []string{"rainy-day"}},
+		{Date: "2024-03-22"},
+	}
+	if filtered := FilterTripsByTag(trips, "field-trip"); len(filtered) != 1 || filtered[0].Date != "2024-03-20" {
+		t.Errorf("Expected only the 2024-03-20 trip tagged field-trip, got %+v", filtered)
+	}
+	if filtered := FilterTripsByTag(trips, "rainy-day"); len(filtered) != 2 {
+		t.Errorf("Expected 2 trips tagged rainy-day, got %d", len(filtered))
+	}
+
+	// Clone must not share tag slices with the original
+	data := &StorageData{Trips: trips}
+	clone := data.Clone()
+	clone.Trips[0].Tags[0] = "changed"
+	if data.Trips[0].Tags[0] != "Field-Trip" {
+		t.Errorf("Expected original tags to be unchanged, got %v", data.Trips[0].Tags)
+	}
+}
+
+func TestTripPassengers(t *testing.T) {
+	trip := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 10, Type: "round"}
+	if got := trip.PassengersOrDefault(); got != 1 {
+		t.Errorf("Expected unset passengers to default to 1, got %d", got)
+	}
+	trip.Passengers = 2
+	if err := trip.Validate(); err != nil {
+		t.Errorf("Expected trip with 2 passengers to be valid, got %v", err)
+	}
+	trip.Passengers = -1
+	var verr *ValidationError
+	if err := trip.Validate(); !errors.As(err, &verr) || verr.Field != "passengers" {
+		t.Errorf("Expected passengers validation error, got %v", err)
+	}
+
+	// Data saved before passengers existed loads as one passenger per trip
+	var legacy Trip
+	if err := json.Unmarshal([]byte(`{"date":"2024-03-20","origin":"Home","destination":"Zoo","miles":10,"type":"single"}`), &legacy); err != nil {
+		t.Fatalf("Failed to decode legacy trip: %v", err)
+	}
+	if got := legacy.PassengersOrDefault(); got != 1 {
+		t.Errorf("Expected legacy trip to have 1 passenger, got %d", got)
+	}
+
+	trips := []Trip{
+		{Date: "2024-03-20", Miles: 10, Type: "round", Passengers: 2},
+		{Date: "2024-03-21", Miles: 5, Type: "single"},
+	}
+	if got := CalculateReimbursementPerPassenger(trips, 0.5); math.Abs(got-7.5) > 0.001 {
+		t.Errorf("Expected per-passenger reimbursement 7.50, got %.2f", got)
+	}
+	data := &StorageData{Trips: trips}
+	CalculateAndUpdateWeeklySummaries(data, 0.5, RoundingNone, time.Sunday)
+	if len(data.WeeklySummaries) != 1 {
+		t.Fatalf("Expected 1 weekly summary, got %d", len(data.WeeklySummaries))
+	}
+	summary := data.WeeklySummaries[0]
+	if summary.TotalAmount != 12.5 || summary.PerPassengerAmount != 7.5 {
+		t.Errorf("Expected total 12.50 and per passenger 7.50, got %.2f and %.2f", summary.TotalAmount, summary.PerPassengerAmount)
+	}
+}
+
 func TestDateValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -241,651 +435,2501 @@ func TestTripDateFiltering(t *testing.T) {
 	}
 }
 
-func TestCalculateWeeklySummaries(t *testing.T) {
+func TestRoundAmount(t *testing.T) {
 	tests := []struct {
-		name        string
-		trips       []Trip
-		expenses    []Expense
-		ratePerMile float64
-		want        []WeeklySummary
+		name   string
+		amount float64
+		mode   string
+		want   float64
 	}{
-		{
-			name:        "no trips or expenses",
-			trips:       []Trip{},
-			expenses:    []Expense{},
-			ratePerMile: 0.70,
-			want:        nil,
-		},
-		{
-			name: "single week with trips and expenses",
-			trips: []Trip{
-				{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
-				{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
-			},
-			expenses: []Expense{
-				{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-				{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
-			},
-			ratePerMile: 0.70,
-			want: []WeeklySummary{
-				{
-					WeekStart:     "2024-03-17", // Sunday of the week
-					WeekEnd:       "2024-03-23", // Saturday of the week
-					TotalMiles:    30.0,         // 10 + (10 * 2)
-					TotalAmount:   21.0,         // 30 miles * 0.70
-					TotalExpenses: 41.25,        // 25.50 + 15.75
-					Trips: []Trip{
-						{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
-						{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
-					},
-					Expenses: []Expense{
-						{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-						{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
-					},
-				},
-			},
-		},
-		{
-			name: "multiple weeks",
-			trips: []Trip{
-				{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
-				{Date: "2024-03-27", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
-			},
-			expenses: []Expense{
-				{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-				{Date: "2024-03-27", Amount: 15.75, Description: "Snacks"},
-			},
-			ratePerMile: 0.70,
-			want: []WeeklySummary{
-				{
-					WeekStart:     "2024-03-24", // Second week (most recent)
-					WeekEnd:       "2024-03-30",
-					TotalMiles:    20.0,  // 10 * 2 for round trip
-					TotalAmount:   14.0,  // 20 miles * 0.70
-					TotalExpenses: 15.75, // Second week expense
-					Trips: []Trip{
-						{Date: "2024-03-27", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
-					},
-					Expenses: []Expense{
-						{Date: "2024-03-27", Amount: 15.75, Description: "Snacks"},
-					},
-				},
-				{
-					WeekStart:     "2024-03-17", // First week
-					WeekEnd:       "2024-03-23",
-					TotalMiles:    10.0,
-					TotalAmount:   7.0,   // 10 miles * 0.70
-					TotalExpenses: 25.50, // First week expense
-					Trips: []Trip{
-						{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
-					},
-					Expenses: []Expense{
-						{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-					},
-				},
-			},
-		},
+		{"none leaves amount unchanged", 26.195, RoundingNone, 26.195},
+		{"empty mode leaves amount unchanged", 26.195, "", 26.195},
+		{"cent rounds half up", 26.195, RoundingCent, 26.20},
+		{"cent rounds half up from float product", 18.75 * 0.70, RoundingCent, 13.13},
+		{"cent rounds half up despite float error", 37.35 * 0.70, RoundingCent, 26.15},
+		{"cent rounds down below half", 26.1949, RoundingCent, 26.19},
+		{"cent keeps exact cents", 37.5 * 0.70, RoundingCent, 26.25},
+		{"nearest dollar rounds half up", 26.50, RoundingNearestDollar, 27},
+		{"nearest dollar rounds down below half", 26.49, RoundingNearestDollar, 26},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CalculateWeeklySummaries(tt.trips, tt.expenses, tt.ratePerMile)
-			if len(got) != len(tt.want) {
-				t.Errorf("CalculateWeeklySummaries() got %d summaries, want %d", len(got), len(tt.want))
-				return
+			if got := RoundAmount(tt.amount, tt.mode); got != tt.want {
+				t.Errorf("RoundAmount(%v, %q) = %v, want %v", tt.amount, tt.mode, got, tt.want)
 			}
+		})
+	}
+}
 
-			for i := range got {
-				if got[i].WeekStart != tt.want[i].WeekStart {
-					t.Errorf("Week %d: WeekStart got %v, want %v", i, got[i].WeekStart, tt.want[i].WeekStart)
-				}
-				if got[i].WeekEnd != tt.want[i].WeekEnd {
-					t.Errorf("Week %d: WeekEnd got %v, want %v", i, got[i].WeekEnd, tt.want[i].WeekEnd)
-				}
-				if got[i].TotalMiles != tt.want[i].TotalMiles {
-					t.Errorf("Week %d: TotalMiles got %v, want %v", i, got[i].TotalMiles, tt.want[i].TotalMiles)
-				}
-				if got[i].TotalAmount != tt.want[i].TotalAmount {
-					t.Errorf("Week %d: TotalAmount got %v, want %v", i, got[i].TotalAmount, tt.want[i].TotalAmount)
-				}
-				if got[i].TotalExpenses != tt.want[i].TotalExpenses {
-					t.Errorf("Week %d: TotalExpenses got %v, want %v", i, got[i].TotalExpenses, tt.want[i].TotalExpenses)
-				}
+func TestWeeklySummaryTripCounts(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-17", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-03-18", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+		{Date: "2024-03-19", Origin: "Home", Destination: "Park", Miles: 3, Type: "round"},
+		{Date: "2024-03-20", Origin: "Work", Destination: "Home", Miles: 10, Type: "single"},
+		{Date: "2024-03-21", Origin: "Home", Destination: "Store", Miles: 2, Type: "single"},
+		{Date: "2024-03-25", Origin: "Home", Destination: "Work", Miles: 10, Type: "round"}, // Next week
+	}
 
-				// Verify trips
-				if len(got[i].Trips) != len(tt.want[i].Trips) {
-					t.Errorf("Week %d: got %d trips, want %d", i, len(got[i].Trips), len(tt.want[i].Trips))
-					continue
-				}
+	summaries := CalculateWeeklySummaries(trips, nil, 0.70, RoundingNone, time.Sunday)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 weekly summaries, got %d", len(summaries))
+	}
 
-				// Verify expenses
-				if len(got[i].Expenses) != len(tt.want[i].Expenses) {
-					t.Errorf("Week %d: got %d expenses, want %d", i, len(got[i].Expenses), len(tt.want[i].Expenses))
-					continue
-				}
-			}
-		})
+	tests := []struct {
+		week         string
+		wantSingle   int
+		wantRound    int
+		summaryIndex int
+	}{
+		{week: "2024-03-24", wantSingle: 0, wantRound: 1, summaryIndex: 0},
+		{week: "2024-03-17", wantSingle: 3, wantRound: 2, summaryIndex: 1},
+	}
+	for _, tt := range tests {
+		summary := summaries[tt.summaryIndex]
+		if summary.WeekStart != tt.week {
+			t.Fatalf("Expected week %s, got %s", tt.week, summary.WeekStart)
+		}
+		if summary.SingleTripCount != tt.wantSingle {
+			t.Errorf("Week %s: expected %d single trips, got %d", tt.week, tt.wantSingle, summary.SingleTripCount)
+		}
+		if summary.RoundTripCount != tt.wantRound {
+			t.Errorf("Week %s: expected %d round trips, got %d", tt.week, tt.wantRound, summary.RoundTripCount)
+		}
 	}
 }
 
-func TestEditTrip(t *testing.T) {
-	data := &StorageData{
-		Trips: []Trip{
-			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
-			{Date: "2024-03-21", Origin: "Work", Destination: "Store", Miles: 2.5, Type: "round"},
-		},
+func TestRoundTripWithReturnDestination(t *testing.T) {
+	doubled := Trip{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 4, Type: "round"}
+	asymmetric := doubled
+	asymmetric.ReturnDestination = "Store"
+	asymmetric.ReturnMiles = 2.5
+
+	if got := doubled.EffectiveMiles(); got != 8 {
+		t.Errorf("Expected a symmetric round trip to count 8 miles, got %.2f", got)
+	}
+	if got := asymmetric.EffectiveMiles(); got != 6.5 {
+		t.Errorf("Expected the outbound plus return leg (6.5 miles), got %.2f", got)
+	}
+	if err := asymmetric.Validate(); err != nil {
+		t.Errorf("Unexpected error for a round trip with a return destination: %v", err)
 	}
 
-	// Test valid edit
-	newTrip := Trip{Date: "2024-03-22", Origin: "Home", Destination: "Gym", Miles: 3.0, Type: "single"}
-	if err := data.EditTrip(0, newTrip); err != nil {
-		t.Errorf("EditTrip failed: %v", err)
+	single := asymmetric
+	single.Type = "single"
+	var validationErr *ValidationError
+	if err := single.Validate(); !errors.As(err, &validationErr) || validationErr.Field != "return_destination" {
+		t.Errorf("Expected a return_destination error, got %v", err)
 	}
-	if data.Trips[0] != newTrip {
-		t.Errorf("Expected trip to be updated, got %+v", data.Trips[0])
+
+	missingMiles := asymmetric
+	missingMiles.ReturnMiles = 0
+	if err := missingMiles.Validate(); err == nil {
+		t.Error("Expected a return destination without return miles to be rejected")
+	}
+	strayMiles := doubled
+	strayMiles.ReturnMiles = 3
+	if err := strayMiles.Validate(); err == nil {
+		t.Error("Expected return miles without a return destination to be rejected")
 	}
+}
 
-	// Test invalid index
-	if err := data.EditTrip(2, newTrip); err == nil {
-		t.Error("Expected error for invalid index")
+func TestCustomTripMiles(t *testing.T) {
+	custom := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Park", Miles: 12.5, Type: "custom"}
+	if err := custom.Validate(); err != nil {
+		t.Errorf("Expected a custom trip with miles to be valid, got %v", err)
+	}
+	if got := custom.EffectiveMiles(); got != 12.5 {
+		t.Errorf("EffectiveMiles: expected 12.5 for a custom trip, got %.2f", got)
+	}
+	if err := custom.ValidateMaxMiles(10); err != nil {
+		t.Errorf("Expected custom trips to skip the maximum, got %v", err)
 	}
 
-	// Test invalid trip
-	invalidTrip := Trip{Date: "invalid", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}
-	if err := data.EditTrip(0, invalidTrip); err == nil {
-		t.Error("Expected error for invalid trip")
+	custom.Miles = 0
+	var verr *ValidationError
+	if err := custom.Validate(); !errors.As(err, &verr) || verr.Field != "miles" {
+		t.Errorf("Expected a miles error for a custom trip without miles, got %v", err)
 	}
 }
 
-func TestDeleteTrip(t *testing.T) {
-	data := &StorageData{
-		Trips: []Trip{
-			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0},
-			{Date: "2024-03-21", Origin: "Work", Destination: "Store", Miles: 2.5},
-		},
+func TestRoundTripMilesCountDouble(t *testing.T) {
+	round := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "round"}
+	single := Trip{Date: "2024-03-21", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"}
+	trips := []Trip{round, single}
+	const rate = 0.5
+
+	if got := round.EffectiveMiles(); got != 20 {
+		t.Errorf("EffectiveMiles: expected 20 for a round trip, got %.2f", got)
+	}
+	if got := single.EffectiveMiles(); got != 10 {
+		t.Errorf("EffectiveMiles: expected 10 for a single trip, got %.2f", got)
+	}
+	recurring := RecurringTrip{Miles: 10, Type: "round"}
+	if got := recurring.EffectiveMiles(); got != 20 {
+		t.Errorf("RecurringTrip.EffectiveMiles: expected 20, got %.2f", got)
 	}
 
-	// Test valid delete
-	if err := data.DeleteTrip(0); err != nil {
-		t.Errorf("DeleteTrip failed: %v", err)
+	if got := CalculateTotalMiles(trips); got != 30 {
+		t.Errorf("CalculateTotalMiles: expected 30, got %.2f", got)
 	}
-	if len(data.Trips) != 1 {
-		t.Errorf("Expected 1 trip after deletion, got %d", len(data.Trips))
+	if got := CalculateReimbursement(trips, rate); got != 15 {
+		t.Errorf("CalculateReimbursement: expected 15, got %.2f", got)
 	}
-	if data.Trips[0].Date != "2024-03-21" {
-		t.Errorf("Expected remaining trip to be the second one, got %+v", data.Trips[0])
+
+	weekly := CalculateWeeklySummaries(append([]Trip(nil), trips...), nil, rate, RoundingNone, time.Sunday)
+	if len(weekly) != 1 || weekly[0].TotalMiles != 30 || weekly[0].TotalAmount != 15 {
+		t.Errorf("CalculateWeeklySummaries: expected 30 miles and 15.00, got %+v", weekly)
+	}
+	if total := CalculateGrandTotal(weekly); total.TotalMiles != 30 {
+		t.Errorf("CalculateGrandTotal: expected 30 miles, got %.2f", total.TotalMiles)
 	}
 
-	// Test invalid index
-	if err := data.DeleteTrip(1); err == nil {
-		t.Error("Expected error for invalid index")
+	monthly, err := CalculateMonthlySummary(trips, nil, rate, "2024-03")
+	if err != nil || monthly.TotalMiles != 30 || monthly.TotalAmount != 15 {
+		t.Errorf("CalculateMonthlySummary: expected 30 miles and 15.00, got %+v (err %v)", monthly, err)
+	}
+
+	yearly := CalculateYearlySummary(trips, nil, rate, 2024)
+	if yearly.TotalMiles != 30 || yearly.Months[2].TotalMiles != 30 || yearly.TotalAmount != 15 {
+		t.Errorf("CalculateYearlySummary: expected 30 miles and 15.00, got %+v", yearly)
 	}
 }
 
-func TestTripTypeValidation(t *testing.T) {
-	tests := []struct {
-		name    string
-		trip    Trip
-		wantErr bool
-	}{
-		{
-			name: "valid single trip",
-			trip: Trip{
-				Origin:      "Home",
-				Destination: "Work",
-				Miles:       5.0,
-				Date:        "2024-03-20",
-				Type:        "single",
+func TestCalculateWeeklySummariesRounding(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 37.425, Type: "single"},
+	}
+
+	unrounded := CalculateWeeklySummaries(trips, nil, 0.70, RoundingNone, time.Sunday)
+	if unrounded[0].TotalAmount == 26.20 {
+		t.Errorf("Expected unrounded amount, got %v", unrounded[0].TotalAmount)
+	}
+
+	rounded := CalculateWeeklySummaries(trips, nil, 0.70, RoundingCent, time.Sunday)
+	if rounded[0].TotalAmount != 26.20 {
+		t.Errorf("Expected amount rounded to 26.20, got %v", rounded[0].TotalAmount)
+	}
+}
+
+func TestSummaryForWeek(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-03-05", Origin: "Home", Destination: "Park", Miles: 4, Type: "single"},
+	}
+	summaries := CalculateWeeklySummaries(trips, nil, 0.5, RoundingNone, time.Sunday)
+
+	tests := []struct {
+		name          string
+		date          string
+		wantIndex     int
+		wantWeekStart string
+		wantMiles     float64
+	}{
+		{"midweek", "2024-03-20", 0, "2024-03-17", 10},
+		{"saturday ends the week", "2024-03-23", 0, "2024-03-17", 10},
+		{"older week", "2024-03-03", 1, "2024-03-03", 4},
+		{"week without trips", "2024-03-12", -1, "2024-03-10", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := WeekIndexContaining(summaries, date); got != tt.wantIndex {
+				t.Errorf("Expected index %d, got %d", tt.wantIndex, got)
+			}
+			summary := SummaryForWeek(summaries, date, time.Sunday)
+			if summary.WeekStart != tt.wantWeekStart || summary.TotalMiles != tt.wantMiles {
+				t.Errorf("Expected week starting %s with %.1f miles, got %+v", tt.wantWeekStart, tt.wantMiles, summary)
+			}
+		})
+	}
+
+	if summary := SummaryForWeek(nil, time.Date(2024, 3, 12, 15, 0, 0, 0, time.UTC), time.Sunday); summary.WeekEnd != "2024-03-16" {
+		t.Errorf("Expected zeroed week ending 2024-03-16, got %+v", summary)
+	}
+}
+
+func TestCalculateWeeklySummariesWeekStartDay(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-10", Origin: "Home", Destination: "Work", Miles: 5, Type: "single"}, // Sunday
+		{Date: "2024-03-11", Origin: "Home", Destination: "Work", Miles: 7, Type: "single"}, // Monday
+	}
+
+	tests := []struct {
+		name      string
+		startDay  time.Weekday
+		wantWeeks [][2]string // WeekStart and WeekEnd, most recent first
+	}{
+		{"sunday start", time.Sunday, [][2]string{{"2024-03-10", "2024-03-16"}}},
+		{"monday start", time.Monday, [][2]string{{"2024-03-11", "2024-03-17"}, {"2024-03-04", "2024-03-10"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summaries := CalculateWeeklySummaries(append([]Trip(nil), trips...), nil, 0.70, RoundingNone, tt.startDay)
+			if len(summaries) != len(tt.wantWeeks) {
+				t.Fatalf("Expected %d weeks, got %d", len(tt.wantWeeks), len(summaries))
+			}
+			for i, want := range tt.wantWeeks {
+				if summaries[i].WeekStart != want[0] || summaries[i].WeekEnd != want[1] {
+					t.Errorf("Expected week %d to span %s to %s, got %s to %s", i, want[0], want[1], summaries[i].WeekStart, summaries[i].WeekEnd)
+				}
+			}
+		})
+	}
+
+	// Empty weeks follow the same boundary
+	if summary := SummaryForWeek(nil, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Monday); summary.WeekStart != "2024-03-04" || summary.WeekEnd != "2024-03-10" {
+		t.Errorf("Expected the empty week 2024-03-04 to 2024-03-10, got %s to %s", summary.WeekStart, summary.WeekEnd)
+	}
+}
+
+func TestSummaryByWeekStart(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-03-05", Origin: "Home", Destination: "Park", Miles: 4, Type: "single"},
+	}
+	summaries := CalculateWeeklySummaries(trips, nil, 0.5, RoundingNone, time.Sunday)
+
+	tests := []struct {
+		name      string
+		weekStart string
+		wantFound bool
+		wantMiles float64
+	}{
+		{"latest week", "2024-03-17", true, 10},
+		{"older week", "2024-03-03", true, 4},
+		{"week without records", "2024-03-10", false, 0},
+		{"midweek date is not a week start", "2024-03-20", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, found := SummaryByWeekStart(summaries, tt.weekStart)
+			if found != tt.wantFound {
+				t.Fatalf("Expected found %v, got %v", tt.wantFound, found)
+			}
+			if summary.TotalMiles != tt.wantMiles {
+				t.Errorf("Expected %.1f miles, got %.1f", tt.wantMiles, summary.TotalMiles)
+			}
+		})
+	}
+}
+
+func TestExpenseTripLink(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 8, Type: "single"},
+		{Date: "2024-03-20", Origin: "Home", Destination: "Pool", Miles: 2, Type: "single", Family: "Jones"},
+		{Date: "2024-03-20", Origin: "Zoo", Destination: "Museum", Miles: 3, Type: "single"},
+		{Date: "2024-03-21", Origin: "Home", Destination: "Park", Miles: 1, Type: "single"},
+	}
+
+	expense := Expense{Date: "2024-03-20", Amount: 6.5, Description: "Parking", TripDate: "2024-03-20", TripIndex: 1}
+	if !expense.LinkByDate(trips) {
+		t.Fatal("Expected the second default-family trip on 2024-03-20 to be found")
+	}
+	if trips[2].ID == "" || expense.TripID != trips[2].ID || expense.Family != DefaultFamily {
+		t.Errorf("Expected the expense to be linked to the Museum trip by ID, got %+v and %+v", expense, trips[2])
+	}
+	if expense.TripDate != "" || expense.TripIndex != 0 {
+		t.Errorf("Expected the date and position to be cleared once linked, got %+v", expense)
+	}
+	if err := expense.Validate(); err != nil {
+		t.Errorf("Expected a linked expense to be valid, got %v", err)
+	}
+
+	// The link survives the trips being reordered by date
+	summaries := CalculateWeeklySummaries(append([]Trip(nil), trips...), []Expense{expense, {Date: "2024-03-21", Amount: 4, Description: "Snack"}}, 0.5, RoundingNone, time.Sunday)
+	byTrip, other := summaries[0].ExpensesByTrip()
+	if len(other) != 1 || other[0].Description != "Snack" {
+		t.Errorf("Expected the snack to stay unlinked, got %+v", other)
+	}
+	for i, linked := range byTrip {
+		if summaries[0].Trips[i].Destination != "Museum" || len(linked) != 1 {
+			t.Errorf("Expected only the Museum trip to have the parking expense, got %s: %+v", summaries[0].Trips[i].Destination, linked)
+		}
+	}
+	if len(byTrip) != 1 {
+		t.Errorf("Expected 1 trip with expenses, got %d", len(byTrip))
+	}
+
+	missing := Expense{Date: "2024-03-20", Amount: 1, Description: "Parking", TripDate: "2024-03-20", TripIndex: 2}
+	if missing.LinkByDate(trips) || missing.IsTripLinked() {
+		t.Error("Expected no third default-family trip on 2024-03-20")
+	}
+	for _, invalid := range []Expense{
+		{Date: "2024-03-20", Amount: 1, Description: "Bad date", TripDate: "March 20"},
+		{Date: "2024-03-20", Amount: 1, Description: "No date", TripIndex: 1},
+		{Date: "2024-03-20", Amount: 1, Description: "Negative", TripDate: "2024-03-20", TripIndex: -1},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestExpenseTripLinkFollowsTrip(t *testing.T) {
+	newData := func() *StorageData {
+		data := &StorageData{Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "Zoo", Miles: 8, Type: "single"},
+			{Date: "2024-03-20", Origin: "Zoo", Destination: "Museum", Miles: 3, Type: "single"},
+		}}
+		expense := Expense{Date: "2024-03-20", Amount: 6.5, Description: "Parking"}
+		expense.LinkToTrip(data.Trips, 1)
+		data.Expenses = []Expense{expense}
+		return data
+	}
+	linkedDestination := func(trips []Trip, expense Expense) string {
+		if i, ok := expense.LinkedTrip(trips); ok {
+			return trips[i].Destination
+		}
+		return ""
+	}
+
+	// Archiving the first trip leaves it out of the week's trips, moving the Museum trip up
+	data := newData()
+	if err := data.ArchiveTrip(0); err != nil {
+		t.Fatalf("Failed to archive trip: %v", err)
+	}
+	summaries := CalculateWeeklySummaries(append([]Trip(nil), data.Trips...), data.Expenses, 0.5, RoundingNone, time.Sunday)
+	byTrip, other := summaries[0].ExpensesByTrip()
+	if len(other) != 0 || len(byTrip) != 1 {
+		t.Fatalf("Expected the parking to stay linked, got %+v and %+v", byTrip, other)
+	}
+	for i := range byTrip {
+		if summaries[0].Trips[i].Destination != "Museum" {
+			t.Errorf("Expected the parking under the Museum trip, got %s", summaries[0].Trips[i].Destination)
+		}
+	}
+
+	// Editing the trip keeps its link
+	data = newData()
+	edited := data.Trips[1]
+	edited.ID = ""
+	edited.Notes = "Members get in free"
+	if err := data.EditTrip(1, edited); err != nil {
+		t.Fatalf("Failed to edit trip: %v", err)
+	}
+	if got := linkedDestination(data.Trips, data.Expenses[0]); got != "Museum" {
+		t.Errorf("Expected the link to survive an edit, got %q", got)
+	}
+
+	// Deleting another trip on the same day keeps the link
+	data = newData()
+	if err := data.DeleteTrip(0); err != nil {
+		t.Fatalf("Failed to delete trip: %v", err)
+	}
+	if got := linkedDestination(data.Trips, data.Expenses[0]); got != "Museum" {
+		t.Errorf("Expected the link to survive deleting another trip, got %q", got)
+	}
+
+	// Deleting the linked trip leaves the expense standing alone
+	if err := data.DeleteTrip(0); err != nil {
+		t.Fatalf("Failed to delete trip: %v", err)
+	}
+	if data.Expenses[0].IsTripLinked() {
+		t.Errorf("Expected the expense to be unlinked from the deleted trip, got %+v", data.Expenses[0])
+	}
+
+	// Merging a duplicate moves its expenses to the trip that is kept
+	data = newData()
+	data.Trips = append([]Trip{data.Trips[1]}, data.Trips...)
+	data.Trips[0].ID = ""
+	if removed := data.MergeDuplicates(); removed != 1 {
+		t.Fatalf("Expected 1 duplicate removed, got %d", removed)
+	}
+	if i, ok := data.Expenses[0].LinkedTrip(data.Trips); !ok || i != 0 {
+		t.Errorf("Expected the parking to move to the kept Museum trip, got %d (%v)", i, ok)
+	}
+}
+
+func TestWeeklySummaryTargetPercent(t *testing.T) {
+	tests := []struct {
+		miles  float64
+		target float64
+		want   float64
+	}{
+		{miles: 42, target: 50, want: 84},
+		{miles: 0, target: 50, want: 0},
+		{miles: 75, target: 50, want: 150},
+		{miles: 42, target: 0, want: 0},
+		{miles: 42, target: -10, want: 0},
+	}
+	for _, tt := range tests {
+		summary := WeeklySummary{TotalMiles: tt.miles}
+		if got := summary.TargetPercent(tt.target); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("TargetPercent(%.2f) with %.2f miles = %.2f, want %.2f", tt.target, tt.miles, got, tt.want)
+		}
+	}
+}
+
+func TestWeeklySummaryActivityFlags(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-05", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"},
+	}
+	expenses := []Expense{
+		{Date: "2024-03-06", Amount: 8.0, Description: "Snacks"},
+		{Date: "2024-03-13", Amount: 12.0, Description: "Museum tickets"},
+	}
+	summaries := CalculateWeeklySummaries(trips, expenses, 0.70, RoundingNone, time.Sunday)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 weekly summaries, got %d", len(summaries))
+	}
+
+	// Most recent first: the week of March 10 only has an expense
+	expenseOnly, both := summaries[0], summaries[1]
+	if expenseOnly.HasTrips || !expenseOnly.HasExpenses {
+		t.Errorf("Expected the week of %s to have expenses but no trips, got HasTrips=%v HasExpenses=%v", expenseOnly.WeekStart, expenseOnly.HasTrips, expenseOnly.HasExpenses)
+	}
+	if !expenseOnly.ExpensesWithoutMiles() {
+		t.Error("Expected the expense-only week to be flagged as having expenses without miles")
+	}
+	if !both.HasTrips || !both.HasExpenses || both.ExpensesWithoutMiles() {
+		t.Errorf("Expected the week of %s to have trips and expenses and not be flagged, got %+v", both.WeekStart, both)
+	}
+
+	tripOnly := CalculateWeeklySummaries(trips[:1], nil, 0.70, RoundingNone, time.Sunday)[0]
+	if !tripOnly.HasTrips || tripOnly.HasExpenses || tripOnly.ExpensesWithoutMiles() {
+		t.Errorf("Expected a trip-only week to have trips and no expenses, got HasTrips=%v HasExpenses=%v", tripOnly.HasTrips, tripOnly.HasExpenses)
+	}
+}
+
+func TestApplyAdjustments(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-05", Origin: "Home", Destination: "School", Miles: 15.0, Type: "round"},
+		{Date: "2024-03-12", Origin: "Home", Destination: "Park", Miles: 4.0, Type: "single"},
+	}
+	adjustments := []Adjustment{
+		{Date: "2024-03-07", Miles: -20.0, Reason: "Logged the school run twice"},
+		{Date: "2024-03-08", Miles: 2.5, Reason: "Detour"},
+		{Date: "2024-03-20", Miles: 6.0, Reason: "Forgot a pickup"},
+	}
+	summaries := CalculateWeeklySummaries(trips, nil, 0.70, RoundingNone, time.Sunday)
+	summaries = ApplyAdjustments(summaries, adjustments, 0.70, RoundingNone, time.Sunday)
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 weekly summaries, got %d", len(summaries))
+	}
+
+	// A week with only an adjustment gets its own summary, most recent first
+	adjustmentOnly := summaries[0]
+	if adjustmentOnly.WeekStart != "2024-03-17" || adjustmentOnly.TotalMiles != 6.0 || math.Abs(adjustmentOnly.TotalAmount-4.2) > 0.001 {
+		t.Errorf("Expected 6 adjusted miles ($4.20) in the week of 2024-03-17, got %+v", adjustmentOnly)
+	}
+	if untouched := summaries[1]; untouched.TotalMiles != 4.0 || untouched.AdjustmentMiles != 0 || len(untouched.Adjustments) != 0 {
+		t.Errorf("Expected the week of %s to be unadjusted, got %+v", untouched.WeekStart, untouched)
+	}
+
+	// 30 round-trip miles less 20 plus 2.5
+	adjusted := summaries[2]
+	if adjusted.TotalMiles != 12.5 || adjusted.AdjustmentMiles != -17.5 {
+		t.Errorf("Expected 12.5 miles after a -17.5 mile adjustment, got %.2f (%.2f)", adjusted.TotalMiles, adjusted.AdjustmentMiles)
+	}
+	if math.Abs(adjusted.TotalAmount-8.75) > 0.001 || math.Abs(adjusted.PerPassengerAmount-8.75) > 0.001 {
+		t.Errorf("Expected $8.75 for the adjusted week, got $%.2f ($%.2f per passenger)", adjusted.TotalAmount, adjusted.PerPassengerAmount)
+	}
+	if len(adjusted.Adjustments) != 2 || adjusted.Adjustments[0].Date != "2024-03-08" {
+		t.Errorf("Expected both adjustments itemized newest first, got %+v", adjusted.Adjustments)
+	}
+	if adjusted.SingleTripCount != 0 || adjusted.RoundTripCount != 1 {
+		t.Errorf("Expected trip counts to be unaffected, got %d single, %d round", adjusted.SingleTripCount, adjusted.RoundTripCount)
+	}
+
+	// Totals never drop below zero, even if a trip the correction covered is later removed
+	park := []Trip{{Date: "2024-03-12", Origin: "Home", Destination: "Park", Miles: 4.0, Type: "single"}}
+	clamped := ApplyAdjustments(CalculateWeeklySummaries(park, nil, 0.70, RoundingNone, time.Sunday),
+		[]Adjustment{{Date: "2024-03-12", Miles: -10, Reason: "Wrong family"}}, 0.70, RoundingNone, time.Sunday)
+	if clamped[0].TotalMiles != 0 || clamped[0].TotalAmount != 0 || clamped[0].AdjustmentMiles != -4.0 {
+		t.Errorf("Expected the week to be clamped at zero miles, got %+v", clamped[0])
+	}
+}
+
+func TestAddAdjustmentCannotGoBelowZero(t *testing.T) {
+	data := &StorageData{Trips: []Trip{
+		{Date: "2024-03-05", Origin: "Home", Destination: "School", Miles: 10.0, Type: "round"},
+		{Date: "2024-03-05", Origin: "Home", Destination: "Zoo", Miles: 50.0, Type: "single", Family: "smith"},
+	}}
+
+	if err := data.AddAdjustment(Adjustment{Date: "2024-03-09", Miles: -20, Reason: "Duplicate trip"}, time.Sunday); err != nil {
+		t.Fatalf("Expected an adjustment down to zero miles to be accepted, got %v", err)
+	}
+
+	// The other family's miles don't count towards this week
+	err := data.AddAdjustment(Adjustment{Date: "2024-03-06", Miles: -0.5, Reason: "Rounding"}, time.Sunday)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "miles" {
+		t.Fatalf("Expected a miles validation error, got %v", err)
+	}
+	if len(data.Adjustments) != 1 {
+		t.Errorf("Expected the rejected adjustment not to be added, got %d adjustments", len(data.Adjustments))
+	}
+
+	// Removing a positive adjustment can't leave the week negative either
+	if err := data.AddAdjustment(Adjustment{Date: "2024-03-06", Miles: 5, Reason: "Detour"}, time.Sunday); err != nil {
+		t.Fatalf("Failed to add adjustment: %v", err)
+	}
+	if err := data.AddAdjustment(Adjustment{Date: "2024-03-07", Miles: -5, Reason: "Wrong day"}, time.Sunday); err != nil {
+		t.Fatalf("Failed to add adjustment: %v", err)
+	}
+	if err := data.DeleteAdjustment(1, time.Sunday); err == nil {
+		t.Error("Expected deleting the positive adjustment to be rejected")
+	}
+	if err := data.DeleteAdjustment(2, time.Sunday); err != nil {
+		t.Errorf("Failed to delete adjustment: %v", err)
+	}
+	if err := data.DeleteAdjustment(5, time.Sunday); err == nil {
+		t.Error("Expected an out of range index to be rejected")
+	}
+
+	for _, adjustment := range []Adjustment{
+		{Date: "2024-03-06", Miles: 0, Reason: "Nothing"},
+		{Date: "2024-03-06", Miles: -1},
+		{Date: "03/06/2024", Miles: -1, Reason: "Bad date"},
+	} {
+		if err := data.AddAdjustment(adjustment, time.Sunday); err == nil {
+			t.Errorf("Expected %+v to be invalid", adjustment)
+		}
+	}
+}
+
+func TestWeeklySummaryCompareTo(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 25, Type: "single"},
+		{Date: "2024-03-13", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-02-14", Origin: "Home", Destination: "Park", Miles: 4, Type: "single"},
+	}
+	expenses := []Expense{
+		{Date: "2024-03-21", Amount: 5, Description: "Snack"},
+		{Date: "2024-03-14", Amount: 10, Description: "Lunch"},
+	}
+	summaries := CalculateWeeklySummaries(trips, expenses, 0.5, RoundingNone, time.Sunday)
+
+	prev, ok := PreviousWeek(summaries, 0)
+	if !ok {
+		t.Fatal("Expected the week of 2024-03-17 to have a previous week")
+	}
+	if prev.WeekStart != "2024-03-10" {
+		t.Errorf("Expected previous week starting 2024-03-10, got %s", prev.WeekStart)
+	}
+
+	delta := summaries[0].CompareTo(prev)
+	if delta.Miles != 15 {
+		t.Errorf("Expected +15 miles, got %+.2f", delta.Miles)
+	}
+	if delta.Amount != 7.5 {
+		t.Errorf("Expected +$7.50 mileage, got %+.2f", delta.Amount)
+	}
+	if delta.Expenses != -5 {
+		t.Errorf("Expected -$5.00 expenses, got %+.2f", delta.Expenses)
+	}
+
+	// A gap of empty weeks means there is nothing to compare against
+	if _, ok := PreviousWeek(summaries, 1); ok {
+		t.Error("Expected no previous week when the week before has no records")
+	}
+	if _, ok := PreviousWeek(summaries, len(summaries)); ok {
+		t.Error("Expected no previous week for an out of range index")
+	}
+}
+
+func TestCalculateWeeklySummaries(t *testing.T) {
+	tests := []struct {
+		name        string
+		trips       []Trip
+		expenses    []Expense
+		ratePerMile float64
+		want        []WeeklySummary
+	}{
+		{
+			name:        "no trips or expenses",
+			trips:       []Trip{},
+			expenses:    []Expense{},
+			ratePerMile: 0.70,
+			want:        nil,
+		},
+		{
+			name: "single week with trips and expenses",
+			trips: []Trip{
+				{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+				{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
 			},
-			wantErr: false,
+			expenses: []Expense{
+				{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+				{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
+			},
+			ratePerMile: 0.70,
+			want: []WeeklySummary{
+				{
+					WeekStart:     "2024-03-17", // Sunday of the week
+					WeekEnd:       "2024-03-23", // Saturday of the week
+					TotalMiles:    30.0,         // 10 + (10 * 2)
+					TotalAmount:   21.0,         // 30 miles * 0.70
+					TotalExpenses: 41.25,        // 25.50 + 15.75
+					Trips: []Trip{
+						{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+						{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
+					},
+					Expenses: []Expense{
+						{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+						{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
+					},
+				},
+			},
+		},
+		{
+			name: "multiple weeks",
+			trips: []Trip{
+				{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+				{Date: "2024-03-27", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
+			},
+			expenses: []Expense{
+				{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+				{Date: "2024-03-27", Amount: 15.75, Description: "Snacks"},
+			},
+			ratePerMile: 0.70,
+			want: []WeeklySummary{
+				{
+					WeekStart:     "2024-03-24", // Second week (most recent)
+					WeekEnd:       "2024-03-30",
+					TotalMiles:    20.0,  // 10 * 2 for round trip
+					TotalAmount:   14.0,  // 20 miles * 0.70
+					TotalExpenses: 15.75, // Second week expense
+					Trips: []Trip{
+						{Date: "2024-03-27", Origin: "Work", Destination: "Home", Miles: 10, Type: "round"},
+					},
+					Expenses: []Expense{
+						{Date: "2024-03-27", Amount: 15.75, Description: "Snacks"},
+					},
+				},
+				{
+					WeekStart:     "2024-03-17", // First week
+					WeekEnd:       "2024-03-23",
+					TotalMiles:    10.0,
+					TotalAmount:   7.0,   // 10 miles * 0.70
+					TotalExpenses: 25.50, // First week expense
+					Trips: []Trip{
+						{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+					},
+					Expenses: []Expense{
+						{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateWeeklySummaries(tt.trips, tt.expenses, tt.ratePerMile, RoundingNone, time.Sunday)
+			if len(got) != len(tt.want) {
+				t.Errorf("CalculateWeeklySummaries(, time.Sunday) got %d summaries, want %d", len(got), len(tt.want))
+				return
+			}
+
+			for i := range got {
+				if got[i].WeekStart != tt.want[i].WeekStart {
+					t.Errorf("Week %d: WeekStart got %v, want %v", i, got[i].WeekStart, tt.want[i].WeekStart)
+				}
+				if got[i].WeekEnd != tt.want[i].WeekEnd {
+					t.Errorf("Week %d: WeekEnd got %v, want %v", i, got[i].WeekEnd, tt.want[i].WeekEnd)
+				}
+				if got[i].TotalMiles != tt.want[i].TotalMiles {
+					t.Errorf("Week %d: TotalMiles got %v, want %v", i, got[i].TotalMiles, tt.want[i].TotalMiles)
+				}
+				if got[i].TotalAmount != tt.want[i].TotalAmount {
+					t.Errorf("Week %d: TotalAmount got %v, want %v", i, got[i].TotalAmount, tt.want[i].TotalAmount)
+				}
+				if got[i].TotalExpenses != tt.want[i].TotalExpenses {
+					t.Errorf("Week %d: TotalExpenses got %v, want %v", i, got[i].TotalExpenses, tt.want[i].TotalExpenses)
+				}
+
+				// Verify trips
+				if len(got[i].Trips) != len(tt.want[i].Trips) {
+					t.Errorf("Week %d: got %d trips, want %d", i, len(got[i].Trips), len(tt.want[i].Trips))
+					continue
+				}
+
+				// Verify expenses
+				if len(got[i].Expenses) != len(tt.want[i].Expenses) {
+					t.Errorf("Week %d: got %d expenses, want %d", i, len(got[i].Expenses), len(tt.want[i].Expenses))
+					continue
+				}
+			}
+		})
+	}
+}
+
+func TestEditTrip(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+			{Date: "2024-03-21", Origin: "Work", Destination: "Store", Miles: 2.5, Type: "round"},
+		},
+	}
+
+	// Test valid edit
+	newTrip := Trip{Date: "2024-03-22", Origin: "Home", Destination: "Gym", Miles: 3.0, Type: "single"}
+	if err := data.EditTrip(0, newTrip); err != nil {
+		t.Errorf("EditTrip failed: %v", err)
+	}
+	if !reflect.DeepEqual(data.Trips[0], newTrip) {
+		t.Errorf("Expected trip to be updated, got %+v", data.Trips[0])
+	}
+
+	// Test invalid index
+	if err := data.EditTrip(2, newTrip); err == nil {
+		t.Error("Expected error for invalid index")
+	}
+
+	// Test invalid trip
+	invalidTrip := Trip{Date: "invalid", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"}
+	if err := data.EditTrip(0, invalidTrip); err == nil {
+		t.Error("Expected error for invalid trip")
+	}
+}
+
+func TestEditTripsIsAllOrNothing(t *testing.T) {
+	original := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Wrok", Miles: 5.0, Type: "single"},
+		{Date: "2024-03-21", Origin: "Home", Destination: "Stroe", Miles: 2.5, Type: "round"},
+	}
+	data := &StorageData{Trips: append([]Trip(nil), original...)}
+
+	// One bad update rejects the whole batch
+	err := data.EditTrips(map[int]Trip{
+		0: {Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+		1: {Date: "2024-03-21", Origin: "Home", Destination: "", Miles: 2.5, Type: "round"},
+		5: {Date: "2024-03-22", Origin: "Home", Destination: "Gym", Miles: 3.0, Type: "single"},
+	})
+	var errs TripEditErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected TripEditErrors, got %v", err)
+	}
+	if len(errs) != 2 || errs[1] == nil || errs[5] == nil {
+		t.Errorf("Expected errors for trips 1 and 5, got %v", errs)
+	}
+	if !reflect.DeepEqual(data.Trips, original) {
+		t.Errorf("Expected no trips to change, got %+v", data.Trips)
+	}
+
+	// A clean batch applies every update
+	if err := data.EditTrips(map[int]Trip{
+		0: {Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+		1: {Date: "2024-03-21", Origin: "Home", Destination: "Store", Miles: 2.5, Type: "round"},
+	}); err != nil {
+		t.Fatalf("EditTrips failed: %v", err)
+	}
+	if data.Trips[0].Destination != "Work" || data.Trips[1].Destination != "Store" {
+		t.Errorf("Expected both destinations corrected, got %+v", data.Trips)
+	}
+}
+
+func TestTopRoutes(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-18", Origin: "Home", Destination: "Park", Miles: 2, Type: "single"},
+			{Date: "2024-03-18", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+			{Date: "2024-03-19", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+			{Date: "2024-03-20", Origin: "Home", Destination: "Library", Miles: 3, Type: "single"},
+			{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 5, Type: "single"},
+			{Date: "2024-03-21", Origin: "School", Destination: "Home", Miles: 5, Type: "single"},
+			{Date: "2024-03-22", Origin: "Home", Destination: "Park", Miles: 2, Type: "single"},
+		},
+	}
+
+	want := []maps.Route{
+		{Origin: "Home", Destination: "School"},
+		{Origin: "Home", Destination: "Park"},
+		// Routes taken once are ordered by date, most recent first
+		{Origin: "School", Destination: "Home"},
+		{Origin: "Home", Destination: "Library"},
+	}
+	if got := data.TopRoutes(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected routes by descending frequency %v, got %v", want, got)
+	}
+	if got := data.TopRoutes(2); !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("Expected the top 2 routes %v, got %v", want[:2], got)
+	}
+	if got := (&StorageData{}).TopRoutes(3); len(got) != 0 {
+		t.Errorf("Expected no routes without trips, got %v", got)
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	if got := NormalizeAddress("  123   Main St\t "); got != "123 Main St" {
+		t.Errorf("Expected '123 Main St', got %q", got)
+	}
+
+	data := &StorageData{}
+	if err := data.AddTrip(Trip{Date: "2024-03-18", Origin: "  Home  ", Destination: "School", Miles: 5, Type: "single"}); err != nil {
+		t.Fatalf("Failed to add trip: %v", err)
+	}
+	if data.Trips[0].Origin != "Home" {
+		t.Errorf("Expected the added trip's origin to be 'Home', got %q", data.Trips[0].Origin)
+	}
+	if err := data.AddTrip(Trip{Date: "2024-03-18", Origin: "   ", Destination: "School", Miles: 5, Type: "single"}); err == nil {
+		t.Error("Expected an origin of only spaces to be rejected")
+	}
+
+	// Trips saved before normalization differ only by trailing whitespace
+	data.Trips = append(data.Trips, Trip{Date: "2024-03-19", Origin: "Home ", Destination: "School", Miles: 5, Type: "single"})
+	want := []maps.Route{{Origin: "Home", Destination: "School"}}
+	if got := data.TopRoutes(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected both trips to share the route %v, got %v", want, got)
+	}
+}
+
+func TestFindAndMergeDuplicateTrips(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 5, Type: "round", Notes: "converted"},
+			{Date: "2024-03-20", Origin: "Home", Destination: "Park", Miles: 2, Type: "single"},
+			{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 5, Type: "round", IsRecurring: true},
+			// Near duplicates: a different type, date or family is a separate trip
+			{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 5, Type: "single"},
+			{Date: "2024-03-21", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+			{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 5, Type: "round", Family: "Jones"},
+		},
+	}
+
+	groups := data.FindDuplicateTrips()
+	if !reflect.DeepEqual(groups, [][]int{{0, 2}}) {
+		t.Fatalf("Expected only trips 0 and 2 to be duplicates, got %v", groups)
+	}
+
+	if removed := data.MergeDuplicates(); removed != 1 {
+		t.Errorf("Expected 1 trip removed, got %d", removed)
+	}
+	if len(data.Trips) != 5 || data.Trips[0].Notes != "converted" {
+		t.Errorf("Expected the first of the pair kept and the near duplicates left alone, got %+v", data.Trips)
+	}
+	if groups := data.FindDuplicateTrips(); len(groups) != 0 {
+		t.Errorf("Expected no duplicates after merging, got %v", groups)
+	}
+	if removed := data.MergeDuplicates(); removed != 0 {
+		t.Errorf("Expected nothing left to merge, got %d", removed)
+	}
+}
+
+func TestFindAndMergeDuplicateExpenses(t *testing.T) {
+	data := &StorageData{
+		Expenses: []Expense{
+			{Date: "2024-03-20", Amount: 12.50, Description: "Museum tickets", Category: "activity"},
+			{Date: "2024-03-20", Amount: 12.50, Description: "Lunch"},
+			{Date: "2024-03-20", Amount: 12.5, Description: "Museum tickets"},
+			{Date: "2024-03-20", Amount: 12.50, Description: "Museum tickets"},
+			// Near duplicates: a different description, amount, date or family is a separate expense
+			{Date: "2024-03-20", Amount: 12.50, Description: "Museum parking"},
+			{Date: "2024-03-20", Amount: 12.51, Description: "Museum tickets"},
+			{Date: "2024-03-21", Amount: 12.50, Description: "Museum tickets"},
+			{Date: "2024-03-20", Amount: 12.50, Description: "Museum tickets", Family: "Jones"},
+		},
+	}
+
+	groups := data.FindDuplicateExpenses()
+	if !reflect.DeepEqual(groups, [][]int{{0, 2, 3}}) {
+		t.Fatalf("Expected only expenses 0, 2 and 3 to be duplicates, got %v", groups)
+	}
+
+	if removed := data.MergeDuplicateExpenses(); removed != 2 {
+		t.Errorf("Expected 2 expenses removed, got %d", removed)
+	}
+	if len(data.Expenses) != 6 || data.Expenses[0].Category != "activity" || data.Expenses[1].Description != "Lunch" {
+		t.Errorf("Expected the first of the group kept and the near duplicates left alone, got %+v", data.Expenses)
+	}
+	if groups := data.FindDuplicateExpenses(); len(groups) != 0 {
+		t.Errorf("Expected no duplicates after merging, got %v", groups)
+	}
+	if removed := data.MergeDuplicateExpenses(); removed != 0 {
+		t.Errorf("Expected nothing left to merge, got %d", removed)
+	}
+}
+
+func TestDeleteTrip(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0},
+			{Date: "2024-03-21", Origin: "Work", Destination: "Store", Miles: 2.5},
+		},
+	}
+
+	// Test valid delete
+	if err := data.DeleteTrip(0); err != nil {
+		t.Errorf("DeleteTrip failed: %v", err)
+	}
+	if len(data.Trips) != 1 {
+		t.Errorf("Expected 1 trip after deletion, got %d", len(data.Trips))
+	}
+	if data.Trips[0].Date != "2024-03-21" {
+		t.Errorf("Expected remaining trip to be the second one, got %+v", data.Trips[0])
+	}
+
+	// Test invalid index
+	if err := data.DeleteTrip(1); err == nil {
+		t.Error("Expected error for invalid index")
+	}
+}
+
+func TestArchiveTrip(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5.0, Type: "single"},
+			{Date: "2024-03-21", Origin: "Work", Destination: "Store", Miles: 2.5, Type: "single"},
+		},
+	}
+
+	if err := data.ArchiveTrip(0); err != nil {
+		t.Fatalf("ArchiveTrip failed: %v", err)
+	}
+	if len(data.Trips) != 2 || !data.Trips[0].Archived {
+		t.Fatalf("Expected the trip kept and archived, got %+v", data.Trips)
+	}
+	if got := UnarchivedTrips(data.Trips); len(got) != 1 || got[0].Destination != "Store" {
+		t.Errorf("Expected only the Store trip unarchived, got %+v", got)
+	}
+
+	// Archived trips are left out of every summary
+	summaries := CalculateWeeklySummaries(append([]Trip(nil), data.Trips...), nil, 0.70, RoundingCent, time.Sunday)
+	if len(summaries) != 1 || summaries[0].TotalMiles != 2.5 {
+		t.Errorf("Expected the weekly summary to cover only 2.5 miles, got %+v", summaries)
+	}
+	monthly, err := CalculateMonthlySummary(data.Trips, nil, 0.70, "2024-03")
+	if err != nil {
+		t.Fatalf("CalculateMonthlySummary failed: %v", err)
+	}
+	if monthly.TotalMiles != 2.5 {
+		t.Errorf("Expected the monthly summary to cover 2.5 miles, got %.2f", monthly.TotalMiles)
+	}
+	if yearly := CalculateYearlySummary(data.Trips, nil, 0.70, 2024); yearly.TotalMiles != 2.5 {
+		t.Errorf("Expected the yearly summary to cover 2.5 miles, got %.2f", yearly.TotalMiles)
+	}
+
+	if err := data.UnarchiveTrip(0); err != nil {
+		t.Fatalf("UnarchiveTrip failed: %v", err)
+	}
+	if data.Trips[0].Archived {
+		t.Error("Expected the trip restored")
+	}
+
+	// Test invalid index
+	if err := data.ArchiveTrip(2); err == nil {
+		t.Error("Expected error for invalid index")
+	}
+	if err := data.UnarchiveTrip(-1); err == nil {
+		t.Error("Expected error for invalid index")
+	}
+}
+
+func TestDeleteTripsInRange(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-02-29", Origin: "Home", Destination: "Work", Miles: 5.0},
+			{Date: "2024-03-01", Origin: "Home", Destination: "Work", Miles: 5.0},
+			{Date: "2024-03-15", Origin: "Home", Destination: "School", Miles: 3.0},
+			{Date: "2024-03-31", Origin: "Home", Destination: "Work", Miles: 5.0},
+			{Date: "2024-04-01", Origin: "Home", Destination: "Work", Miles: 5.0},
+		},
+	}
+
+	// Test valid range (inclusive on both ends)
+	removed, err := data.DeleteTripsInRange("2024-03-01", "2024-03-31")
+	if err != nil {
+		t.Fatalf("DeleteTripsInRange failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Expected 3 trips removed, got %d", removed)
+	}
+	if len(data.Trips) != 2 {
+		t.Fatalf("Expected 2 trips remaining, got %d", len(data.Trips))
+	}
+	if data.Trips[0].Date != "2024-02-29" || data.Trips[1].Date != "2024-04-01" {
+		t.Errorf("Unexpected remaining trips: %+v", data.Trips)
+	}
+
+	// Test invalid dates
+	if _, err := data.DeleteTripsInRange("03/01/2024", "2024-03-31"); err == nil {
+		t.Error("Expected error for invalid from date")
+	}
+	if _, err := data.DeleteTripsInRange("2024-03-01", ""); err == nil {
+		t.Error("Expected error for empty to date")
+	}
+	if _, err := data.DeleteTripsInRange("2024-04-01", "2024-03-01"); err == nil {
+		t.Error("Expected error for reversed range")
+	}
+	if len(data.Trips) != 2 {
+		t.Errorf("Expected invalid ranges to leave trips untouched, got %d", len(data.Trips))
+	}
+}
+
+func TestTripTypeValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		trip    Trip
+		wantErr bool
+	}{
+		{
+			name: "valid single trip",
+			trip: Trip{
+				Origin:      "Home",
+				Destination: "Work",
+				Miles:       5.0,
+				Date:        "2024-03-20",
+				Type:        "single",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid round trip",
+			trip: Trip{
+				Origin:      "Home",
+				Destination: "Work",
+				Miles:       5.0,
+				Date:        "2024-03-20",
+				Type:        "round",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty trip type",
+			trip: Trip{
+				Origin:      "Home",
+				Destination: "Work",
+				Miles:       5.0,
+				Date:        "2024-03-20",
+				Type:        "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid trip type",
+			trip: Trip{
+				Origin:      "Home",
+				Destination: "Work",
+				Miles:       5.0,
+				Date:        "2024-03-20",
+				Type:        "invalid",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.trip.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Trip.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRoundTripMileageCalculation(t *testing.T) {
+	trips := []Trip{
+		{
+			Origin:      "Home",
+			Destination: "Work",
+			Miles:       10.0,
+			Date:        "2024-03-20",
+			Type:        "single",
+		},
+		{
+			Origin:      "Home",
+			Destination: "Store",
+			Miles:       5.0,
+			Date:        "2024-03-20",
+			Type:        "round",
+		},
+	}
+
+	totalMiles := CalculateTotalMiles(trips)
+	// First trip: 10 miles (single)
+	// Second trip: 5 miles * 2 (round trip)
+	expectedMiles := 20.0
+
+	if totalMiles != expectedMiles {
+		t.Errorf("CalculateTotalMiles() = %v, want %v", totalMiles, expectedMiles)
+	}
+}
+
+func TestTripTypeSerialization(t *testing.T) {
+	originalTrip := Trip{
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       5.0,
+		Date:        "2024-03-20",
+		Type:        "round",
+	}
+
+	// Serialize to JSON
+	data, err := json.Marshal(originalTrip)
+	if err != nil {
+		t.Fatalf("Failed to marshal trip: %v", err)
+	}
+
+	// Deserialize back
+	var deserializedTrip Trip
+	if err := json.Unmarshal(data, &deserializedTrip); err != nil {
+		t.Fatalf("Failed to unmarshal trip: %v", err)
+	}
+
+	// Verify type was preserved
+	if deserializedTrip.Type != originalTrip.Type {
+		t.Errorf("Trip type not preserved during serialization. Got %s, want %s",
+			deserializedTrip.Type, originalTrip.Type)
+	}
+}
+
+func TestExpenseValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		expense Expense
+		wantErr bool
+	}{
+		{
+			name: "valid expense",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      25.50,
+				Description: "Lunch for kids",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty date",
+			expense: Expense{
+				Date:        "",
+				Amount:      25.50,
+				Description: "Lunch for kids",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid date format",
+			expense: Expense{
+				Date:        "03/20/2024",
+				Amount:      25.50,
+				Description: "Lunch for kids",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero amount",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      0,
+				Description: "Lunch for kids",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative amount",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      -25.50,
+				Description: "Lunch for kids",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty description",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      25.50,
+				Description: "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid category",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      25.50,
+				Description: "Lunch for kids",
+				Category:    "food",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown category",
+			expense: Expense{
+				Date:        "2024-03-20",
+				Amount:      25.50,
+				Description: "Lunch for kids",
+				Category:    "travel",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.expense.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expense.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCalculateExpensesByCategory(t *testing.T) {
+	expenses := []Expense{
+		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch", Category: "food"},
+		{Date: "2024-03-21", Amount: 10.00, Description: "Snacks", Category: "food"},
+		{Date: "2024-03-21", Amount: 15.75, Description: "Museum", Category: "activities"},
+		{Date: "2024-03-22", Amount: 5.00, Description: "Parking"},
+	}
+
+	totals := CalculateExpensesByCategory(expenses)
+	if totals["food"] != 35.50 {
+		t.Errorf("Expected food total 35.50, got %.2f", totals["food"])
+	}
+	if totals["activities"] != 15.75 {
+		t.Errorf("Expected activities total 15.75, got %.2f", totals["activities"])
+	}
+	if totals[DefaultExpenseCategory] != 5.00 {
+		t.Errorf("Expected uncategorized expense under %q, got %.2f", DefaultExpenseCategory, totals[DefaultExpenseCategory])
+	}
+	if len(totals) != 3 {
+		t.Errorf("Expected 3 categories, got %d", len(totals))
+	}
+}
+
+func TestPersonalExpensesExcludedFromBillableTotal(t *testing.T) {
+	personal := false
+	expenses := []Expense{
+		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch", Category: "food"},
+		{Date: "2024-03-21", Amount: 4.25, Description: "My coffee", Category: "food", Reimbursable: &personal},
+		{Date: "2024-03-22", Amount: 15.00, Description: "Museum", Category: "activities"},
+	}
+
+	if got := CalculateReimbursableExpenses(expenses); got != 40.50 {
+		t.Errorf("Expected reimbursable total 40.50, got %.2f", got)
+	}
+	if got := CalculatePersonalExpenses(expenses); got != 4.25 {
+		t.Errorf("Expected personal total 4.25, got %.2f", got)
+	}
+
+	summaries := CalculateWeeklySummaries(nil, expenses, 0.5, RoundingNone, time.Sunday)
+	if len(summaries) != 1 {
+		t.Fatalf("Expected 1 summary, got %d", len(summaries))
+	}
+	summary := summaries[0]
+	if summary.TotalExpenses != 40.50 || summary.TotalPersonalExpenses != 4.25 {
+		t.Errorf("Expected $40.50 billable and $4.25 personal, got $%.2f and $%.2f", summary.TotalExpenses, summary.TotalPersonalExpenses)
+	}
+	if summary.ExpensesByCategory["food"] != 25.50 {
+		t.Errorf("Expected billable food subtotal 25.50, got %.2f", summary.ExpensesByCategory["food"])
+	}
+	if len(summary.Expenses) != 3 {
+		t.Errorf("Expected all 3 expenses to stay itemized, got %d", len(summary.Expenses))
+	}
+
+	monthly, err := CalculateMonthlySummary(nil, expenses, 0.5, "2024-03")
+	if err != nil {
+		t.Fatalf("Failed to calculate monthly summary: %v", err)
+	}
+	if monthly.TotalExpenses != 40.50 {
+		t.Errorf("Expected monthly billable expenses 40.50, got %.2f", monthly.TotalExpenses)
+	}
+
+	// Expenses saved without the field are reimbursable
+	var legacy Expense
+	if err := json.Unmarshal([]byte(`{"date":"2024-03-20","amount":5,"description":"Snack"}`), &legacy); err != nil {
+		t.Fatalf("Failed to unmarshal expense: %v", err)
+	}
+	if !legacy.IsReimbursable() {
+		t.Error("Expected an expense without the field to be reimbursable")
+	}
+}
+
+func TestFilterByFamily(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Smiths", Miles: 5, Type: "single", Family: "Smith"},
+		{Date: "2024-03-21", Origin: "Home", Destination: "Joneses", Miles: 7, Type: "single", Family: "Jones"},
+		{Date: "2024-03-22", Origin: "Home", Destination: "Work", Miles: 3, Type: "single"},
+	}
+	expenses := []Expense{
+		{Date: "2024-03-20", Amount: 10, Description: "Lunch", Family: "Smith"},
+		{Date: "2024-03-21", Amount: 5, Description: "Parking"},
+	}
+
+	if got := FilterTripsByFamily(trips, ""); len(got) != 3 {
+		t.Errorf("Expected all 3 trips without a family filter, got %d", len(got))
+	}
+	if got := FilterTripsByFamily(trips, "Smith"); len(got) != 1 || got[0].Destination != "Smiths" {
+		t.Errorf("Expected only the Smith trip, got %+v", got)
+	}
+	if got := FilterTripsByFamily(trips, DefaultFamily); len(got) != 1 || got[0].Destination != "Work" {
+		t.Errorf("Expected trips without a family under %q, got %+v", DefaultFamily, got)
+	}
+	if got := FilterExpensesByFamily(expenses, "Smith"); len(got) != 1 || got[0].Description != "Lunch" {
+		t.Errorf("Expected only the Smith expense, got %+v", got)
+	}
+	if got := FilterExpensesByFamily(expenses, "Jones"); len(got) != 0 {
+		t.Errorf("Expected no Jones expenses, got %+v", got)
+	}
+}
+
+func TestCalculateTotalExpenses(t *testing.T) {
+	expenses := []Expense{
+		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
+		{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
+		{Date: "2024-03-22", Amount: 30.00, Description: "Activities"},
+	}
+
+	expected := 71.25
+	got := CalculateTotalExpenses(expenses)
+	if got != expected {
+		t.Errorf("CalculateTotalExpenses() = %v, want %v", got, expected)
+	}
+}
+
+func TestCalculateGrandTotal(t *testing.T) {
+	summaries := []WeeklySummary{
+		{WeekStart: "2024-03-17", WeekEnd: "2024-03-23", TotalMiles: 20, TotalAmount: 14.00, TotalExpenses: 25.50},
+		{WeekStart: "2024-03-24", WeekEnd: "2024-03-30", TotalMiles: 15, TotalAmount: 10.50, TotalExpenses: 10.00},
+	}
+
+	total := CalculateGrandTotal(summaries)
+	if total.TotalMiles != 35 {
+		t.Errorf("Expected total miles 35, got %.2f", total.TotalMiles)
+	}
+	if total.TotalAmount != 24.50 {
+		t.Errorf("Expected total amount 24.50, got %.2f", total.TotalAmount)
+	}
+	if total.TotalExpenses != 35.50 {
+		t.Errorf("Expected total expenses 35.50, got %.2f", total.TotalExpenses)
+	}
+
+	if empty := CalculateGrandTotal(nil); empty != (GrandTotal{}) {
+		t.Errorf("Expected zero grand total for no summaries, got %+v", empty)
+	}
+}
+
+func TestCalculateMonthlySummary(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-02-20", Origin: "Home", Destination: "Park", Miles: 4, Type: "round"},
+		{Date: "2024-02-03", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-03-01", Origin: "Home", Destination: "Work", Miles: 30, Type: "single"},
+	}
+	expenses := []Expense{
+		{Date: "2024-02-12", Amount: 15, Description: "Zoo"},
+		{Date: "2024-01-31", Amount: 99, Description: "Other month"},
+	}
+
+	summary, err := CalculateMonthlySummary(trips, expenses, 0.5, "2024-02")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(summary.Trips) != 2 || summary.Trips[0].Date != "2024-02-03" {
+		t.Errorf("Expected February trips sorted by date, got %+v", summary.Trips)
+	}
+	if len(summary.Expenses) != 1 {
+		t.Errorf("Expected 1 February expense, got %d", len(summary.Expenses))
+	}
+	if summary.TotalMiles != 18 {
+		t.Errorf("Expected 18 miles, got %.2f", summary.TotalMiles)
+	}
+	if summary.TotalAmount != 9 {
+		t.Errorf("Expected 9.00 reimbursement, got %.2f", summary.TotalAmount)
+	}
+	if summary.TotalExpenses != 15 {
+		t.Errorf("Expected 15.00 in expenses, got %.2f", summary.TotalExpenses)
+	}
+	if summary.RatePerMile != 0.5 {
+		t.Errorf("Expected rate 0.5, got %.2f", summary.RatePerMile)
+	}
+
+	if _, err := CalculateMonthlySummary(trips, expenses, 0.5, "2024-2"); err == nil {
+		t.Error("Expected error for invalid month")
+	}
+}
+
+func TestCalculateYearlySummary(t *testing.T) {
+	trips := []Trip{
+		{Date: "2023-12-31", Origin: "Home", Destination: "Work", Miles: 50, Type: "single"},
+		{Date: "2024-01-15", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-01-20", Origin: "Home", Destination: "School", Miles: 5, Type: "round"},
+		{Date: "2024-03-02", Origin: "Home", Destination: "Park", Miles: 8, Type: "single"},
+		{Date: "2025-01-01", Origin: "Home", Destination: "Work", Miles: 40, Type: "single"},
+	}
+	expenses := []Expense{
+		{Date: "2024-01-15", Amount: 12.50, Description: "Lunch"},
+		{Date: "2024-12-31", Amount: 7.50, Description: "Snacks"},
+		{Date: "2025-01-01", Amount: 100, Description: "Outside the year"},
+	}
+
+	summary := CalculateYearlySummary(trips, expenses, 0.70, 2024)
+
+	if summary.Year != 2024 {
+		t.Errorf("Expected year 2024, got %d", summary.Year)
+	}
+	if summary.TotalMiles != 28 {
+		t.Errorf("Expected 28 total miles, got %.2f", summary.TotalMiles)
+	}
+	if math.Abs(summary.TotalAmount-19.60) > 0.001 {
+		t.Errorf("Expected total amount 19.60, got %.2f", summary.TotalAmount)
+	}
+	if summary.TotalExpenses != 20 {
+		t.Errorf("Expected total expenses 20.00, got %.2f", summary.TotalExpenses)
+	}
+
+	if len(summary.Months) != 12 {
+		t.Fatalf("Expected 12 months, got %d", len(summary.Months))
+	}
+	january := summary.Months[0]
+	if january.Month != "2024-01" || january.TotalMiles != 20 || january.TotalExpenses != 12.50 {
+		t.Errorf("Unexpected January summary: %+v", january)
+	}
+	if summary.Months[1].TotalMiles != 0 {
+		t.Errorf("Expected no miles in February, got %.2f", summary.Months[1].TotalMiles)
+	}
+	if summary.Months[2].TotalMiles != 8 {
+		t.Errorf("Expected 8 miles in March, got %.2f", summary.Months[2].TotalMiles)
+	}
+	if summary.Months[11].Month != "2024-12" || summary.Months[11].TotalExpenses != 7.50 {
+		t.Errorf("Unexpected December summary: %+v", summary.Months[11])
+	}
+}
+
+func TestWeeklySummaries(t *testing.T) {
+	trips := []Trip{
+		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
+		{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "single"},
+	}
+
+	expenses := []Expense{} // Empty expenses list for this test
+	ratePerMile := 0.70
+	summaries := CalculateWeeklySummaries(trips, expenses, ratePerMile, RoundingNone, time.Sunday)
+
+	if len(summaries) != 1 {
+		t.Errorf("Expected 1 weekly summary, got %d", len(summaries))
+	}
+
+	summary := summaries[0]
+	expectedMiles := 20.0
+	expectedAmount := 14.0 // 20 miles * 0.70
+
+	if summary.TotalMiles != expectedMiles {
+		t.Errorf("Expected %v miles, got %v", expectedMiles, summary.TotalMiles)
+	}
+	if summary.TotalAmount != expectedAmount {
+		t.Errorf("Expected $%v amount, got $%v", expectedAmount, summary.TotalAmount)
+	}
+}
+
+func TestStorageDataExpenseOperations(t *testing.T) {
+	data := &StorageData{
+		Trips:    []Trip{},
+		Expenses: []Expense{},
+	}
+
+	// Test AddExpense
+	expense := Expense{
+		Date:        "2024-03-20",
+		Amount:      25.50,
+		Description: "Lunch",
+	}
+	err := data.AddExpense(expense)
+	if err != nil {
+		t.Errorf("AddExpense() error = %v", err)
+	}
+	if len(data.Expenses) != 1 {
+		t.Errorf("Expected 1 expense, got %d", len(data.Expenses))
+	}
+
+	// Test EditExpense
+	editedExpense := Expense{
+		Date:        "2024-03-20",
+		Amount:      30.00,
+		Description: "Lunch and snacks",
+	}
+	err = data.EditExpense(0, editedExpense)
+	if err != nil {
+		t.Errorf("EditExpense() error = %v", err)
+	}
+	if data.Expenses[0].Amount != 30.00 {
+		t.Errorf("Expected amount $30.00, got $%v", data.Expenses[0].Amount)
+	}
+
+	// Test DeleteExpense
+	err = data.DeleteExpense(0)
+	if err != nil {
+		t.Errorf("DeleteExpense() error = %v", err)
+	}
+	if len(data.Expenses) != 0 {
+		t.Errorf("Expected 0 expenses, got %d", len(data.Expenses))
+	}
+
+	// Test invalid operations
+	err = data.EditExpense(0, editedExpense)
+	if err == nil {
+		t.Error("Expected error for invalid index in EditExpense")
+	}
+
+	err = data.DeleteExpense(0)
+	if err == nil {
+		t.Error("Expected error for invalid index in DeleteExpense")
+	}
+}
+
+func TestStorage(t *testing.T) {
+	data := &StorageData{
+		Trips:    []Trip{},
+		Expenses: []Expense{},
+	}
+
+	// Add a trip
+	trip := Trip{
+		Date:        "2024-03-20",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       10,
+		Type:        "single",
+	}
+	err := data.AddTrip(trip)
+	if err != nil {
+		t.Errorf("AddTrip() error = %v", err)
+	}
+
+	// Calculate weekly summaries
+	ratePerMile := 0.70
+	CalculateAndUpdateWeeklySummaries(data, ratePerMile, RoundingNone, time.Sunday)
+
+	if len(data.WeeklySummaries) != 1 {
+		t.Errorf("Expected 1 weekly summary, got %d", len(data.WeeklySummaries))
+	}
+
+	summary := data.WeeklySummaries[0]
+	expectedMiles := 10.0
+	expectedAmount := 7.0 // 10 miles * 0.70
+
+	if summary.TotalMiles != expectedMiles {
+		t.Errorf("Expected %v miles, got %v", expectedMiles, summary.TotalMiles)
+	}
+	if summary.TotalAmount != expectedAmount {
+		t.Errorf("Expected $%v amount, got $%v", expectedAmount, summary.TotalAmount)
+	}
+}
+
+func TestAddingTrip(t *testing.T) {
+	data := &StorageData{
+		Trips:    []Trip{},
+		Expenses: []Expense{},
+	}
+
+	trip := Trip{
+		Date:        "2024-03-20",
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       10,
+		Type:        "single",
+	}
+
+	err := data.AddTrip(trip)
+	if err != nil {
+		t.Errorf("AddTrip() error = %v", err)
+	}
+
+	if len(data.Trips) != 1 {
+		t.Errorf("Expected 1 trip, got %d", len(data.Trips))
+	}
+
+	if data.Trips[0].Date != trip.Date {
+		t.Errorf("Expected date %v, got %v", trip.Date, data.Trips[0].Date)
+	}
+	if data.Trips[0].Origin != trip.Origin {
+		t.Errorf("Expected origin %v, got %v", trip.Origin, data.Trips[0].Origin)
+	}
+	if data.Trips[0].Destination != trip.Destination {
+		t.Errorf("Expected destination %v, got %v", trip.Destination, data.Trips[0].Destination)
+	}
+	if data.Trips[0].Miles != trip.Miles {
+		t.Errorf("Expected miles %v, got %v", trip.Miles, data.Trips[0].Miles)
+	}
+	if data.Trips[0].Type != trip.Type {
+		t.Errorf("Expected type %v, got %v", trip.Type, data.Trips[0].Type)
+	}
+}
+
+func TestStorageDataTripTemplateOperations(t *testing.T) {
+	data := &StorageData{
+		Trips:         []Trip{},
+		Expenses:      []Expense{},
+		TripTemplates: []TripTemplate{},
+	}
+
+	// Test adding a valid template
+	template := TripTemplate{
+		Name:        "Work Commute",
+		Origin:      "123 Home St",
+		Destination: "456 Work Ave",
+		TripType:    "single",
+		Notes:       "Regular work commute",
+	}
+
+	// Test adding template to storage
+	if err := data.AddTripTemplate(template); err != nil {
+		t.Errorf("AddTripTemplate() error = %v", err)
+	}
+	if len(data.TripTemplates) != 1 {
+		t.Errorf("Expected 1 template, got %d", len(data.TripTemplates))
+	}
+
+	// Test editing template
+	editedTemplate := TripTemplate{
+		Name:        "Work Commute (Updated)",
+		Origin:      "123 Home St",
+		Destination: "456 Work Ave",
+		TripType:    "round",
+		Notes:       "Updated work commute",
+	}
+	if err := data.EditTripTemplate(0, editedTemplate); err != nil {
+		t.Errorf("EditTripTemplate() error = %v", err)
+	}
+	if data.TripTemplates[0].Name != editedTemplate.Name {
+		t.Errorf("Expected name %v, got %v", editedTemplate.Name, data.TripTemplates[0].Name)
+	}
+	if data.TripTemplates[0].TripType != editedTemplate.TripType {
+		t.Errorf("Expected type %v, got %v", editedTemplate.TripType, data.TripTemplates[0].TripType)
+	}
+
+	// Test counting uses
+	for i := 0; i < 2; i++ {
+		if err := data.UseTripTemplate(0); err != nil {
+			t.Errorf("UseTripTemplate() error = %v", err)
+		}
+	}
+	if data.TripTemplates[0].UsageCount != 2 {
+		t.Errorf("Expected usage count 2, got %d", data.TripTemplates[0].UsageCount)
+	}
+
+	// Test deleting template
+	if err := data.DeleteTripTemplate(0); err != nil {
+		t.Errorf("DeleteTripTemplate() error = %v", err)
+	}
+	if len(data.TripTemplates) != 0 {
+		t.Errorf("Expected 0 templates after deletion, got %d", len(data.TripTemplates))
+	}
+
+	// Test invalid operations
+	if err := data.EditTripTemplate(0, editedTemplate); err == nil {
+		t.Error("Expected error for invalid index in EditTripTemplate")
+	}
+
+	if err := data.DeleteTripTemplate(0); err == nil {
+		t.Error("Expected error for invalid index in DeleteTripTemplate")
+	}
+
+	if err := data.UseTripTemplate(0); err == nil {
+		t.Error("Expected error for invalid index in UseTripTemplate")
+	}
+}
+
+func TestTripTemplateSerialization(t *testing.T) {
+	originalTemplate := TripTemplate{
+		Name:        "Work Commute",
+		Origin:      "123 Home St",
+		Destination: "456 Work Ave",
+		TripType:    "round",
+		Notes:       "Regular work commute",
+	}
+
+	// Serialize to JSON
+	data, err := json.Marshal(originalTemplate)
+	if err != nil {
+		t.Fatalf("Failed to marshal template: %v", err)
+	}
+
+	// Deserialize back
+	var deserializedTemplate TripTemplate
+	if err := json.Unmarshal(data, &deserializedTemplate); err != nil {
+		t.Fatalf("Failed to unmarshal template: %v", err)
+	}
+
+	// Verify all fields were preserved
+	if deserializedTemplate.Name != originalTemplate.Name {
+		t.Errorf("Name not preserved during serialization. Got %s, want %s",
+			deserializedTemplate.Name, originalTemplate.Name)
+	}
+	if deserializedTemplate.Origin != originalTemplate.Origin {
+		t.Errorf("Origin not preserved during serialization. Got %s, want %s",
+			deserializedTemplate.Origin, originalTemplate.Origin)
+	}
+	if deserializedTemplate.Destination != originalTemplate.Destination {
+		t.Errorf("Destination not preserved during serialization. Got %s, want %s",
+			deserializedTemplate.Destination, originalTemplate.Destination)
+	}
+	if deserializedTemplate.TripType != originalTemplate.TripType {
+		t.Errorf("TripType not preserved during serialization. Got %s, want %s",
+			deserializedTemplate.TripType, originalTemplate.TripType)
+	}
+	if deserializedTemplate.Notes != originalTemplate.Notes {
+		t.Errorf("Notes not preserved during serialization. Got %s, want %s",
+			deserializedTemplate.Notes, originalTemplate.Notes)
+	}
+}
+
+func TestTripValidateWithBounds(t *testing.T) {
+	now := time.Date(2024, 3, 20, 15, 30, 0, 0, time.UTC)
+	trip := Trip{Origin: "Home", Destination: "Work", Miles: 10, Type: "single"}
+
+	tests := []struct {
+		name          string
+		date          string
+		maxFutureDays int
+		wantErr       bool
+	}{
+		{"today", "2024-03-20", 365, false},
+		{"past date", "2020-01-01", 365, false},
+		{"exactly at the limit", "2025-03-20", 365, false},
+		{"one day past the limit", "2025-03-21", 365, true},
+		{"typo year", "2204-03-20", 365, true},
+		{"check disabled", "2204-03-20", 0, false},
+		{"invalid trip still rejected", "not-a-date", 365, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trip.Date = tt.date
+			err := trip.ValidateWithBounds(now, tt.maxFutureDays)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWithBounds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTripValidateMaxMiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		miles    float64
+		maxMiles float64
+		wantErr  bool
+	}{
+		{"under the limit", 12.5, 100, false},
+		{"exactly at the limit", 100, 100, false},
+		{"over the limit", 2400, 100, true},
+		{"check disabled", 2400, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trip := Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: tt.miles, Type: "single"}
+			err := trip.ValidateMaxMiles(tt.maxMiles)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMaxMiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurringTripFrequencyValidation(t *testing.T) {
+	base := RecurringTrip{
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       5.0,
+		StartDate:   "2024-03-01",
+		EndDate:     "2024-06-30",
+		Type:        "single",
+		Weekday:     3,
+	}
+
+	tests := []struct {
+		name       string
+		frequency  string
+		dayOfMonth int
+		wantErr    bool
+	}{
+		{name: "empty frequency defaults to weekly", frequency: "", wantErr: false},
+		{name: "weekly", frequency: "weekly", wantErr: false},
+		{name: "biweekly", frequency: "biweekly", wantErr: false},
+		{name: "monthly with valid day", frequency: "monthly", dayOfMonth: 31, wantErr: false},
+		{name: "monthly without day", frequency: "monthly", dayOfMonth: 0, wantErr: true},
+		{name: "monthly with day out of range", frequency: "monthly", dayOfMonth: 32, wantErr: true},
+		{name: "unknown frequency", frequency: "daily", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := base
+			rt.Frequency = tt.frequency
+			rt.DayOfMonth = tt.dayOfMonth
+			err := rt.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTrip.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecurringTripExcludedDatesValidation(t *testing.T) {
+	base := RecurringTrip{
+		Origin:      "Home",
+		Destination: "Work",
+		Miles:       5.0,
+		StartDate:   "2024-03-01",
+		EndDate:     "2024-06-30",
+		Type:        "single",
+		Weekday:     3,
+	}
+
+	tests := []struct {
+		name     string
+		excluded []string
+		wantErr  bool
+	}{
+		{name: "no exclusions", excluded: nil, wantErr: false},
+		{name: "valid dates", excluded: []string{"2024-03-13", "2024-12-25"}, wantErr: false},
+		{name: "invalid format", excluded: []string{"2024-03-13", "03/20/2024"}, wantErr: true},
+		{name: "empty date", excluded: []string{""}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := base
+			rt.ExcludedDates = tt.excluded
+			err := rt.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTrip.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerateTripsFrequencies(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		recurring RecurringTrip
+		start     time.Time
+		end       time.Time
+		want      []string
+	}{
+		{
+			name:      "weekly",
+			recurring: RecurringTrip{Weekday: 3, Frequency: "weekly"},
+			start:     start,
+			end:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			want:      []string{"2024-01-03", "2024-01-10", "2024-01-17", "2024-01-24", "2024-01-31"},
 		},
 		{
-			name: "valid round trip",
-			trip: Trip{
-				Origin:      "Home",
-				Destination: "Work",
-				Miles:       5.0,
-				Date:        "2024-03-20",
-				Type:        "round",
-			},
-			wantErr: false,
+			name:      "biweekly",
+			recurring: RecurringTrip{Weekday: 3, Frequency: "biweekly"},
+			start:     start,
+			end:       time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			want:      []string{"2024-01-03", "2024-01-17", "2024-01-31", "2024-02-14", "2024-02-28"},
 		},
 		{
-			name: "empty trip type",
-			trip: Trip{
-				Origin:      "Home",
-				Destination: "Work",
-				Miles:       5.0,
-				Date:        "2024-03-20",
-				Type:        "",
-			},
-			wantErr: true,
+			name:      "monthly clamps to last day of shorter months",
+			recurring: RecurringTrip{Frequency: "monthly", DayOfMonth: 31},
+			start:     start,
+			end:       end,
+			want:      []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"},
 		},
 		{
-			name: "invalid trip type",
-			trip: Trip{
-				Origin:      "Home",
-				Destination: "Work",
-				Miles:       5.0,
-				Date:        "2024-03-20",
-				Type:        "invalid",
-			},
-			wantErr: true,
+			name:      "weekly skips excluded dates",
+			recurring: RecurringTrip{Weekday: 3, Frequency: "weekly", ExcludedDates: []string{"2024-01-17", "2024-01-18"}},
+			start:     start,
+			end:       time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+			want:      []string{"2024-01-03", "2024-01-10", "2024-01-24", "2024-01-31"},
+		},
+		{
+			name:      "monthly skips day before start date",
+			recurring: RecurringTrip{Frequency: "monthly", DayOfMonth: 5},
+			start:     time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+			end:       end,
+			want:      []string{"2024-02-05", "2024-03-05", "2024-04-05"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.trip.Validate()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Trip.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			trips := tt.recurring.GenerateTrips(tt.start, tt.end)
+			if len(trips) != len(tt.want) {
+				t.Fatalf("Expected %d trips, got %d", len(tt.want), len(trips))
+			}
+			for i, trip := range trips {
+				if trip.Date != tt.want[i] {
+					t.Errorf("Trip %d: expected date %s, got %s", i, tt.want[i], trip.Date)
+				}
 			}
 		})
 	}
 }
 
-func TestRoundTripMileageCalculation(t *testing.T) {
-	trips := []Trip{
-		{
-			Origin:      "Home",
-			Destination: "Work",
-			Miles:       10.0,
-			Date:        "2024-03-20",
-			Type:        "single",
-		},
-		{
-			Origin:      "Home",
-			Destination: "Store",
-			Miles:       5.0,
-			Date:        "2024-03-20",
-			Type:        "round",
+func TestGenerateTripsFromRecurringExcludedDates(t *testing.T) {
+	data := &StorageData{
+		ReferenceDate: "2024-03-01",
+		RecurringTrips: []RecurringTrip{
+			{
+				Origin:        "Home",
+				Destination:   "School",
+				Miles:         4.0,
+				StartDate:     "2024-03-01",
+				EndDate:       "2024-03-31",
+				Type:          "single",
+				Weekday:       3, // Wednesday
+				ExcludedDates: []string{"2024-03-13"},
+			},
 		},
 	}
 
-	totalMiles := CalculateTotalMiles(trips)
-	// First trip: 10 miles (single)
-	// Second trip: 5 miles * 2 (round trip)
-	expectedMiles := 20.0
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
 
-	if totalMiles != expectedMiles {
-		t.Errorf("CalculateTotalMiles() = %v, want %v", totalMiles, expectedMiles)
+	dates := make(map[string]bool)
+	for _, trip := range data.Trips {
+		dates[trip.Date] = true
+	}
+	if dates["2024-03-13"] {
+		t.Error("Expected no trip on the excluded Wednesday")
+	}
+	for _, date := range []string{"2024-03-06", "2024-03-20", "2024-03-27"} {
+		if !dates[date] {
+			t.Errorf("Expected a trip on %s", date)
+		}
+	}
+	if len(data.Trips) != 3 {
+		t.Errorf("Expected 3 trips, got %d", len(data.Trips))
 	}
 }
 
-func TestTripTypeSerialization(t *testing.T) {
-	originalTrip := Trip{
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       5.0,
-		Date:        "2024-03-20",
-		Type:        "round",
+func TestRecurringTripNextOccurrence(t *testing.T) {
+	weekly := RecurringTrip{
+		Origin:        "Home",
+		Destination:   "School",
+		Miles:         4.0,
+		StartDate:     "2024-03-01",
+		EndDate:       "2024-03-27",
+		Type:          "single",
+		Weekday:       3, // Wednesday
+		ExcludedDates: []string{"2024-03-13"},
+	}
+	biweekly := weekly
+	biweekly.Frequency = "biweekly"
+	biweekly.EndDate = ""
+	biweekly.ExcludedDates = nil
+	monthly := RecurringTrip{Origin: "Home", Destination: "Clinic", Miles: 6.0, StartDate: "2024-01-01", Type: "round", Frequency: "monthly", DayOfMonth: 31}
+
+	tests := []struct {
+		name     string
+		rt       RecurringTrip
+		after    string
+		wantDate string
+		wantOK   bool
+	}{
+		{"before the start date", weekly, "2024-02-01", "2024-03-06", true},
+		{"on an occurrence", weekly, "2024-03-06", "2024-03-06", true},
+		{"skips an excluded date", weekly, "2024-03-07", "2024-03-20", true},
+		{"on the end date", weekly, "2024-03-27", "2024-03-27", true},
+		{"after the last occurrence", weekly, "2024-03-28", "", false},
+		{"biweekly keeps its alignment", biweekly, "2024-03-07", "2024-03-20", true},
+		{"monthly clamps to short months", monthly, "2024-04-01", "2024-04-30", true},
 	}
 
-	// Serialize to JSON
-	data, err := json.Marshal(originalTrip)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after, _ := time.Parse("2006-01-02", tt.after)
+			got, ok := tt.rt.NextOccurrence(after)
+			if got != tt.wantDate || ok != tt.wantOK {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.wantDate, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestGenerateTripsFromRecurringPerFamily(t *testing.T) {
+	data := &StorageData{
+		ReferenceDate: "2024-03-01",
+		// An unrelated trip on one of the scheduled Wednesdays
+		Trips: []Trip{
+			{Origin: "Home", Destination: "Store", Miles: 2, Date: "2024-03-06", Type: "single", Family: "Smith"},
+		},
+		RecurringTrips: []RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3, Family: "Smith"},
+			{Origin: "Home", Destination: "Pool", Miles: 6, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3, Family: "Jones"},
+		},
+	}
+
+	projected, err := data.ProjectRecurringTrips("2024-03-31")
 	if err != nil {
-		t.Fatalf("Failed to marshal trip: %v", err)
+		t.Fatalf("Failed to project trips: %v", err)
+	}
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
 	}
 
-	// Deserialize back
-	var deserializedTrip Trip
-	if err := json.Unmarshal(data, &deserializedTrip); err != nil {
-		t.Fatalf("Failed to unmarshal trip: %v", err)
+	generated := make(map[string]int)
+	for _, trip := range data.Trips {
+		if trip.IsRecurring {
+			generated[trip.FamilyOrDefault()]++
+		}
+	}
+	if generated["Smith"] != 4 || generated["Jones"] != 4 {
+		t.Errorf("Expected 4 Wednesdays for each family, got %v", generated)
+	}
+	if len(projected) != generated["Smith"]+generated["Jones"] {
+		t.Errorf("Expected generation to create the %d projected trips, got %v", len(projected), generated)
 	}
 
-	// Verify type was preserved
-	if deserializedTrip.Type != originalTrip.Type {
-		t.Errorf("Trip type not preserved during serialization. Got %s, want %s",
-			deserializedTrip.Type, originalTrip.Type)
+	// Generating again adds nothing
+	count := len(data.Trips)
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	if len(data.Trips) != count {
+		t.Errorf("Expected no new trips, got %d more", len(data.Trips)-count)
 	}
 }
 
-func TestExpenseValidation(t *testing.T) {
+func TestGenerateTripsFromRecurringOccurrences(t *testing.T) {
 	tests := []struct {
-		name    string
-		expense Expense
-		wantErr bool
+		name      string
+		recurring RecurringTrip
+		wantDates []string
 	}{
 		{
-			name: "valid expense",
-			expense: Expense{
-				Date:        "2024-03-20",
-				Amount:      25.50,
-				Description: "Lunch for kids",
-			},
-			wantErr: false,
-		},
-		{
-			name: "empty date",
-			expense: Expense{
-				Date:        "",
-				Amount:      25.50,
-				Description: "Lunch for kids",
-			},
-			wantErr: true,
+			name:      "count without end date",
+			recurring: RecurringTrip{StartDate: "2024-03-01", Weekday: 3, Occurrences: 3},
+			wantDates: []string{"2024-03-06", "2024-03-13", "2024-03-20"},
 		},
 		{
-			name: "invalid date format",
-			expense: Expense{
-				Date:        "03/20/2024",
-				Amount:      25.50,
-				Description: "Lunch for kids",
-			},
-			wantErr: true,
+			name:      "count reached before end date",
+			recurring: RecurringTrip{StartDate: "2024-03-01", EndDate: "2024-03-31", Weekday: 3, Occurrences: 2},
+			wantDates: []string{"2024-03-06", "2024-03-13"},
 		},
 		{
-			name: "zero amount",
-			expense: Expense{
-				Date:        "2024-03-20",
-				Amount:      0,
-				Description: "Lunch for kids",
-			},
-			wantErr: true,
+			name:      "end date reached before count",
+			recurring: RecurringTrip{StartDate: "2024-03-01", EndDate: "2024-03-14", Weekday: 3, Occurrences: 8},
+			wantDates: []string{"2024-03-06", "2024-03-13"},
 		},
 		{
-			name: "negative amount",
-			expense: Expense{
-				Date:        "2024-03-20",
-				Amount:      -25.50,
-				Description: "Lunch for kids",
-			},
-			wantErr: true,
+			name:      "excluded dates do not count",
+			recurring: RecurringTrip{StartDate: "2024-03-01", Weekday: 3, Occurrences: 2, ExcludedDates: []string{"2024-03-06"}},
+			wantDates: []string{"2024-03-13", "2024-03-20"},
 		},
 		{
-			name: "empty description",
-			expense: Expense{
-				Date:        "2024-03-20",
-				Amount:      25.50,
-				Description: "",
-			},
-			wantErr: true,
+			name:      "biweekly count",
+			recurring: RecurringTrip{StartDate: "2024-03-01", Weekday: 3, Frequency: "biweekly", Occurrences: 2},
+			wantDates: []string{"2024-03-06", "2024-03-20"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.expense.Validate()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Expense.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			rt := tt.recurring
+			rt.Origin, rt.Destination, rt.Miles, rt.Type = "Home", "School", 4.0, "single"
+			data := &StorageData{ReferenceDate: "2024-03-01", RecurringTrips: []RecurringTrip{rt}}
+			if err := data.GenerateTripsFromRecurring(); err != nil {
+				t.Fatalf("Failed to generate trips: %v", err)
+			}
+
+			var dates []string
+			for _, trip := range data.Trips {
+				dates = append(dates, trip.Date)
+			}
+			sort.Strings(dates)
+			if strings.Join(dates, ",") != strings.Join(tt.wantDates, ",") {
+				t.Errorf("Expected trips on %v, got %v", tt.wantDates, dates)
+			}
+		})
+	}
+}
+
+func TestRecurringTripLimitValidation(t *testing.T) {
+	base := RecurringTrip{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", Type: "single", Weekday: 3}
+
+	tests := []struct {
+		name        string
+		endDate     string
+		occurrences int
+		wantErr     bool
+	}{
+		{name: "end date only", endDate: "2024-03-31"},
+		{name: "count only", occurrences: 8},
+		{name: "both", endDate: "2024-03-31", occurrences: 8},
+		{name: "neither", wantErr: true},
+		{name: "negative count", endDate: "2024-03-31", occurrences: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := base
+			rt.EndDate = tt.endDate
+			rt.Occurrences = tt.occurrences
+			if err := rt.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("RecurringTrip.Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-func TestCalculateTotalExpenses(t *testing.T) {
-	expenses := []Expense{
-		{Date: "2024-03-20", Amount: 25.50, Description: "Lunch"},
-		{Date: "2024-03-21", Amount: 15.75, Description: "Snacks"},
-		{Date: "2024-03-22", Amount: 30.00, Description: "Activities"},
+func TestDeleteGeneratedTrips(t *testing.T) {
+	data := &StorageData{
+		ReferenceDate: "2024-03-01",
+		RecurringTrips: []RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3, Frequency: "biweekly"},
+		},
 	}
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	data.Trips = append(data.Trips,
+		Trip{Date: "2024-03-13", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"}, // Off-week Wednesday
+		Trip{Date: "2024-03-20", Origin: "Home", Destination: "School", Miles: 4.0, Type: "round"},  // Different type
+		Trip{Date: "2024-04-03", Origin: "Home", Destination: "School", Miles: 4.0, Type: "single"}, // After end date
+	)
 
-	expected := 71.25
-	got := CalculateTotalExpenses(expenses)
-	if got != expected {
-		t.Errorf("CalculateTotalExpenses() = %v, want %v", got, expected)
+	count, err := data.CountGeneratedTrips(0)
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 generated trips counted, got %d (err %v)", count, err)
 	}
-}
 
-func TestWeeklySummaries(t *testing.T) {
-	trips := []Trip{
-		{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"},
-		{Date: "2024-03-21", Origin: "Work", Destination: "Home", Miles: 10, Type: "single"},
+	removed, err := data.DeleteGeneratedTrips(0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 generated trips removed, got %d", removed)
+	}
+	if len(data.Trips) != 3 {
+		t.Errorf("Expected the 3 unrelated trips to remain, got %d", len(data.Trips))
 	}
 
-	expenses := []Expense{} // Empty expenses list for this test
-	ratePerMile := 0.70
-	summaries := CalculateWeeklySummaries(trips, expenses, ratePerMile)
+	if _, err := data.DeleteGeneratedTrips(1); err == nil {
+		t.Error("Expected error for invalid recurring trip index")
+	}
+}
 
-	if len(summaries) != 1 {
-		t.Errorf("Expected 1 weekly summary, got %d", len(summaries))
+func TestExtendRecurringTrips(t *testing.T) {
+	data := &StorageData{
+		ReferenceDate: "2024-03-01",
+		RecurringTrips: []RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4.0, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3},
+			{Origin: "Home", Destination: "Park", Miles: 2.0, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 5, Occurrences: 2}, // Already used up
+			{Origin: "Home", Destination: "Gym", Miles: 3.0, StartDate: "2024-03-01", Type: "single", Weekday: 1},                                         // No end date to extend
+		},
+	}
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
+	}
+	before := make(map[string]bool)
+	for _, trip := range data.Trips {
+		before[trip.Date+trip.Destination] = true
 	}
 
-	summary := summaries[0]
-	expectedMiles := 20.0
-	expectedAmount := 14.0 // 20 miles * 0.70
+	created, err := data.ExtendRecurringTrips("2024-04-30")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created != 4 {
+		t.Errorf("Expected 4 new trips for the Wednesdays in April, got %d", created)
+	}
+	for _, trip := range data.Trips {
+		if before[trip.Date+trip.Destination] {
+			continue
+		}
+		if trip.Destination != "School" || trip.Date <= "2024-03-31" {
+			t.Errorf("Expected new trips only to School after the old end date, got %+v", trip)
+		}
+	}
+	if data.RecurringTrips[0].EndDate != "2024-04-30" {
+		t.Errorf("Expected the end date to move to 2024-04-30, got %s", data.RecurringTrips[0].EndDate)
+	}
+	if data.RecurringTrips[1].EndDate != "2024-03-31" || data.RecurringTrips[2].EndDate != "" {
+		t.Errorf("Expected inactive recurring trips to be left alone, got %+v", data.RecurringTrips[1:])
+	}
 
-	if summary.TotalMiles != expectedMiles {
-		t.Errorf("Expected %v miles, got %v", expectedMiles, summary.TotalMiles)
+	// Extending to the same date again creates nothing
+	if created, err := data.ExtendRecurringTrips("2024-04-30"); err != nil || created != 0 {
+		t.Errorf("Expected no new trips, got %d (err %v)", created, err)
 	}
-	if summary.TotalAmount != expectedAmount {
-		t.Errorf("Expected $%v amount, got $%v", expectedAmount, summary.TotalAmount)
+
+	var validationErr *ValidationError
+	if _, err := data.ExtendRecurringTrips("04/30/2024"); !errors.As(err, &validationErr) || validationErr.Field != "end_date" {
+		t.Errorf("Expected an end_date error, got %v", err)
 	}
 }
 
-func TestStorageDataExpenseOperations(t *testing.T) {
+func TestCalculateWeeklySummariesWithProjections(t *testing.T) {
+	recurring := RecurringTrip{Origin: "Home", Destination: "School", Miles: 5.0, StartDate: "2024-03-04", Type: "single", Weekday: int(time.Monday)}
 	data := &StorageData{
-		Trips:    []Trip{},
-		Expenses: []Expense{},
+		ReferenceDate:  "2024-03-13",
+		RecurringTrips: []RecurringTrip{recurring},
+		Trips: []Trip{
+			// Generated trips already cover the first two Mondays
+			{Date: "2024-03-04", Origin: "Home", Destination: "School", Miles: 5.0, Type: "single", IsRecurring: true},
+			{Date: "2024-03-11", Origin: "Home", Destination: "School", Miles: 5.0, Type: "single", IsRecurring: true},
+		},
 	}
 
-	// Test AddExpense
-	expense := Expense{
-		Date:        "2024-03-20",
-		Amount:      25.50,
-		Description: "Lunch",
-	}
-	err := data.AddExpense(expense)
+	projected, err := data.ProjectRecurringTrips("2024-03-25")
 	if err != nil {
-		t.Errorf("AddExpense() error = %v", err)
+		t.Fatalf("ProjectRecurringTrips failed: %v", err)
 	}
-	if len(data.Expenses) != 1 {
-		t.Errorf("Expected 1 expense, got %d", len(data.Expenses))
+	if len(projected) != 2 || projected[0].Date != "2024-03-18" || projected[1].Date != "2024-03-25" || !projected[0].Projected {
+		t.Fatalf("Expected projected trips on 2024-03-18 and 2024-03-25, got %+v", projected)
 	}
 
-	// Test EditExpense
-	editedExpense := Expense{
-		Date:        "2024-03-20",
-		Amount:      30.00,
-		Description: "Lunch and snacks",
-	}
-	err = data.EditExpense(0, editedExpense)
+	summaries, err := CalculateWeeklySummariesWithProjections(data, 0.70, RoundingCent, time.Sunday, "2024-03-25")
 	if err != nil {
-		t.Errorf("EditExpense() error = %v", err)
+		t.Fatalf("CalculateWeeklySummariesWithProjections failed: %v", err)
 	}
-	if data.Expenses[0].Amount != 30.00 {
-		t.Errorf("Expected amount $30.00, got $%v", data.Expenses[0].Amount)
+	if len(summaries) != 4 {
+		t.Fatalf("Expected 4 weeks with the projections, got %d", len(summaries))
+	}
+	if summaries[0].WeekStart != "2024-03-24" || summaries[0].ProjectedMiles != 5.0 || !summaries[0].Trips[0].Projected {
+		t.Errorf("Expected the last week to be a projected 5 miles, got %+v", summaries[0])
+	}
+	if summaries[2].WeekStart != "2024-03-10" || summaries[2].ProjectedMiles != 0 || summaries[2].Trips[0].Projected {
+		t.Errorf("Expected the recorded week to have no projections, got %+v", summaries[2])
 	}
 
-	// Test DeleteExpense
-	err = data.DeleteExpense(0)
-	if err != nil {
-		t.Errorf("DeleteExpense() error = %v", err)
+	// Without projections only the recorded trips are summarised, and data is unchanged
+	historical := CalculateWeeklySummaries(append([]Trip(nil), data.Trips...), nil, 0.70, RoundingCent, time.Sunday)
+	if len(historical) != 2 {
+		t.Errorf("Expected 2 recorded weeks, got %d", len(historical))
 	}
-	if len(data.Expenses) != 0 {
-		t.Errorf("Expected 0 expenses, got %d", len(data.Expenses))
+	for _, summary := range historical {
+		if summary.ProjectedMiles != 0 {
+			t.Errorf("Expected no projected miles in week %s, got %.2f", summary.WeekStart, summary.ProjectedMiles)
+		}
+	}
+	if len(data.Trips) != 2 {
+		t.Errorf("Expected projections not to be saved as trips, got %d trips", len(data.Trips))
 	}
 
-	// Test invalid operations
-	err = data.EditExpense(0, editedExpense)
-	if err == nil {
-		t.Error("Expected error for invalid index in EditExpense")
+	// Projections stop at the recurring trip's end date
+	data.RecurringTrips[0].EndDate = "2024-03-20"
+	if projected, _ := data.ProjectRecurringTrips("2024-03-25"); len(projected) != 1 {
+		t.Errorf("Expected one projection before the end date, got %+v", projected)
 	}
 
-	err = data.DeleteExpense(0)
-	if err == nil {
-		t.Error("Expected error for invalid index in DeleteExpense")
+	if _, err := CalculateWeeklySummariesWithProjections(data, 0.70, RoundingCent, time.Sunday, "2024-13-01"); err == nil {
+		t.Error("Expected an error for an invalid until date")
 	}
 }
 
-func TestStorage(t *testing.T) {
+func TestGenerateTripsFromRecurringMonthly(t *testing.T) {
 	data := &StorageData{
-		Trips:    []Trip{},
-		Expenses: []Expense{},
-	}
-
-	// Add a trip
-	trip := Trip{
-		Date:        "2024-03-20",
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       10,
-		Type:        "single",
-	}
-	err := data.AddTrip(trip)
-	if err != nil {
-		t.Errorf("AddTrip() error = %v", err)
+		ReferenceDate: "2024-02-10",
+		RecurringTrips: []RecurringTrip{
+			{
+				Origin:      "Home",
+				Destination: "School",
+				Miles:       4.0,
+				StartDate:   "2023-12-01",
+				EndDate:     "2024-02-29",
+				Type:        "single",
+				Frequency:   "monthly",
+				DayOfMonth:  30,
+			},
+		},
 	}
 
-	// Calculate weekly summaries
-	ratePerMile := 0.70
-	CalculateAndUpdateWeeklySummaries(data, ratePerMile)
-
-	if len(data.WeeklySummaries) != 1 {
-		t.Errorf("Expected 1 weekly summary, got %d", len(data.WeeklySummaries))
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("GenerateTripsFromRecurring() error = %v", err)
 	}
 
-	summary := data.WeeklySummaries[0]
-	expectedMiles := 10.0
-	expectedAmount := 7.0 // 10 miles * 0.70
-
-	if summary.TotalMiles != expectedMiles {
-		t.Errorf("Expected %v miles, got %v", expectedMiles, summary.TotalMiles)
+	want := []string{"2023-12-30", "2024-01-30", "2024-02-29"}
+	if len(data.Trips) != len(want) {
+		t.Fatalf("Expected %d trips, got %d", len(want), len(data.Trips))
 	}
-	if summary.TotalAmount != expectedAmount {
-		t.Errorf("Expected $%v amount, got $%v", expectedAmount, summary.TotalAmount)
+	for i, trip := range data.Trips {
+		if trip.Date != want[i] {
+			t.Errorf("Trip %d: expected date %s, got %s", i, want[i], trip.Date)
+		}
 	}
 }
 
-func TestAddingTrip(t *testing.T) {
+func TestStorageDataClone(t *testing.T) {
 	data := &StorageData{
-		Trips:    []Trip{},
-		Expenses: []Expense{},
+		Trips:         []Trip{{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 10, Type: "single"}},
+		Expenses:      []Expense{{Date: "2024-03-20", Amount: 5, Description: "Snacks"}},
+		TripTemplates: []TripTemplate{{Name: "School", Origin: "Home", Destination: "School", TripType: "round"}},
 	}
 
-	trip := Trip{
-		Date:        "2024-03-20",
-		Origin:      "Home",
-		Destination: "Work",
-		Miles:       10,
-		Type:        "single",
-	}
+	clone := data.Clone()
+	clone.Trips[0].Miles = 99
+	clone.Expenses = append(clone.Expenses[:0], clone.Expenses[1:]...)
+	clone.TripTemplates[0].Name = "Changed"
 
-	err := data.AddTrip(trip)
-	if err != nil {
-		t.Errorf("AddTrip() error = %v", err)
+	if data.Trips[0].Miles != 10 {
+		t.Errorf("Expected original trip miles to stay 10, got %.2f", data.Trips[0].Miles)
 	}
-
-	if len(data.Trips) != 1 {
-		t.Errorf("Expected 1 trip, got %d", len(data.Trips))
+	if len(data.Expenses) != 1 {
+		t.Errorf("Expected original expenses to be untouched, got %d", len(data.Expenses))
 	}
+	if data.TripTemplates[0].Name != "School" {
+		t.Errorf("Expected original template name to stay School, got %s", data.TripTemplates[0].Name)
+	}
+}
 
-	if data.Trips[0].Date != trip.Date {
-		t.Errorf("Expected date %v, got %v", trip.Date, data.Trips[0].Date)
+func TestStorageDataValidate(t *testing.T) {
+	valid := StorageData{
+		Trips:          []Trip{{Origin: "Home", Destination: "Work", Miles: 5.0, Date: "2024-03-20", Type: "single"}},
+		RecurringTrips: []RecurringTrip{{Origin: "Home", Destination: "School", Miles: 3.0, StartDate: "2024-03-01", Occurrences: 8, Type: "round", Weekday: 3}},
+		Expenses:       []Expense{{Date: "2024-03-20", Amount: 12.5, Description: "Lunch"}},
+		TripTemplates:  []TripTemplate{{Name: "Commute", Origin: "Home", Destination: "Work", TripType: "single"}},
 	}
-	if data.Trips[0].Origin != trip.Origin {
-		t.Errorf("Expected origin %v, got %v", trip.Origin, data.Trips[0].Origin)
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid data, got error: %v", err)
 	}
-	if data.Trips[0].Destination != trip.Destination {
-		t.Errorf("Expected destination %v, got %v", trip.Destination, data.Trips[0].Destination)
+
+	invalidExpense := valid
+	invalidExpense.Expenses = []Expense{valid.Expenses[0], {Date: "2024-03-21", Amount: 0, Description: "Nothing"}}
+	err := invalidExpense.Validate()
+	if err == nil {
+		t.Fatal("Expected error for invalid expense")
 	}
-	if data.Trips[0].Miles != trip.Miles {
-		t.Errorf("Expected miles %v, got %v", trip.Miles, data.Trips[0].Miles)
+	if err.Error() != "expense 1: amount must be greater than 0" {
+		t.Errorf("Expected error to identify the expense, got: %v", err)
 	}
-	if data.Trips[0].Type != trip.Type {
-		t.Errorf("Expected type %v, got %v", trip.Type, data.Trips[0].Type)
+
+	invalidTemplate := valid
+	invalidTemplate.TripTemplates = []TripTemplate{{Name: "", Origin: "Home", Destination: "Work", TripType: "single"}}
+	if err := invalidTemplate.Validate(); err == nil {
+		t.Error("Expected error for invalid template")
 	}
 }
 
-func TestStorageDataTripTemplateOperations(t *testing.T) {
+func TestGeneratedTripsAreMarkedRecurring(t *testing.T) {
 	data := &StorageData{
-		Trips:         []Trip{},
-		Expenses:      []Expense{},
-		TripTemplates: []TripTemplate{},
+		ReferenceDate: "2024-03-01",
+		Trips: []Trip{
+			{Origin: "Home", Destination: "Work", Miles: 10, Date: "2024-03-04", Type: "single"},
+		},
+		RecurringTrips: []RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3},
+		},
+	}
+	if err := data.GenerateTripsFromRecurring(); err != nil {
+		t.Fatalf("Failed to generate trips: %v", err)
 	}
 
-	// Test adding a valid template
-	template := TripTemplate{
-		Name:        "Work Commute",
-		Origin:      "123 Home St",
-		Destination: "456 Work Ave",
-		TripType:    "single",
-		Notes:       "Regular work commute",
+	if data.Trips[0].IsRecurring {
+		t.Error("Expected the manually added trip not to be marked recurring")
+	}
+	generated := data.Trips[1:]
+	if len(generated) != 4 {
+		t.Fatalf("Expected 4 generated trips, got %d", len(generated))
+	}
+	for _, trip := range generated {
+		if !trip.IsRecurring {
+			t.Errorf("Expected generated trip on %s to be marked recurring", trip.Date)
+		}
 	}
 
-	// Test adding template to storage
-	if err := data.AddTripTemplate(template); err != nil {
-		t.Errorf("AddTripTemplate() error = %v", err)
+	// Editing a generated trip keeps the marker even when the new trip omits it
+	edited := generated[0]
+	edited.IsRecurring = false
+	edited.Notes = "Early pickup"
+	if err := data.EditTrip(1, edited); err != nil {
+		t.Fatalf("Failed to edit trip: %v", err)
 	}
-	if len(data.TripTemplates) != 1 {
-		t.Errorf("Expected 1 template, got %d", len(data.TripTemplates))
+	if !data.Trips[1].IsRecurring || data.Trips[1].Notes != "Early pickup" {
+		t.Errorf("Expected the edited trip to stay recurring, got %+v", data.Trips[1])
 	}
+}
 
-	// Test editing template
-	editedTemplate := TripTemplate{
-		Name:        "Work Commute (Updated)",
-		Origin:      "123 Home St",
-		Destination: "456 Work Ave",
-		TripType:    "round",
-		Notes:       "Updated work commute",
+func TestRecalculateTripMiles(t *testing.T) {
+	data := &StorageData{
+		Trips: []Trip{
+			{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 3.0, Type: "single"},
+			{Date: "2024-03-21", Origin: "Home", Destination: "School", Miles: 4.0, Type: "round", ReturnDestination: "Park", ReturnMiles: 1.0},
+			{Date: "2024-03-22", Origin: "Home", Destination: "Work", Miles: 3.0, Type: "single"},
+		},
 	}
-	if err := data.EditTripTemplate(0, editedTemplate); err != nil {
-		t.Errorf("EditTripTemplate() error = %v", err)
+	mock := maps.NewMockClient()
+
+	if err := data.RecalculateTripMiles(context.Background(), mock, []int{1}); err != nil {
+		t.Fatalf("Failed to recalculate miles: %v", err)
 	}
-	if data.TripTemplates[0].Name != editedTemplate.Name {
-		t.Errorf("Expected name %v, got %v", editedTemplate.Name, data.TripTemplates[0].Name)
+	if data.Trips[1].Miles != 10.0 || data.Trips[1].ReturnMiles != 10.0 {
+		t.Errorf("Expected both legs overwritten with 10.0 miles, got %+v", data.Trips[1])
 	}
-	if data.TripTemplates[0].TripType != editedTemplate.TripType {
-		t.Errorf("Expected type %v, got %v", editedTemplate.TripType, data.TripTemplates[0].TripType)
+	if data.Trips[0].Miles != 3.0 {
+		t.Errorf("Expected other trips to keep their miles, got %.2f", data.Trips[0].Miles)
 	}
 
-	// Test deleting template
-	if err := data.DeleteTripTemplate(0); err != nil {
-		t.Errorf("DeleteTripTemplate() error = %v", err)
+	mock = maps.NewMockClient()
+	if err := data.RecalculateTripMiles(context.Background(), mock, []int{0, 2}); err != nil {
+		t.Fatalf("Failed to recalculate miles: %v", err)
 	}
-	if len(data.TripTemplates) != 0 {
-		t.Errorf("Expected 0 templates after deletion, got %d", len(data.TripTemplates))
+	if data.Trips[0].Miles != 10.0 || data.Trips[2].Miles != 10.0 {
+		t.Errorf("Expected 10.0 miles, got %+v", data.Trips)
 	}
-
-	// Test invalid operations
-	if err := data.EditTripTemplate(0, editedTemplate); err == nil {
-		t.Error("Expected error for invalid index in EditTripTemplate")
+	if mock.Calls() != 1 {
+		t.Errorf("Expected a repeated route to be looked up once, got %d calls", mock.Calls())
 	}
 
-	if err := data.DeleteTripTemplate(0); err == nil {
-		t.Error("Expected error for invalid index in DeleteTripTemplate")
+	if err := data.RecalculateTripMiles(context.Background(), mock, []int{3}); err == nil {
+		t.Error("Expected error for invalid index")
 	}
 }
 
-func TestTripTemplateSerialization(t *testing.T) {
-	originalTemplate := TripTemplate{
-		Name:        "Work Commute",
-		Origin:      "123 Home St",
-		Destination: "456 Work Ave",
-		TripType:    "round",
-		Notes:       "Regular work commute",
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{value: "2024-03-20", format: DateFormatISO, want: "2024-03-20"},
+		{value: "03/20/2024", format: DateFormatUS, want: "2024-03-20"},
+		{value: "3/5/2024", format: DateFormatUS, want: "2024-03-05"},
+		{value: "20/03/2024", format: DateFormatEU, want: "2024-03-20"},
+		{value: "5/3/2024", format: DateFormatEU, want: "2024-03-05"},
+		{value: " 03/20/2024 ", format: DateFormatUS, want: "2024-03-20"},
+		// The canonical form is accepted whatever the format
+		{value: "2024-03-20", format: DateFormatUS, want: "2024-03-20"},
+		{value: "2024-03-20", format: DateFormatEU, want: "2024-03-20"},
+		{value: "20/03/2024", format: DateFormatUS, wantErr: true},
+		{value: "03/20/2024", format: DateFormatEU, wantErr: true},
+		{value: "03/20/2024", format: DateFormatISO, wantErr: true},
+		{value: "02/30/2024", format: DateFormatUS, wantErr: true},
+		{value: "01/01/0999", format: DateFormatUS, wantErr: true},
+		{value: "", format: DateFormatUS, wantErr: true},
 	}
 
-	// Serialize to JSON
-	data, err := json.Marshal(originalTemplate)
-	if err != nil {
-		t.Fatalf("Failed to marshal template: %v", err)
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.value, func(t *testing.T) {
+			got, err := ParseDate(tt.value, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate(%q, %q) error = %v, wantErr %v", tt.value, tt.format, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDate(%q, %q) = %q, want %q", tt.value, tt.format, got, tt.want)
+			}
+		})
 	}
 
-	// Deserialize back
-	var deserializedTemplate TripTemplate
-	if err := json.Unmarshal(data, &deserializedTemplate); err != nil {
-		t.Fatalf("Failed to unmarshal template: %v", err)
+	if _, err := ParseDate("20/03/2024", DateFormatUS); err == nil || !strings.Contains(err.Error(), "MM/DD/YYYY") {
+		t.Errorf("Expected the error to name the expected format, got %v", err)
 	}
+}
 
-	// Verify all fields were preserved
-	if deserializedTemplate.Name != originalTemplate.Name {
-		t.Errorf("Name not preserved during serialization. Got %s, want %s",
-			deserializedTemplate.Name, originalTemplate.Name)
-	}
-	if deserializedTemplate.Origin != originalTemplate.Origin {
-		t.Errorf("Origin not preserved during serialization. Got %s, want %s",
-			deserializedTemplate.Origin, originalTemplate.Origin)
-	}
-	if deserializedTemplate.Destination != originalTemplate.Destination {
-		t.Errorf("Destination not preserved during serialization. Got %s, want %s",
-			deserializedTemplate.Destination, originalTemplate.Destination)
+func TestFormatDate(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: DateFormatISO, want: "2024-03-05"},
+		{format: DateFormatUS, want: "03/05/2024"},
+		{format: DateFormatEU, want: "05/03/2024"},
+		{format: "", want: "2024-03-05"},
 	}
-	if deserializedTemplate.TripType != originalTemplate.TripType {
-		t.Errorf("TripType not preserved during serialization. Got %s, want %s",
-			deserializedTemplate.TripType, originalTemplate.TripType)
+	for _, tt := range tests {
+		if got := FormatDate("2024-03-05", tt.format); got != tt.want {
+			t.Errorf("FormatDate(%q) = %q, want %q", tt.format, got, tt.want)
+		}
 	}
-	if deserializedTemplate.Notes != originalTemplate.Notes {
-		t.Errorf("Notes not preserved during serialization. Got %s, want %s",
-			deserializedTemplate.Notes, originalTemplate.Notes)
+	if got := FormatDate("someday", DateFormatUS); got != "someday" {
+		t.Errorf("Expected a non-date to be left alone, got %q", got)
 	}
 }
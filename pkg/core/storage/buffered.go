@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// BufferedStorage keeps the data in memory in front of a FileStorage, writing changes
+// to disk at most once per interval instead of on every save. The file is read again
+// whenever another program, such as the TUI, has written it since it was last read or
+// written here. Changes made in the last interval are lost if the process dies without
+// calling Close. If another program writes the file while changes are pending, the
+// newer of the two versions, by UpdatedAt, is kept.
+type BufferedStorage struct {
+	file     *FileStorage
+	interval time.Duration
+
+	mu       sync.RWMutex
+	data     *model.StorageData // nil until first loaded
+	dirty    bool               // data has changes not yet written to the file
+	fileInfo fileInfo           // the file as it was when data was last read or written
+	timer    *time.Timer        // pending flush, if any
+	closed   bool
+}
+
+// fileInfo is what tells whether a file was written since it was last looked at
+type fileInfo struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewBuffered wraps file so that changes are written at most once per interval. An
+// interval of zero or less skips the buffer altogether, reading and writing file directly.
+func NewBuffered(file *FileStorage, interval time.Duration) *BufferedStorage {
+	return &BufferedStorage{
+		file:     file,
+		interval: interval,
+	}
+}
+
+// SaveData replaces the stored data, bumping its UpdatedAt timestamp
+func (b *BufferedStorage) SaveData(data *model.StorageData) error {
+	if b.interval <= 0 {
+		return b.file.SaveData(data)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stamp(data)
+	saved, err := clone(data)
+	if err != nil {
+		return err
+	}
+	return b.replace(saved)
+}
+
+// LoadData returns a copy of the stored data, which the caller is free to modify
+func (b *BufferedStorage) LoadData() (*model.StorageData, error) {
+	if b.interval <= 0 {
+		return b.file.LoadData()
+	}
+	b.mu.RLock()
+	if b.data != nil && b.statFile() == b.fileInfo {
+		defer b.mu.RUnlock()
+		return clone(b.data)
+	}
+	b.mu.RUnlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return clone(b.data)
+}
+
+// Update applies fn to a copy of the data and keeps the result, holding the write lock
+// so no other change can interleave. Nothing changes if fn returns an error, which is
+// passed back unchanged.
+func (b *BufferedStorage) Update(fn func(*model.StorageData) error) error {
+	if b.interval <= 0 {
+		return b.file.Update(fn)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.load(); err != nil {
+		return err
+	}
+	data, err := clone(b.data)
+	if err != nil {
+		return err
+	}
+	if err := fn(data); err != nil {
+		return err
+	}
+	stamp(data)
+	return b.replace(data)
+}
+
+// Check verifies that the underlying data file can still be read and written
+func (b *BufferedStorage) Check() error {
+	return b.file.Check()
+}
+
+// Flush writes any pending changes to the file now
+func (b *BufferedStorage) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.flush()
+}
+
+// Close writes any pending changes and stops the flush timer. Changes made after Close
+// are written immediately.
+func (b *BufferedStorage) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return b.flush()
+}
+
+// load reads the data from the file if it has not been yet or the file has been written
+// by someone else since; the caller must hold the write lock
+func (b *BufferedStorage) load() error {
+	// Look at the file before reading it, so a write in between is caught next time
+	info := b.statFile()
+	if b.data != nil && info == b.fileInfo {
+		return nil
+	}
+	data, err := b.file.LoadData()
+	if err != nil {
+		return err
+	}
+	b.fileInfo = info
+	if b.data != nil && b.dirty {
+		if !data.UpdatedAt.After(b.data.UpdatedAt) {
+			// The pending changes are newer and will be written over the file
+			return nil
+		}
+		slog.Warn("Data file was changed by another program; discarding unsaved changes", "path", b.file.filePath)
+	}
+	b.data, b.dirty = data, false
+	return nil
+}
+
+// statFile returns the data file's modification time and size, or the zero value when
+// it can't be read
+func (b *BufferedStorage) statFile() fileInfo {
+	info, err := os.Stat(b.file.filePath)
+	if err != nil {
+		return fileInfo{}
+	}
+	return fileInfo{modTime: info.ModTime(), size: info.Size()}
+}
+
+// replace makes data the stored data, writing it now or scheduling a flush; the caller
+// must hold the write lock
+func (b *BufferedStorage) replace(data *model.StorageData) error {
+	if b.closed {
+		// Like FileStorage, a change that could not be written is not kept
+		previous, wasDirty := b.data, b.dirty
+		b.data, b.dirty = data, true
+		if err := b.flush(); err != nil {
+			b.data, b.dirty = previous, wasDirty
+			return err
+		}
+		return nil
+	}
+
+	b.data, b.dirty = data, true
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flushOnTimer)
+	}
+	return nil
+}
+
+// flushOnTimer writes pending changes when the flush interval has passed
+func (b *BufferedStorage) flushOnTimer() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.timer = nil
+	if err := b.flush(); err != nil {
+		// Keep the changes and try again after another interval
+		slog.Error("Failed to save data", "path", b.file.filePath, "error", err)
+		if !b.closed {
+			b.timer = time.AfterFunc(b.interval, b.flushOnTimer)
+		}
+	}
+}
+
+// flush writes the data if it has unsaved changes; the caller must hold the write lock
+func (b *BufferedStorage) flush() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if !b.dirty {
+		return nil
+	}
+
+	b.file.mu.Lock()
+	defer b.file.mu.Unlock()
+	// The data was stamped when it changed, so write it as is to keep its version
+	if err := b.file.writeData(b.data); err != nil {
+		return err
+	}
+	b.dirty = false
+	b.fileInfo = b.statFile()
+	return nil
+}
+
+// clone returns a deep copy of data, so callers never share the stored slices
+func clone(data *model.StorageData) (*model.StorageData, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	copied := &model.StorageData{}
+	if err := json.Unmarshal(jsonData, copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// addTrip is an Update function that appends a trip to the given destination
+func addTrip(destination string) func(*model.StorageData) error {
+	return func(d *model.StorageData) error {
+		d.Trips = append(d.Trips, model.Trip{Date: "2024-03-20", Origin: "Home", Destination: destination, Miles: 5.0, Type: "single"})
+		return nil
+	}
+}
+
+func TestBufferedStorageFlushesOnTimer(t *testing.T) {
+	file := New(filepath.Join(t.TempDir(), "trips.json"))
+	store := NewBuffered(file, 50*time.Millisecond)
+	defer store.Close()
+
+	if err := store.Update(addTrip("Work")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := store.Update(addTrip("School")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Reads are served from memory before anything is written
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+	if len(data.Trips) != 2 {
+		t.Fatalf("Expected 2 trips in memory, got %d", len(data.Trips))
+	}
+	onDisk, err := file.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if len(onDisk.Trips) != 0 {
+		t.Errorf("Expected nothing written before the interval, got %d trips", len(onDisk.Trips))
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		onDisk, err = file.LoadData()
+		if err != nil {
+			t.Fatalf("Failed to load file: %v", err)
+		}
+		if len(onDisk.Trips) == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected both trips to be written after the interval, got %d", len(onDisk.Trips))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// The file keeps the version clients were given
+	if !onDisk.UpdatedAt.Equal(data.UpdatedAt) {
+		t.Errorf("Expected the file to keep UpdatedAt %s, got %s", data.UpdatedAt, onDisk.UpdatedAt)
+	}
+}
+
+func TestBufferedStorageFlushesOnClose(t *testing.T) {
+	file := New(filepath.Join(t.TempDir(), "trips.json"))
+	store := NewBuffered(file, time.Hour)
+
+	if err := store.Update(addTrip("Work")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	onDisk, err := file.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if len(onDisk.Trips) != 1 {
+		t.Fatalf("Expected the pending trip to be written on Close, got %d trips", len(onDisk.Trips))
+	}
+
+	// Once closed, changes are written straight away
+	if err := store.Update(addTrip("School")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if onDisk, _ = file.LoadData(); len(onDisk.Trips) != 2 {
+		t.Errorf("Expected a change after Close to be written immediately, got %d trips", len(onDisk.Trips))
+	}
+}
+
+func TestBufferedStorageWithoutIntervalWritesThrough(t *testing.T) {
+	file := New(filepath.Join(t.TempDir(), "trips.json"))
+	if err := file.SaveData(&model.StorageData{Trips: []model.Trip{{Date: "2024-03-19", Origin: "Home", Destination: "Park", Miles: 2.0, Type: "single"}}}); err != nil {
+		t.Fatalf("Failed to seed file: %v", err)
+	}
+	store := NewBuffered(file, 0)
+
+	if err := store.Update(addTrip("Work")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	onDisk, err := file.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load file: %v", err)
+	}
+	if len(onDisk.Trips) != 2 {
+		t.Errorf("Expected the existing and new trip to be written immediately, got %d trips", len(onDisk.Trips))
+	}
+}
+
+func TestBufferedStorageReturnsCopies(t *testing.T) {
+	store := NewBuffered(New(filepath.Join(t.TempDir(), "trips.json")), time.Hour)
+	defer store.Close()
+
+	if err := store.Update(addTrip("Work")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+	data.Trips[0].Destination = "Changed"
+
+	// A failed update leaves the data as it was
+	rejected := errors.New("rejected")
+	if err := store.Update(func(d *model.StorageData) error {
+		d.Trips = nil
+		return rejected
+	}); err != rejected {
+		t.Fatalf("Expected the update's error back, got %v", err)
+	}
+
+	again, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("LoadData failed: %v", err)
+	}
+	if len(again.Trips) != 1 || again.Trips[0].Destination != "Work" {
+		t.Errorf("Expected the stored trip to be unchanged, got %+v", again.Trips)
+	}
+}
+
+func TestBufferedStorageSeesChangesFromOtherWriters(t *testing.T) {
+	for _, interval := range []time.Duration{0, time.Hour} {
+		path := filepath.Join(t.TempDir(), "trips.json")
+		store := NewBuffered(New(path), interval)
+
+		if err := store.Update(addTrip("Work")); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if err := store.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if _, err := store.LoadData(); err != nil {
+			t.Fatalf("LoadData failed: %v", err)
+		}
+
+		// Another program, such as the TUI, adds a trip to the same file
+		if err := New(path).Update(addTrip("School")); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		data, err := store.LoadData()
+		if err != nil {
+			t.Fatalf("LoadData failed: %v", err)
+		}
+		if len(data.Trips) != 2 {
+			t.Errorf("interval %s: expected the other program's trip to be read, got %d trips", interval, len(data.Trips))
+		}
+		if err := store.Update(addTrip("Park")); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		onDisk, err := New(path).LoadData()
+		if err != nil {
+			t.Fatalf("Failed to load file: %v", err)
+		}
+		if len(onDisk.Trips) != 3 {
+			t.Errorf("interval %s: expected all 3 trips written, got %d", interval, len(onDisk.Trips))
+		}
+	}
+}
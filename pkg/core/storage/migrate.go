@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+
+	model "github.com/laurendc/nannytracker/pkg/core"
+)
+
+// CurrentSchemaVersion is the layout version written by SaveData
+const CurrentSchemaVersion = 4
+
+// migrations[i] upgrades data from schema version i to i+1
+var migrations = []func(data *model.StorageData){
+	migrateV0,
+	migrateV1,
+	migrateV2,
+	migrateV3,
+}
+
+// openEndedEndDate is the end date given to recurring trips saved before one was
+// required. Generation never runs past the current month, so they keep adding each
+// month's trips as they did before.
+const openEndedEndDate = "9999-12-31"
+
+// Migrate upgrades data loaded from an older file to CurrentSchemaVersion in place,
+// filling defaults for fields added since it was written. Data from a newer version is
+// rejected rather than risk dropping fields this build does not know about.
+func Migrate(data *model.StorageData) error {
+	if data.SchemaVersion > CurrentSchemaVersion {
+		return fmt.Errorf("data schema version %d is newer than supported version %d", data.SchemaVersion, CurrentSchemaVersion)
+	}
+	for version := data.SchemaVersion; version < CurrentSchemaVersion; version++ {
+		migrations[version](data)
+	}
+	data.SchemaVersion = CurrentSchemaVersion
+	return nil
+}
+
+// migrateV0 upgrades files written before the schema was versioned
+func migrateV0(data *model.StorageData) {
+	// Expenses saved before categories existed default to "other"
+	for i := range data.Expenses {
+		if data.Expenses[i].Category == "" {
+			data.Expenses[i].Category = model.DefaultExpenseCategory
+		}
+	}
+
+	// Records saved before families existed belong to the default family
+	for i := range data.Expenses {
+		if data.Expenses[i].Family == "" {
+			data.Expenses[i].Family = model.DefaultFamily
+		}
+	}
+	for i := range data.Trips {
+		if data.Trips[i].Family == "" {
+			data.Trips[i].Family = model.DefaultFamily
+		}
+	}
+	for i := range data.RecurringTrips {
+		if data.RecurringTrips[i].Family == "" {
+			data.RecurringTrips[i].Family = model.DefaultFamily
+		}
+	}
+}
+
+// migrateV1 marks trips generated before generated trips were flagged as recurring
+func migrateV1(data *model.StorageData) {
+	for i := range data.Trips {
+		for _, rt := range data.RecurringTrips {
+			if rt.Matches(data.Trips[i]) {
+				data.Trips[i].IsRecurring = true
+				break
+			}
+		}
+	}
+}
+
+// migrateV2 turns links from expenses to a trip's date and position into links by trip
+// ID, which keep pointing at the same trip when trips are sorted, archived or deleted.
+// Links that name no trip are dropped.
+func migrateV2(data *model.StorageData) {
+	for i := range data.Expenses {
+		expense := &data.Expenses[i]
+		if expense.TripDate == "" {
+			continue
+		}
+		if !expense.LinkByDate(data.Trips) {
+			expense.TripDate = ""
+			expense.TripIndex = 0
+		}
+	}
+}
+
+// migrateV3 gives recurring trips saved without an end date or number of occurrences,
+// which validation now requires, an end date that keeps them running
+func migrateV3(data *model.StorageData) {
+	for i := range data.RecurringTrips {
+		if data.RecurringTrips[i].EndDate == "" && data.RecurringTrips[i].Occurrences == 0 {
+			data.RecurringTrips[i].EndDate = openEndedEndDate
+		}
+	}
+}
@@ -1,8 +1,15 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	model "github.com/laurendc/nannytracker/pkg/core"
 )
@@ -13,9 +20,19 @@ type Storage interface {
 	LoadData() (*model.StorageData, error)
 }
 
-// FileStorage implements Storage using a JSON file
+// ErrCorruptData is returned when the data file is empty, or by strict storage when it
+// is not valid JSON
+var ErrCorruptData = errors.New("data file is corrupt")
+
+// FileStorage implements Storage using a JSON file. It is safe for concurrent use; callers
+// that read, modify and write the data should use Update so concurrent changes are not lost.
 type FileStorage struct {
+	// Strict makes a corrupt data file an error instead of setting it aside and starting
+	// with empty data; set it before the storage is first used
+	Strict bool
+
 	filePath string
+	mu       sync.RWMutex
 }
 
 // New creates a new FileStorage instance
@@ -25,25 +42,111 @@ func New(filePath string) *FileStorage {
 	}
 }
 
-// SaveData saves the complete data structure to the file
+// SaveData saves the complete data structure to the file, bumping its UpdatedAt timestamp
 func (s *FileStorage) SaveData(data *model.StorageData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saveData(data)
+}
+
+// LoadData loads the complete data structure from the file
+func (s *FileStorage) LoadData() (*model.StorageData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadData()
+}
+
+// Update loads the data, applies fn to it and saves the result while holding the write
+// lock, so no other load or save can interleave. Nothing is saved if fn returns an error,
+// which is passed back unchanged.
+func (s *FileStorage) Update(fn func(*model.StorageData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := s.loadData()
+	if err != nil {
+		return err
+	}
+	if err := fn(data); err != nil {
+		return err
+	}
+	return s.saveData(data)
+}
+
+// saveData writes the data to the file; the caller must hold the write lock
+func (s *FileStorage) saveData(data *model.StorageData) error {
+	stamp(data)
+	return s.writeData(data)
+}
+
+// stamp marks data as a new version in the current schema
+func stamp(data *model.StorageData) {
+	// Keep the timestamp strictly increasing so every save yields a new version
+	now := time.Now().UTC()
+	if !now.After(data.UpdatedAt) {
+		now = data.UpdatedAt.Add(time.Nanosecond)
+	}
+	data.UpdatedAt = now
+	data.SchemaVersion = CurrentSchemaVersion
+}
+
+// writeData writes the data to the file as it is; the caller must hold the write lock.
+// The TUI and web server can share the file from separate processes, so the data is
+// written to a temporary file that then replaces it, and readers never see a partial write.
+func (s *FileStorage) writeData(data *model.StorageData) error {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filePath, jsonData, 0600)
+	tmp, err := os.CreateTemp(filepath.Dir(s.filePath), "."+filepath.Base(s.filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(jsonData); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.filePath)
 }
 
-// LoadData loads the complete data structure from the file
-func (s *FileStorage) LoadData() (*model.StorageData, error) {
+// readRetries and readRetryDelay bound how long a load waits for a write that another
+// program is still making to finish before taking an empty or unparsable file as it is
+const (
+	readRetries    = 5
+	readRetryDelay = 20 * time.Millisecond
+)
+
+// readFile reads the data file, reading it again while it is empty or not valid JSON in
+// case an older version of the app is writing it in place
+func (s *FileStorage) readFile() ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		fileData, err := os.ReadFile(s.filePath)
+		if err != nil || json.Valid(fileData) || attempt == readRetries {
+			return fileData, err
+		}
+		time.Sleep(readRetryDelay)
+	}
+}
+
+// loadData reads the data from the file; the caller must hold the lock
+func (s *FileStorage) loadData() (*model.StorageData, error) {
 	data := &model.StorageData{
 		Trips:           make([]model.Trip, 0),
 		WeeklySummaries: make([]model.WeeklySummary, 0),
 		TripTemplates:   make([]model.TripTemplate, 0),
 	}
 
-	fileData, err := os.ReadFile(s.filePath)
+	fileData, err := s.readFile()
 	if err != nil {
 		if os.IsNotExist(err) {
 			return data, nil
@@ -51,9 +154,84 @@ func (s *FileStorage) LoadData() (*model.StorageData, error) {
 		return nil, err
 	}
 
+	// An empty file may be a save that went wrong; starting over would overwrite it on
+	// the next save, so leave it for the user to look at
+	if len(bytes.TrimSpace(fileData)) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrCorruptData, s.filePath)
+	}
+
 	if err := json.Unmarshal(fileData, data); err != nil {
+		if s.Strict {
+			return nil, fmt.Errorf("%w: %s: %v", ErrCorruptData, s.filePath, err)
+		}
+		return s.recoverCorrupt(err)
+	}
+
+	// Bring files written by older versions up to date
+	if err := Migrate(data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// recoverCorrupt moves a data file that could not be parsed aside, so it can be fixed by
+// hand, and returns empty data in its place
+func (s *FileStorage) recoverCorrupt(parseErr error) (*model.StorageData, error) {
+	backupPath := fmt.Sprintf("%s.corrupt.%s", s.filePath, time.Now().UTC().Format("20060102T150405Z"))
+	// Another reader may have moved it already
+	if err := os.Rename(s.filePath, backupPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s: %v (backing it up failed: %v)", ErrCorruptData, s.filePath, parseErr, err)
+	}
+	slog.Warn("Data file is not valid JSON; moved it aside and starting with empty data", "path", s.filePath, "backup", backupPath, "error", parseErr)
+
+	return &model.StorageData{
+		Trips:           make([]model.Trip, 0),
+		WeeklySummaries: make([]model.WeeklySummary, 0),
+		TripTemplates:   make([]model.TripTemplate, 0),
+	}, nil
+}
+
+// LoadAndRepair loads the data and recomputes the weekly summaries from the trips and
+// expenses, so summaries left stale by hand edits to the file are corrected. The
+// repaired data is not saved; call SaveData to persist it.
+func (s *FileStorage) LoadAndRepair(ratePerMile float64, roundingMode string, weekStartDay time.Weekday) (*model.StorageData, error) {
+	data, err := s.LoadData()
+	if err != nil {
 		return nil, err
 	}
 
+	// Summaries sort their inputs in place, so work on copies to keep the stored order
+	data.WeeklySummaries = model.CalculateWeeklySummaries(
+		append([]model.Trip(nil), data.Trips...),
+		append([]model.Expense(nil), data.Expenses...),
+		ratePerMile, roundingMode, weekStartDay)
+	data.WeeklySummaries = model.ApplyAdjustments(data.WeeklySummaries, data.Adjustments, ratePerMile, roundingMode, weekStartDay)
+	if data.WeeklySummaries == nil {
+		data.WeeklySummaries = make([]model.WeeklySummary, 0)
+	}
 	return data, nil
 }
+
+// Check verifies that the data file can be read and that its directory accepts new
+// files, without touching the data itself. A missing data file is fine since it is
+// created on the first save.
+func (s *FileStorage) Check() error {
+	f, err := os.Open(s.filePath)
+	if err == nil {
+		f.Close()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.filePath), ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write([]byte("ok")); err != nil {
+		tmp.Close()
+		return err
+	}
+	return tmp.Close()
+}
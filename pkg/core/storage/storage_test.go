@@ -1,9 +1,14 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	model "github.com/laurendc/nannytracker/pkg/core"
 )
@@ -145,3 +150,547 @@ func TestStorage(t *testing.T) {
 		t.Errorf("Expected 0 trip templates from non-existent file, got %d", len(emptyData.TripTemplates))
 	}
 }
+
+func TestLoadDataWithoutTripNotes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nannytracker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// A file written before trips had notes
+	filePath := filepath.Join(tmpDir, "trips.json")
+	legacy := `{"trips":[{"origin":"Home","destination":"Work","miles":5,"date":"2024-03-20","type":"single"}]}`
+	if err := os.WriteFile(filePath, []byte(legacy), 0600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	store := New(filePath)
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load legacy data: %v", err)
+	}
+	if len(data.Trips) != 1 {
+		t.Fatalf("Expected 1 trip, got %d", len(data.Trips))
+	}
+	if data.Trips[0].Notes != "" {
+		t.Errorf("Expected empty notes, got %q", data.Trips[0].Notes)
+	}
+	if len(data.Trips[0].Tags) != 0 {
+		t.Errorf("Expected no tags, got %v", data.Trips[0].Tags)
+	}
+
+	// Notes and tags survive a save/load round trip
+	data.Trips[0].Notes = "Ballet practice"
+	data.Trips[0].Tags = []string{"field-trip", "rainy-day"}
+	if err := store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	reloaded, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to reload data: %v", err)
+	}
+	if reloaded.Trips[0].Notes != "Ballet practice" {
+		t.Errorf("Expected notes 'Ballet practice', got %q", reloaded.Trips[0].Notes)
+	}
+	if len(reloaded.Trips[0].Tags) != 2 || reloaded.Trips[0].Tags[1] != "rainy-day" {
+		t.Errorf("Expected tags [field-trip rainy-day], got %v", reloaded.Trips[0].Tags)
+	}
+}
+
+func TestSaveDataBumpsUpdatedAt(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nannytracker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	storage := New(filepath.Join(tmpDir, "trips.json"))
+	data := &model.StorageData{}
+
+	if err := storage.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	first := data.UpdatedAt
+	if first.IsZero() {
+		t.Fatal("Expected UpdatedAt to be set on save")
+	}
+
+	if err := storage.SaveData(data); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	if !data.UpdatedAt.After(first) {
+		t.Errorf("Expected UpdatedAt to increase on each save, got %v then %v", first, data.UpdatedAt)
+	}
+
+	loaded, err := storage.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if !loaded.UpdatedAt.Equal(data.UpdatedAt) {
+		t.Errorf("Expected loaded UpdatedAt %v, got %v", data.UpdatedAt, loaded.UpdatedAt)
+	}
+}
+
+func TestLoadDataDefaultsFamily(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "nannytracker-test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// A file written before records had families
+	filePath := filepath.Join(tmpDir, "trips.json")
+	legacy := `{
+		"trips":[{"origin":"Home","destination":"Work","miles":5,"date":"2024-03-20","type":"single"}],
+		"recurring_trips":[{"origin":"Home","destination":"School","miles":3,"start_date":"2024-03-01","type":"round","weekday":1}],
+		"expenses":[{"date":"2024-03-20","amount":12.5,"description":"Lunch"}]
+	}`
+	if err := os.WriteFile(filePath, []byte(legacy), 0600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	data, err := New(filePath).LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load legacy data: %v", err)
+	}
+	if data.Trips[0].Family != model.DefaultFamily {
+		t.Errorf("Expected trip family %q, got %q", model.DefaultFamily, data.Trips[0].Family)
+	}
+	if data.RecurringTrips[0].Family != model.DefaultFamily {
+		t.Errorf("Expected recurring trip family %q, got %q", model.DefaultFamily, data.RecurringTrips[0].Family)
+	}
+	if data.Expenses[0].Family != model.DefaultFamily {
+		t.Errorf("Expected expense family %q, got %q", model.DefaultFamily, data.Expenses[0].Family)
+	}
+}
+
+func TestLoadDataRecoversCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "trips.json")
+	corrupt := []byte(`{"trips": [{"date": "2024-03-20",`)
+	if err := os.WriteFile(filePath, corrupt, 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	store := New(filePath)
+
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Expected a corrupt file to be recovered, got %v", err)
+	}
+	if len(data.Trips) != 0 || data.Trips == nil {
+		t.Errorf("Expected empty data, got %+v", data)
+	}
+
+	backups, err := filepath.Glob(filePath + ".corrupt.*")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected one backup of the corrupt file, got %v (%v)", backups, err)
+	}
+	if saved, err := os.ReadFile(backups[0]); err != nil || string(saved) != string(corrupt) {
+		t.Errorf("Expected the backup to keep the corrupt contents, got %q (%v)", saved, err)
+	}
+	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the corrupt file to be moved aside, got %v", err)
+	}
+
+	// New data can be saved in its place
+	if err := store.SaveData(&model.StorageData{Trips: []model.Trip{{Date: "2024-03-21", Origin: "Home", Destination: "Work", Miles: 5}}}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	if data, err := store.LoadData(); err != nil || len(data.Trips) != 1 {
+		t.Errorf("Expected the new trip to load, got %+v (%v)", data, err)
+	}
+}
+
+func TestLoadDataStrictRejectsCorruptFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trips.json")
+	if err := os.WriteFile(filePath, []byte("not json"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	store := New(filePath)
+	store.Strict = true
+
+	if _, err := store.LoadData(); !errors.Is(err, ErrCorruptData) {
+		t.Errorf("Expected ErrCorruptData, got %v", err)
+	}
+	if saved, err := os.ReadFile(filePath); err != nil || string(saved) != "not json" {
+		t.Errorf("Expected the file to be left alone, got %q (%v)", saved, err)
+	}
+	if backups, _ := filepath.Glob(filePath + ".corrupt.*"); len(backups) != 0 {
+		t.Errorf("Expected no backup in strict mode, got %v", backups)
+	}
+}
+
+func TestLoadDataEmptyFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trips.json")
+	if err := os.WriteFile(filePath, []byte("\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	store := New(filePath)
+
+	// Loading empty data would let the next save replace whatever the file should hold
+	if _, err := store.LoadData(); !errors.Is(err, ErrCorruptData) {
+		t.Errorf("Expected ErrCorruptData for an empty file, got %v", err)
+	}
+	if err := store.Update(func(*model.StorageData) error { return nil }); !errors.Is(err, ErrCorruptData) {
+		t.Errorf("Expected Update to refuse an empty file, got %v", err)
+	}
+	if saved, err := os.ReadFile(filePath); err != nil || string(saved) != "\n" {
+		t.Errorf("Expected the file to be left alone, got %q (%v)", saved, err)
+	}
+}
+
+func TestLoadDataWaitsForWriteInProgress(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trips.json")
+	full := []byte(`{"trips":[{"date":"2024-03-20","origin":"Home","destination":"Work","miles":5,"type":"single"}]}`)
+
+	// An older version writes the file in place, so it is briefly empty and then partial
+	for _, partial := range [][]byte{nil, full[:len(full)/2]} {
+		if err := os.WriteFile(filePath, partial, 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+		done := make(chan error, 1)
+		go func() {
+			time.Sleep(2 * readRetryDelay)
+			done <- os.WriteFile(filePath, full, 0600)
+		}()
+
+		data, err := New(filePath).LoadData()
+		if err := <-done; err != nil {
+			t.Fatalf("Failed to finish writing file: %v", err)
+		}
+		if err != nil {
+			t.Fatalf("Expected the finished write to load, got %v", err)
+		}
+		if len(data.Trips) != 1 {
+			t.Errorf("Expected the trip from the finished write, got %+v", data.Trips)
+		}
+		if backups, _ := filepath.Glob(filePath + ".corrupt.*"); len(backups) != 0 {
+			t.Errorf("Expected no backup of a file being written, got %v", backups)
+		}
+	}
+}
+
+func TestLoadDataWhileAnotherProcessSaves(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "trips.json")
+	trips := make([]model.Trip, 500)
+	for i := range trips {
+		trips[i] = model.Trip{Date: "2024-03-20", Origin: "Home", Destination: "Work", Miles: 5, Type: "single"}
+	}
+
+	// Separate stores share no lock, as the TUI and web server don't
+	writer, reader := New(filePath), New(filePath)
+	if err := writer.SaveData(&model.StorageData{Trips: trips}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				done <- nil
+				return
+			default:
+			}
+			if err := writer.SaveData(&model.StorageData{Trips: trips}); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		data, err := reader.LoadData()
+		if err != nil {
+			t.Fatalf("Load %d failed during a save: %v", i, err)
+		}
+		if len(data.Trips) != len(trips) {
+			t.Fatalf("Load %d saw %d trips during a save, expected %d", i, len(data.Trips), len(trips))
+		}
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+
+	if backups, _ := filepath.Glob(filePath + ".corrupt.*"); len(backups) != 0 {
+		t.Errorf("Expected no backups, got %v", backups)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, ".trips.json.tmp-*")); len(leftovers) != 0 {
+		t.Errorf("Expected no temporary files left behind, got %v", leftovers)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A missing data file is fine as long as the directory is writable
+	store := New(filepath.Join(tempDir, "trips.json"))
+	if err := store.Check(); err != nil {
+		t.Errorf("Expected no error for a writable directory, got %v", err)
+	}
+
+	if err := store.SaveData(&model.StorageData{}); err != nil {
+		t.Fatalf("Failed to save data: %v", err)
+	}
+	if err := store.Check(); err != nil {
+		t.Errorf("Expected no error for an existing data file, got %v", err)
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the check to leave only the data file behind, got %d entries", len(entries))
+	}
+
+	missing := New(filepath.Join(tempDir, "missing", "trips.json"))
+	if err := missing.Check(); err == nil {
+		t.Error("Expected an error when the data directory does not exist")
+	}
+}
+
+func TestLoadAndRepair(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trips.json")
+
+	// Summaries that no longer match the trips, as after a hand edit
+	stale := `{
+		"trips": [
+			{"origin":"Home","destination":"Work","miles":10,"date":"2024-03-20","type":"round"},
+			{"origin":"Home","destination":"Park","miles":5,"date":"2024-03-12","type":"single"}
+		],
+		"expenses": [{"date":"2024-03-21","amount":12.5,"description":"Lunch"}],
+		"weekly_summaries": [
+			{"WeekStart":"2024-03-17","WeekEnd":"2024-03-23","TotalMiles":999,"TotalAmount":1,"TotalExpenses":0},
+			{"WeekStart":"2024-01-07","WeekEnd":"2024-01-13","TotalMiles":3,"TotalAmount":2,"TotalExpenses":0}
+		]
+	}`
+	if err := os.WriteFile(filePath, []byte(stale), 0600); err != nil {
+		t.Fatalf("Failed to write stale file: %v", err)
+	}
+
+	store := New(filePath)
+	data, err := store.LoadAndRepair(0.5, model.RoundingNone, time.Sunday)
+	if err != nil {
+		t.Fatalf("Failed to load and repair: %v", err)
+	}
+
+	if len(data.WeeklySummaries) != 2 {
+		t.Fatalf("Expected 2 recomputed weeks, got %d", len(data.WeeklySummaries))
+	}
+	latest := data.WeeklySummaries[0]
+	if latest.WeekStart != "2024-03-17" || latest.TotalMiles != 20 || latest.TotalAmount != 10 || latest.TotalExpenses != 12.5 {
+		t.Errorf("Expected week of 2024-03-17 with 20 miles, $10.00 and $12.50 expenses, got %+v", latest)
+	}
+	if earlier := data.WeeklySummaries[1]; earlier.WeekStart != "2024-03-10" || earlier.TotalMiles != 5 {
+		t.Errorf("Expected week of 2024-03-10 with 5 miles, got %+v", earlier)
+	}
+
+	// Repairing leaves the trips in their stored order
+	if data.Trips[0].Date != "2024-03-20" || data.Trips[1].Date != "2024-03-12" {
+		t.Errorf("Expected trips to keep their order, got %s then %s", data.Trips[0].Date, data.Trips[1].Date)
+	}
+
+	// The repaired summaries persist once saved
+	if err := store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save repaired data: %v", err)
+	}
+	reloaded, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to reload data: %v", err)
+	}
+	if len(reloaded.WeeklySummaries) != 2 || reloaded.WeeklySummaries[0].TotalMiles != 20 {
+		t.Errorf("Expected repaired summaries to be saved, got %+v", reloaded.WeeklySummaries)
+	}
+}
+
+func TestUpdateConcurrentAppends(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "trips.json"))
+
+	const writers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := store.Update(func(data *model.StorageData) error {
+				data.Expenses = append(data.Expenses, model.Expense{Date: "2024-03-20", Amount: 1, Description: "Snack"})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != writers {
+		t.Errorf("Expected %d expenses, got %d", writers, len(data.Expenses))
+	}
+}
+
+func TestUpdateErrorSkipsSave(t *testing.T) {
+	store := New(filepath.Join(t.TempDir(), "trips.json"))
+
+	rejected := errors.New("rejected")
+	err := store.Update(func(data *model.StorageData) error {
+		data.Expenses = append(data.Expenses, model.Expense{Date: "2024-03-20", Amount: 1, Description: "Snack"})
+		return rejected
+	})
+	if !errors.Is(err, rejected) {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+	if len(data.Expenses) != 0 {
+		t.Errorf("Expected nothing saved after a rejected update, got %d expenses", len(data.Expenses))
+	}
+}
+
+func TestMigrateVersionZero(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "trips.json")
+
+	// A file written before schema versions, categories and families existed
+	legacy := `{
+		"trips": [{"origin":"Home","destination":"Work","miles":10,"date":"2024-03-20","type":"round","notes":"Early start"}],
+		"recurring_trips": [{"origin":"Home","destination":"School","miles":4,"start_date":"2024-03-01","end_date":"2024-03-31","type":"single","weekday":3}],
+		"expenses": [{"date":"2024-03-21","amount":12.5,"description":"Lunch"}],
+		"trip_templates": [{"name":"Commute","origin":"Home","destination":"Work","trip_type":"single"}]
+	}`
+	if err := os.WriteFile(filePath, []byte(legacy), 0600); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	store := New(filePath)
+	data, err := store.LoadData()
+	if err != nil {
+		t.Fatalf("Failed to load legacy data: %v", err)
+	}
+
+	if data.SchemaVersion != CurrentSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", CurrentSchemaVersion, data.SchemaVersion)
+	}
+	if data.Expenses[0].Category != model.DefaultExpenseCategory || data.Expenses[0].Family != model.DefaultFamily {
+		t.Errorf("Expected expense defaults to be filled, got %+v", data.Expenses[0])
+	}
+	if data.Trips[0].Family != model.DefaultFamily || data.RecurringTrips[0].Family != model.DefaultFamily {
+		t.Errorf("Expected trips to move to the default family, got %q and %q", data.Trips[0].Family, data.RecurringTrips[0].Family)
+	}
+
+	// Nothing else is lost along the way
+	if trip := data.Trips[0]; trip.Miles != 10 || trip.Type != "round" || trip.Notes != "Early start" {
+		t.Errorf("Expected trip fields to survive migration, got %+v", trip)
+	}
+	if data.Expenses[0].Amount != 12.5 || data.RecurringTrips[0].EndDate != "2024-03-31" || data.TripTemplates[0].Name != "Commute" {
+		t.Errorf("Expected records to survive migration, got %+v", data)
+	}
+
+	// The upgraded version is written back on save
+	if err := store.SaveData(data); err != nil {
+		t.Fatalf("Failed to save migrated data: %v", err)
+	}
+	raw, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read saved file: %v", err)
+	}
+	if !strings.Contains(string(raw), fmt.Sprintf(`"schema_version": %d`, CurrentSchemaVersion)) {
+		t.Errorf("Expected saved file to record the schema version, got: %s", raw)
+	}
+}
+
+func TestMigrateMarksGeneratedTrips(t *testing.T) {
+	data := &model.StorageData{
+		SchemaVersion: 1,
+		Trips: []model.Trip{
+			{Origin: "Home", Destination: "School", Miles: 4, Date: "2024-03-06", Type: "single", Family: model.DefaultFamily},
+			{Origin: "Home", Destination: "School", Miles: 4, Date: "2024-03-07", Type: "single", Family: model.DefaultFamily},
+		},
+		RecurringTrips: []model.RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 4, StartDate: "2024-03-01", EndDate: "2024-03-31", Type: "single", Weekday: 3, Family: model.DefaultFamily},
+		},
+	}
+	if err := Migrate(data); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if !data.Trips[0].IsRecurring {
+		t.Error("Expected the Wednesday trip to be marked as generated")
+	}
+	if data.Trips[1].IsRecurring {
+		t.Error("Expected the off-schedule trip to stay a regular trip")
+	}
+}
+
+func TestMigrateLinksExpensesByTripID(t *testing.T) {
+	data := &model.StorageData{
+		SchemaVersion: 2,
+		Trips: []model.Trip{
+			{Origin: "Home", Destination: "Zoo", Miles: 8, Date: "2024-03-20", Type: "single", Family: model.DefaultFamily},
+			{Origin: "Zoo", Destination: "Museum", Miles: 3, Date: "2024-03-20", Type: "single", Family: model.DefaultFamily},
+		},
+		Expenses: []model.Expense{
+			{Date: "2024-03-20", Amount: 6.5, Description: "Parking", Family: model.DefaultFamily, TripDate: "2024-03-20", TripIndex: 1},
+			{Date: "2024-03-20", Amount: 2, Description: "Toll", Family: model.DefaultFamily, TripDate: "2024-03-20", TripIndex: 5},
+		},
+	}
+	if err := Migrate(data); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if i, ok := data.Expenses[0].LinkedTrip(data.Trips); !ok || data.Trips[i].Destination != "Museum" {
+		t.Errorf("Expected the parking to be linked to the Museum trip, got %+v", data.Expenses[0])
+	}
+	if data.Trips[0].ID != "" {
+		t.Errorf("Expected only linked trips to get an ID, got %q", data.Trips[0].ID)
+	}
+	if expense := data.Expenses[1]; expense.IsTripLinked() || expense.TripDate != "" || expense.TripIndex != 0 {
+		t.Errorf("Expected a link to a missing trip to be dropped, got %+v", expense)
+	}
+}
+
+func TestMigrateEndsOpenEndedRecurringTrips(t *testing.T) {
+	data := &model.StorageData{
+		SchemaVersion: 3,
+		RecurringTrips: []model.RecurringTrip{
+			{Origin: "Home", Destination: "School", Miles: 5, StartDate: "2024-01-01", Type: "single", Weekday: 1, Family: model.DefaultFamily},
+			{Origin: "Home", Destination: "Park", Miles: 2, StartDate: "2024-01-01", EndDate: "2024-06-30", Type: "single", Weekday: 3, Family: model.DefaultFamily},
+			{Origin: "Home", Destination: "Pool", Miles: 4, StartDate: "2024-01-01", Type: "single", Weekday: 5, Family: model.DefaultFamily, Occurrences: 10},
+		},
+	}
+	if err := Migrate(data); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if got := data.RecurringTrips[0].EndDate; got != openEndedEndDate {
+		t.Errorf("Expected the open-ended trip to end on %s, got %q", openEndedEndDate, got)
+	}
+	if got := data.RecurringTrips[1].EndDate; got != "2024-06-30" {
+		t.Errorf("Expected an existing end date to be kept, got %q", got)
+	}
+	if got := data.RecurringTrips[2].EndDate; got != "" {
+		t.Errorf("Expected a trip with occurrences to be left alone, got %q", got)
+	}
+	for i, rt := range data.RecurringTrips {
+		if err := rt.Validate(); err != nil {
+			t.Errorf("Expected recurring trip %d to be valid after migrating, got %v", i, err)
+		}
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	data := &model.StorageData{SchemaVersion: CurrentSchemaVersion + 1}
+	if err := Migrate(data); err == nil {
+		t.Error("Expected an error for data from a newer version")
+	}
+	if len(migrations) != CurrentSchemaVersion {
+		t.Errorf("Expected one migration per schema version, got %d for version %d", len(migrations), CurrentSchemaVersion)
+	}
+}
@@ -1,9 +1,5 @@
 package model
 
-import (
-	"fmt"
-)
-
 // TripTemplate represents a saved trip template.
 type TripTemplate struct {
 	Name        string `json:"name"`
@@ -11,21 +7,25 @@ type TripTemplate struct {
 	Destination string `json:"destination"`
 	TripType    string `json:"tripType"`
 	Notes       string `json:"notes"`
+	UsageCount  int    `json:"usageCount,omitempty"` // Times the template has been used to start a trip
 }
 
-// Validate checks if the trip template is valid.
+// Validate normalizes the whitespace in the template's addresses (see NormalizeAddress)
+// and checks if the trip template is valid.
 func (t *TripTemplate) Validate() error {
+	t.Origin = NormalizeAddress(t.Origin)
+	t.Destination = NormalizeAddress(t.Destination)
 	if t.Name == "" {
-		return fmt.Errorf("template name cannot be empty")
+		return invalid("name", "template name cannot be empty")
 	}
 	if t.Origin == "" {
-		return fmt.Errorf("origin cannot be empty")
+		return invalid("origin", "origin cannot be empty")
 	}
 	if t.Destination == "" {
-		return fmt.Errorf("destination cannot be empty")
+		return invalid("destination", "destination cannot be empty")
 	}
 	if t.TripType != "single" && t.TripType != "round" {
-		return fmt.Errorf("invalid trip type: must be 'single' or 'round'")
+		return invalid("tripType", "invalid trip type: must be 'single' or 'round'")
 	}
 	return nil
 }
@@ -83,6 +83,16 @@ func TestTripTemplateValidation(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "whitespace-only origin",
+			template: TripTemplate{
+				Name:        "Work Commute",
+				Origin:      "   ",
+				Destination: "456 Work Ave",
+				TripType:    "single",
+			},
+			expectError: true,
+		},
 		{
 			name: "empty destination",
 			template: TripTemplate{
@@ -113,4 +123,12 @@ func TestTripTemplateValidation(t *testing.T) {
 			}
 		})
 	}
+
+	template := TripTemplate{Name: "Work Commute", Origin: " 123  Home St ", Destination: "456 Work Ave\t", TripType: "single"}
+	if err := template.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if template.Origin != "123 Home St" || template.Destination != "456 Work Ave" {
+		t.Errorf("Expected normalized addresses, got %q and %q", template.Origin, template.Destination)
+	}
 }